@@ -0,0 +1,4 @@
+// Package statev1 holds the protobuf messages and gRPC stubs generated from state.proto
+package statev1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative state/v1/state.proto
@@ -0,0 +1,3475 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.28.3
+// source: state/v1/state.proto
+
+// The gRPC API of the state service. It serves the same operations as the REST API under /api/v1
+// through the same services, the messages mirror the dhauli-base runtime types field by field.
+
+package statev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Status int32
+
+const (
+	Status_STATUS_UNSPECIFIED Status = 0
+	Status_STATUS_PENDING     Status = 1
+	Status_STATUS_RUNNING     Status = 2
+	Status_STATUS_SUCCESS     Status = 3
+	Status_STATUS_ERROR       Status = 4
+	Status_STATUS_STOP        Status = 5
+)
+
+// Enum value maps for Status.
+var (
+	Status_name = map[int32]string{
+		0: "STATUS_UNSPECIFIED",
+		1: "STATUS_PENDING",
+		2: "STATUS_RUNNING",
+		3: "STATUS_SUCCESS",
+		4: "STATUS_ERROR",
+		5: "STATUS_STOP",
+	}
+	Status_value = map[string]int32{
+		"STATUS_UNSPECIFIED": 0,
+		"STATUS_PENDING":     1,
+		"STATUS_RUNNING":     2,
+		"STATUS_SUCCESS":     3,
+		"STATUS_ERROR":       4,
+		"STATUS_STOP":        5,
+	}
+)
+
+func (x Status) Enum() *Status {
+	p := new(Status)
+	*p = x
+	return p
+}
+
+func (x Status) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (Status) Descriptor() protoreflect.EnumDescriptor {
+	return file_state_v1_state_proto_enumTypes[0].Descriptor()
+}
+
+func (Status) Type() protoreflect.EnumType {
+	return &file_state_v1_state_proto_enumTypes[0]
+}
+
+func (x Status) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use Status.Descriptor instead.
+func (Status) EnumDescriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{0}
+}
+
+type Query struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Tags          []string               `protobuf:"bytes,3,rep,name=tags,proto3" json:"tags,omitempty"`
+	Metadata      map[string]string      `protobuf:"bytes,4,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Query) Reset() {
+	*x = Query{}
+	mi := &file_state_v1_state_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Query) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Query) ProtoMessage() {}
+
+func (x *Query) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Query.ProtoReflect.Descriptor instead.
+func (*Query) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Query) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Query) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Query) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Query) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *Query) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type Answer struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Answer) Reset() {
+	*x = Answer{}
+	mi := &file_state_v1_state_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Answer) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Answer) ProtoMessage() {}
+
+func (x *Answer) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Answer.ProtoReflect.Descriptor instead.
+func (*Answer) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Answer) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Answer) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Answer) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type Message struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	Query         *Query                 `protobuf:"bytes,3,opt,name=query,proto3" json:"query,omitempty"`
+	Answer        *Answer                `protobuf:"bytes,4,opt,name=answer,proto3" json:"answer,omitempty"`
+	Timestamp     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Message) Reset() {
+	*x = Message{}
+	mi := &file_state_v1_state_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Message) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Message) ProtoMessage() {}
+
+func (x *Message) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Message.ProtoReflect.Descriptor instead.
+func (*Message) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Message) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Message) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Message) GetQuery() *Query {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *Message) GetAnswer() *Answer {
+	if x != nil {
+		return x.Answer
+	}
+	return nil
+}
+
+func (x *Message) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+type Context struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Workspace     map[string]string      `protobuf:"bytes,3,rep,name=workspace,proto3" json:"workspace,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Knowledge     map[string]string      `protobuf:"bytes,4,rep,name=knowledge,proto3" json:"knowledge,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Context) Reset() {
+	*x = Context{}
+	mi := &file_state_v1_state_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Context) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Context) ProtoMessage() {}
+
+func (x *Context) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Context.ProtoReflect.Descriptor instead.
+func (*Context) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Context) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Context) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Context) GetWorkspace() map[string]string {
+	if x != nil {
+		return x.Workspace
+	}
+	return nil
+}
+
+func (x *Context) GetKnowledge() map[string]string {
+	if x != nil {
+		return x.Knowledge
+	}
+	return nil
+}
+
+type Agent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Model         string                 `protobuf:"bytes,4,opt,name=model,proto3" json:"model,omitempty"`
+	Role          string                 `protobuf:"bytes,5,opt,name=role,proto3" json:"role,omitempty"`
+	SystemPrompt  string                 `protobuf:"bytes,6,opt,name=system_prompt,json=systemPrompt,proto3" json:"system_prompt,omitempty"`
+	UserPrompt    string                 `protobuf:"bytes,7,opt,name=user_prompt,json=userPrompt,proto3" json:"user_prompt,omitempty"`
+	Capabilities  []string               `protobuf:"bytes,8,rep,name=capabilities,proto3" json:"capabilities,omitempty"`
+	Parameters    *structpb.Struct       `protobuf:"bytes,9,opt,name=parameters,proto3" json:"parameters,omitempty"`
+	LastUpdatedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=last_updated_at,json=lastUpdatedAt,proto3" json:"last_updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Agent) Reset() {
+	*x = Agent{}
+	mi := &file_state_v1_state_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Agent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Agent) ProtoMessage() {}
+
+func (x *Agent) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Agent.ProtoReflect.Descriptor instead.
+func (*Agent) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Agent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Agent) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Agent) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Agent) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *Agent) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *Agent) GetSystemPrompt() string {
+	if x != nil {
+		return x.SystemPrompt
+	}
+	return ""
+}
+
+func (x *Agent) GetUserPrompt() string {
+	if x != nil {
+		return x.UserPrompt
+	}
+	return ""
+}
+
+func (x *Agent) GetCapabilities() []string {
+	if x != nil {
+		return x.Capabilities
+	}
+	return nil
+}
+
+func (x *Agent) GetParameters() *structpb.Struct {
+	if x != nil {
+		return x.Parameters
+	}
+	return nil
+}
+
+func (x *Agent) GetLastUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUpdatedAt
+	}
+	return nil
+}
+
+type AgentRef struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentRef) Reset() {
+	*x = AgentRef{}
+	mi := &file_state_v1_state_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentRef) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentRef) ProtoMessage() {}
+
+func (x *AgentRef) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentRef.ProtoReflect.Descriptor instead.
+func (*AgentRef) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *AgentRef) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AgentRef) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+type Tool struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	InputSchema   *structpb.Struct       `protobuf:"bytes,3,opt,name=input_schema,json=inputSchema,proto3" json:"input_schema,omitempty"`
+	OutputSchema  *structpb.Struct       `protobuf:"bytes,4,opt,name=output_schema,json=outputSchema,proto3" json:"output_schema,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tool) Reset() {
+	*x = Tool{}
+	mi := &file_state_v1_state_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tool) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tool) ProtoMessage() {}
+
+func (x *Tool) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tool.ProtoReflect.Descriptor instead.
+func (*Tool) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Tool) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Tool) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Tool) GetInputSchema() *structpb.Struct {
+	if x != nil {
+		return x.InputSchema
+	}
+	return nil
+}
+
+func (x *Tool) GetOutputSchema() *structpb.Struct {
+	if x != nil {
+		return x.OutputSchema
+	}
+	return nil
+}
+
+type Mcp struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Tools         []*Tool                `protobuf:"bytes,3,rep,name=tools,proto3" json:"tools,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Mcp) Reset() {
+	*x = Mcp{}
+	mi := &file_state_v1_state_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mcp) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mcp) ProtoMessage() {}
+
+func (x *Mcp) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mcp.ProtoReflect.Descriptor instead.
+func (*Mcp) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Mcp) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Mcp) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Mcp) GetTools() []*Tool {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+type McpToolInvocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ToolName      string                 `protobuf:"bytes,2,opt,name=tool_name,json=toolName,proto3" json:"tool_name,omitempty"`
+	McpId         string                 `protobuf:"bytes,3,opt,name=mcp_id,json=mcpId,proto3" json:"mcp_id,omitempty"`
+	Category      string                 `protobuf:"bytes,4,opt,name=category,proto3" json:"category,omitempty"`
+	Input         *structpb.Struct       `protobuf:"bytes,5,opt,name=input,proto3" json:"input,omitempty"`
+	Output        *structpb.Struct       `protobuf:"bytes,6,opt,name=output,proto3" json:"output,omitempty"`
+	Status        Status                 `protobuf:"varint,7,opt,name=status,proto3,enum=dhauli.state.v1.Status" json:"status,omitempty"`
+	AgentId       string                 `protobuf:"bytes,8,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,9,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt    *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Error         string                 `protobuf:"bytes,12,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *McpToolInvocation) Reset() {
+	*x = McpToolInvocation{}
+	mi := &file_state_v1_state_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *McpToolInvocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*McpToolInvocation) ProtoMessage() {}
+
+func (x *McpToolInvocation) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use McpToolInvocation.ProtoReflect.Descriptor instead.
+func (*McpToolInvocation) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *McpToolInvocation) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *McpToolInvocation) GetToolName() string {
+	if x != nil {
+		return x.ToolName
+	}
+	return ""
+}
+
+func (x *McpToolInvocation) GetMcpId() string {
+	if x != nil {
+		return x.McpId
+	}
+	return ""
+}
+
+func (x *McpToolInvocation) GetCategory() string {
+	if x != nil {
+		return x.Category
+	}
+	return ""
+}
+
+func (x *McpToolInvocation) GetInput() *structpb.Struct {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *McpToolInvocation) GetOutput() *structpb.Struct {
+	if x != nil {
+		return x.Output
+	}
+	return nil
+}
+
+func (x *McpToolInvocation) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *McpToolInvocation) GetAgentId() string {
+	if x != nil {
+		return x.AgentId
+	}
+	return ""
+}
+
+func (x *McpToolInvocation) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+func (x *McpToolInvocation) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *McpToolInvocation) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+func (x *McpToolInvocation) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type Artifact struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Id              string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name            string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Path            string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	Type            string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	Content         *structpb.Struct       `protobuf:"bytes,5,opt,name=content,proto3" json:"content,omitempty"`
+	CreatedByStepId string                 `protobuf:"bytes,6,opt,name=created_by_step_id,json=createdByStepId,proto3" json:"created_by_step_id,omitempty"`
+	CreatedAt       *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Artifact) Reset() {
+	*x = Artifact{}
+	mi := &file_state_v1_state_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Artifact) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Artifact) ProtoMessage() {}
+
+func (x *Artifact) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Artifact.ProtoReflect.Descriptor instead.
+func (*Artifact) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *Artifact) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Artifact) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Artifact) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *Artifact) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Artifact) GetContent() *structpb.Struct {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *Artifact) GetCreatedByStepId() string {
+	if x != nil {
+		return x.CreatedByStepId
+	}
+	return ""
+}
+
+func (x *Artifact) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type PlanStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	DependsOn     []string               `protobuf:"bytes,4,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanStep) Reset() {
+	*x = PlanStep{}
+	mi := &file_state_v1_state_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanStep) ProtoMessage() {}
+
+func (x *PlanStep) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanStep.ProtoReflect.Descriptor instead.
+func (*PlanStep) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *PlanStep) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlanStep) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PlanStep) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PlanStep) GetDependsOn() []string {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+type Plan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Steps         []*PlanStep            `protobuf:"bytes,2,rep,name=steps,proto3" json:"steps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Plan) Reset() {
+	*x = Plan{}
+	mi := &file_state_v1_state_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plan) ProtoMessage() {}
+
+func (x *Plan) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plan.ProtoReflect.Descriptor instead.
+func (*Plan) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Plan) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Plan) GetSteps() []*PlanStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+type Edge struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	From          string                 `protobuf:"bytes,1,opt,name=from,proto3" json:"from,omitempty"`
+	To            string                 `protobuf:"bytes,2,opt,name=to,proto3" json:"to,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Label         string                 `protobuf:"bytes,4,opt,name=label,proto3" json:"label,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Edge) Reset() {
+	*x = Edge{}
+	mi := &file_state_v1_state_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Edge) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Edge) ProtoMessage() {}
+
+func (x *Edge) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Edge.ProtoReflect.Descriptor instead.
+func (*Edge) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Edge) GetFrom() string {
+	if x != nil {
+		return x.From
+	}
+	return ""
+}
+
+func (x *Edge) GetTo() string {
+	if x != nil {
+		return x.To
+	}
+	return ""
+}
+
+func (x *Edge) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Edge) GetLabel() string {
+	if x != nil {
+		return x.Label
+	}
+	return ""
+}
+
+type ExecutionNode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StepId        string                 `protobuf:"bytes,1,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Status        Status                 `protobuf:"varint,3,opt,name=status,proto3,enum=dhauli.state.v1.Status" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutionNode) Reset() {
+	*x = ExecutionNode{}
+	mi := &file_state_v1_state_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionNode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionNode) ProtoMessage() {}
+
+func (x *ExecutionNode) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionNode.ProtoReflect.Descriptor instead.
+func (*ExecutionNode) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ExecutionNode) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+func (x *ExecutionNode) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ExecutionNode) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+type ExecutionGraph struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Nodes         []*ExecutionNode       `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Edges         []*Edge                `protobuf:"bytes,3,rep,name=edges,proto3" json:"edges,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecutionGraph) Reset() {
+	*x = ExecutionGraph{}
+	mi := &file_state_v1_state_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionGraph) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionGraph) ProtoMessage() {}
+
+func (x *ExecutionGraph) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionGraph.ProtoReflect.Descriptor instead.
+func (*ExecutionGraph) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ExecutionGraph) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ExecutionGraph) GetNodes() []*ExecutionNode {
+	if x != nil {
+		return x.Nodes
+	}
+	return nil
+}
+
+func (x *ExecutionGraph) GetEdges() []*Edge {
+	if x != nil {
+		return x.Edges
+	}
+	return nil
+}
+
+type ExecutionFlow struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Id               string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name             string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description      string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Agents           []*AgentRef            `protobuf:"bytes,4,rep,name=agents,proto3" json:"agents,omitempty"`
+	Variables        map[string]string      `protobuf:"bytes,5,rep,name=variables,proto3" json:"variables,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Mode             string                 `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`
+	Graph            *ExecutionGraph        `protobuf:"bytes,7,opt,name=graph,proto3" json:"graph,omitempty"`
+	AvailableMcpRefs []string               `protobuf:"bytes,8,rep,name=available_mcp_refs,json=availableMcpRefs,proto3" json:"available_mcp_refs,omitempty"`
+	PlanId           string                 `protobuf:"bytes,9,opt,name=plan_id,json=planId,proto3" json:"plan_id,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ExecutionFlow) Reset() {
+	*x = ExecutionFlow{}
+	mi := &file_state_v1_state_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecutionFlow) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecutionFlow) ProtoMessage() {}
+
+func (x *ExecutionFlow) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecutionFlow.ProtoReflect.Descriptor instead.
+func (*ExecutionFlow) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ExecutionFlow) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ExecutionFlow) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *ExecutionFlow) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ExecutionFlow) GetAgents() []*AgentRef {
+	if x != nil {
+		return x.Agents
+	}
+	return nil
+}
+
+func (x *ExecutionFlow) GetVariables() map[string]string {
+	if x != nil {
+		return x.Variables
+	}
+	return nil
+}
+
+func (x *ExecutionFlow) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *ExecutionFlow) GetGraph() *ExecutionGraph {
+	if x != nil {
+		return x.Graph
+	}
+	return nil
+}
+
+func (x *ExecutionFlow) GetAvailableMcpRefs() []string {
+	if x != nil {
+		return x.AvailableMcpRefs
+	}
+	return nil
+}
+
+func (x *ExecutionFlow) GetPlanId() string {
+	if x != nil {
+		return x.PlanId
+	}
+	return ""
+}
+
+type Step struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Index         int32                  `protobuf:"varint,2,opt,name=index,proto3" json:"index,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Status        Status                 `protobuf:"varint,4,opt,name=status,proto3,enum=dhauli.state.v1.Status" json:"status,omitempty"`
+	Error         string                 `protobuf:"bytes,5,opt,name=error,proto3" json:"error,omitempty"`
+	Agent         *Agent                 `protobuf:"bytes,6,opt,name=agent,proto3" json:"agent,omitempty"`
+	InputContext  *Context               `protobuf:"bytes,7,opt,name=input_context,json=inputContext,proto3" json:"input_context,omitempty"`
+	OutputContext *Context               `protobuf:"bytes,8,opt,name=output_context,json=outputContext,proto3" json:"output_context,omitempty"`
+	Query         *Query                 `protobuf:"bytes,9,opt,name=query,proto3" json:"query,omitempty"`
+	Answer        *Answer                `protobuf:"bytes,10,opt,name=answer,proto3" json:"answer,omitempty"`
+	Artifacts     []*Artifact            `protobuf:"bytes,11,rep,name=artifacts,proto3" json:"artifacts,omitempty"`
+	CuratedTools  []*McpToolInvocation   `protobuf:"bytes,12,rep,name=curated_tools,json=curatedTools,proto3" json:"curated_tools,omitempty"`
+	InputStepId   string                 `protobuf:"bytes,13,opt,name=input_step_id,json=inputStepId,proto3" json:"input_step_id,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt    *timestamppb.Timestamp `protobuf:"bytes,15,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Step) Reset() {
+	*x = Step{}
+	mi := &file_state_v1_state_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Step) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Step) ProtoMessage() {}
+
+func (x *Step) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Step.ProtoReflect.Descriptor instead.
+func (*Step) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *Step) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Step) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Step) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Step) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+func (x *Step) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+func (x *Step) GetAgent() *Agent {
+	if x != nil {
+		return x.Agent
+	}
+	return nil
+}
+
+func (x *Step) GetInputContext() *Context {
+	if x != nil {
+		return x.InputContext
+	}
+	return nil
+}
+
+func (x *Step) GetOutputContext() *Context {
+	if x != nil {
+		return x.OutputContext
+	}
+	return nil
+}
+
+func (x *Step) GetQuery() *Query {
+	if x != nil {
+		return x.Query
+	}
+	return nil
+}
+
+func (x *Step) GetAnswer() *Answer {
+	if x != nil {
+		return x.Answer
+	}
+	return nil
+}
+
+func (x *Step) GetArtifacts() []*Artifact {
+	if x != nil {
+		return x.Artifacts
+	}
+	return nil
+}
+
+func (x *Step) GetCuratedTools() []*McpToolInvocation {
+	if x != nil {
+		return x.CuratedTools
+	}
+	return nil
+}
+
+func (x *Step) GetInputStepId() string {
+	if x != nil {
+		return x.InputStepId
+	}
+	return ""
+}
+
+func (x *Step) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Step) GetFinishedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FinishedAt
+	}
+	return nil
+}
+
+type Interaction struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	BaseQuery     *Query                 `protobuf:"bytes,2,opt,name=base_query,json=baseQuery,proto3" json:"base_query,omitempty"`
+	BaseContext   *Context               `protobuf:"bytes,3,opt,name=base_context,json=baseContext,proto3" json:"base_context,omitempty"`
+	Plan          *Plan                  `protobuf:"bytes,4,opt,name=plan,proto3" json:"plan,omitempty"`
+	Workflow      *ExecutionFlow         `protobuf:"bytes,5,opt,name=workflow,proto3" json:"workflow,omitempty"`
+	Messages      []*Message             `protobuf:"bytes,6,rep,name=messages,proto3" json:"messages,omitempty"`
+	Summary       string                 `protobuf:"bytes,7,opt,name=summary,proto3" json:"summary,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	CompletedAt   *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Interaction) Reset() {
+	*x = Interaction{}
+	mi := &file_state_v1_state_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Interaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Interaction) ProtoMessage() {}
+
+func (x *Interaction) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Interaction.ProtoReflect.Descriptor instead.
+func (*Interaction) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Interaction) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Interaction) GetBaseQuery() *Query {
+	if x != nil {
+		return x.BaseQuery
+	}
+	return nil
+}
+
+func (x *Interaction) GetBaseContext() *Context {
+	if x != nil {
+		return x.BaseContext
+	}
+	return nil
+}
+
+func (x *Interaction) GetPlan() *Plan {
+	if x != nil {
+		return x.Plan
+	}
+	return nil
+}
+
+func (x *Interaction) GetWorkflow() *ExecutionFlow {
+	if x != nil {
+		return x.Workflow
+	}
+	return nil
+}
+
+func (x *Interaction) GetMessages() []*Message {
+	if x != nil {
+		return x.Messages
+	}
+	return nil
+}
+
+func (x *Interaction) GetSummary() string {
+	if x != nil {
+		return x.Summary
+	}
+	return ""
+}
+
+func (x *Interaction) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Interaction) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+// InteractionEvent is a state change of an interaction, data is the json document of the event
+type InteractionEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// stream_id is the position of the event in the stream of the interaction, a watch resumes
+	// after it with last_event_id
+	StreamId      string                 `protobuf:"bytes,1,opt,name=stream_id,json=streamId,proto3" json:"stream_id,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	InteractionId string                 `protobuf:"bytes,4,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,5,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,6,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,7,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	McpId         string                 `protobuf:"bytes,8,opt,name=mcp_id,json=mcpId,proto3" json:"mcp_id,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=time,proto3" json:"time,omitempty"`
+	Data          *structpb.Value        `protobuf:"bytes,10,opt,name=data,proto3" json:"data,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *InteractionEvent) Reset() {
+	*x = InteractionEvent{}
+	mi := &file_state_v1_state_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InteractionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InteractionEvent) ProtoMessage() {}
+
+func (x *InteractionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InteractionEvent.ProtoReflect.Descriptor instead.
+func (*InteractionEvent) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *InteractionEvent) GetStreamId() string {
+	if x != nil {
+		return x.StreamId
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetMcpId() string {
+	if x != nil {
+		return x.McpId
+	}
+	return ""
+}
+
+func (x *InteractionEvent) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *InteractionEvent) GetData() *structpb.Value {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+type GetInteractionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetInteractionRequest) Reset() {
+	*x = GetInteractionRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetInteractionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetInteractionRequest) ProtoMessage() {}
+
+func (x *GetInteractionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetInteractionRequest.ProtoReflect.Descriptor instead.
+func (*GetInteractionRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GetInteractionRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+type CreateInteractionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interaction   *Interaction           `protobuf:"bytes,1,opt,name=interaction,proto3" json:"interaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateInteractionRequest) Reset() {
+	*x = CreateInteractionRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateInteractionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateInteractionRequest) ProtoMessage() {}
+
+func (x *CreateInteractionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateInteractionRequest.ProtoReflect.Descriptor instead.
+func (*CreateInteractionRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *CreateInteractionRequest) GetInteraction() *Interaction {
+	if x != nil {
+		return x.Interaction
+	}
+	return nil
+}
+
+type UpdateInteractionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Interaction   *Interaction           `protobuf:"bytes,1,opt,name=interaction,proto3" json:"interaction,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateInteractionRequest) Reset() {
+	*x = UpdateInteractionRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInteractionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInteractionRequest) ProtoMessage() {}
+
+func (x *UpdateInteractionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInteractionRequest.ProtoReflect.Descriptor instead.
+func (*UpdateInteractionRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *UpdateInteractionRequest) GetInteraction() *Interaction {
+	if x != nil {
+		return x.Interaction
+	}
+	return nil
+}
+
+type DeleteInteractionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteInteractionRequest) Reset() {
+	*x = DeleteInteractionRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteInteractionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteInteractionRequest) ProtoMessage() {}
+
+func (x *DeleteInteractionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteInteractionRequest.ProtoReflect.Descriptor instead.
+func (*DeleteInteractionRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *DeleteInteractionRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+type UpdateExecutionFlowRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	Workflow      *ExecutionFlow         `protobuf:"bytes,3,opt,name=workflow,proto3" json:"workflow,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateExecutionFlowRequest) Reset() {
+	*x = UpdateExecutionFlowRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateExecutionFlowRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateExecutionFlowRequest) ProtoMessage() {}
+
+func (x *UpdateExecutionFlowRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateExecutionFlowRequest.ProtoReflect.Descriptor instead.
+func (*UpdateExecutionFlowRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *UpdateExecutionFlowRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *UpdateExecutionFlowRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *UpdateExecutionFlowRequest) GetWorkflow() *ExecutionFlow {
+	if x != nil {
+		return x.Workflow
+	}
+	return nil
+}
+
+type UpdateExecutionGraphRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	Graph         *ExecutionGraph        `protobuf:"bytes,4,opt,name=graph,proto3" json:"graph,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateExecutionGraphRequest) Reset() {
+	*x = UpdateExecutionGraphRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateExecutionGraphRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateExecutionGraphRequest) ProtoMessage() {}
+
+func (x *UpdateExecutionGraphRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateExecutionGraphRequest.ProtoReflect.Descriptor instead.
+func (*UpdateExecutionGraphRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *UpdateExecutionGraphRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *UpdateExecutionGraphRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *UpdateExecutionGraphRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *UpdateExecutionGraphRequest) GetGraph() *ExecutionGraph {
+	if x != nil {
+		return x.Graph
+	}
+	return nil
+}
+
+type WatchInteractionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	// last_event_id resumes after the event with this stream id, without it the watch starts with
+	// the events retained for the interaction
+	LastEventId string `protobuf:"bytes,2,opt,name=last_event_id,json=lastEventId,proto3" json:"last_event_id,omitempty"`
+	// types limits the watch to these event types, like StepStatusChanged
+	Types         []string `protobuf:"bytes,3,rep,name=types,proto3" json:"types,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchInteractionRequest) Reset() {
+	*x = WatchInteractionRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchInteractionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchInteractionRequest) ProtoMessage() {}
+
+func (x *WatchInteractionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchInteractionRequest.ProtoReflect.Descriptor instead.
+func (*WatchInteractionRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *WatchInteractionRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *WatchInteractionRequest) GetLastEventId() string {
+	if x != nil {
+		return x.LastEventId
+	}
+	return ""
+}
+
+func (x *WatchInteractionRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+type GetStepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,4,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStepRequest) Reset() {
+	*x = GetStepRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStepRequest) ProtoMessage() {}
+
+func (x *GetStepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStepRequest.ProtoReflect.Descriptor instead.
+func (*GetStepRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *GetStepRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *GetStepRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *GetStepRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *GetStepRequest) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+type CreateStepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	Step          *Step                  `protobuf:"bytes,4,opt,name=step,proto3" json:"step,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateStepRequest) Reset() {
+	*x = CreateStepRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateStepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateStepRequest) ProtoMessage() {}
+
+func (x *CreateStepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateStepRequest.ProtoReflect.Descriptor instead.
+func (*CreateStepRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *CreateStepRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *CreateStepRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *CreateStepRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *CreateStepRequest) GetStep() *Step {
+	if x != nil {
+		return x.Step
+	}
+	return nil
+}
+
+type UpdateStepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	Step          *Step                  `protobuf:"bytes,4,opt,name=step,proto3" json:"step,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStepRequest) Reset() {
+	*x = UpdateStepRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStepRequest) ProtoMessage() {}
+
+func (x *UpdateStepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStepRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStepRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *UpdateStepRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *UpdateStepRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *UpdateStepRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *UpdateStepRequest) GetStep() *Step {
+	if x != nil {
+		return x.Step
+	}
+	return nil
+}
+
+type UpdateStepStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,4,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	Status        Status                 `protobuf:"varint,5,opt,name=status,proto3,enum=dhauli.state.v1.Status" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateStepStatusRequest) Reset() {
+	*x = UpdateStepStatusRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateStepStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateStepStatusRequest) ProtoMessage() {}
+
+func (x *UpdateStepStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateStepStatusRequest.ProtoReflect.Descriptor instead.
+func (*UpdateStepStatusRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *UpdateStepStatusRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *UpdateStepStatusRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *UpdateStepStatusRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *UpdateStepStatusRequest) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+func (x *UpdateStepStatusRequest) GetStatus() Status {
+	if x != nil {
+		return x.Status
+	}
+	return Status_STATUS_UNSPECIFIED
+}
+
+type DeleteStepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,4,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteStepRequest) Reset() {
+	*x = DeleteStepRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteStepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteStepRequest) ProtoMessage() {}
+
+func (x *DeleteStepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteStepRequest.ProtoReflect.Descriptor instead.
+func (*DeleteStepRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *DeleteStepRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *DeleteStepRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *DeleteStepRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *DeleteStepRequest) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+type AddArtifactRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,4,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	Artifact      *Artifact              `protobuf:"bytes,5,opt,name=artifact,proto3" json:"artifact,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddArtifactRequest) Reset() {
+	*x = AddArtifactRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddArtifactRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddArtifactRequest) ProtoMessage() {}
+
+func (x *AddArtifactRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddArtifactRequest.ProtoReflect.Descriptor instead.
+func (*AddArtifactRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *AddArtifactRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *AddArtifactRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *AddArtifactRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *AddArtifactRequest) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+func (x *AddArtifactRequest) GetArtifact() *Artifact {
+	if x != nil {
+		return x.Artifact
+	}
+	return nil
+}
+
+type RecordToolInvocationRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,4,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	Invocation    *McpToolInvocation     `protobuf:"bytes,5,opt,name=invocation,proto3" json:"invocation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecordToolInvocationRequest) Reset() {
+	*x = RecordToolInvocationRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecordToolInvocationRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecordToolInvocationRequest) ProtoMessage() {}
+
+func (x *RecordToolInvocationRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecordToolInvocationRequest.ProtoReflect.Descriptor instead.
+func (*RecordToolInvocationRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *RecordToolInvocationRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *RecordToolInvocationRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *RecordToolInvocationRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *RecordToolInvocationRequest) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+func (x *RecordToolInvocationRequest) GetInvocation() *McpToolInvocation {
+	if x != nil {
+		return x.Invocation
+	}
+	return nil
+}
+
+type ListToolInvocationsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	ExecutionId   string                 `protobuf:"bytes,3,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
+	StepId        string                 `protobuf:"bytes,4,opt,name=step_id,json=stepId,proto3" json:"step_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolInvocationsRequest) Reset() {
+	*x = ListToolInvocationsRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolInvocationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolInvocationsRequest) ProtoMessage() {}
+
+func (x *ListToolInvocationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolInvocationsRequest.ProtoReflect.Descriptor instead.
+func (*ListToolInvocationsRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ListToolInvocationsRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *ListToolInvocationsRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *ListToolInvocationsRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
+func (x *ListToolInvocationsRequest) GetStepId() string {
+	if x != nil {
+		return x.StepId
+	}
+	return ""
+}
+
+type ListToolInvocationsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Invocations   []*McpToolInvocation   `protobuf:"bytes,1,rep,name=invocations,proto3" json:"invocations,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListToolInvocationsResponse) Reset() {
+	*x = ListToolInvocationsResponse{}
+	mi := &file_state_v1_state_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListToolInvocationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListToolInvocationsResponse) ProtoMessage() {}
+
+func (x *ListToolInvocationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListToolInvocationsResponse.ProtoReflect.Descriptor instead.
+func (*ListToolInvocationsResponse) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ListToolInvocationsResponse) GetInvocations() []*McpToolInvocation {
+	if x != nil {
+		return x.Invocations
+	}
+	return nil
+}
+
+type GetMcpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	McpId         string                 `protobuf:"bytes,3,opt,name=mcp_id,json=mcpId,proto3" json:"mcp_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMcpRequest) Reset() {
+	*x = GetMcpRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMcpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMcpRequest) ProtoMessage() {}
+
+func (x *GetMcpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMcpRequest.ProtoReflect.Descriptor instead.
+func (*GetMcpRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *GetMcpRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *GetMcpRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *GetMcpRequest) GetMcpId() string {
+	if x != nil {
+		return x.McpId
+	}
+	return ""
+}
+
+type CreateMcpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	Mcp           *Mcp                   `protobuf:"bytes,3,opt,name=mcp,proto3" json:"mcp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateMcpRequest) Reset() {
+	*x = CreateMcpRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateMcpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateMcpRequest) ProtoMessage() {}
+
+func (x *CreateMcpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateMcpRequest.ProtoReflect.Descriptor instead.
+func (*CreateMcpRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *CreateMcpRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *CreateMcpRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *CreateMcpRequest) GetMcp() *Mcp {
+	if x != nil {
+		return x.Mcp
+	}
+	return nil
+}
+
+type UpdateMcpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	Mcp           *Mcp                   `protobuf:"bytes,3,opt,name=mcp,proto3" json:"mcp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateMcpRequest) Reset() {
+	*x = UpdateMcpRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMcpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMcpRequest) ProtoMessage() {}
+
+func (x *UpdateMcpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateMcpRequest.ProtoReflect.Descriptor instead.
+func (*UpdateMcpRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *UpdateMcpRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *UpdateMcpRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *UpdateMcpRequest) GetMcp() *Mcp {
+	if x != nil {
+		return x.Mcp
+	}
+	return nil
+}
+
+type DeleteMcpRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	McpId         string                 `protobuf:"bytes,3,opt,name=mcp_id,json=mcpId,proto3" json:"mcp_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMcpRequest) Reset() {
+	*x = DeleteMcpRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMcpRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMcpRequest) ProtoMessage() {}
+
+func (x *DeleteMcpRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMcpRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMcpRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *DeleteMcpRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *DeleteMcpRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *DeleteMcpRequest) GetMcpId() string {
+	if x != nil {
+		return x.McpId
+	}
+	return ""
+}
+
+type AddToolRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	InteractionId string                 `protobuf:"bytes,1,opt,name=interaction_id,json=interactionId,proto3" json:"interaction_id,omitempty"`
+	WorkflowId    string                 `protobuf:"bytes,2,opt,name=workflow_id,json=workflowId,proto3" json:"workflow_id,omitempty"`
+	McpId         string                 `protobuf:"bytes,3,opt,name=mcp_id,json=mcpId,proto3" json:"mcp_id,omitempty"`
+	Tool          *Tool                  `protobuf:"bytes,4,opt,name=tool,proto3" json:"tool,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddToolRequest) Reset() {
+	*x = AddToolRequest{}
+	mi := &file_state_v1_state_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddToolRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddToolRequest) ProtoMessage() {}
+
+func (x *AddToolRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_state_v1_state_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddToolRequest.ProtoReflect.Descriptor instead.
+func (*AddToolRequest) Descriptor() ([]byte, []int) {
+	return file_state_v1_state_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *AddToolRequest) GetInteractionId() string {
+	if x != nil {
+		return x.InteractionId
+	}
+	return ""
+}
+
+func (x *AddToolRequest) GetWorkflowId() string {
+	if x != nil {
+		return x.WorkflowId
+	}
+	return ""
+}
+
+func (x *AddToolRequest) GetMcpId() string {
+	if x != nil {
+		return x.McpId
+	}
+	return ""
+}
+
+func (x *AddToolRequest) GetTool() *Tool {
+	if x != nil {
+		return x.Tool
+	}
+	return nil
+}
+
+var File_state_v1_state_proto protoreflect.FileDescriptor
+
+const file_state_v1_state_proto_rawDesc = "" +
+	"\n" +
+	"\x14state/v1/state.proto\x12\x0fdhauli.state.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1cgoogle/protobuf/struct.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xfe\x01\n" +
+	"\x05Query\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x12\n" +
+	"\x04tags\x18\x03 \x03(\tR\x04tags\x12@\n" +
+	"\bmetadata\x18\x04 \x03(\v2$.dhauli.state.v1.Query.MetadataEntryR\bmetadata\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"l\n" +
+	"\x06Answer\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x128\n" +
+	"\ttimestamp\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\xc6\x01\n" +
+	"\aMessage\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12,\n" +
+	"\x05query\x18\x03 \x01(\v2\x16.dhauli.state.v1.QueryR\x05query\x12/\n" +
+	"\x06answer\x18\x04 \x01(\v2\x17.dhauli.state.v1.AnswerR\x06answer\x128\n" +
+	"\ttimestamp\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\ttimestamp\"\xbd\x02\n" +
+	"\aContext\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12E\n" +
+	"\tworkspace\x18\x03 \x03(\v2'.dhauli.state.v1.Context.WorkspaceEntryR\tworkspace\x12E\n" +
+	"\tknowledge\x18\x04 \x03(\v2'.dhauli.state.v1.Context.KnowledgeEntryR\tknowledge\x1a<\n" +
+	"\x0eWorkspaceEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a<\n" +
+	"\x0eKnowledgeEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xde\x02\n" +
+	"\x05Agent\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x14\n" +
+	"\x05model\x18\x04 \x01(\tR\x05model\x12\x12\n" +
+	"\x04role\x18\x05 \x01(\tR\x04role\x12#\n" +
+	"\rsystem_prompt\x18\x06 \x01(\tR\fsystemPrompt\x12\x1f\n" +
+	"\vuser_prompt\x18\a \x01(\tR\n" +
+	"userPrompt\x12\"\n" +
+	"\fcapabilities\x18\b \x03(\tR\fcapabilities\x127\n" +
+	"\n" +
+	"parameters\x18\t \x01(\v2\x17.google.protobuf.StructR\n" +
+	"parameters\x12B\n" +
+	"\x0flast_updated_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\rlastUpdatedAt\".\n" +
+	"\bAgentRef\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\"\xb6\x01\n" +
+	"\x04Tool\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12:\n" +
+	"\finput_schema\x18\x03 \x01(\v2\x17.google.protobuf.StructR\vinputSchema\x12<\n" +
+	"\routput_schema\x18\x04 \x01(\v2\x17.google.protobuf.StructR\foutputSchema\"V\n" +
+	"\x03Mcp\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12+\n" +
+	"\x05tools\x18\x03 \x03(\v2\x15.dhauli.state.v1.ToolR\x05tools\"\xc6\x03\n" +
+	"\x11McpToolInvocation\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\ttool_name\x18\x02 \x01(\tR\btoolName\x12\x15\n" +
+	"\x06mcp_id\x18\x03 \x01(\tR\x05mcpId\x12\x1a\n" +
+	"\bcategory\x18\x04 \x01(\tR\bcategory\x12-\n" +
+	"\x05input\x18\x05 \x01(\v2\x17.google.protobuf.StructR\x05input\x12/\n" +
+	"\x06output\x18\x06 \x01(\v2\x17.google.protobuf.StructR\x06output\x12/\n" +
+	"\x06status\x18\a \x01(\x0e2\x17.dhauli.state.v1.StatusR\x06status\x12\x19\n" +
+	"\bagent_id\x18\b \x01(\tR\aagentId\x12\x17\n" +
+	"\astep_id\x18\t \x01(\tR\x06stepId\x129\n" +
+	"\n" +
+	"started_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\x12\x14\n" +
+	"\x05error\x18\f \x01(\tR\x05error\"\xf1\x01\n" +
+	"\bArtifact\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x121\n" +
+	"\acontent\x18\x05 \x01(\v2\x17.google.protobuf.StructR\acontent\x12+\n" +
+	"\x12created_by_step_id\x18\x06 \x01(\tR\x0fcreatedByStepId\x129\n" +
+	"\n" +
+	"created_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"o\n" +
+	"\bPlanStep\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\x04 \x03(\tR\tdependsOn\"G\n" +
+	"\x04Plan\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12/\n" +
+	"\x05steps\x18\x02 \x03(\v2\x19.dhauli.state.v1.PlanStepR\x05steps\"T\n" +
+	"\x04Edge\x12\x12\n" +
+	"\x04from\x18\x01 \x01(\tR\x04from\x12\x0e\n" +
+	"\x02to\x18\x02 \x01(\tR\x02to\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x14\n" +
+	"\x05label\x18\x04 \x01(\tR\x05label\"m\n" +
+	"\rExecutionNode\x12\x17\n" +
+	"\astep_id\x18\x01 \x01(\tR\x06stepId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12/\n" +
+	"\x06status\x18\x03 \x01(\x0e2\x17.dhauli.state.v1.StatusR\x06status\"\x83\x01\n" +
+	"\x0eExecutionGraph\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x124\n" +
+	"\x05nodes\x18\x02 \x03(\v2\x1e.dhauli.state.v1.ExecutionNodeR\x05nodes\x12+\n" +
+	"\x05edges\x18\x03 \x03(\v2\x15.dhauli.state.v1.EdgeR\x05edges\"\xa5\x03\n" +
+	"\rExecutionFlow\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x121\n" +
+	"\x06agents\x18\x04 \x03(\v2\x19.dhauli.state.v1.AgentRefR\x06agents\x12K\n" +
+	"\tvariables\x18\x05 \x03(\v2-.dhauli.state.v1.ExecutionFlow.VariablesEntryR\tvariables\x12\x12\n" +
+	"\x04mode\x18\x06 \x01(\tR\x04mode\x125\n" +
+	"\x05graph\x18\a \x01(\v2\x1f.dhauli.state.v1.ExecutionGraphR\x05graph\x12,\n" +
+	"\x12available_mcp_refs\x18\b \x03(\tR\x10availableMcpRefs\x12\x17\n" +
+	"\aplan_id\x18\t \x01(\tR\x06planId\x1a<\n" +
+	"\x0eVariablesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xb2\x05\n" +
+	"\x04Step\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12/\n" +
+	"\x06status\x18\x04 \x01(\x0e2\x17.dhauli.state.v1.StatusR\x06status\x12\x14\n" +
+	"\x05error\x18\x05 \x01(\tR\x05error\x12,\n" +
+	"\x05agent\x18\x06 \x01(\v2\x16.dhauli.state.v1.AgentR\x05agent\x12=\n" +
+	"\rinput_context\x18\a \x01(\v2\x18.dhauli.state.v1.ContextR\finputContext\x12?\n" +
+	"\x0eoutput_context\x18\b \x01(\v2\x18.dhauli.state.v1.ContextR\routputContext\x12,\n" +
+	"\x05query\x18\t \x01(\v2\x16.dhauli.state.v1.QueryR\x05query\x12/\n" +
+	"\x06answer\x18\n" +
+	" \x01(\v2\x17.dhauli.state.v1.AnswerR\x06answer\x127\n" +
+	"\tartifacts\x18\v \x03(\v2\x19.dhauli.state.v1.ArtifactR\tartifacts\x12G\n" +
+	"\rcurated_tools\x18\f \x03(\v2\".dhauli.state.v1.McpToolInvocationR\fcuratedTools\x12\"\n" +
+	"\rinput_step_id\x18\r \x01(\tR\vinputStepId\x129\n" +
+	"\n" +
+	"started_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12;\n" +
+	"\vfinished_at\x18\x0f \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"finishedAt\"\xc2\x03\n" +
+	"\vInteraction\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x125\n" +
+	"\n" +
+	"base_query\x18\x02 \x01(\v2\x16.dhauli.state.v1.QueryR\tbaseQuery\x12;\n" +
+	"\fbase_context\x18\x03 \x01(\v2\x18.dhauli.state.v1.ContextR\vbaseContext\x12)\n" +
+	"\x04plan\x18\x04 \x01(\v2\x15.dhauli.state.v1.PlanR\x04plan\x12:\n" +
+	"\bworkflow\x18\x05 \x01(\v2\x1e.dhauli.state.v1.ExecutionFlowR\bworkflow\x124\n" +
+	"\bmessages\x18\x06 \x03(\v2\x18.dhauli.state.v1.MessageR\bmessages\x12\x18\n" +
+	"\asummary\x18\a \x01(\tR\asummary\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x12=\n" +
+	"\fcompleted_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\"\xca\x02\n" +
+	"\x10InteractionEvent\x12\x1b\n" +
+	"\tstream_id\x18\x01 \x01(\tR\bstreamId\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12%\n" +
+	"\x0einteraction_id\x18\x04 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x05 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x06 \x01(\tR\vexecutionId\x12\x17\n" +
+	"\astep_id\x18\a \x01(\tR\x06stepId\x12\x15\n" +
+	"\x06mcp_id\x18\b \x01(\tR\x05mcpId\x12.\n" +
+	"\x04time\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12*\n" +
+	"\x04data\x18\n" +
+	" \x01(\v2\x16.google.protobuf.ValueR\x04data\">\n" +
+	"\x15GetInteractionRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\"Z\n" +
+	"\x18CreateInteractionRequest\x12>\n" +
+	"\vinteraction\x18\x01 \x01(\v2\x1c.dhauli.state.v1.InteractionR\vinteraction\"Z\n" +
+	"\x18UpdateInteractionRequest\x12>\n" +
+	"\vinteraction\x18\x01 \x01(\v2\x1c.dhauli.state.v1.InteractionR\vinteraction\"A\n" +
+	"\x18DeleteInteractionRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\"\xa0\x01\n" +
+	"\x1aUpdateExecutionFlowRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12:\n" +
+	"\bworkflow\x18\x03 \x01(\v2\x1e.dhauli.state.v1.ExecutionFlowR\bworkflow\"\xbf\x01\n" +
+	"\x1bUpdateExecutionGraphRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x125\n" +
+	"\x05graph\x18\x04 \x01(\v2\x1f.dhauli.state.v1.ExecutionGraphR\x05graph\"z\n" +
+	"\x17WatchInteractionRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\"\n" +
+	"\rlast_event_id\x18\x02 \x01(\tR\vlastEventId\x12\x14\n" +
+	"\x05types\x18\x03 \x03(\tR\x05types\"\x94\x01\n" +
+	"\x0eGetStepRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12\x17\n" +
+	"\astep_id\x18\x04 \x01(\tR\x06stepId\"\xa9\x01\n" +
+	"\x11CreateStepRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12)\n" +
+	"\x04step\x18\x04 \x01(\v2\x15.dhauli.state.v1.StepR\x04step\"\xa9\x01\n" +
+	"\x11UpdateStepRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12)\n" +
+	"\x04step\x18\x04 \x01(\v2\x15.dhauli.state.v1.StepR\x04step\"\xce\x01\n" +
+	"\x17UpdateStepStatusRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12\x17\n" +
+	"\astep_id\x18\x04 \x01(\tR\x06stepId\x12/\n" +
+	"\x06status\x18\x05 \x01(\x0e2\x17.dhauli.state.v1.StatusR\x06status\"\x97\x01\n" +
+	"\x11DeleteStepRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12\x17\n" +
+	"\astep_id\x18\x04 \x01(\tR\x06stepId\"\xcf\x01\n" +
+	"\x12AddArtifactRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12\x17\n" +
+	"\astep_id\x18\x04 \x01(\tR\x06stepId\x125\n" +
+	"\bartifact\x18\x05 \x01(\v2\x19.dhauli.state.v1.ArtifactR\bartifact\"\xe5\x01\n" +
+	"\x1bRecordToolInvocationRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12\x17\n" +
+	"\astep_id\x18\x04 \x01(\tR\x06stepId\x12B\n" +
+	"\n" +
+	"invocation\x18\x05 \x01(\v2\".dhauli.state.v1.McpToolInvocationR\n" +
+	"invocation\"\xa0\x01\n" +
+	"\x1aListToolInvocationsRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12!\n" +
+	"\fexecution_id\x18\x03 \x01(\tR\vexecutionId\x12\x17\n" +
+	"\astep_id\x18\x04 \x01(\tR\x06stepId\"c\n" +
+	"\x1bListToolInvocationsResponse\x12D\n" +
+	"\vinvocations\x18\x01 \x03(\v2\".dhauli.state.v1.McpToolInvocationR\vinvocations\"n\n" +
+	"\rGetMcpRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12\x15\n" +
+	"\x06mcp_id\x18\x03 \x01(\tR\x05mcpId\"\x82\x01\n" +
+	"\x10CreateMcpRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12&\n" +
+	"\x03mcp\x18\x03 \x01(\v2\x14.dhauli.state.v1.McpR\x03mcp\"\x82\x01\n" +
+	"\x10UpdateMcpRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12&\n" +
+	"\x03mcp\x18\x03 \x01(\v2\x14.dhauli.state.v1.McpR\x03mcp\"q\n" +
+	"\x10DeleteMcpRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12\x15\n" +
+	"\x06mcp_id\x18\x03 \x01(\tR\x05mcpId\"\x9a\x01\n" +
+	"\x0eAddToolRequest\x12%\n" +
+	"\x0einteraction_id\x18\x01 \x01(\tR\rinteractionId\x12\x1f\n" +
+	"\vworkflow_id\x18\x02 \x01(\tR\n" +
+	"workflowId\x12\x15\n" +
+	"\x06mcp_id\x18\x03 \x01(\tR\x05mcpId\x12)\n" +
+	"\x04tool\x18\x04 \x01(\v2\x15.dhauli.state.v1.ToolR\x04tool*\x7f\n" +
+	"\x06Status\x12\x16\n" +
+	"\x12STATUS_UNSPECIFIED\x10\x00\x12\x12\n" +
+	"\x0eSTATUS_PENDING\x10\x01\x12\x12\n" +
+	"\x0eSTATUS_RUNNING\x10\x02\x12\x12\n" +
+	"\x0eSTATUS_SUCCESS\x10\x03\x12\x10\n" +
+	"\fSTATUS_ERROR\x10\x04\x12\x0f\n" +
+	"\vSTATUS_STOP\x10\x052\xa9\x05\n" +
+	"\x12InteractionService\x12V\n" +
+	"\x0eGetInteraction\x12&.dhauli.state.v1.GetInteractionRequest\x1a\x1c.dhauli.state.v1.Interaction\x12\\\n" +
+	"\x11CreateInteraction\x12).dhauli.state.v1.CreateInteractionRequest\x1a\x1c.dhauli.state.v1.Interaction\x12\\\n" +
+	"\x11UpdateInteraction\x12).dhauli.state.v1.UpdateInteractionRequest\x1a\x1c.dhauli.state.v1.Interaction\x12V\n" +
+	"\x11DeleteInteraction\x12).dhauli.state.v1.DeleteInteractionRequest\x1a\x16.google.protobuf.Empty\x12`\n" +
+	"\x13UpdateExecutionFlow\x12+.dhauli.state.v1.UpdateExecutionFlowRequest\x1a\x1c.dhauli.state.v1.Interaction\x12b\n" +
+	"\x14UpdateExecutionGraph\x12,.dhauli.state.v1.UpdateExecutionGraphRequest\x1a\x1c.dhauli.state.v1.Interaction\x12a\n" +
+	"\x10WatchInteraction\x12(.dhauli.state.v1.WatchInteractionRequest\x1a!.dhauli.state.v1.InteractionEvent0\x012\xcc\x03\n" +
+	"\vStepService\x12A\n" +
+	"\aGetStep\x12\x1f.dhauli.state.v1.GetStepRequest\x1a\x15.dhauli.state.v1.Step\x12G\n" +
+	"\n" +
+	"CreateStep\x12\".dhauli.state.v1.CreateStepRequest\x1a\x15.dhauli.state.v1.Step\x12G\n" +
+	"\n" +
+	"UpdateStep\x12\".dhauli.state.v1.UpdateStepRequest\x1a\x15.dhauli.state.v1.Step\x12S\n" +
+	"\x10UpdateStepStatus\x12(.dhauli.state.v1.UpdateStepStatusRequest\x1a\x15.dhauli.state.v1.Step\x12H\n" +
+	"\n" +
+	"DeleteStep\x12\".dhauli.state.v1.DeleteStepRequest\x1a\x16.google.protobuf.Empty\x12I\n" +
+	"\vAddArtifact\x12#.dhauli.state.v1.AddArtifactRequest\x1a\x15.dhauli.state.v1.Step2\xe6\x01\n" +
+	"\x15ToolInvocationService\x12[\n" +
+	"\x14RecordToolInvocation\x12,.dhauli.state.v1.RecordToolInvocationRequest\x1a\x15.dhauli.state.v1.Step\x12p\n" +
+	"\x13ListToolInvocations\x12+.dhauli.state.v1.ListToolInvocationsRequest\x1a,.dhauli.state.v1.ListToolInvocationsResponse2\xe2\x02\n" +
+	"\n" +
+	"McpService\x12>\n" +
+	"\x06GetMcp\x12\x1e.dhauli.state.v1.GetMcpRequest\x1a\x14.dhauli.state.v1.Mcp\x12D\n" +
+	"\tCreateMcp\x12!.dhauli.state.v1.CreateMcpRequest\x1a\x14.dhauli.state.v1.Mcp\x12D\n" +
+	"\tUpdateMcp\x12!.dhauli.state.v1.UpdateMcpRequest\x1a\x14.dhauli.state.v1.Mcp\x12F\n" +
+	"\tDeleteMcp\x12!.dhauli.state.v1.DeleteMcpRequest\x1a\x16.google.protobuf.Empty\x12@\n" +
+	"\aAddTool\x12\x1f.dhauli.state.v1.AddToolRequest\x1a\x14.dhauli.state.v1.McpB:Z8github.com/mangudaigb/state-service/api/state/v1;statev1b\x06proto3"
+
+var (
+	file_state_v1_state_proto_rawDescOnce sync.Once
+	file_state_v1_state_proto_rawDescData []byte
+)
+
+func file_state_v1_state_proto_rawDescGZIP() []byte {
+	file_state_v1_state_proto_rawDescOnce.Do(func() {
+		file_state_v1_state_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_state_v1_state_proto_rawDesc), len(file_state_v1_state_proto_rawDesc)))
+	})
+	return file_state_v1_state_proto_rawDescData
+}
+
+var file_state_v1_state_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_state_v1_state_proto_msgTypes = make([]protoimpl.MessageInfo, 44)
+var file_state_v1_state_proto_goTypes = []any{
+	(Status)(0),                         // 0: dhauli.state.v1.Status
+	(*Query)(nil),                       // 1: dhauli.state.v1.Query
+	(*Answer)(nil),                      // 2: dhauli.state.v1.Answer
+	(*Message)(nil),                     // 3: dhauli.state.v1.Message
+	(*Context)(nil),                     // 4: dhauli.state.v1.Context
+	(*Agent)(nil),                       // 5: dhauli.state.v1.Agent
+	(*AgentRef)(nil),                    // 6: dhauli.state.v1.AgentRef
+	(*Tool)(nil),                        // 7: dhauli.state.v1.Tool
+	(*Mcp)(nil),                         // 8: dhauli.state.v1.Mcp
+	(*McpToolInvocation)(nil),           // 9: dhauli.state.v1.McpToolInvocation
+	(*Artifact)(nil),                    // 10: dhauli.state.v1.Artifact
+	(*PlanStep)(nil),                    // 11: dhauli.state.v1.PlanStep
+	(*Plan)(nil),                        // 12: dhauli.state.v1.Plan
+	(*Edge)(nil),                        // 13: dhauli.state.v1.Edge
+	(*ExecutionNode)(nil),               // 14: dhauli.state.v1.ExecutionNode
+	(*ExecutionGraph)(nil),              // 15: dhauli.state.v1.ExecutionGraph
+	(*ExecutionFlow)(nil),               // 16: dhauli.state.v1.ExecutionFlow
+	(*Step)(nil),                        // 17: dhauli.state.v1.Step
+	(*Interaction)(nil),                 // 18: dhauli.state.v1.Interaction
+	(*InteractionEvent)(nil),            // 19: dhauli.state.v1.InteractionEvent
+	(*GetInteractionRequest)(nil),       // 20: dhauli.state.v1.GetInteractionRequest
+	(*CreateInteractionRequest)(nil),    // 21: dhauli.state.v1.CreateInteractionRequest
+	(*UpdateInteractionRequest)(nil),    // 22: dhauli.state.v1.UpdateInteractionRequest
+	(*DeleteInteractionRequest)(nil),    // 23: dhauli.state.v1.DeleteInteractionRequest
+	(*UpdateExecutionFlowRequest)(nil),  // 24: dhauli.state.v1.UpdateExecutionFlowRequest
+	(*UpdateExecutionGraphRequest)(nil), // 25: dhauli.state.v1.UpdateExecutionGraphRequest
+	(*WatchInteractionRequest)(nil),     // 26: dhauli.state.v1.WatchInteractionRequest
+	(*GetStepRequest)(nil),              // 27: dhauli.state.v1.GetStepRequest
+	(*CreateStepRequest)(nil),           // 28: dhauli.state.v1.CreateStepRequest
+	(*UpdateStepRequest)(nil),           // 29: dhauli.state.v1.UpdateStepRequest
+	(*UpdateStepStatusRequest)(nil),     // 30: dhauli.state.v1.UpdateStepStatusRequest
+	(*DeleteStepRequest)(nil),           // 31: dhauli.state.v1.DeleteStepRequest
+	(*AddArtifactRequest)(nil),          // 32: dhauli.state.v1.AddArtifactRequest
+	(*RecordToolInvocationRequest)(nil), // 33: dhauli.state.v1.RecordToolInvocationRequest
+	(*ListToolInvocationsRequest)(nil),  // 34: dhauli.state.v1.ListToolInvocationsRequest
+	(*ListToolInvocationsResponse)(nil), // 35: dhauli.state.v1.ListToolInvocationsResponse
+	(*GetMcpRequest)(nil),               // 36: dhauli.state.v1.GetMcpRequest
+	(*CreateMcpRequest)(nil),            // 37: dhauli.state.v1.CreateMcpRequest
+	(*UpdateMcpRequest)(nil),            // 38: dhauli.state.v1.UpdateMcpRequest
+	(*DeleteMcpRequest)(nil),            // 39: dhauli.state.v1.DeleteMcpRequest
+	(*AddToolRequest)(nil),              // 40: dhauli.state.v1.AddToolRequest
+	nil,                                 // 41: dhauli.state.v1.Query.MetadataEntry
+	nil,                                 // 42: dhauli.state.v1.Context.WorkspaceEntry
+	nil,                                 // 43: dhauli.state.v1.Context.KnowledgeEntry
+	nil,                                 // 44: dhauli.state.v1.ExecutionFlow.VariablesEntry
+	(*timestamppb.Timestamp)(nil),       // 45: google.protobuf.Timestamp
+	(*structpb.Struct)(nil),             // 46: google.protobuf.Struct
+	(*structpb.Value)(nil),              // 47: google.protobuf.Value
+	(*emptypb.Empty)(nil),               // 48: google.protobuf.Empty
+}
+var file_state_v1_state_proto_depIdxs = []int32{
+	41, // 0: dhauli.state.v1.Query.metadata:type_name -> dhauli.state.v1.Query.MetadataEntry
+	45, // 1: dhauli.state.v1.Query.timestamp:type_name -> google.protobuf.Timestamp
+	45, // 2: dhauli.state.v1.Answer.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 3: dhauli.state.v1.Message.query:type_name -> dhauli.state.v1.Query
+	2,  // 4: dhauli.state.v1.Message.answer:type_name -> dhauli.state.v1.Answer
+	45, // 5: dhauli.state.v1.Message.timestamp:type_name -> google.protobuf.Timestamp
+	42, // 6: dhauli.state.v1.Context.workspace:type_name -> dhauli.state.v1.Context.WorkspaceEntry
+	43, // 7: dhauli.state.v1.Context.knowledge:type_name -> dhauli.state.v1.Context.KnowledgeEntry
+	46, // 8: dhauli.state.v1.Agent.parameters:type_name -> google.protobuf.Struct
+	45, // 9: dhauli.state.v1.Agent.last_updated_at:type_name -> google.protobuf.Timestamp
+	46, // 10: dhauli.state.v1.Tool.input_schema:type_name -> google.protobuf.Struct
+	46, // 11: dhauli.state.v1.Tool.output_schema:type_name -> google.protobuf.Struct
+	7,  // 12: dhauli.state.v1.Mcp.tools:type_name -> dhauli.state.v1.Tool
+	46, // 13: dhauli.state.v1.McpToolInvocation.input:type_name -> google.protobuf.Struct
+	46, // 14: dhauli.state.v1.McpToolInvocation.output:type_name -> google.protobuf.Struct
+	0,  // 15: dhauli.state.v1.McpToolInvocation.status:type_name -> dhauli.state.v1.Status
+	45, // 16: dhauli.state.v1.McpToolInvocation.started_at:type_name -> google.protobuf.Timestamp
+	45, // 17: dhauli.state.v1.McpToolInvocation.finished_at:type_name -> google.protobuf.Timestamp
+	46, // 18: dhauli.state.v1.Artifact.content:type_name -> google.protobuf.Struct
+	45, // 19: dhauli.state.v1.Artifact.created_at:type_name -> google.protobuf.Timestamp
+	11, // 20: dhauli.state.v1.Plan.steps:type_name -> dhauli.state.v1.PlanStep
+	0,  // 21: dhauli.state.v1.ExecutionNode.status:type_name -> dhauli.state.v1.Status
+	14, // 22: dhauli.state.v1.ExecutionGraph.nodes:type_name -> dhauli.state.v1.ExecutionNode
+	13, // 23: dhauli.state.v1.ExecutionGraph.edges:type_name -> dhauli.state.v1.Edge
+	6,  // 24: dhauli.state.v1.ExecutionFlow.agents:type_name -> dhauli.state.v1.AgentRef
+	44, // 25: dhauli.state.v1.ExecutionFlow.variables:type_name -> dhauli.state.v1.ExecutionFlow.VariablesEntry
+	15, // 26: dhauli.state.v1.ExecutionFlow.graph:type_name -> dhauli.state.v1.ExecutionGraph
+	0,  // 27: dhauli.state.v1.Step.status:type_name -> dhauli.state.v1.Status
+	5,  // 28: dhauli.state.v1.Step.agent:type_name -> dhauli.state.v1.Agent
+	4,  // 29: dhauli.state.v1.Step.input_context:type_name -> dhauli.state.v1.Context
+	4,  // 30: dhauli.state.v1.Step.output_context:type_name -> dhauli.state.v1.Context
+	1,  // 31: dhauli.state.v1.Step.query:type_name -> dhauli.state.v1.Query
+	2,  // 32: dhauli.state.v1.Step.answer:type_name -> dhauli.state.v1.Answer
+	10, // 33: dhauli.state.v1.Step.artifacts:type_name -> dhauli.state.v1.Artifact
+	9,  // 34: dhauli.state.v1.Step.curated_tools:type_name -> dhauli.state.v1.McpToolInvocation
+	45, // 35: dhauli.state.v1.Step.started_at:type_name -> google.protobuf.Timestamp
+	45, // 36: dhauli.state.v1.Step.finished_at:type_name -> google.protobuf.Timestamp
+	1,  // 37: dhauli.state.v1.Interaction.base_query:type_name -> dhauli.state.v1.Query
+	4,  // 38: dhauli.state.v1.Interaction.base_context:type_name -> dhauli.state.v1.Context
+	12, // 39: dhauli.state.v1.Interaction.plan:type_name -> dhauli.state.v1.Plan
+	16, // 40: dhauli.state.v1.Interaction.workflow:type_name -> dhauli.state.v1.ExecutionFlow
+	3,  // 41: dhauli.state.v1.Interaction.messages:type_name -> dhauli.state.v1.Message
+	45, // 42: dhauli.state.v1.Interaction.created_at:type_name -> google.protobuf.Timestamp
+	45, // 43: dhauli.state.v1.Interaction.completed_at:type_name -> google.protobuf.Timestamp
+	45, // 44: dhauli.state.v1.InteractionEvent.time:type_name -> google.protobuf.Timestamp
+	47, // 45: dhauli.state.v1.InteractionEvent.data:type_name -> google.protobuf.Value
+	18, // 46: dhauli.state.v1.CreateInteractionRequest.interaction:type_name -> dhauli.state.v1.Interaction
+	18, // 47: dhauli.state.v1.UpdateInteractionRequest.interaction:type_name -> dhauli.state.v1.Interaction
+	16, // 48: dhauli.state.v1.UpdateExecutionFlowRequest.workflow:type_name -> dhauli.state.v1.ExecutionFlow
+	15, // 49: dhauli.state.v1.UpdateExecutionGraphRequest.graph:type_name -> dhauli.state.v1.ExecutionGraph
+	17, // 50: dhauli.state.v1.CreateStepRequest.step:type_name -> dhauli.state.v1.Step
+	17, // 51: dhauli.state.v1.UpdateStepRequest.step:type_name -> dhauli.state.v1.Step
+	0,  // 52: dhauli.state.v1.UpdateStepStatusRequest.status:type_name -> dhauli.state.v1.Status
+	10, // 53: dhauli.state.v1.AddArtifactRequest.artifact:type_name -> dhauli.state.v1.Artifact
+	9,  // 54: dhauli.state.v1.RecordToolInvocationRequest.invocation:type_name -> dhauli.state.v1.McpToolInvocation
+	9,  // 55: dhauli.state.v1.ListToolInvocationsResponse.invocations:type_name -> dhauli.state.v1.McpToolInvocation
+	8,  // 56: dhauli.state.v1.CreateMcpRequest.mcp:type_name -> dhauli.state.v1.Mcp
+	8,  // 57: dhauli.state.v1.UpdateMcpRequest.mcp:type_name -> dhauli.state.v1.Mcp
+	7,  // 58: dhauli.state.v1.AddToolRequest.tool:type_name -> dhauli.state.v1.Tool
+	20, // 59: dhauli.state.v1.InteractionService.GetInteraction:input_type -> dhauli.state.v1.GetInteractionRequest
+	21, // 60: dhauli.state.v1.InteractionService.CreateInteraction:input_type -> dhauli.state.v1.CreateInteractionRequest
+	22, // 61: dhauli.state.v1.InteractionService.UpdateInteraction:input_type -> dhauli.state.v1.UpdateInteractionRequest
+	23, // 62: dhauli.state.v1.InteractionService.DeleteInteraction:input_type -> dhauli.state.v1.DeleteInteractionRequest
+	24, // 63: dhauli.state.v1.InteractionService.UpdateExecutionFlow:input_type -> dhauli.state.v1.UpdateExecutionFlowRequest
+	25, // 64: dhauli.state.v1.InteractionService.UpdateExecutionGraph:input_type -> dhauli.state.v1.UpdateExecutionGraphRequest
+	26, // 65: dhauli.state.v1.InteractionService.WatchInteraction:input_type -> dhauli.state.v1.WatchInteractionRequest
+	27, // 66: dhauli.state.v1.StepService.GetStep:input_type -> dhauli.state.v1.GetStepRequest
+	28, // 67: dhauli.state.v1.StepService.CreateStep:input_type -> dhauli.state.v1.CreateStepRequest
+	29, // 68: dhauli.state.v1.StepService.UpdateStep:input_type -> dhauli.state.v1.UpdateStepRequest
+	30, // 69: dhauli.state.v1.StepService.UpdateStepStatus:input_type -> dhauli.state.v1.UpdateStepStatusRequest
+	31, // 70: dhauli.state.v1.StepService.DeleteStep:input_type -> dhauli.state.v1.DeleteStepRequest
+	32, // 71: dhauli.state.v1.StepService.AddArtifact:input_type -> dhauli.state.v1.AddArtifactRequest
+	33, // 72: dhauli.state.v1.ToolInvocationService.RecordToolInvocation:input_type -> dhauli.state.v1.RecordToolInvocationRequest
+	34, // 73: dhauli.state.v1.ToolInvocationService.ListToolInvocations:input_type -> dhauli.state.v1.ListToolInvocationsRequest
+	36, // 74: dhauli.state.v1.McpService.GetMcp:input_type -> dhauli.state.v1.GetMcpRequest
+	37, // 75: dhauli.state.v1.McpService.CreateMcp:input_type -> dhauli.state.v1.CreateMcpRequest
+	38, // 76: dhauli.state.v1.McpService.UpdateMcp:input_type -> dhauli.state.v1.UpdateMcpRequest
+	39, // 77: dhauli.state.v1.McpService.DeleteMcp:input_type -> dhauli.state.v1.DeleteMcpRequest
+	40, // 78: dhauli.state.v1.McpService.AddTool:input_type -> dhauli.state.v1.AddToolRequest
+	18, // 79: dhauli.state.v1.InteractionService.GetInteraction:output_type -> dhauli.state.v1.Interaction
+	18, // 80: dhauli.state.v1.InteractionService.CreateInteraction:output_type -> dhauli.state.v1.Interaction
+	18, // 81: dhauli.state.v1.InteractionService.UpdateInteraction:output_type -> dhauli.state.v1.Interaction
+	48, // 82: dhauli.state.v1.InteractionService.DeleteInteraction:output_type -> google.protobuf.Empty
+	18, // 83: dhauli.state.v1.InteractionService.UpdateExecutionFlow:output_type -> dhauli.state.v1.Interaction
+	18, // 84: dhauli.state.v1.InteractionService.UpdateExecutionGraph:output_type -> dhauli.state.v1.Interaction
+	19, // 85: dhauli.state.v1.InteractionService.WatchInteraction:output_type -> dhauli.state.v1.InteractionEvent
+	17, // 86: dhauli.state.v1.StepService.GetStep:output_type -> dhauli.state.v1.Step
+	17, // 87: dhauli.state.v1.StepService.CreateStep:output_type -> dhauli.state.v1.Step
+	17, // 88: dhauli.state.v1.StepService.UpdateStep:output_type -> dhauli.state.v1.Step
+	17, // 89: dhauli.state.v1.StepService.UpdateStepStatus:output_type -> dhauli.state.v1.Step
+	48, // 90: dhauli.state.v1.StepService.DeleteStep:output_type -> google.protobuf.Empty
+	17, // 91: dhauli.state.v1.StepService.AddArtifact:output_type -> dhauli.state.v1.Step
+	17, // 92: dhauli.state.v1.ToolInvocationService.RecordToolInvocation:output_type -> dhauli.state.v1.Step
+	35, // 93: dhauli.state.v1.ToolInvocationService.ListToolInvocations:output_type -> dhauli.state.v1.ListToolInvocationsResponse
+	8,  // 94: dhauli.state.v1.McpService.GetMcp:output_type -> dhauli.state.v1.Mcp
+	8,  // 95: dhauli.state.v1.McpService.CreateMcp:output_type -> dhauli.state.v1.Mcp
+	8,  // 96: dhauli.state.v1.McpService.UpdateMcp:output_type -> dhauli.state.v1.Mcp
+	48, // 97: dhauli.state.v1.McpService.DeleteMcp:output_type -> google.protobuf.Empty
+	8,  // 98: dhauli.state.v1.McpService.AddTool:output_type -> dhauli.state.v1.Mcp
+	79, // [79:99] is the sub-list for method output_type
+	59, // [59:79] is the sub-list for method input_type
+	59, // [59:59] is the sub-list for extension type_name
+	59, // [59:59] is the sub-list for extension extendee
+	0,  // [0:59] is the sub-list for field type_name
+}
+
+func init() { file_state_v1_state_proto_init() }
+func file_state_v1_state_proto_init() {
+	if File_state_v1_state_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_state_v1_state_proto_rawDesc), len(file_state_v1_state_proto_rawDesc)),
+			NumEnums:      1,
+			NumMessages:   44,
+			NumExtensions: 0,
+			NumServices:   4,
+		},
+		GoTypes:           file_state_v1_state_proto_goTypes,
+		DependencyIndexes: file_state_v1_state_proto_depIdxs,
+		EnumInfos:         file_state_v1_state_proto_enumTypes,
+		MessageInfos:      file_state_v1_state_proto_msgTypes,
+	}.Build()
+	File_state_v1_state_proto = out.File
+	file_state_v1_state_proto_goTypes = nil
+	file_state_v1_state_proto_depIdxs = nil
+}
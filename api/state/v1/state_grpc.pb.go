@@ -0,0 +1,1045 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: state/v1/state.proto
+
+// The gRPC API of the state service. It serves the same operations as the REST API under /api/v1
+// through the same services, the messages mirror the dhauli-base runtime types field by field.
+
+package statev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	InteractionService_GetInteraction_FullMethodName       = "/dhauli.state.v1.InteractionService/GetInteraction"
+	InteractionService_CreateInteraction_FullMethodName    = "/dhauli.state.v1.InteractionService/CreateInteraction"
+	InteractionService_UpdateInteraction_FullMethodName    = "/dhauli.state.v1.InteractionService/UpdateInteraction"
+	InteractionService_DeleteInteraction_FullMethodName    = "/dhauli.state.v1.InteractionService/DeleteInteraction"
+	InteractionService_UpdateExecutionFlow_FullMethodName  = "/dhauli.state.v1.InteractionService/UpdateExecutionFlow"
+	InteractionService_UpdateExecutionGraph_FullMethodName = "/dhauli.state.v1.InteractionService/UpdateExecutionGraph"
+	InteractionService_WatchInteraction_FullMethodName     = "/dhauli.state.v1.InteractionService/WatchInteraction"
+)
+
+// InteractionServiceClient is the client API for InteractionService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type InteractionServiceClient interface {
+	GetInteraction(ctx context.Context, in *GetInteractionRequest, opts ...grpc.CallOption) (*Interaction, error)
+	CreateInteraction(ctx context.Context, in *CreateInteractionRequest, opts ...grpc.CallOption) (*Interaction, error)
+	UpdateInteraction(ctx context.Context, in *UpdateInteractionRequest, opts ...grpc.CallOption) (*Interaction, error)
+	DeleteInteraction(ctx context.Context, in *DeleteInteractionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	UpdateExecutionFlow(ctx context.Context, in *UpdateExecutionFlowRequest, opts ...grpc.CallOption) (*Interaction, error)
+	UpdateExecutionGraph(ctx context.Context, in *UpdateExecutionGraphRequest, opts ...grpc.CallOption) (*Interaction, error)
+	// WatchInteraction streams the events of an interaction until the client cancels
+	WatchInteraction(ctx context.Context, in *WatchInteractionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InteractionEvent], error)
+}
+
+type interactionServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewInteractionServiceClient(cc grpc.ClientConnInterface) InteractionServiceClient {
+	return &interactionServiceClient{cc}
+}
+
+func (c *interactionServiceClient) GetInteraction(ctx context.Context, in *GetInteractionRequest, opts ...grpc.CallOption) (*Interaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Interaction)
+	err := c.cc.Invoke(ctx, InteractionService_GetInteraction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interactionServiceClient) CreateInteraction(ctx context.Context, in *CreateInteractionRequest, opts ...grpc.CallOption) (*Interaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Interaction)
+	err := c.cc.Invoke(ctx, InteractionService_CreateInteraction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interactionServiceClient) UpdateInteraction(ctx context.Context, in *UpdateInteractionRequest, opts ...grpc.CallOption) (*Interaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Interaction)
+	err := c.cc.Invoke(ctx, InteractionService_UpdateInteraction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interactionServiceClient) DeleteInteraction(ctx context.Context, in *DeleteInteractionRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, InteractionService_DeleteInteraction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interactionServiceClient) UpdateExecutionFlow(ctx context.Context, in *UpdateExecutionFlowRequest, opts ...grpc.CallOption) (*Interaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Interaction)
+	err := c.cc.Invoke(ctx, InteractionService_UpdateExecutionFlow_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interactionServiceClient) UpdateExecutionGraph(ctx context.Context, in *UpdateExecutionGraphRequest, opts ...grpc.CallOption) (*Interaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Interaction)
+	err := c.cc.Invoke(ctx, InteractionService_UpdateExecutionGraph_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *interactionServiceClient) WatchInteraction(ctx context.Context, in *WatchInteractionRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[InteractionEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &InteractionService_ServiceDesc.Streams[0], InteractionService_WatchInteraction_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchInteractionRequest, InteractionEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type InteractionService_WatchInteractionClient = grpc.ServerStreamingClient[InteractionEvent]
+
+// InteractionServiceServer is the server API for InteractionService service.
+// All implementations must embed UnimplementedInteractionServiceServer
+// for forward compatibility.
+type InteractionServiceServer interface {
+	GetInteraction(context.Context, *GetInteractionRequest) (*Interaction, error)
+	CreateInteraction(context.Context, *CreateInteractionRequest) (*Interaction, error)
+	UpdateInteraction(context.Context, *UpdateInteractionRequest) (*Interaction, error)
+	DeleteInteraction(context.Context, *DeleteInteractionRequest) (*emptypb.Empty, error)
+	UpdateExecutionFlow(context.Context, *UpdateExecutionFlowRequest) (*Interaction, error)
+	UpdateExecutionGraph(context.Context, *UpdateExecutionGraphRequest) (*Interaction, error)
+	// WatchInteraction streams the events of an interaction until the client cancels
+	WatchInteraction(*WatchInteractionRequest, grpc.ServerStreamingServer[InteractionEvent]) error
+	mustEmbedUnimplementedInteractionServiceServer()
+}
+
+// UnimplementedInteractionServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedInteractionServiceServer struct{}
+
+func (UnimplementedInteractionServiceServer) GetInteraction(context.Context, *GetInteractionRequest) (*Interaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetInteraction not implemented")
+}
+func (UnimplementedInteractionServiceServer) CreateInteraction(context.Context, *CreateInteractionRequest) (*Interaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateInteraction not implemented")
+}
+func (UnimplementedInteractionServiceServer) UpdateInteraction(context.Context, *UpdateInteractionRequest) (*Interaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateInteraction not implemented")
+}
+func (UnimplementedInteractionServiceServer) DeleteInteraction(context.Context, *DeleteInteractionRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteInteraction not implemented")
+}
+func (UnimplementedInteractionServiceServer) UpdateExecutionFlow(context.Context, *UpdateExecutionFlowRequest) (*Interaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateExecutionFlow not implemented")
+}
+func (UnimplementedInteractionServiceServer) UpdateExecutionGraph(context.Context, *UpdateExecutionGraphRequest) (*Interaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateExecutionGraph not implemented")
+}
+func (UnimplementedInteractionServiceServer) WatchInteraction(*WatchInteractionRequest, grpc.ServerStreamingServer[InteractionEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchInteraction not implemented")
+}
+func (UnimplementedInteractionServiceServer) mustEmbedUnimplementedInteractionServiceServer() {}
+func (UnimplementedInteractionServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafeInteractionServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to InteractionServiceServer will
+// result in compilation errors.
+type UnsafeInteractionServiceServer interface {
+	mustEmbedUnimplementedInteractionServiceServer()
+}
+
+func RegisterInteractionServiceServer(s grpc.ServiceRegistrar, srv InteractionServiceServer) {
+	// If the following call pancis, it indicates UnimplementedInteractionServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&InteractionService_ServiceDesc, srv)
+}
+
+func _InteractionService_GetInteraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetInteractionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InteractionServiceServer).GetInteraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InteractionService_GetInteraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InteractionServiceServer).GetInteraction(ctx, req.(*GetInteractionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InteractionService_CreateInteraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateInteractionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InteractionServiceServer).CreateInteraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InteractionService_CreateInteraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InteractionServiceServer).CreateInteraction(ctx, req.(*CreateInteractionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InteractionService_UpdateInteraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateInteractionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InteractionServiceServer).UpdateInteraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InteractionService_UpdateInteraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InteractionServiceServer).UpdateInteraction(ctx, req.(*UpdateInteractionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InteractionService_DeleteInteraction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteInteractionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InteractionServiceServer).DeleteInteraction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InteractionService_DeleteInteraction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InteractionServiceServer).DeleteInteraction(ctx, req.(*DeleteInteractionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InteractionService_UpdateExecutionFlow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateExecutionFlowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InteractionServiceServer).UpdateExecutionFlow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InteractionService_UpdateExecutionFlow_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InteractionServiceServer).UpdateExecutionFlow(ctx, req.(*UpdateExecutionFlowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InteractionService_UpdateExecutionGraph_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateExecutionGraphRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(InteractionServiceServer).UpdateExecutionGraph(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: InteractionService_UpdateExecutionGraph_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(InteractionServiceServer).UpdateExecutionGraph(ctx, req.(*UpdateExecutionGraphRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _InteractionService_WatchInteraction_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchInteractionRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(InteractionServiceServer).WatchInteraction(m, &grpc.GenericServerStream[WatchInteractionRequest, InteractionEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type InteractionService_WatchInteractionServer = grpc.ServerStreamingServer[InteractionEvent]
+
+// InteractionService_ServiceDesc is the grpc.ServiceDesc for InteractionService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var InteractionService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dhauli.state.v1.InteractionService",
+	HandlerType: (*InteractionServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetInteraction",
+			Handler:    _InteractionService_GetInteraction_Handler,
+		},
+		{
+			MethodName: "CreateInteraction",
+			Handler:    _InteractionService_CreateInteraction_Handler,
+		},
+		{
+			MethodName: "UpdateInteraction",
+			Handler:    _InteractionService_UpdateInteraction_Handler,
+		},
+		{
+			MethodName: "DeleteInteraction",
+			Handler:    _InteractionService_DeleteInteraction_Handler,
+		},
+		{
+			MethodName: "UpdateExecutionFlow",
+			Handler:    _InteractionService_UpdateExecutionFlow_Handler,
+		},
+		{
+			MethodName: "UpdateExecutionGraph",
+			Handler:    _InteractionService_UpdateExecutionGraph_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchInteraction",
+			Handler:       _InteractionService_WatchInteraction_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "state/v1/state.proto",
+}
+
+const (
+	StepService_GetStep_FullMethodName          = "/dhauli.state.v1.StepService/GetStep"
+	StepService_CreateStep_FullMethodName       = "/dhauli.state.v1.StepService/CreateStep"
+	StepService_UpdateStep_FullMethodName       = "/dhauli.state.v1.StepService/UpdateStep"
+	StepService_UpdateStepStatus_FullMethodName = "/dhauli.state.v1.StepService/UpdateStepStatus"
+	StepService_DeleteStep_FullMethodName       = "/dhauli.state.v1.StepService/DeleteStep"
+	StepService_AddArtifact_FullMethodName      = "/dhauli.state.v1.StepService/AddArtifact"
+)
+
+// StepServiceClient is the client API for StepService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type StepServiceClient interface {
+	GetStep(ctx context.Context, in *GetStepRequest, opts ...grpc.CallOption) (*Step, error)
+	CreateStep(ctx context.Context, in *CreateStepRequest, opts ...grpc.CallOption) (*Step, error)
+	UpdateStep(ctx context.Context, in *UpdateStepRequest, opts ...grpc.CallOption) (*Step, error)
+	UpdateStepStatus(ctx context.Context, in *UpdateStepStatusRequest, opts ...grpc.CallOption) (*Step, error)
+	DeleteStep(ctx context.Context, in *DeleteStepRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AddArtifact(ctx context.Context, in *AddArtifactRequest, opts ...grpc.CallOption) (*Step, error)
+}
+
+type stepServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStepServiceClient(cc grpc.ClientConnInterface) StepServiceClient {
+	return &stepServiceClient{cc}
+}
+
+func (c *stepServiceClient) GetStep(ctx context.Context, in *GetStepRequest, opts ...grpc.CallOption) (*Step, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Step)
+	err := c.cc.Invoke(ctx, StepService_GetStep_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stepServiceClient) CreateStep(ctx context.Context, in *CreateStepRequest, opts ...grpc.CallOption) (*Step, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Step)
+	err := c.cc.Invoke(ctx, StepService_CreateStep_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stepServiceClient) UpdateStep(ctx context.Context, in *UpdateStepRequest, opts ...grpc.CallOption) (*Step, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Step)
+	err := c.cc.Invoke(ctx, StepService_UpdateStep_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stepServiceClient) UpdateStepStatus(ctx context.Context, in *UpdateStepStatusRequest, opts ...grpc.CallOption) (*Step, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Step)
+	err := c.cc.Invoke(ctx, StepService_UpdateStepStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stepServiceClient) DeleteStep(ctx context.Context, in *DeleteStepRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, StepService_DeleteStep_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *stepServiceClient) AddArtifact(ctx context.Context, in *AddArtifactRequest, opts ...grpc.CallOption) (*Step, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Step)
+	err := c.cc.Invoke(ctx, StepService_AddArtifact_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// StepServiceServer is the server API for StepService service.
+// All implementations must embed UnimplementedStepServiceServer
+// for forward compatibility.
+type StepServiceServer interface {
+	GetStep(context.Context, *GetStepRequest) (*Step, error)
+	CreateStep(context.Context, *CreateStepRequest) (*Step, error)
+	UpdateStep(context.Context, *UpdateStepRequest) (*Step, error)
+	UpdateStepStatus(context.Context, *UpdateStepStatusRequest) (*Step, error)
+	DeleteStep(context.Context, *DeleteStepRequest) (*emptypb.Empty, error)
+	AddArtifact(context.Context, *AddArtifactRequest) (*Step, error)
+	mustEmbedUnimplementedStepServiceServer()
+}
+
+// UnimplementedStepServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStepServiceServer struct{}
+
+func (UnimplementedStepServiceServer) GetStep(context.Context, *GetStepRequest) (*Step, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStep not implemented")
+}
+func (UnimplementedStepServiceServer) CreateStep(context.Context, *CreateStepRequest) (*Step, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateStep not implemented")
+}
+func (UnimplementedStepServiceServer) UpdateStep(context.Context, *UpdateStepRequest) (*Step, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateStep not implemented")
+}
+func (UnimplementedStepServiceServer) UpdateStepStatus(context.Context, *UpdateStepStatusRequest) (*Step, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateStepStatus not implemented")
+}
+func (UnimplementedStepServiceServer) DeleteStep(context.Context, *DeleteStepRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteStep not implemented")
+}
+func (UnimplementedStepServiceServer) AddArtifact(context.Context, *AddArtifactRequest) (*Step, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddArtifact not implemented")
+}
+func (UnimplementedStepServiceServer) mustEmbedUnimplementedStepServiceServer() {}
+func (UnimplementedStepServiceServer) testEmbeddedByValue()                     {}
+
+// UnsafeStepServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StepServiceServer will
+// result in compilation errors.
+type UnsafeStepServiceServer interface {
+	mustEmbedUnimplementedStepServiceServer()
+}
+
+func RegisterStepServiceServer(s grpc.ServiceRegistrar, srv StepServiceServer) {
+	// If the following call pancis, it indicates UnimplementedStepServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StepService_ServiceDesc, srv)
+}
+
+func _StepService_GetStep_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StepServiceServer).GetStep(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StepService_GetStep_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StepServiceServer).GetStep(ctx, req.(*GetStepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StepService_CreateStep_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateStepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StepServiceServer).CreateStep(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StepService_CreateStep_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StepServiceServer).CreateStep(ctx, req.(*CreateStepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StepService_UpdateStep_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateStepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StepServiceServer).UpdateStep(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StepService_UpdateStep_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StepServiceServer).UpdateStep(ctx, req.(*UpdateStepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StepService_UpdateStepStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateStepStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StepServiceServer).UpdateStepStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StepService_UpdateStepStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StepServiceServer).UpdateStepStatus(ctx, req.(*UpdateStepStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StepService_DeleteStep_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteStepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StepServiceServer).DeleteStep(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StepService_DeleteStep_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StepServiceServer).DeleteStep(ctx, req.(*DeleteStepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StepService_AddArtifact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddArtifactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StepServiceServer).AddArtifact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: StepService_AddArtifact_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StepServiceServer).AddArtifact(ctx, req.(*AddArtifactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// StepService_ServiceDesc is the grpc.ServiceDesc for StepService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StepService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dhauli.state.v1.StepService",
+	HandlerType: (*StepServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetStep",
+			Handler:    _StepService_GetStep_Handler,
+		},
+		{
+			MethodName: "CreateStep",
+			Handler:    _StepService_CreateStep_Handler,
+		},
+		{
+			MethodName: "UpdateStep",
+			Handler:    _StepService_UpdateStep_Handler,
+		},
+		{
+			MethodName: "UpdateStepStatus",
+			Handler:    _StepService_UpdateStepStatus_Handler,
+		},
+		{
+			MethodName: "DeleteStep",
+			Handler:    _StepService_DeleteStep_Handler,
+		},
+		{
+			MethodName: "AddArtifact",
+			Handler:    _StepService_AddArtifact_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "state/v1/state.proto",
+}
+
+const (
+	ToolInvocationService_RecordToolInvocation_FullMethodName = "/dhauli.state.v1.ToolInvocationService/RecordToolInvocation"
+	ToolInvocationService_ListToolInvocations_FullMethodName  = "/dhauli.state.v1.ToolInvocationService/ListToolInvocations"
+)
+
+// ToolInvocationServiceClient is the client API for ToolInvocationService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type ToolInvocationServiceClient interface {
+	RecordToolInvocation(ctx context.Context, in *RecordToolInvocationRequest, opts ...grpc.CallOption) (*Step, error)
+	ListToolInvocations(ctx context.Context, in *ListToolInvocationsRequest, opts ...grpc.CallOption) (*ListToolInvocationsResponse, error)
+}
+
+type toolInvocationServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewToolInvocationServiceClient(cc grpc.ClientConnInterface) ToolInvocationServiceClient {
+	return &toolInvocationServiceClient{cc}
+}
+
+func (c *toolInvocationServiceClient) RecordToolInvocation(ctx context.Context, in *RecordToolInvocationRequest, opts ...grpc.CallOption) (*Step, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Step)
+	err := c.cc.Invoke(ctx, ToolInvocationService_RecordToolInvocation_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *toolInvocationServiceClient) ListToolInvocations(ctx context.Context, in *ListToolInvocationsRequest, opts ...grpc.CallOption) (*ListToolInvocationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListToolInvocationsResponse)
+	err := c.cc.Invoke(ctx, ToolInvocationService_ListToolInvocations_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ToolInvocationServiceServer is the server API for ToolInvocationService service.
+// All implementations must embed UnimplementedToolInvocationServiceServer
+// for forward compatibility.
+type ToolInvocationServiceServer interface {
+	RecordToolInvocation(context.Context, *RecordToolInvocationRequest) (*Step, error)
+	ListToolInvocations(context.Context, *ListToolInvocationsRequest) (*ListToolInvocationsResponse, error)
+	mustEmbedUnimplementedToolInvocationServiceServer()
+}
+
+// UnimplementedToolInvocationServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedToolInvocationServiceServer struct{}
+
+func (UnimplementedToolInvocationServiceServer) RecordToolInvocation(context.Context, *RecordToolInvocationRequest) (*Step, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RecordToolInvocation not implemented")
+}
+func (UnimplementedToolInvocationServiceServer) ListToolInvocations(context.Context, *ListToolInvocationsRequest) (*ListToolInvocationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListToolInvocations not implemented")
+}
+func (UnimplementedToolInvocationServiceServer) mustEmbedUnimplementedToolInvocationServiceServer() {}
+func (UnimplementedToolInvocationServiceServer) testEmbeddedByValue()                               {}
+
+// UnsafeToolInvocationServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ToolInvocationServiceServer will
+// result in compilation errors.
+type UnsafeToolInvocationServiceServer interface {
+	mustEmbedUnimplementedToolInvocationServiceServer()
+}
+
+func RegisterToolInvocationServiceServer(s grpc.ServiceRegistrar, srv ToolInvocationServiceServer) {
+	// If the following call pancis, it indicates UnimplementedToolInvocationServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ToolInvocationService_ServiceDesc, srv)
+}
+
+func _ToolInvocationService_RecordToolInvocation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecordToolInvocationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ToolInvocationServiceServer).RecordToolInvocation(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ToolInvocationService_RecordToolInvocation_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ToolInvocationServiceServer).RecordToolInvocation(ctx, req.(*RecordToolInvocationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ToolInvocationService_ListToolInvocations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListToolInvocationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ToolInvocationServiceServer).ListToolInvocations(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ToolInvocationService_ListToolInvocations_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ToolInvocationServiceServer).ListToolInvocations(ctx, req.(*ListToolInvocationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ToolInvocationService_ServiceDesc is the grpc.ServiceDesc for ToolInvocationService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ToolInvocationService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dhauli.state.v1.ToolInvocationService",
+	HandlerType: (*ToolInvocationServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "RecordToolInvocation",
+			Handler:    _ToolInvocationService_RecordToolInvocation_Handler,
+		},
+		{
+			MethodName: "ListToolInvocations",
+			Handler:    _ToolInvocationService_ListToolInvocations_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "state/v1/state.proto",
+}
+
+const (
+	McpService_GetMcp_FullMethodName    = "/dhauli.state.v1.McpService/GetMcp"
+	McpService_CreateMcp_FullMethodName = "/dhauli.state.v1.McpService/CreateMcp"
+	McpService_UpdateMcp_FullMethodName = "/dhauli.state.v1.McpService/UpdateMcp"
+	McpService_DeleteMcp_FullMethodName = "/dhauli.state.v1.McpService/DeleteMcp"
+	McpService_AddTool_FullMethodName   = "/dhauli.state.v1.McpService/AddTool"
+)
+
+// McpServiceClient is the client API for McpService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type McpServiceClient interface {
+	GetMcp(ctx context.Context, in *GetMcpRequest, opts ...grpc.CallOption) (*Mcp, error)
+	CreateMcp(ctx context.Context, in *CreateMcpRequest, opts ...grpc.CallOption) (*Mcp, error)
+	UpdateMcp(ctx context.Context, in *UpdateMcpRequest, opts ...grpc.CallOption) (*Mcp, error)
+	DeleteMcp(ctx context.Context, in *DeleteMcpRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	AddTool(ctx context.Context, in *AddToolRequest, opts ...grpc.CallOption) (*Mcp, error)
+}
+
+type mcpServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMcpServiceClient(cc grpc.ClientConnInterface) McpServiceClient {
+	return &mcpServiceClient{cc}
+}
+
+func (c *mcpServiceClient) GetMcp(ctx context.Context, in *GetMcpRequest, opts ...grpc.CallOption) (*Mcp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Mcp)
+	err := c.cc.Invoke(ctx, McpService_GetMcp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mcpServiceClient) CreateMcp(ctx context.Context, in *CreateMcpRequest, opts ...grpc.CallOption) (*Mcp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Mcp)
+	err := c.cc.Invoke(ctx, McpService_CreateMcp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mcpServiceClient) UpdateMcp(ctx context.Context, in *UpdateMcpRequest, opts ...grpc.CallOption) (*Mcp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Mcp)
+	err := c.cc.Invoke(ctx, McpService_UpdateMcp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mcpServiceClient) DeleteMcp(ctx context.Context, in *DeleteMcpRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, McpService_DeleteMcp_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *mcpServiceClient) AddTool(ctx context.Context, in *AddToolRequest, opts ...grpc.CallOption) (*Mcp, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Mcp)
+	err := c.cc.Invoke(ctx, McpService_AddTool_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// McpServiceServer is the server API for McpService service.
+// All implementations must embed UnimplementedMcpServiceServer
+// for forward compatibility.
+type McpServiceServer interface {
+	GetMcp(context.Context, *GetMcpRequest) (*Mcp, error)
+	CreateMcp(context.Context, *CreateMcpRequest) (*Mcp, error)
+	UpdateMcp(context.Context, *UpdateMcpRequest) (*Mcp, error)
+	DeleteMcp(context.Context, *DeleteMcpRequest) (*emptypb.Empty, error)
+	AddTool(context.Context, *AddToolRequest) (*Mcp, error)
+	mustEmbedUnimplementedMcpServiceServer()
+}
+
+// UnimplementedMcpServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMcpServiceServer struct{}
+
+func (UnimplementedMcpServiceServer) GetMcp(context.Context, *GetMcpRequest) (*Mcp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetMcp not implemented")
+}
+func (UnimplementedMcpServiceServer) CreateMcp(context.Context, *CreateMcpRequest) (*Mcp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateMcp not implemented")
+}
+func (UnimplementedMcpServiceServer) UpdateMcp(context.Context, *UpdateMcpRequest) (*Mcp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateMcp not implemented")
+}
+func (UnimplementedMcpServiceServer) DeleteMcp(context.Context, *DeleteMcpRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMcp not implemented")
+}
+func (UnimplementedMcpServiceServer) AddTool(context.Context, *AddToolRequest) (*Mcp, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddTool not implemented")
+}
+func (UnimplementedMcpServiceServer) mustEmbedUnimplementedMcpServiceServer() {}
+func (UnimplementedMcpServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeMcpServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to McpServiceServer will
+// result in compilation errors.
+type UnsafeMcpServiceServer interface {
+	mustEmbedUnimplementedMcpServiceServer()
+}
+
+func RegisterMcpServiceServer(s grpc.ServiceRegistrar, srv McpServiceServer) {
+	// If the following call pancis, it indicates UnimplementedMcpServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&McpService_ServiceDesc, srv)
+}
+
+func _McpService_GetMcp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetMcpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(McpServiceServer).GetMcp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: McpService_GetMcp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(McpServiceServer).GetMcp(ctx, req.(*GetMcpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _McpService_CreateMcp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateMcpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(McpServiceServer).CreateMcp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: McpService_CreateMcp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(McpServiceServer).CreateMcp(ctx, req.(*CreateMcpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _McpService_UpdateMcp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateMcpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(McpServiceServer).UpdateMcp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: McpService_UpdateMcp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(McpServiceServer).UpdateMcp(ctx, req.(*UpdateMcpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _McpService_DeleteMcp_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMcpRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(McpServiceServer).DeleteMcp(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: McpService_DeleteMcp_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(McpServiceServer).DeleteMcp(ctx, req.(*DeleteMcpRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _McpService_AddTool_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddToolRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(McpServiceServer).AddTool(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: McpService_AddTool_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(McpServiceServer).AddTool(ctx, req.(*AddToolRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// McpService_ServiceDesc is the grpc.ServiceDesc for McpService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var McpService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "dhauli.state.v1.McpService",
+	HandlerType: (*McpServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetMcp",
+			Handler:    _McpService_GetMcp_Handler,
+		},
+		{
+			MethodName: "CreateMcp",
+			Handler:    _McpService_CreateMcp_Handler,
+		},
+		{
+			MethodName: "UpdateMcp",
+			Handler:    _McpService_UpdateMcp_Handler,
+		},
+		{
+			MethodName: "DeleteMcp",
+			Handler:    _McpService_DeleteMcp_Handler,
+		},
+		{
+			MethodName: "AddTool",
+			Handler:    _McpService_AddTool_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "state/v1/state.proto",
+}
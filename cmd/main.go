@@ -35,8 +35,6 @@ func main() {
 	registry := discover.NewRegistryInfo(cfg, log)
 	registry.Register(discover.SERVICE)
 
-	//StartConsumer(context.Background(), cfg, tr, log)
-
 	server := pkg.NewStateServer(cfg, log, tr)
 	server.Start()
 
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/pkg/client"
+)
+
+func runGet(ctx context.Context, c *client.Client, args []string) error {
+	args, err := parse(flag.NewFlagSet("get", flag.ContinueOnError), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	interaction, err := c.GetInteraction(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, interaction)
+}
+
+// runList lists the interactions of the audit entries, as the api has no listing of interactions.
+// An interaction that was not written since -since is not listed.
+func runList(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	since := fs.Duration("since", 24*time.Hour, "list the interactions written in this duration")
+	actor := fs.String("actor", "", "list the interactions written by this actor")
+	limit := fs.Int("limit", 1000, "read at most this many audit entries")
+	if _, err := parse(fs, args, 0, 0); err != nil {
+		return err
+	}
+	entries, err := c.ListAuditEntries(ctx, client.AuditQuery{
+		Actor: *actor,
+		From:  time.Now().Add(-*since),
+		Limit: *limit,
+	})
+	if err != nil {
+		return err
+	}
+	type row struct {
+		last  time.Time
+		actor string
+		op    string
+	}
+	rows := map[string]*row{}
+	var order []string
+	for _, entry := range entries {
+		if entry.InteractionId == "" {
+			continue
+		}
+		r, ok := rows[entry.InteractionId]
+		if !ok {
+			r = &row{}
+			rows[entry.InteractionId] = r
+			order = append(order, entry.InteractionId)
+		}
+		if entry.Time.After(r.last) {
+			r.last, r.actor, r.op = entry.Time, entry.Actor, entry.Operation
+		}
+	}
+	for _, id := range order {
+		r := rows[id]
+		fmt.Printf("%s\t%s\t%s\t%s\n", id, r.last.Local().Format(time.RFC3339), r.op, r.actor)
+	}
+	return nil
+}
+
+func runWatch(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	types := fs.String("types", "", "comma separated event types to print, all when empty")
+	asJSON := fs.Bool("json", false, "print the events as json lines")
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	var opts client.StreamOptions
+	for _, t := range strings.Split(*types, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			opts.Types = append(opts.Types, client.EventType(t))
+		}
+	}
+	stream, err := c.StreamEvents(ctx, args[0], opts)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	for evt := range stream.Events() {
+		if *asJSON {
+			if err = enc.Encode(evt.Event); err != nil {
+				return err
+			}
+			continue
+		}
+		fmt.Printf("%s  %-22s %s\n", evt.Time.Local().Format(time.TimeOnly), evt.Type, subject(evt.Event))
+	}
+	return stream.Err()
+}
+
+// subject names what an event is about
+func subject(evt client.Event) string {
+	var parts []string
+	if evt.StepId != "" {
+		parts = append(parts, "step="+evt.StepId)
+	}
+	if evt.McpId != "" {
+		parts = append(parts, "mcp="+evt.McpId)
+	}
+	if evt.Type == events.StepStatusChanged {
+		var change events.StatusChange
+		if json.Unmarshal(evt.Data, &change) == nil {
+			parts = append(parts, change.Previous+"->"+change.Status)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func runMessages(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("messages", flag.ContinueOnError)
+	follow := fs.Bool("f", false, "keep printing the messages that are added")
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	// the stream starts before the read so that no message is missed in between
+	var stream *client.EventStream
+	if *follow {
+		if stream, err = c.StreamEvents(ctx, args[0], client.StreamOptions{Types: []client.EventType{events.MessageAdded}}); err != nil {
+			return err
+		}
+	}
+	interaction, err := c.GetInteraction(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	seen := map[string]bool{}
+	for _, msg := range interaction.Messages {
+		seen[msg.ID] = true
+		printMessage(msg)
+	}
+	if stream == nil {
+		return nil
+	}
+	for evt := range stream.Events() {
+		var msg runtime.Message
+		if err = json.Unmarshal(evt.Data, &msg); err != nil {
+			return fmt.Errorf("decoding message of event %s: %w", evt.ID, err)
+		}
+		if !seen[msg.ID] {
+			seen[msg.ID] = true
+			printMessage(msg)
+		}
+	}
+	return stream.Err()
+}
+
+func printMessage(msg runtime.Message) {
+	var text string
+	switch {
+	case msg.Query != nil:
+		text = msg.Query.Content
+	case msg.Answer != nil:
+		text = msg.Answer.Content
+	}
+	fmt.Printf("[%s] %s: %s\n", msg.Timestamp.Local().Format(time.DateTime), msg.Role, text)
+}
+
+func runSteps(ctx context.Context, c *client.Client, args []string) error {
+	args, err := parse(flag.NewFlagSet("steps", flag.ContinueOnError), args, 1, 2)
+	if err != nil {
+		return err
+	}
+	ex, err := resolve(ctx, c, args[0])
+	if err != nil {
+		return err
+	}
+	steps, err := ex.steps(ctx, c, optional(args, 1))
+	if err != nil {
+		return err
+	}
+	if len(args) == 2 {
+		return printJSON(os.Stdout, steps[0])
+	}
+	return printJSON(os.Stdout, steps)
+}
+
+func runArtifacts(ctx context.Context, c *client.Client, args []string) error {
+	args, err := parse(flag.NewFlagSet("artifacts", flag.ContinueOnError), args, 1, 2)
+	if err != nil {
+		return err
+	}
+	ex, err := resolve(ctx, c, args[0])
+	if err != nil {
+		return err
+	}
+	steps, err := ex.steps(ctx, c, optional(args, 1))
+	if err != nil {
+		return err
+	}
+	artifacts := []runtime.Artifact{}
+	for _, step := range steps {
+		artifacts = append(artifacts, step.Artifacts...)
+	}
+	return printJSON(os.Stdout, artifacts)
+}
+
+func runWorkflows(ctx context.Context, c *client.Client, args []string) error {
+	args, err := parse(flag.NewFlagSet("workflows", flag.ContinueOnError), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	workflows, err := c.ListWorkflows(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	for _, workflow := range workflows {
+		mark, graphId, nodes := " ", "-", 0
+		if workflow.Active {
+			mark = "*"
+		}
+		if graph := workflow.ExecutionGraph; graph != nil {
+			graphId, nodes = graph.ID, len(graph.Nodes)
+		}
+		fmt.Printf("%s %s\tgraph %s\t%d steps\n", mark, workflow.ID, graphId, nodes)
+	}
+	return nil
+}
+
+func runActivate(ctx context.Context, c *client.Client, args []string) error {
+	args, err := parse(flag.NewFlagSet("activate", flag.ContinueOnError), args, 2, 2)
+	if err != nil {
+		return err
+	}
+	interaction, err := c.ActivateWorkflow(ctx, args[0], args[1])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", interaction.ID, interaction.ExecutionFlow.ID)
+	return nil
+}
+
+func runSetStatus(ctx context.Context, c *client.Client, args []string) error {
+	args, err := parse(flag.NewFlagSet("set-status", flag.ContinueOnError), args, 3, 3)
+	if err != nil {
+		return err
+	}
+	step, err := c.UpdateStepStatusById(ctx, args[0], args[1], runtime.Status(args[2]))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", step.ID, step.Status)
+	return nil
+}
+
+func optional(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/pkg/client"
+)
+
+func runGraph(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ContinueOnError)
+	workflowId := fs.String("workflow", "", "print the graph of this workflow instead of the active one")
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := resolveWorkflow(ctx, c, args[0], *workflowId)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s  workflow %s  graph %s\n", ex.interaction.ID, ex.workflowId, ex.graph.ID)
+	printTree(os.Stdout, ex.graph, ex.children)
+	return nil
+}
+
+func runTree(ctx context.Context, c *client.Client, args []string) error {
+	args, err := parse(flag.NewFlagSet("tree", flag.ContinueOnError), args, 1, 1)
+	if err != nil {
+		return err
+	}
+	tree, err := c.GetInteractionTree(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	var walk func(t *client.InteractionTree, prefix, branch, indent string)
+	walk = func(t *client.InteractionTree, prefix, branch, indent string) {
+		line := fmt.Sprintf("%s [%s]", t.Interaction.ID, status(t.Status))
+		if t.Link != nil && t.Link.NodeId != t.Interaction.ID {
+			line = fmt.Sprintf("%s (%s) [%s]", t.Link.NodeId, t.Interaction.ID, status(t.Status))
+		}
+		fmt.Println(prefix + branch + line)
+		for i, child := range t.Children {
+			if i == len(t.Children)-1 {
+				walk(child, prefix+indent, "└── ", "    ")
+			} else {
+				walk(child, prefix+indent, "├── ", "│   ")
+			}
+		}
+	}
+	walk(tree, "", "", "")
+	return nil
+}
+
+// printTree prints the graph as a tree from the nodes without incoming edges. A node with several
+// parents is printed below the first one and referred to below the others; the nodes of a cycle
+// that no root reaches are printed from the first of them in the graph. The nodes in children run
+// the child interaction they map to.
+func printTree(w io.Writer, graph *runtime.ExecutionGraph, children map[string]string) {
+	nodes := map[string]runtime.ExecutionNode{}
+	for _, node := range graph.Nodes {
+		nodes[node.StepId] = node
+	}
+	next := map[string][]runtime.Edge{}
+	incoming := map[string]bool{}
+	for _, edge := range graph.Edges {
+		next[edge.From] = append(next[edge.From], edge)
+		incoming[edge.To] = true
+	}
+
+	printed := map[string]bool{}
+	var walk func(stepId, label, prefix string, last bool, depth int)
+	walk = func(stepId, label, prefix string, last bool, depth int) {
+		branch, indent := "├── ", "│   "
+		if last {
+			branch, indent = "└── ", "    "
+		}
+		if depth == 0 {
+			branch, indent = "", ""
+		}
+		line := prefix + branch + describe(nodes, stepId)
+		if childId, ok := children[stepId]; ok {
+			line += "  -> interaction " + childId
+		}
+		if label != "" {
+			line += "  <" + label + ">"
+		}
+		if printed[stepId] {
+			fmt.Fprintln(w, line+"  (see above)")
+			return
+		}
+		fmt.Fprintln(w, line)
+		printed[stepId] = true
+		edges := next[stepId]
+		for i, edge := range edges {
+			walk(edge.To, edgeLabel(edge), prefix+indent, i == len(edges)-1, depth+1)
+		}
+	}
+
+	for _, node := range graph.Nodes {
+		if !incoming[node.StepId] {
+			walk(node.StepId, "", "", true, 0)
+		}
+	}
+	for _, node := range graph.Nodes {
+		if !printed[node.StepId] {
+			walk(node.StepId, "", "", true, 0)
+		}
+	}
+}
+
+// describe is the line of a node, an edge may refer to a step that is not a node of the graph
+func describe(nodes map[string]runtime.ExecutionNode, stepId string) string {
+	node, ok := nodes[stepId]
+	if !ok {
+		return fmt.Sprintf("%s [missing node]", stepId)
+	}
+	if node.Name == "" || node.Name == node.StepId {
+		return fmt.Sprintf("%s [%s]", node.StepId, status(node.Status))
+	}
+	return fmt.Sprintf("%s (%s) [%s]", node.Name, node.StepId, status(node.Status))
+}
+
+func edgeLabel(edge runtime.Edge) string {
+	switch {
+	case edge.Label != "" && edge.Type != "":
+		return edge.Type + ": " + edge.Label
+	case edge.Label != "":
+		return edge.Label
+	default:
+		return edge.Type
+	}
+}
+
+func status(s runtime.Status) string {
+	if s == "" {
+		return string(runtime.StatusPending)
+	}
+	return string(s)
+}
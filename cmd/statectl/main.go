@@ -0,0 +1,207 @@
+// Command statectl inspects and edits the interactions of a state service through its REST api.
+// The workflow and execution ids of the nested routes are resolved from the interaction, so steps
+// are addressed by the interaction id and the step id only.
+//
+//	statectl [-server url] [-actor name] <command> [flags] [args]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"syscall"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/pkg/client"
+)
+
+// command is a subcommand, run gets the arguments after the command name
+type command struct {
+	usage string
+	help  string
+	run   func(ctx context.Context, c *client.Client, args []string) error
+}
+
+// commands is set in init as the commands refer to it for their usage
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"get":        {"get <interaction>", "print the interaction", runGet},
+		"list":       {"list [-since 24h] [-actor name] [-limit n]", "list the interactions written recently, from the audit log", runList},
+		"tree":       {"tree <interaction>", "print the interaction with its child interactions and their statuses", runTree},
+		"watch":      {"watch [-types t1,t2] [-json] <interaction>", "print the events of the interaction as they happen", runWatch},
+		"graph":      {"graph [-workflow id] <interaction>", "print the execution graph as a tree with the step statuses", runGraph},
+		"messages":   {"messages [-f] <interaction>", "print the messages, -f follows new ones", runMessages},
+		"steps":      {"steps <interaction> [step]", "print the steps of the graph, or one step", runSteps},
+		"artifacts":  {"artifacts <interaction> [step]", "print the artifacts of the steps, or of one step", runArtifacts},
+		"workflows":  {"workflows <interaction>", "list the workflows of the interaction, * marks the active one", runWorkflows},
+		"activate":   {"activate <interaction> <workflow>", "make the workflow the active workflow of the interaction", runActivate},
+		"set-status": {"set-status <interaction> <step> <status>", "set the status of a step", runSetStatus},
+		"export":     {"export [-o file] <interaction>", "write the interaction with its steps and mcps as json", runExport},
+		"import":     {"import [-id interaction] <file|->", "create an interaction from an export", runImport},
+	}
+}
+
+func main() {
+	flags := flag.NewFlagSet("statectl", flag.ExitOnError)
+	server := flags.String("server", envOr("STATECTL_SERVER", "http://localhost:8080"), "base url of the state service, or $STATECTL_SERVER")
+	actor := flags.String("actor", envOr("STATECTL_ACTOR", ""), "actor recorded in the audit log, or $STATECTL_ACTOR")
+	flags.Usage = func() { usage(flags) }
+	_ = flags.Parse(os.Args[1:])
+	if flags.NArg() == 0 {
+		usage(flags)
+		os.Exit(2)
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "statectl: unknown command %q\n", flags.Arg(0))
+		usage(flags)
+		os.Exit(2)
+	}
+
+	opts := []client.Option{}
+	if *actor != "" {
+		opts = append(opts, client.WithActor(*actor))
+	}
+	c, err := client.NewClient(*server, opts...)
+	if err != nil {
+		fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err = cmd.run(ctx, c, flags.Args()[1:]); err != nil && !errors.Is(err, context.Canceled) {
+		stop()
+		fatal(err)
+	}
+}
+
+func usage(flags *flag.FlagSet) {
+	out := flags.Output()
+	fmt.Fprintln(out, "usage: statectl [-server url] [-actor name] <command> [flags] [args]")
+	fmt.Fprintln(out, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %-45s %s\n", commands[name].usage, commands[name].help)
+	}
+	fmt.Fprintln(out, "\nflags:")
+	flags.PrintDefaults()
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "statectl:", err)
+	os.Exit(1)
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// parse parses the flags of a command and checks that it got between min and max arguments
+func parse(fs *flag.FlagSet, args []string, min, max int) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	usage := fmt.Errorf("usage: statectl %s", commands[fs.Name()].usage)
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil, usage
+		}
+		return nil, fmt.Errorf("%w\n%w", err, usage)
+	}
+	if fs.NArg() < min || fs.NArg() > max {
+		return nil, usage
+	}
+	return fs.Args(), nil
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// execution is the workflow and execution graph that the step routes of an interaction need.
+// children maps the nodes that run a child interaction, which have no step, to the child.
+type execution struct {
+	interaction *runtime.Interaction
+	workflowId  string
+	executionId string
+	graph       *runtime.ExecutionGraph
+	children    map[string]string
+}
+
+// resolve returns the execution of the active workflow of the interaction
+func resolve(ctx context.Context, c *client.Client, interactionId string) (*execution, error) {
+	return resolveWorkflow(ctx, c, interactionId, "")
+}
+
+// resolveWorkflow returns the execution of the workflow, of the active one when workflowId is empty
+func resolveWorkflow(ctx context.Context, c *client.Client, interactionId, workflowId string) (*execution, error) {
+	interaction, err := c.GetInteraction(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	flow := interaction.ExecutionFlow
+	if workflowId != "" && (flow == nil || flow.ID != workflowId) {
+		workflow, err := c.GetWorkflow(ctx, interactionId, workflowId)
+		if err != nil {
+			return nil, err
+		}
+		flow = &workflow.ExecutionFlow
+	}
+	if flow == nil || flow.ExecutionGraph == nil {
+		return nil, fmt.Errorf("interaction %s has no execution graph", interactionId)
+	}
+	links, err := c.ListChildren(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	children := map[string]string{}
+	for _, link := range links {
+		if link.WorkflowId == flow.ID && link.ExecutionId == flow.ExecutionGraph.ID {
+			children[link.NodeId] = link.ChildId
+		}
+	}
+	return &execution{
+		interaction: interaction,
+		workflowId:  flow.ID,
+		executionId: flow.ExecutionGraph.ID,
+		graph:       flow.ExecutionGraph,
+		children:    children,
+	}, nil
+}
+
+// steps fetches the step of stepId, or the steps of the nodes of the graph that run a step when it
+// is empty
+func (e *execution) steps(ctx context.Context, c *client.Client, stepId string) ([]*runtime.Step, error) {
+	ids := []string{stepId}
+	if stepId == "" {
+		ids = ids[:0]
+		for _, node := range e.graph.Nodes {
+			if _, child := e.children[node.StepId]; !child {
+				ids = append(ids, node.StepId)
+			}
+		}
+	}
+	steps := make([]*runtime.Step, 0, len(ids))
+	for _, id := range ids {
+		step, err := c.GetStep(ctx, e.interaction.ID, e.workflowId, e.executionId, id)
+		if err != nil {
+			return nil, fmt.Errorf("getting step %s: %w", id, err)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/pkg/client"
+)
+
+// export is an interaction with the steps of its graph and the mcps of its workflow, which the
+// interaction document refers to by id only. Child interactions are exported on their own, the
+// nodes that ran them are imported without the link.
+type export struct {
+	Interaction *runtime.Interaction `json:"interaction"`
+	Steps       []*runtime.Step      `json:"steps"`
+	Mcps        []*runtime.MCP       `json:"mcps"`
+}
+
+func runExport(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := fs.String("o", "-", "file to write, - for stdout")
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	ex, err := resolve(ctx, c, args[0])
+	if err != nil {
+		return err
+	}
+	steps, err := ex.steps(ctx, c, "")
+	if err != nil {
+		return err
+	}
+	doc := export{Interaction: ex.interaction, Steps: steps, Mcps: []*runtime.MCP{}}
+	for _, mcpId := range ex.interaction.ExecutionFlow.AvailableMcpRefs {
+		mcp, err := c.GetMcp(ctx, ex.interaction.ID, ex.workflowId, mcpId)
+		if err != nil {
+			return fmt.Errorf("getting mcp %s: %w", mcpId, err)
+		}
+		doc.Mcps = append(doc.Mcps, mcp)
+	}
+
+	if *out == "-" {
+		return printJSON(os.Stdout, doc)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err = printJSON(f, doc); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// runImport creates the interaction of an export. The creates append the steps to the graph and
+// the mcps to the refs of the workflow, so the interaction is created without them and the
+// exported graph, with the statuses of its nodes, is written once every step exists.
+func runImport(ctx context.Context, c *client.Client, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	id := fs.String("id", "", "create the interaction with this id instead of the exported one")
+	args, err := parse(fs, args, 1, 1)
+	if err != nil {
+		return err
+	}
+	doc, err := readExport(args[0])
+	if err != nil {
+		return err
+	}
+	interaction := doc.Interaction
+	if *id != "" {
+		interaction.ID = *id
+	}
+	flow := interaction.ExecutionFlow
+	if flow == nil || flow.ExecutionGraph == nil {
+		return fmt.Errorf("exported interaction %s has no execution graph", interaction.ID)
+	}
+	if _, err = c.GetInteraction(ctx, interaction.ID); err == nil {
+		return fmt.Errorf("interaction %s already exists, import it with -id", interaction.ID)
+	} else if !errors.Is(err, client.ErrNotFound) {
+		return err
+	}
+
+	graph := flow.ExecutionGraph
+	flow.ExecutionGraph = &runtime.ExecutionGraph{ID: graph.ID, Edges: graph.Edges}
+	flow.AvailableMcpRefs = nil
+	if _, err = c.CreateInteraction(ctx, interaction); err != nil {
+		return fmt.Errorf("creating interaction: %w", err)
+	}
+	for _, mcp := range doc.Mcps {
+		if _, err = c.CreateMcp(ctx, interaction.ID, flow.ID, mcp); err != nil {
+			return fmt.Errorf("creating mcp %s: %w", mcp.ID, err)
+		}
+	}
+	for _, step := range doc.Steps {
+		// the create resets the status, the update restores the exported step as it was
+		created := *step
+		if _, err = c.CreateStep(ctx, interaction.ID, flow.ID, graph.ID, &created); err != nil {
+			return fmt.Errorf("creating step %s: %w", step.ID, err)
+		}
+		if _, err = c.UpdateStep(ctx, interaction.ID, flow.ID, graph.ID, step); err != nil {
+			return fmt.Errorf("updating step %s: %w", step.ID, err)
+		}
+	}
+	if _, err = c.UpdateExecutionGraph(ctx, interaction.ID, flow.ID, graph.ID, graph); err != nil {
+		return fmt.Errorf("updating execution graph: %w", err)
+	}
+	fmt.Println(interaction.ID)
+	return nil
+}
+
+func readExport(name string) (*export, error) {
+	var r io.Reader = os.Stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+	var doc export
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decoding export: %w", err)
+	}
+	if doc.Interaction == nil {
+		return nil, errors.New("export has no interaction")
+	}
+	return &doc, nil
+}
@@ -0,0 +1,141 @@
+// Package audit carries the changes made while serving an audited operation. The operation puts a
+// Recorder in the context and every committed write adds its before and after documents to it.
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mangudaigb/state-service/internal/model"
+)
+
+type recorderKey struct{}
+
+type Recorder struct {
+	mu      sync.Mutex
+	changes []model.AuditChange
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func WithRecorder(ctx context.Context, rec *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, rec)
+}
+
+// FromContext returns the recorder of the operation, nil when the operation is not audited
+func FromContext(ctx context.Context) *Recorder {
+	rec, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return rec
+}
+
+// Record adds a committed write of key, after is nil when the key was deleted
+func (r *Recorder) Record(key string, op model.HistoryOp, before, after []byte) {
+	fields, err := Diff(before, after)
+	if err != nil {
+		fields = []model.FieldChange{{Path: "", Before: before, After: after}}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, model.AuditChange{Key: key, Op: op, Fields: fields})
+}
+
+// Record adds a committed write of key to the recorder of the operation, if it is audited. Every
+// store the api writes through records its writes here.
+func Record(ctx context.Context, key string, before, after []byte) {
+	rec := FromContext(ctx)
+	if rec == nil {
+		return
+	}
+	op := model.HistorySet
+	if after == nil {
+		op = model.HistoryDelete
+	}
+	rec.Record(key, op, before, after)
+}
+
+func (r *Recorder) Changes() []model.AuditChange {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]model.AuditChange(nil), r.changes...)
+}
+
+// Diff lists the members that differ between two json documents. Objects are compared member by
+// member, any other value, arrays included, is reported as a whole.
+func Diff(before, after []byte) ([]model.FieldChange, error) {
+	a, err := decode(before)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decode(after)
+	if err != nil {
+		return nil, err
+	}
+	var fields []model.FieldChange
+	err = diff("", a, b, &fields)
+	return fields, err
+}
+
+func decode(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	err := dec.Decode(&v)
+	return v, err
+}
+
+func diff(path string, a, b any, fields *[]model.FieldChange) error {
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if aok && bok {
+		keys := make(map[string]bool, len(am)+len(bm))
+		for k := range am {
+			keys[k] = true
+		}
+		for k := range bm {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			if err := diff(path+"/"+escape(k), am[k], bm[k], fields); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+	change := model.FieldChange{Path: path}
+	var err error
+	if a != nil {
+		if change.Before, err = json.Marshal(a); err != nil {
+			return err
+		}
+	}
+	if b != nil {
+		if change.After, err = json.Marshal(b); err != nil {
+			return err
+		}
+	}
+	*fields = append(*fields, change)
+	return nil
+}
+
+// escape encodes a member name as a JSON pointer token
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
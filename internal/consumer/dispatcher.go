@@ -0,0 +1,98 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/svc"
+)
+
+// ErrInvalidCommand marks commands that can never succeed, they go to the dead letter topic
+// without being retried.
+var ErrInvalidCommand = errs.Invalid("invalid command")
+
+// Dispatcher applies commands through the same services the http handlers use.
+type Dispatcher struct {
+	interactionSvc svc.InteractionService
+	stepSvc        svc.StepService
+}
+
+func (d *Dispatcher) Dispatch(ctx context.Context, cmd *model.Command) error {
+	switch cmd.Type {
+	case model.CommandCreateInteraction:
+		var interaction runtime.Interaction
+		if err := decodePayload(cmd, &interaction); err != nil {
+			return err
+		}
+		if cmd.InteractionId != "" {
+			interaction.ID = cmd.InteractionId
+		}
+		// a retried command has to create the same interaction, not a second one
+		if interaction.ID == "" {
+			interaction.ID = cmd.ID
+		}
+		_, err := d.interactionSvc.Create(ctx, &interaction)
+		return err
+	case model.CommandAddStep:
+		var step runtime.Step
+		if err := decodePayload(cmd, &step); err != nil {
+			return err
+		}
+		// a retried command has to create the same step, not a second one
+		if step.ID == "" {
+			step.ID = cmd.ID
+		}
+		_, err := d.stepSvc.CreateByInteractionIdAndExecutionId(ctx, cmd.InteractionId, cmd.WorkflowId, cmd.ExecutionId, &step)
+		if errors.Is(err, svc.ErrStepExists) {
+			// a redelivered command finds the step it created
+			return nil
+		}
+		return err
+	case model.CommandUpdateStepStatus:
+		var payload struct {
+			Status runtime.Status `json:"status"`
+		}
+		if err := decodePayload(cmd, &payload); err != nil {
+			return err
+		}
+		if payload.Status == "" {
+			return fmt.Errorf("%w: status is required", ErrInvalidCommand)
+		}
+		_, err := d.stepSvc.UpdateStatusByInteractionIdAndExecutionIdAndId(ctx, cmd.InteractionId, cmd.WorkflowId, cmd.ExecutionId, cmd.StepId, payload.Status)
+		return err
+	case model.CommandRecordToolInvocation:
+		var invocation runtime.McpToolInvocation
+		if err := decodePayload(cmd, &invocation); err != nil {
+			return err
+		}
+		if invocation.ID == "" {
+			invocation.ID = cmd.ID
+		}
+		_, err := d.stepSvc.AddToolInvocation(ctx, cmd.InteractionId, cmd.WorkflowId, cmd.ExecutionId, cmd.StepId, &invocation)
+		return err
+	default:
+		return fmt.Errorf("%w: unknown command type %q", ErrInvalidCommand, cmd.Type)
+	}
+}
+
+func decodePayload(cmd *model.Command, v any) error {
+	if len(cmd.Payload) == 0 {
+		return fmt.Errorf("%w: %s command has no payload", ErrInvalidCommand, cmd.Type)
+	}
+	if err := json.Unmarshal(cmd.Payload, v); err != nil {
+		return fmt.Errorf("%w: decoding %s payload: %v", ErrInvalidCommand, cmd.Type, err)
+	}
+	return nil
+}
+
+func NewDispatcher(interactionSvc svc.InteractionService, stepSvc svc.StepService) *Dispatcher {
+	return &Dispatcher{
+		interactionSvc: interactionSvc,
+		stepSvc:        stepSvc,
+	}
+}
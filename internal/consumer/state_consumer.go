@@ -0,0 +1,210 @@
+package consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/audit"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/segmentio/kafka-go"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	headerDeadLetterReason = "x-dead-letter-reason"
+	headerOriginalTopic    = "x-original-topic"
+	headerOriginalOffset   = "x-original-offset"
+)
+
+// StateConsumer reads commands from the state topic. Offsets are committed only after a command
+// was applied or parked on the dead letter topic, so delivery is at-least-once and redelivered
+// commands are skipped by their id.
+type StateConsumer struct {
+	log        *logger.Logger
+	tr         trace.Tracer
+	topic      string
+	cfg        settings.Kafka
+	reader     *kafka.Reader
+	deadLetter *kafka.Writer
+	dispatcher *Dispatcher
+	commands   repo.CommandRepo
+	auditSvc   svc.AuditService
+	done       chan struct{}
+}
+
+func (sc *StateConsumer) Start(ctx context.Context) {
+	go func() {
+		defer close(sc.done)
+		sc.log.Infof("Consuming state commands from topic: %s", sc.topic)
+		for {
+			msg, err := sc.reader.FetchMessage(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				sc.log.Errorf("Error while fetching state command: %v", err)
+				continue
+			}
+			// a command that was neither applied nor dead lettered blocks the partition, committing
+			// a later offset would skip it
+			for err = sc.handle(ctx, msg); err != nil; err = sc.handle(ctx, msg) {
+				sc.log.Errorf("Error while handling state command at offset: %d by error: %v", msg.Offset, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(sc.cfg.RetryBackoff):
+				}
+			}
+			if err = sc.reader.CommitMessages(ctx, msg); err != nil {
+				sc.log.Errorf("Error while committing offset: %d by error: %v", msg.Offset, err)
+			}
+		}
+	}()
+}
+
+func (sc *StateConsumer) handle(ctx context.Context, msg kafka.Message) error {
+	var cmd model.Command
+	if err := json.Unmarshal(msg.Value, &cmd); err != nil {
+		return sc.toDeadLetter(ctx, msg, fmt.Sprintf("malformed command: %v", err))
+	}
+	if cmd.ID == "" {
+		return sc.toDeadLetter(ctx, msg, "command has no id")
+	}
+	if _, err := sc.commands.Get(ctx, cmd.ID); err == nil {
+		sc.log.Infof("Skipping already processed command id: %s", cmd.ID)
+		return nil
+	} else if !errors.Is(err, errs.ErrNotFound) {
+		return err
+	}
+
+	rec := audit.NewRecorder()
+	ctx = audit.WithRecorder(ctx, rec)
+	var err error
+	backoff := sc.cfg.RetryBackoff
+	for attempt := 0; attempt <= sc.cfg.MaxRetries; attempt++ {
+		if err = sc.dispatcher.Dispatch(ctx, &cmd); err == nil || permanent(err) {
+			break
+		}
+		sc.log.Warnf("Attempt %d of command id: %s failed: %v", attempt+1, cmd.ID, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+	sc.audit(ctx, msg, &cmd, rec, err)
+	if err != nil {
+		return sc.toDeadLetter(ctx, msg, err.Error())
+	}
+
+	// the mark is retried on its own, handling the message again would apply the command twice
+	processed := &model.ProcessedCommand{ID: cmd.ID, Type: cmd.Type, ProcessedAt: time.Now()}
+	for err = sc.commands.Save(ctx, processed); err != nil; err = sc.commands.Save(ctx, processed) {
+		sc.log.Errorf("Error while marking command id: %s as processed: %v", cmd.ID, err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(sc.cfg.RetryBackoff):
+		}
+	}
+	return nil
+}
+
+// permanent reports whether a command failed in a way a retry cannot fix, like an invalid payload
+// or an interaction that does not exist
+func permanent(err error) bool {
+	return errors.Is(err, errs.ErrInvalid) || errors.Is(err, errs.ErrNotFound)
+}
+
+// audit records the writes made by the command, a command without an actor is attributed to the
+// topic it came from
+func (sc *StateConsumer) audit(ctx context.Context, msg kafka.Message, cmd *model.Command, rec *audit.Recorder, err error) {
+	entry := &model.AuditEntry{
+		Actor:         cmd.Actor,
+		Operation:     string(cmd.Type),
+		Path:          msg.Topic,
+		InteractionId: cmd.InteractionId,
+		Changes:       rec.Changes(),
+	}
+	if entry.Actor == "" {
+		entry.Actor = "kafka:" + msg.Topic
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	_ = sc.auditSvc.Record(context.WithoutCancel(ctx), entry)
+}
+
+func (sc *StateConsumer) toDeadLetter(ctx context.Context, msg kafka.Message, reason string) error {
+	sc.log.Errorf("Moving message at offset: %d to dead letter topic: %s, reason: %s", msg.Offset, sc.cfg.DeadLetterTopic, reason)
+	headers := append([]kafka.Header{}, msg.Headers...)
+	headers = append(headers,
+		kafka.Header{Key: headerDeadLetterReason, Value: []byte(reason)},
+		kafka.Header{Key: headerOriginalTopic, Value: []byte(msg.Topic)},
+		kafka.Header{Key: headerOriginalOffset, Value: []byte(strconv.FormatInt(msg.Offset, 10))},
+	)
+	return sc.deadLetter.WriteMessages(ctx, kafka.Message{
+		Key:     msg.Key,
+		Value:   msg.Value,
+		Headers: headers,
+	})
+}
+
+// Close waits for the consume loop to stop, the context passed to Start has to be cancelled first
+func (sc *StateConsumer) Close() {
+	<-sc.done
+	if err := sc.reader.Close(); err != nil {
+		sc.log.Errorf("Error while closing kafka reader: %v", err)
+	}
+	if err := sc.deadLetter.Close(); err != nil {
+		sc.log.Errorf("Error while closing kafka writer: %v", err)
+	}
+}
+
+func NewStateConsumer(cfg *config.Config, log *logger.Logger, tr trace.Tracer, dispatcher *Dispatcher, commands repo.CommandRepo, auditSvc svc.AuditService) (*StateConsumer, error) {
+	kcfg := settings.GetKafka()
+	// kafka.NewReader panics on a config it cannot use
+	switch {
+	case len(cfg.Kafka.Brokers) == 0:
+		return nil, errors.New("kafka brokers are not configured")
+	case cfg.Kafka.GroupId == "":
+		return nil, errors.New("kafka group id is not configured")
+	case cfg.Kafka.Topic == "":
+		return nil, errors.New("kafka topic is not configured")
+	case kcfg.DeadLetterTopic == "":
+		return nil, errors.New("kafka dead letter topic is not configured")
+	}
+	return &StateConsumer{
+		log:   log,
+		tr:    tr,
+		topic: cfg.Kafka.Topic,
+		cfg:   kcfg,
+		reader: kafka.NewReader(kafka.ReaderConfig{
+			Brokers: cfg.Kafka.Brokers,
+			GroupID: cfg.Kafka.GroupId,
+			Topic:   cfg.Kafka.Topic,
+		}),
+		deadLetter: &kafka.Writer{
+			Addr:                   kafka.TCP(cfg.Kafka.Brokers...),
+			Topic:                  kcfg.DeadLetterTopic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+		},
+		dispatcher: dispatcher,
+		commands:   commands,
+		auditSvc:   auditSvc,
+		done:       make(chan struct{}),
+	}, nil
+}
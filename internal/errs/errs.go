@@ -0,0 +1,62 @@
+// Package errs defines the kinds of errors the repo and svc packages return, so the api can tell
+// a missing resource from a conflicting write, a rejected request, a storage outage or a broken
+// document. Errors of a kind match the kind with errors.Is, however deep they are wrapped.
+package errs
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound    = errors.New("not found")
+	ErrConflict    = errors.New("conflict")
+	ErrInvalid     = errors.New("invalid")
+	ErrUnavailable = errors.New("unavailable")
+	ErrInternal    = errors.New("internal")
+)
+
+// Error is an error of a kind with a message for the caller and the underlying cause, if any
+type Error struct {
+	kind  error
+	msg   string
+	cause error
+}
+
+func (e *Error) Error() string {
+	return e.msg
+}
+
+func (e *Error) Unwrap() []error {
+	if e.cause != nil {
+		return []error{e.kind, e.cause}
+	}
+	return []error{e.kind}
+}
+
+func NotFound(format string, args ...any) error {
+	return &Error{kind: ErrNotFound, msg: fmt.Sprintf(format, args...)}
+}
+
+func Conflict(format string, args ...any) error {
+	return &Error{kind: ErrConflict, msg: fmt.Sprintf(format, args...)}
+}
+
+func Invalid(format string, args ...any) error {
+	return &Error{kind: ErrInvalid, msg: fmt.Sprintf(format, args...)}
+}
+
+// Unavailable marks a failure of a backing store or service, the request may succeed when retried
+func Unavailable(cause error) error {
+	return &Error{kind: ErrUnavailable, msg: cause.Error(), cause: cause}
+}
+
+// Internal marks a failure that retrying will not fix, like a document that no longer decodes
+func Internal(cause error) error {
+	return &Error{kind: ErrInternal, msg: cause.Error(), cause: cause}
+}
+
+// Typed reports whether err is of one of the kinds
+func Typed(err error) bool {
+	return errors.Is(err, ErrNotFound) || errors.Is(err, ErrConflict) || errors.Is(err, ErrInvalid) || errors.Is(err, ErrUnavailable) || errors.Is(err, ErrInternal)
+}
@@ -0,0 +1,130 @@
+// Package events defines the domain events emitted for every state change and the publishers
+// that deliver them to downstream services.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/state-service/internal/settings"
+)
+
+type Type string
+
+const (
+	InteractionCreated    Type = "InteractionCreated"
+	InteractionUpdated    Type = "InteractionUpdated"
+	InteractionDeleted    Type = "InteractionDeleted"
+	InteractionCompleted  Type = "InteractionCompleted"
+	PlanRevised           Type = "PlanRevised"
+	ExecutionFlowUpdated  Type = "ExecutionFlowUpdated"
+	WorkflowCreated       Type = "WorkflowCreated"
+	WorkflowActivated     Type = "WorkflowActivated"
+	WorkflowDeleted       Type = "WorkflowDeleted"
+	ExecutionGraphUpdated Type = "ExecutionGraphUpdated"
+	StepCreated           Type = "StepCreated"
+	StepUpdated           Type = "StepUpdated"
+	StepStatusChanged     Type = "StepStatusChanged"
+	StepFailed            Type = "StepFailed"
+	StepDeleted           Type = "StepDeleted"
+	ChildCreated          Type = "ChildCreated"
+	ToolInvoked           Type = "ToolInvoked"
+	MessageAdded          Type = "MessageAdded"
+	ArtifactAdded         Type = "ArtifactAdded"
+	McpCreated            Type = "McpCreated"
+	McpUpdated            Type = "McpUpdated"
+	McpDeleted            Type = "McpDeleted"
+	McpToolsChanged       Type = "McpToolsChanged"
+)
+
+// Event is the envelope published for a state change. Protocol carries the discovery.protocol
+// of the service so consumers know how to decode Data.
+type Event struct {
+	ID            string          `json:"id"`
+	Type          Type            `json:"type"`
+	Protocol      string          `json:"protocol"`
+	Source        string          `json:"source"`
+	InteractionId string          `json:"interaction_id"`
+	WorkflowId    string          `json:"workflow_id,omitempty"`
+	ExecutionId   string          `json:"execution_id,omitempty"`
+	StepId        string          `json:"step_id,omitempty"`
+	McpId         string          `json:"mcp_id,omitempty"`
+	Time          time.Time       `json:"time"`
+	Data          json.RawMessage `json:"data,omitempty"`
+}
+
+// New builds an event for the interaction with data encoded as json. Location ids are set by the
+// caller on the returned event.
+func New(t Type, interactionId string, data any) *Event {
+	evt := &Event{
+		ID:            uuid.NewString(),
+		Type:          t,
+		InteractionId: interactionId,
+		Time:          time.Now().UTC(),
+	}
+	if data != nil {
+		evt.Data, _ = json.Marshal(data)
+	}
+	return evt
+}
+
+// StatusChange is the data of a StepStatusChanged event
+type StatusChange struct {
+	StepId   string `json:"step_id"`
+	Previous string `json:"previous"`
+	Status   string `json:"status"`
+}
+
+// Publisher delivers events, implementations keep events of one interaction in order.
+type Publisher interface {
+	Publish(ctx context.Context, evt *Event) error
+	Close() error
+}
+
+// BatchPublisher is a Publisher that delivers several events in one call. It returns an error per
+// event, nil for the delivered ones.
+type BatchPublisher interface {
+	Publisher
+	PublishBatch(ctx context.Context, evts []*Event) []error
+}
+
+// PublishAll publishes the events in order, in one call when the publisher is a BatchPublisher. It
+// returns an error per event, nil for the delivered ones. After an event of an interaction failed
+// the later events of that interaction are not tried, so none is delivered ahead of it.
+func PublishAll(ctx context.Context, p Publisher, evts []*Event) []error {
+	if bp, ok := p.(BatchPublisher); ok {
+		return bp.PublishBatch(ctx, evts)
+	}
+	results := make([]error, len(evts))
+	failed := make(map[string]error)
+	for i, evt := range evts {
+		if err, ok := failed[evt.InteractionId]; ok {
+			results[i] = fmt.Errorf("held back behind a failed event: %w", err)
+			continue
+		}
+		if err := p.Publish(ctx, evt); err != nil {
+			results[i] = err
+			failed[evt.InteractionId] = err
+		}
+	}
+	return results
+}
+
+// StreamEntry is an event read back from the interaction stream, StreamId is the position used to
+// resume reading after it.
+type StreamEntry struct {
+	StreamId string
+	Event    Event
+}
+
+// NewPublisher builds the publisher for the configured broker, memory keeps the last StreamMaxLen
+// events in process
+func NewPublisher(cfg settings.Events, brokers []string) Publisher {
+	if cfg.Broker == "memory" {
+		return NewMemoryBroker(cfg.Protocol, int(cfg.StreamMaxLen))
+	}
+	return NewKafkaPublisher(brokers, cfg.Topic, cfg.Protocol, cfg.Source)
+}
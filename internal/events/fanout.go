@@ -0,0 +1,119 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// maxPartial bounds the failed events a Fanout remembers the publishers of
+const maxPartial = 10000
+
+// Fanout publishes every event to all of its publishers. An event fails when one publisher fails
+// and the caller retries it, the Fanout remembers the publishers that took the event and sends the
+// retry to the others only.
+type Fanout struct {
+	publishers []Publisher
+
+	mu sync.Mutex
+	// partial holds the publishers that took each event that failed on another one
+	partial map[string][]bool
+	order   []string
+}
+
+func (f *Fanout) Publish(ctx context.Context, evt *Event) error {
+	return f.PublishBatch(ctx, []*Event{evt})[0]
+}
+
+// PublishBatch publishes the events to every publisher that has not taken them yet, an event fails
+// when one publisher failed it
+func (f *Fanout) PublishBatch(ctx context.Context, evts []*Event) []error {
+	results := make([][]error, len(evts))
+	taken := make([][]bool, len(evts))
+	for i, evt := range evts {
+		taken[i] = f.taken(evt.ID)
+	}
+	for p, publisher := range f.publishers {
+		var pending []*Event
+		var indexes []int
+		for i, evt := range evts {
+			if !taken[i][p] {
+				pending = append(pending, evt)
+				indexes = append(indexes, i)
+			}
+		}
+		if len(pending) == 0 {
+			continue
+		}
+		for j, err := range PublishAll(ctx, publisher, pending) {
+			if err != nil {
+				results[indexes[j]] = append(results[indexes[j]], err)
+			} else {
+				taken[indexes[j]][p] = true
+			}
+		}
+	}
+	joined := make([]error, len(evts))
+	for i, errs := range results {
+		joined[i] = errors.Join(errs...)
+		f.remember(evts[i].ID, taken[i], joined[i] != nil)
+	}
+	return joined
+}
+
+// taken returns the publishers that took the event on an earlier attempt
+func (f *Fanout) taken(eventId string) []bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	taken := make([]bool, len(f.publishers))
+	if known, ok := f.partial[eventId]; ok {
+		copy(taken, known)
+	}
+	return taken
+}
+
+// remember keeps the publishers that took a failed event and forgets a delivered one. The oldest
+// event is forgotten once maxPartial events are remembered, its retry goes to every publisher.
+func (f *Fanout) remember(eventId string, taken []bool, failed bool) {
+	if eventId == "" {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !failed {
+		delete(f.partial, eventId)
+		return
+	}
+	if _, ok := f.partial[eventId]; !ok {
+		f.order = append(f.order, eventId)
+	}
+	f.partial[eventId] = taken
+	for len(f.partial) > maxPartial {
+		delete(f.partial, f.order[0])
+		f.order = f.order[1:]
+	}
+	if len(f.order) > 2*maxPartial {
+		// drop the ids of delivered events from the order
+		order := f.order[:0]
+		for _, id := range f.order {
+			if _, ok := f.partial[id]; ok {
+				order = append(order, id)
+			}
+		}
+		f.order = order
+	}
+}
+
+func (f *Fanout) Close() error {
+	var errs []error
+	for _, p := range f.publishers {
+		if err := p.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func NewFanout(publishers ...Publisher) *Fanout {
+	return &Fanout{publishers: publishers, partial: make(map[string][]bool)}
+}
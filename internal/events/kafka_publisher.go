@@ -0,0 +1,95 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaPublisher writes events to a topic keyed by interaction id, the hash balancer sends every
+// event of an interaction to the same partition which keeps them ordered.
+type KafkaPublisher struct {
+	writer   *kafka.Writer
+	protocol string
+	source   string
+}
+
+func (kp *KafkaPublisher) Publish(ctx context.Context, evt *Event) error {
+	msg, err := kp.message(evt)
+	if err != nil {
+		return err
+	}
+	return kp.writer.WriteMessages(ctx, msg)
+}
+
+// PublishBatch writes the events in one call, the writer sends them to their partitions in batches
+func (kp *KafkaPublisher) PublishBatch(ctx context.Context, evts []*Event) []error {
+	results := make([]error, len(evts))
+	msgs := make([]kafka.Message, 0, len(evts))
+	sent := make([]int, 0, len(evts))
+	for i, evt := range evts {
+		msg, err := kp.message(evt)
+		if err != nil {
+			results[i] = err
+			continue
+		}
+		msgs = append(msgs, msg)
+		sent = append(sent, i)
+	}
+	if len(msgs) == 0 {
+		return results
+	}
+	err := kp.writer.WriteMessages(ctx, msgs...)
+	var writeErrs kafka.WriteErrors
+	for j, i := range sent {
+		switch {
+		case err == nil:
+		case errors.As(err, &writeErrs) && len(writeErrs) == len(msgs):
+			results[i] = writeErrs[j]
+		default:
+			results[i] = err
+		}
+	}
+	return results
+}
+
+func (kp *KafkaPublisher) message(evt *Event) (kafka.Message, error) {
+	evt.Protocol = kp.protocol
+	evt.Source = kp.source
+	value, err := json.Marshal(evt)
+	if err != nil {
+		return kafka.Message{}, err
+	}
+	return kafka.Message{
+		Key:   []byte(evt.InteractionId),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "type", Value: []byte(evt.Type)},
+			{Key: "protocol", Value: []byte(kp.protocol)},
+		},
+	}, nil
+}
+
+func (kp *KafkaPublisher) Close() error {
+	return kp.writer.Close()
+}
+
+func NewKafkaPublisher(brokers []string, topic, protocol, source string) *KafkaPublisher {
+	return &KafkaPublisher{
+		writer: &kafka.Writer{
+			Addr:                   kafka.TCP(brokers...),
+			Topic:                  topic,
+			Balancer:               &kafka.Hash{},
+			RequiredAcks:           kafka.RequireAll,
+			AllowAutoTopicCreation: true,
+			// the relay writes one event or one batch at a time and waits for it, the default of a
+			// second would hold every write for the full timeout
+			BatchTimeout: 10 * time.Millisecond,
+		},
+		protocol: protocol,
+		source:   source,
+	}
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryBroker is an in-process stand-in for the event topic. It keeps the latest published events
+// and fans them out to subscribers, which makes publishers testable without a Kafka cluster. A
+// subscriber whose buffer is full misses the event, which is counted instead of holding up the
+// publisher.
+type MemoryBroker struct {
+	mu          sync.Mutex
+	protocol    string
+	retain      int
+	events      []Event
+	subscribers map[chan Event]struct{}
+	dropped     atomic.Int64
+}
+
+func (mb *MemoryBroker) Publish(ctx context.Context, evt *Event) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	evt.Protocol = mb.protocol
+	mb.events = append(mb.events, *evt)
+	if len(mb.events) > mb.retain {
+		// copy the kept events down, the backing array stays as long as retain
+		mb.events = append(mb.events[:0], mb.events[len(mb.events)-mb.retain:]...)
+	}
+	for ch := range mb.subscribers {
+		select {
+		case ch <- *evt:
+		default:
+			mb.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+// Dropped returns the number of events subscribers missed because their buffer was full
+func (mb *MemoryBroker) Dropped() int64 {
+	return mb.dropped.Load()
+}
+
+// Subscribe returns a channel receiving every event published from now on. The returned function
+// removes the subscription and closes the channel.
+func (mb *MemoryBroker) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	mb.mu.Lock()
+	mb.subscribers[ch] = struct{}{}
+	mb.mu.Unlock()
+	return ch, func() {
+		mb.mu.Lock()
+		defer mb.mu.Unlock()
+		if _, ok := mb.subscribers[ch]; ok {
+			delete(mb.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Events returns the retained events, optionally only those of one interaction
+func (mb *MemoryBroker) Events(interactionId string) []Event {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	out := make([]Event, 0, len(mb.events))
+	for _, evt := range mb.events {
+		if interactionId == "" || evt.InteractionId == interactionId {
+			out = append(out, evt)
+		}
+	}
+	return out
+}
+
+func (mb *MemoryBroker) Close() error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	for ch := range mb.subscribers {
+		delete(mb.subscribers, ch)
+		close(ch)
+	}
+	return nil
+}
+
+// NewMemoryBroker returns a broker keeping the last retain events
+func NewMemoryBroker(protocol string, retain int) *MemoryBroker {
+	return &MemoryBroker{
+		protocol:    protocol,
+		retain:      max(retain, 0),
+		subscribers: make(map[chan Event]struct{}),
+	}
+}
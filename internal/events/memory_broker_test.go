@@ -0,0 +1,129 @@
+package events
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryBrokerKeepsTheLatestEvents(t *testing.T) {
+	mb := NewMemoryBroker("v1", 2)
+	ctx := context.Background()
+	for _, iid := range []string{"a", "b", "c"} {
+		if err := mb.Publish(ctx, New(InteractionUpdated, iid, nil)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	evts := mb.Events("")
+	if len(evts) != 2 || evts[0].InteractionId != "b" || evts[1].InteractionId != "c" {
+		t.Errorf("events = %+v, want those of b and c", evts)
+	}
+}
+
+func TestMemoryBrokerRecordsEvents(t *testing.T) {
+	mb := NewMemoryBroker("v1", 100)
+	ctx := context.Background()
+	for _, iid := range []string{"a", "b", "a"} {
+		if err := mb.Publish(ctx, New(InteractionUpdated, iid, nil)); err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	}
+	if got := len(mb.Events("")); got != 3 {
+		t.Errorf("events = %d, want 3", got)
+	}
+	evts := mb.Events("a")
+	if len(evts) != 2 {
+		t.Fatalf("events of a = %d, want 2", len(evts))
+	}
+	for _, evt := range evts {
+		if evt.InteractionId != "a" || evt.Protocol != "v1" {
+			t.Errorf("event = %+v, want interaction a with protocol v1", evt)
+		}
+	}
+}
+
+func TestMemoryBrokerFansOutToSubscribers(t *testing.T) {
+	mb := NewMemoryBroker("v1", 100)
+	first, cancelFirst := mb.Subscribe(1)
+	defer cancelFirst()
+	second, cancelSecond := mb.Subscribe(1)
+	defer cancelSecond()
+
+	if err := mb.Publish(context.Background(), New(InteractionCreated, "a", nil)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	for _, ch := range []<-chan Event{first, second} {
+		select {
+		case evt := <-ch:
+			if evt.Type != InteractionCreated || evt.InteractionId != "a" {
+				t.Errorf("event = %+v, want the created event of a", evt)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("subscriber did not receive the event")
+		}
+	}
+}
+
+func TestMemoryBrokerDoesNotBlockOnFullSubscriber(t *testing.T) {
+	mb := NewMemoryBroker("v1", 100)
+	full, cancelFull := mb.Subscribe(1)
+	defer cancelFull()
+	reader, cancelReader := mb.Subscribe(3)
+	defer cancelReader()
+
+	published := make(chan error, 1)
+	go func() {
+		for i := 0; i < 3; i++ {
+			if err := mb.Publish(context.Background(), New(InteractionUpdated, "a", nil)); err != nil {
+				published <- err
+				return
+			}
+		}
+		published <- nil
+	}()
+	select {
+	case err := <-published:
+		if err != nil {
+			t.Fatalf("Publish: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("publish is blocked by a subscriber that does not read")
+	}
+	if got := mb.Dropped(); got != 2 {
+		t.Errorf("dropped = %d, want 2", got)
+	}
+	if got := len(full); got != 1 {
+		t.Errorf("buffered events of the full subscriber = %d, want 1", got)
+	}
+	if got := len(reader); got != 3 {
+		t.Errorf("buffered events of the reading subscriber = %d, want 3", got)
+	}
+	if got := len(mb.Events("")); got != 3 {
+		t.Errorf("events = %d, want 3", got)
+	}
+}
+
+func TestMemoryBrokerUnsubscribe(t *testing.T) {
+	mb := NewMemoryBroker("v1", 100)
+	ch, cancel := mb.Subscribe(1)
+	cancel()
+	// cancelling twice is harmless
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Fatal("channel is open after unsubscribing")
+	}
+	if err := mb.Publish(context.Background(), New(InteractionUpdated, "a", nil)); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if got := mb.Dropped(); got != 0 {
+		t.Errorf("dropped = %d, want 0 without subscribers", got)
+	}
+
+	open, _ := mb.Subscribe(1)
+	if err := mb.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, ok := <-open; ok {
+		t.Fatal("channel is open after closing the broker")
+	}
+}
@@ -0,0 +1,115 @@
+package gql
+
+import (
+	"context"
+
+	"github.com/graph-gophers/dataloader/v7"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+)
+
+type stepRef struct {
+	interactionId, workflowId, executionId, stepId string
+}
+
+type mcpRef struct {
+	interactionId, workflowId, mcpId string
+}
+
+// loaders collect the step and mcp lookups of the resolvers of one request and read them together,
+// each distinct step or mcp is read once per request
+type loaders struct {
+	steps *dataloader.Loader[stepRef, *runtime.Step]
+	mcps  *dataloader.Loader[mcpRef, *runtime.MCP]
+}
+
+type loadersKey struct{}
+
+func withLoaders(ctx context.Context, l *loaders) context.Context {
+	return context.WithValue(ctx, loadersKey{}, l)
+}
+
+func loadersOf(ctx context.Context) *loaders {
+	l, _ := ctx.Value(loadersKey{}).(*loaders)
+	return l
+}
+
+// newLoaders builds the loaders of a request. The loaders of a subscription live as long as it does
+// and are not cached, so every event reads the steps and mcps as they are at that event.
+func newLoaders(stepSvc svc.StepService, mcpSvc svc.McpService, cfg settings.GraphQL, cached bool) *loaders {
+	stepOpts := []dataloader.Option[stepRef, *runtime.Step]{
+		dataloader.WithWait[stepRef, *runtime.Step](cfg.LoaderWait),
+		dataloader.WithBatchCapacity[stepRef, *runtime.Step](cfg.LoaderBatch),
+	}
+	mcpOpts := []dataloader.Option[mcpRef, *runtime.MCP]{
+		dataloader.WithWait[mcpRef, *runtime.MCP](cfg.LoaderWait),
+		dataloader.WithBatchCapacity[mcpRef, *runtime.MCP](cfg.LoaderBatch),
+	}
+	if !cached {
+		stepOpts = append(stepOpts, dataloader.WithCache[stepRef, *runtime.Step](&dataloader.NoCache[stepRef, *runtime.Step]{}))
+		mcpOpts = append(mcpOpts, dataloader.WithCache[mcpRef, *runtime.MCP](&dataloader.NoCache[mcpRef, *runtime.MCP]{}))
+	}
+	return &loaders{
+		steps: dataloader.NewBatchedLoader(batchSteps(stepSvc), stepOpts...),
+		mcps:  dataloader.NewBatchedLoader(batchMcps(mcpSvc), mcpOpts...),
+	}
+}
+
+// batchSteps reads the steps of every execution graph in the batch with one call, a step that does
+// not exist resolves to nil
+func batchSteps(stepSvc svc.StepService) dataloader.BatchFunc[stepRef, *runtime.Step] {
+	return func(ctx context.Context, refs []stepRef) []*dataloader.Result[*runtime.Step] {
+		type graph struct{ interactionId, workflowId, executionId string }
+		results := make([]*dataloader.Result[*runtime.Step], len(refs))
+		groups := make(map[graph][]int)
+		for i, ref := range refs {
+			g := graph{ref.interactionId, ref.workflowId, ref.executionId}
+			groups[g] = append(groups[g], i)
+		}
+		for g, indexes := range groups {
+			stepIds := make([]string, len(indexes))
+			for j, i := range indexes {
+				stepIds[j] = refs[i].stepId
+			}
+			steps, err := stepSvc.GetAllByInteractionIdAndExecutionId(ctx, g.interactionId, g.workflowId, g.executionId, stepIds)
+			for j, i := range indexes {
+				if err != nil {
+					results[i] = &dataloader.Result[*runtime.Step]{Error: err}
+				} else {
+					results[i] = &dataloader.Result[*runtime.Step]{Data: steps[j]}
+				}
+			}
+		}
+		return results
+	}
+}
+
+// batchMcps reads the mcps of every workflow in the batch with one call, an mcp that does not exist
+// resolves to nil
+func batchMcps(mcpSvc svc.McpService) dataloader.BatchFunc[mcpRef, *runtime.MCP] {
+	return func(ctx context.Context, refs []mcpRef) []*dataloader.Result[*runtime.MCP] {
+		type workflow struct{ interactionId, workflowId string }
+		results := make([]*dataloader.Result[*runtime.MCP], len(refs))
+		groups := make(map[workflow][]int)
+		for i, ref := range refs {
+			w := workflow{ref.interactionId, ref.workflowId}
+			groups[w] = append(groups[w], i)
+		}
+		for w, indexes := range groups {
+			mcpIds := make([]string, len(indexes))
+			for j, i := range indexes {
+				mcpIds[j] = refs[i].mcpId
+			}
+			mcps, err := mcpSvc.GetAllByInteractionIdAndWorkflowId(ctx, w.interactionId, w.workflowId, mcpIds)
+			for j, i := range indexes {
+				if err != nil {
+					results[i] = &dataloader.Result[*runtime.MCP]{Error: err}
+				} else {
+					results[i] = &dataloader.Result[*runtime.MCP]{Data: mcps[j]}
+				}
+			}
+		}
+		return results
+	}
+}
@@ -0,0 +1,174 @@
+// Package gql serves the interaction tree as a graphql schema over the services. Only the fields
+// a query selects are resolved, and the step and mcp lookups of one request are batched by
+// dataloaders so that a graph of n steps costs one read instead of n.
+package gql
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+//go:embed schema.graphql
+var schemaSDL string
+
+// Request is a graphql request as sent over http or in a subscribe message
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+type Schema struct {
+	log     *logger.Logger
+	schema  *graphql.Schema
+	stepSvc svc.StepService
+	mcpSvc  svc.McpService
+	cfg     settings.GraphQL
+}
+
+// Exec runs a query with loaders of its own
+func (s *Schema) Exec(ctx context.Context, req *Request) *graphql.Response {
+	ctx = withLoaders(ctx, newLoaders(s.stepSvc, s.mcpSvc, s.cfg, true))
+	return s.schema.Exec(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+// Subscribe runs a subscription until ctx is done. Its loaders do not cache, so a step selected in
+// the payload of an event is read as it is after the change.
+func (s *Schema) Subscribe(ctx context.Context, req *Request) (<-chan any, error) {
+	ctx = withLoaders(ctx, newLoaders(s.stepSvc, s.mcpSvc, s.cfg, false))
+	return s.schema.Subscribe(ctx, req.Query, req.OperationName, req.Variables)
+}
+
+type resolver struct {
+	log            *logger.Logger
+	tr             trace.Tracer
+	interactionSvc svc.InteractionService
+	stepSvc        svc.StepService
+	hub            svc.SubscriptionHub
+}
+
+// Interaction resolves to null when there is no interaction with the id
+func (r *resolver) Interaction(ctx context.Context, args struct{ ID graphql.ID }) (*interactionResolver, error) {
+	interaction, err := r.interactionSvc.GetById(ctx, string(args.ID))
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && interaction == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		r.log.Errorf("Error while getting interaction by id: %s by error: %v", args.ID, err)
+		return nil, err
+	}
+	return &interactionResolver{interaction}, nil
+}
+
+func (r *resolver) Step(ctx context.Context, args struct {
+	InteractionId graphql.ID
+	WorkflowId    graphql.ID
+	ExecutionId   graphql.ID
+	StepId        graphql.ID
+}) (*stepResolver, error) {
+	return loadStep(ctx, stepRef{
+		interactionId: string(args.InteractionId),
+		workflowId:    string(args.WorkflowId),
+		executionId:   string(args.ExecutionId),
+		stepId:        string(args.StepId),
+	})
+}
+
+// StepStatusChanged delivers the StepStatusChanged events of the live event stream. The step of a
+// change is read when the subscriber selects it, events missed while the subscriber was too slow
+// are dropped like on the websocket subscriptions.
+func (r *resolver) StepStatusChanged(ctx context.Context, args struct {
+	InteractionId *graphql.ID
+	StepId        *graphql.ID
+}) (<-chan *statusChangeResolver, error) {
+	filter := model.SubscriptionFilter{Types: []string{string(events.StepStatusChanged)}}
+	if args.InteractionId != nil {
+		filter.InteractionIds = []string{string(*args.InteractionId)}
+	}
+	subscriber := r.hub.Attach()
+	subscriber.Subscribe(uuid.NewString(), filter)
+
+	changes := make(chan *statusChangeResolver)
+	go func() {
+		defer close(changes)
+		defer r.hub.Detach(subscriber)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case delivery := <-subscriber.Deliveries():
+				change := r.statusChange(&delivery.Event)
+				if change == nil || (args.StepId != nil && change.ref.stepId != string(*args.StepId)) {
+					continue
+				}
+				select {
+				case changes <- change:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return changes, nil
+}
+
+func (r *resolver) statusChange(evt *events.Event) *statusChangeResolver {
+	var data events.StatusChange
+	if err := json.Unmarshal(evt.Data, &data); err != nil {
+		r.log.Warnf("Error while decoding %s event id: %s by error: %v", evt.Type, evt.ID, err)
+		return nil
+	}
+	stepId := evt.StepId
+	if stepId == "" {
+		stepId = data.StepId
+	}
+	return &statusChangeResolver{
+		ref: stepRef{
+			interactionId: evt.InteractionId,
+			workflowId:    evt.WorkflowId,
+			executionId:   evt.ExecutionId,
+			stepId:        stepId,
+		},
+		previous: runtime.Status(data.Previous),
+		status:   runtime.Status(data.Status),
+		time:     evt.Time,
+	}
+}
+
+func NewSchema(log *logger.Logger, tr trace.Tracer, interactionSvc svc.InteractionService, stepSvc svc.StepService, mcpSvc svc.McpService, hub svc.SubscriptionHub) (*Schema, error) {
+	cfg := settings.GetGraphQL()
+	root := &resolver{
+		log:            log,
+		tr:             tr,
+		interactionSvc: interactionSvc,
+		stepSvc:        stepSvc,
+		hub:            hub,
+	}
+	schema, err := graphql.ParseSchema(schemaSDL, root,
+		graphql.MaxDepth(cfg.MaxDepth),
+		graphql.MaxParallelism(cfg.MaxParallelism),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{
+		log:     log,
+		schema:  schema,
+		stepSvc: stepSvc,
+		mcpSvc:  mcpSvc,
+		cfg:     cfg,
+	}, nil
+}
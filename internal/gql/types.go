@@ -0,0 +1,520 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+// JSON is the scalar of free form members like the parameters of an agent
+type JSON struct {
+	Value any
+}
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input any) error {
+	j.Value = input
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	return json.Marshal(j.Value)
+}
+
+func toJSON[M ~map[string]V, V any](m M) *JSON {
+	if m == nil {
+		return nil
+	}
+	return &JSON{Value: m}
+}
+
+func toTime(t time.Time) *graphql.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &graphql.Time{Time: t}
+}
+
+func toStatus(s runtime.Status) *runtime.Status {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func orEmpty(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+type interactionResolver struct {
+	i *runtime.Interaction
+}
+
+func (r *interactionResolver) ID() graphql.ID { return graphql.ID(r.i.ID) }
+
+func (r *interactionResolver) BaseQuery() *queryResolver { return newQuery(r.i.BaseQuery) }
+
+func (r *interactionResolver) BaseContext() *contextResolver { return newContext(r.i.BaseContext) }
+
+func (r *interactionResolver) Plan() *planResolver {
+	if r.i.Plan == nil {
+		return nil
+	}
+	return &planResolver{r.i.Plan}
+}
+
+func (r *interactionResolver) Workflow() *flowResolver {
+	if r.i.ExecutionFlow == nil {
+		return nil
+	}
+	return &flowResolver{interactionId: r.i.ID, f: r.i.ExecutionFlow}
+}
+
+func (r *interactionResolver) Messages() []*messageResolver {
+	messages := make([]*messageResolver, len(r.i.Messages))
+	for i := range r.i.Messages {
+		messages[i] = &messageResolver{&r.i.Messages[i]}
+	}
+	return messages
+}
+
+func (r *interactionResolver) Summary() string { return r.i.Summary }
+
+func (r *interactionResolver) CreatedAt() *graphql.Time { return toTime(r.i.CreatedAt) }
+
+func (r *interactionResolver) CompletedAt() *graphql.Time { return toTime(r.i.CompletedAt) }
+
+type queryResolver struct {
+	q *runtime.Query
+}
+
+func newQuery(q *runtime.Query) *queryResolver {
+	if q == nil {
+		return nil
+	}
+	return &queryResolver{q}
+}
+
+func (r *queryResolver) ID() graphql.ID { return graphql.ID(r.q.ID) }
+
+func (r *queryResolver) Content() string { return r.q.Content }
+
+func (r *queryResolver) Tags() []string { return orEmpty(r.q.Tags) }
+
+func (r *queryResolver) Metadata() *JSON { return toJSON(r.q.Metadata) }
+
+func (r *queryResolver) Timestamp() *graphql.Time { return toTime(r.q.Timestamp) }
+
+type answerResolver struct {
+	a *runtime.Answer
+}
+
+func newAnswer(a *runtime.Answer) *answerResolver {
+	if a == nil {
+		return nil
+	}
+	return &answerResolver{a}
+}
+
+func (r *answerResolver) ID() graphql.ID { return graphql.ID(r.a.ID) }
+
+func (r *answerResolver) Content() string { return r.a.Content }
+
+func (r *answerResolver) Timestamp() *graphql.Time { return toTime(r.a.Timestamp) }
+
+type messageResolver struct {
+	m *runtime.Message
+}
+
+func (r *messageResolver) ID() graphql.ID { return graphql.ID(r.m.ID) }
+
+func (r *messageResolver) Role() string { return r.m.Role }
+
+func (r *messageResolver) Query() *queryResolver { return newQuery(r.m.Query) }
+
+func (r *messageResolver) Answer() *answerResolver { return newAnswer(r.m.Answer) }
+
+func (r *messageResolver) Timestamp() *graphql.Time { return toTime(r.m.Timestamp) }
+
+type contextResolver struct {
+	c *runtime.Context
+}
+
+func newContext(c *runtime.Context) *contextResolver {
+	if c == nil {
+		return nil
+	}
+	return &contextResolver{c}
+}
+
+func (r *contextResolver) ID() graphql.ID { return graphql.ID(r.c.ID) }
+
+func (r *contextResolver) Content() string { return r.c.Content }
+
+func (r *contextResolver) Workspace() *JSON { return toJSON(r.c.Workspace) }
+
+func (r *contextResolver) Knowledge() *JSON { return toJSON(r.c.Knowledge) }
+
+type planResolver struct {
+	p *runtime.Plan
+}
+
+func (r *planResolver) ID() graphql.ID { return graphql.ID(r.p.ID) }
+
+func (r *planResolver) Steps() []*planStepResolver {
+	steps := make([]*planStepResolver, len(r.p.Steps))
+	for i := range r.p.Steps {
+		steps[i] = &planStepResolver{&r.p.Steps[i]}
+	}
+	return steps
+}
+
+type planStepResolver struct {
+	s *runtime.PlanStep
+}
+
+func (r *planStepResolver) ID() graphql.ID { return graphql.ID(r.s.ID) }
+
+func (r *planStepResolver) Name() string { return r.s.Name }
+
+func (r *planStepResolver) Description() string { return r.s.Description }
+
+func (r *planStepResolver) DependsOn() []string { return orEmpty(r.s.DependsOn) }
+
+type flowResolver struct {
+	interactionId string
+	f             *runtime.ExecutionFlow
+}
+
+func (r *flowResolver) ID() graphql.ID { return graphql.ID(r.f.ID) }
+
+func (r *flowResolver) Name() string { return r.f.Name }
+
+func (r *flowResolver) Description() string { return r.f.Description }
+
+func (r *flowResolver) Agents() []*agentRefResolver {
+	agents := make([]*agentRefResolver, len(r.f.Agents))
+	for i := range r.f.Agents {
+		agents[i] = &agentRefResolver{&r.f.Agents[i]}
+	}
+	return agents
+}
+
+func (r *flowResolver) Variables() *JSON { return toJSON(r.f.Variables) }
+
+func (r *flowResolver) Mode() string { return r.f.Mode }
+
+func (r *flowResolver) Graph() *graphResolver {
+	if r.f.ExecutionGraph == nil {
+		return nil
+	}
+	return &graphResolver{interactionId: r.interactionId, workflowId: r.f.ID, g: r.f.ExecutionGraph}
+}
+
+func (r *flowResolver) AvailableMcpRefs() []string { return orEmpty(r.f.AvailableMcpRefs) }
+
+func (r *flowResolver) Mcps(ctx context.Context) ([]*mcpResolver, error) {
+	refs := make([]mcpRef, len(r.f.AvailableMcpRefs))
+	for i, mcpId := range r.f.AvailableMcpRefs {
+		refs[i] = mcpRef{interactionId: r.interactionId, workflowId: r.f.ID, mcpId: mcpId}
+	}
+	found, errs := loadersOf(ctx).mcps.LoadMany(ctx, refs)()
+	mcps := make([]*mcpResolver, 0, len(found))
+	for i, mcp := range found {
+		if len(errs) > i && errs[i] != nil {
+			return nil, errs[i]
+		}
+		if mcp != nil {
+			mcps = append(mcps, &mcpResolver{mcp})
+		}
+	}
+	return mcps, nil
+}
+
+func (r *flowResolver) PlanID() string { return r.f.PlanID }
+
+type agentRefResolver struct {
+	a *runtime.AgentRef
+}
+
+func (r *agentRefResolver) ID() graphql.ID { return graphql.ID(r.a.ID) }
+
+func (r *agentRefResolver) Role() string { return r.a.Role }
+
+type graphResolver struct {
+	interactionId, workflowId string
+	g                         *runtime.ExecutionGraph
+}
+
+func (r *graphResolver) ID() graphql.ID { return graphql.ID(r.g.ID) }
+
+func (r *graphResolver) ref(stepId string) stepRef {
+	return stepRef{interactionId: r.interactionId, workflowId: r.workflowId, executionId: r.g.ID, stepId: stepId}
+}
+
+func (r *graphResolver) Nodes() []*nodeResolver {
+	nodes := make([]*nodeResolver, len(r.g.Nodes))
+	for i := range r.g.Nodes {
+		nodes[i] = &nodeResolver{ref: r.ref(r.g.Nodes[i].StepId), n: &r.g.Nodes[i]}
+	}
+	return nodes
+}
+
+func (r *graphResolver) Edges() []*edgeResolver {
+	edges := make([]*edgeResolver, len(r.g.Edges))
+	for i := range r.g.Edges {
+		edges[i] = &edgeResolver{&r.g.Edges[i]}
+	}
+	return edges
+}
+
+func (r *graphResolver) Steps(ctx context.Context) ([]*stepResolver, error) {
+	refs := make([]stepRef, len(r.g.Nodes))
+	for i, n := range r.g.Nodes {
+		refs[i] = r.ref(n.StepId)
+	}
+	found, errs := loadersOf(ctx).steps.LoadMany(ctx, refs)()
+	steps := make([]*stepResolver, 0, len(found))
+	for i, step := range found {
+		if len(errs) > i && errs[i] != nil {
+			return nil, errs[i]
+		}
+		if step != nil {
+			steps = append(steps, &stepResolver{ref: refs[i], s: step})
+		}
+	}
+	return steps, nil
+}
+
+type nodeResolver struct {
+	ref stepRef
+	n   *runtime.ExecutionNode
+}
+
+func (r *nodeResolver) StepID() graphql.ID { return graphql.ID(r.n.StepId) }
+
+func (r *nodeResolver) Name() string { return r.n.Name }
+
+func (r *nodeResolver) Status() *runtime.Status { return toStatus(r.n.Status) }
+
+func (r *nodeResolver) Step(ctx context.Context) (*stepResolver, error) {
+	return loadStep(ctx, r.ref)
+}
+
+func loadStep(ctx context.Context, ref stepRef) (*stepResolver, error) {
+	step, err := loadersOf(ctx).steps.Load(ctx, ref)()
+	if err != nil || step == nil {
+		return nil, err
+	}
+	return &stepResolver{ref: ref, s: step}, nil
+}
+
+type edgeResolver struct {
+	e *runtime.Edge
+}
+
+func (r *edgeResolver) From() string { return r.e.From }
+
+func (r *edgeResolver) To() string { return r.e.To }
+
+func (r *edgeResolver) Type() string { return r.e.Type }
+
+func (r *edgeResolver) Label() string { return r.e.Label }
+
+type stepResolver struct {
+	ref stepRef
+	s   *runtime.Step
+}
+
+func (r *stepResolver) ID() graphql.ID { return graphql.ID(r.s.ID) }
+
+func (r *stepResolver) Index() int32 { return int32(r.s.Index) }
+
+func (r *stepResolver) Name() string { return r.s.Name }
+
+func (r *stepResolver) Status() *runtime.Status { return toStatus(r.s.Status) }
+
+func (r *stepResolver) Error() string { return r.s.Error }
+
+func (r *stepResolver) Agent() *agentResolver {
+	if r.s.Agent == nil {
+		return nil
+	}
+	return &agentResolver{r.s.Agent}
+}
+
+func (r *stepResolver) InputContext() *contextResolver { return newContext(r.s.InputContext) }
+
+func (r *stepResolver) OutputContext() *contextResolver { return newContext(r.s.OutputContext) }
+
+func (r *stepResolver) Query() *queryResolver { return newQuery(r.s.Query) }
+
+func (r *stepResolver) Answer() *answerResolver { return newAnswer(r.s.Answer) }
+
+func (r *stepResolver) Artifacts() []*artifactResolver {
+	artifacts := make([]*artifactResolver, len(r.s.Artifacts))
+	for i := range r.s.Artifacts {
+		artifacts[i] = &artifactResolver{&r.s.Artifacts[i]}
+	}
+	return artifacts
+}
+
+func (r *stepResolver) ToolInvocations() []*invocationResolver {
+	invocations := make([]*invocationResolver, len(r.s.CuratedTools))
+	for i := range r.s.CuratedTools {
+		invocations[i] = &invocationResolver{ref: r.ref, i: &r.s.CuratedTools[i]}
+	}
+	return invocations
+}
+
+func (r *stepResolver) InputStepID() string { return r.s.InputStepID }
+
+func (r *stepResolver) StartedAt() *graphql.Time { return toTime(r.s.StartedAt) }
+
+func (r *stepResolver) FinishedAt() *graphql.Time { return toTime(r.s.FinishedAt) }
+
+type agentResolver struct {
+	a *runtime.Agent
+}
+
+func (r *agentResolver) ID() graphql.ID { return graphql.ID(r.a.ID) }
+
+func (r *agentResolver) Name() string { return r.a.Name }
+
+func (r *agentResolver) Description() string { return r.a.Description }
+
+func (r *agentResolver) Model() string { return r.a.Model }
+
+func (r *agentResolver) Role() string { return r.a.Role }
+
+func (r *agentResolver) SystemPrompt() string { return r.a.SystemPrompt }
+
+func (r *agentResolver) UserPrompt() string { return r.a.UserPrompt }
+
+func (r *agentResolver) Capabilities() []string { return orEmpty(r.a.Capabilities) }
+
+func (r *agentResolver) Parameters() *JSON { return toJSON(r.a.Parameters) }
+
+func (r *agentResolver) LastUpdatedAt() *graphql.Time { return toTime(r.a.LastUpdatedAt) }
+
+type artifactResolver struct {
+	a *runtime.Artifact
+}
+
+func (r *artifactResolver) ID() graphql.ID { return graphql.ID(r.a.ID) }
+
+func (r *artifactResolver) Name() string { return r.a.Name }
+
+func (r *artifactResolver) Path() string { return r.a.Path }
+
+func (r *artifactResolver) Type() string { return r.a.Type }
+
+func (r *artifactResolver) Content() *JSON { return toJSON(r.a.Content) }
+
+func (r *artifactResolver) CreatedByStepID() string { return r.a.CreatedByStepID }
+
+func (r *artifactResolver) CreatedAt() *graphql.Time { return toTime(r.a.CreatedAt) }
+
+type invocationResolver struct {
+	ref stepRef
+	i   *runtime.McpToolInvocation
+}
+
+func (r *invocationResolver) ID() graphql.ID { return graphql.ID(r.i.ID) }
+
+func (r *invocationResolver) ToolName() string { return r.i.ToolName }
+
+func (r *invocationResolver) McpID() string { return r.i.MCPID }
+
+// Mcp resolves the mcp of the invocation among the mcps of the workflow of the step
+func (r *invocationResolver) Mcp(ctx context.Context) (*mcpResolver, error) {
+	if r.i.MCPID == "" {
+		return nil, nil
+	}
+	mcp, err := loadersOf(ctx).mcps.Load(ctx, mcpRef{interactionId: r.ref.interactionId, workflowId: r.ref.workflowId, mcpId: r.i.MCPID})()
+	if err != nil || mcp == nil {
+		return nil, err
+	}
+	return &mcpResolver{mcp}, nil
+}
+
+func (r *invocationResolver) Category() string { return r.i.Category }
+
+func (r *invocationResolver) Input() *JSON { return toJSON(r.i.Input) }
+
+func (r *invocationResolver) Output() *JSON { return toJSON(r.i.Output) }
+
+func (r *invocationResolver) Status() *runtime.Status { return toStatus(r.i.Status) }
+
+func (r *invocationResolver) AgentID() string { return r.i.AgentID }
+
+func (r *invocationResolver) StepID() string { return r.i.StepID }
+
+func (r *invocationResolver) StartedAt() *graphql.Time { return toTime(r.i.StartedAt) }
+
+func (r *invocationResolver) FinishedAt() *graphql.Time { return toTime(r.i.FinishedAt) }
+
+func (r *invocationResolver) Error() string { return r.i.Error }
+
+type mcpResolver struct {
+	m *runtime.MCP
+}
+
+func (r *mcpResolver) ID() graphql.ID { return graphql.ID(r.m.ID) }
+
+func (r *mcpResolver) Name() string { return r.m.Name }
+
+func (r *mcpResolver) Tools() []*toolResolver {
+	tools := make([]*toolResolver, len(r.m.Tools))
+	for i := range r.m.Tools {
+		tools[i] = &toolResolver{&r.m.Tools[i]}
+	}
+	return tools
+}
+
+type toolResolver struct {
+	t *runtime.Tool
+}
+
+func (r *toolResolver) Name() string { return r.t.Name }
+
+func (r *toolResolver) Description() string { return r.t.Description }
+
+func (r *toolResolver) InputSchema() *JSON { return toJSON(r.t.InputSchema) }
+
+func (r *toolResolver) OutputSchema() *JSON { return toJSON(r.t.OutputSchema) }
+
+// statusChangeResolver is a StepStatusChanged event
+type statusChangeResolver struct {
+	ref      stepRef
+	previous runtime.Status
+	status   runtime.Status
+	time     time.Time
+}
+
+func (r *statusChangeResolver) InteractionID() graphql.ID { return graphql.ID(r.ref.interactionId) }
+
+func (r *statusChangeResolver) WorkflowID() graphql.ID { return graphql.ID(r.ref.workflowId) }
+
+func (r *statusChangeResolver) ExecutionID() graphql.ID { return graphql.ID(r.ref.executionId) }
+
+func (r *statusChangeResolver) StepID() graphql.ID { return graphql.ID(r.ref.stepId) }
+
+func (r *statusChangeResolver) Previous() *runtime.Status { return toStatus(r.previous) }
+
+func (r *statusChangeResolver) Status() *runtime.Status { return toStatus(r.status) }
+
+func (r *statusChangeResolver) Time() graphql.Time { return graphql.Time{Time: r.time} }
+
+func (r *statusChangeResolver) Step(ctx context.Context) (*stepResolver, error) {
+	return loadStep(ctx, r.ref)
+}
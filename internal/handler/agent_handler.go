@@ -0,0 +1,128 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type AgentHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.AgentService
+}
+
+// AgentEndpoints document the routes of the AgentHandler
+var AgentEndpoints = struct {
+	CreateAgent, ListAgents, GetAgent, CreateVersion, GetVersion openapi.Endpoint
+}{
+	CreateAgent: openapi.Endpoint{Id: "createAgent", Tag: "agents", Summary: "Create an agent with its first version",
+		Body: agentVersionRequest{}, Status: http.StatusCreated, Response: model.AgentVersion{}},
+	ListAgents: openapi.Endpoint{Id: "listAgents", Tag: "agents", Summary: "List the agents",
+		Response: []model.AgentHead{}},
+	GetAgent: openapi.Endpoint{Id: "getAgent", Tag: "agents", Summary: "Get the latest version of an agent",
+		Response: model.AgentHead{}},
+	CreateVersion: openapi.Endpoint{Id: "createAgentVersion", Tag: "agents", Summary: "Add a version to an agent",
+		Body: agentVersionRequest{}, Status: http.StatusCreated, Response: model.AgentVersion{}},
+	GetVersion: openapi.Endpoint{Id: "getAgentVersion", Tag: "agents", Summary: "Get a version of an agent",
+		Response: model.AgentVersion{}},
+}
+
+type agentVersionRequest struct {
+	Agent   runtime.Agent `json:"agent"`
+	Comment string        `json:"comment"`
+}
+
+func (ah *AgentHandler) ListAgentsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	heads, err := ah.svc.List(ctx)
+	if err != nil {
+		ah.log.Errorf("Error while listing agents: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, heads)
+}
+
+func (ah *AgentHandler) GetAgentHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	agentId := c.Param("agentId")
+	head, err := ah.svc.GetHead(ctx, agentId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, head)
+}
+
+func (ah *AgentHandler) CreateAgentHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req agentVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ah.log.Errorf("Error while binding request data to Agent: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	av, err := ah.svc.Create(ctx, &req.Agent, req.Comment)
+	if err != nil {
+		ah.log.Errorf("Error while creating agent: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, av)
+}
+
+func (ah *AgentHandler) CreateVersionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	agentId := c.Param("agentId")
+	var req agentVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ah.log.Errorf("Error while binding request data to Agent: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if req.Agent.ID != "" && req.Agent.ID != agentId {
+		ah.log.Errorf("Invalid agent id: %s and Agent json ID: %s", agentId, req.Agent.ID)
+		_ = c.Error(errs.Invalid("Invalid agent id"))
+		return
+	}
+	av, err := ah.svc.AddVersion(ctx, agentId, &req.Agent, req.Comment)
+	if err != nil {
+		ah.log.Errorf("Error while creating agent version: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, av)
+}
+
+func (ah *AgentHandler) GetVersionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	agentId := c.Param("agentId")
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		_ = c.Error(errs.Invalid("Invalid agent version"))
+		return
+	}
+	av, err := ah.svc.GetVersion(ctx, agentId, version)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, av)
+}
+
+func NewAgentHandler(log *logger.Logger, tr trace.Tracer, svc svc.AgentService) *AgentHandler {
+	return &AgentHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+	}
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/audit"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// ActorKey is the gin context key an authentication middleware sets to the caller identity, it
+	// takes precedence over the X-Actor header
+	ActorKey    = "actor"
+	HeaderActor = "X-Actor"
+
+	anonymousActor = "anonymous"
+)
+
+type AuditHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.AuditService
+}
+
+// AuditEndpoints document the routes of the AuditHandler
+var AuditEndpoints = struct {
+	ListEntries openapi.Endpoint
+}{
+	ListEntries: openapi.Endpoint{Id: "listAuditEntries", Tag: "audit", Summary: "Query the audit log",
+		Query: []openapi.Param{
+			{Name: "interactionId"},
+			{Name: "actor"},
+			{Name: "from", Description: "RFC 3339 timestamp or unix seconds"},
+			{Name: "to", Description: "RFC 3339 timestamp or unix seconds"},
+			{Name: "limit", Type: "integer"},
+		},
+		Response: []model.AuditEntry{}},
+}
+
+// RecordWrites is the middleware auditing every write request. The writes committed while the
+// request is served are collected with their before and after documents and stored with the
+// caller, the handler that served it and the response status once it completes.
+func (auh *AuditHandler) RecordWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		rec := audit.NewRecorder()
+		c.Request = c.Request.WithContext(audit.WithRecorder(c.Request.Context(), rec))
+		c.Next()
+
+		entry := &model.AuditEntry{
+			Actor:         actorOf(c),
+			Operation:     operationOf(c),
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			InteractionId: c.Param("interactionId"),
+			Status:        c.Writer.Status(),
+			Error:         c.Errors.String(),
+			Changes:       rec.Changes(),
+		}
+		// the entry is written even when the client went away before the response
+		_ = auh.svc.Record(context.WithoutCancel(c.Request.Context()), entry)
+	}
+}
+
+func actorOf(c *gin.Context) string {
+	if actor := c.GetString(ActorKey); actor != "" {
+		return actor
+	}
+	if actor := strings.TrimSpace(c.GetHeader(HeaderActor)); actor != "" {
+		return actor
+	}
+	return anonymousActor
+}
+
+// operationOf returns the name of the handler method, like UpdateStepHandler
+func operationOf(c *gin.Context) string {
+	name := strings.TrimSuffix(c.HandlerName(), "-fm")
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// ListEntriesHandler queries the audit log by the interactionId and actor query parameters and the
+// from and to time range, newest first
+func (auh *AuditHandler) ListEntriesHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	query := model.AuditQuery{
+		InteractionId: c.Query("interactionId"),
+		Actor:         c.Query("actor"),
+	}
+	var err error
+	if from := c.Query("from"); from != "" {
+		if query.From, err = parseTime(from); err != nil {
+			_ = c.Error(invalid(err))
+			return
+		}
+	}
+	if to := c.Query("to"); to != "" {
+		if query.To, err = parseTime(to); err != nil {
+			_ = c.Error(invalid(err))
+			return
+		}
+	}
+	if !query.From.IsZero() && !query.To.IsZero() && query.To.Before(query.From) {
+		_ = c.Error(errs.Invalid("to is before from"))
+		return
+	}
+	if limit := c.Query("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			_ = c.Error(errs.Invalid("invalid limit"))
+			return
+		}
+	}
+	entries, err := auh.svc.Query(ctx, query)
+	if err != nil {
+		auh.log.Errorf("Error while querying audit log: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+func NewAuditHandler(log *logger.Logger, tr trace.Tracer, svc svc.AuditService) *AuditHandler {
+	return &AuditHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+	}
+}
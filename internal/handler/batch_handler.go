@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type BatchHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.BatchService
+	v   *validation.Validator
+}
+
+// BatchEndpoints document the routes of the BatchHandler
+var BatchEndpoints = struct {
+	ExecuteBatch openapi.Endpoint
+}{
+	ExecuteBatch: openapi.Endpoint{Id: "executeBatch", Tag: "batch", Summary: "Apply writes on one interaction atomically",
+		Headers: []openapi.Param{idempotencyKey}, Body: model.BatchRequest{}, Response: model.BatchResponse{}},
+}
+
+// ExecuteBatchHandler runs the operations of the batch on one interaction. Either all of them are
+// committed and their results returned in order, or none is and the problem names the operation
+// that failed.
+func (bh *BatchHandler) ExecuteBatchHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req model.BatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		bh.log.Errorf("Error while binding request data to BatchRequest: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := bh.v.Batch(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	resp, err := bh.svc.Execute(ctx, &req)
+	if err != nil {
+		bh.log.Errorf("Error while executing batch on interaction id: %s by error: %v", req.InteractionId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func NewBatchHandler(log *logger.Logger, tr trace.Tracer, svc svc.BatchService) *BatchHandler {
+	return &BatchHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+		v:   validation.New(settings.GetValidation()),
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type ChildHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.ChildService
+	v   *validation.Validator
+}
+
+// ChildEndpoints document the routes of the ChildHandler
+var ChildEndpoints = struct {
+	CreateChild, ListChildren, GetTree openapi.Endpoint
+}{
+	CreateChild: openapi.Endpoint{Id: "createChild", Tag: "children", Summary: "Create a child interaction under a new node of an execution graph",
+		Headers: []openapi.Param{idempotencyKey}, Body: model.CreateChildRequest{}, Status: http.StatusCreated, Response: model.ChildLink{}},
+	ListChildren: openapi.Endpoint{Id: "listChildren", Tag: "children", Summary: "List the child interactions of an interaction",
+		Response: []model.ChildLink{}},
+	GetTree: openapi.Endpoint{Id: "getInteractionTree", Tag: "children", Summary: "Get an interaction with its child interactions, nested",
+		Response: model.InteractionTree{}},
+}
+
+// CreateChildHandler creates a child interaction under a new node of the execution graph
+func (ch *ChildHandler) CreateChildHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	executionId := c.Param("executionId")
+	var req model.CreateChildRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ch.log.Errorf("Error while binding request data to child: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if req.Interaction == nil {
+		_ = c.Error(errs.Invalid("interaction is required"))
+		return
+	}
+	if err := ch.v.Interaction(req.Interaction); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	link, err := ch.svc.Create(ctx, interactionId, workflowId, executionId, &req)
+	if err != nil {
+		ch.log.Errorf("Error while creating child of interaction id: %s by error: %v", interactionId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, link)
+}
+
+func (ch *ChildHandler) ListChildrenHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	children, err := ch.svc.Children(ctx, interactionId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, children)
+}
+
+// GetTreeHandler returns the interaction with its child interactions, nested down to the last ones
+func (ch *ChildHandler) GetTreeHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	tree, err := ch.svc.Tree(ctx, interactionId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, tree)
+}
+
+func NewChildHandler(log *logger.Logger, tr trace.Tracer, svc svc.ChildService) *ChildHandler {
+	return &ChildHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+		v:   validation.New(settings.GetValidation()),
+	}
+}
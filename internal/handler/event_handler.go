@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// streamIdPattern matches the ids of redis stream entries
+var streamIdPattern = regexp.MustCompile(`^[0-9]+-[0-9]+$`)
+
+type EventHandler struct {
+	log            *logger.Logger
+	tr             trace.Tracer
+	svc            svc.EventStreamService
+	interactionSvc svc.InteractionService
+	heartbeat      time.Duration
+}
+
+// EventEndpoints document the routes of the EventHandler
+var EventEndpoints = struct {
+	StreamEvents openapi.Endpoint
+}{
+	StreamEvents: openapi.Endpoint{Id: "streamEvents", Tag: "events", Summary: "Stream the events of an interaction as server-sent events",
+		Query: []openapi.Param{
+			{Name: "types", Description: "Comma separated event types to send"},
+			{Name: "lastEventId", Description: "Stream id to resume after, like the Last-Event-ID header"},
+		},
+		Headers:  []openapi.Param{{Name: "Last-Event-ID", Description: "Stream id to resume after"}},
+		Response: events.Event{}, ResponseType: "text/event-stream"},
+}
+
+// StreamEventsHandler sends the events of an interaction as server-sent events. The stream id is
+// the event id, so a reconnecting client resumes with Last-Event-ID. The optional types query
+// parameter is a comma separated list of event types to send.
+func (eh *EventHandler) StreamEventsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	interaction, err := eh.interactionSvc.GetById(ctx, interactionId)
+	if err == nil && interaction == nil {
+		err = errs.NotFound("interaction id: %s not found", interactionId)
+	}
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	lastEventId := c.GetHeader("Last-Event-ID")
+	if lastEventId == "" {
+		lastEventId = c.Query("lastEventId")
+	}
+	if lastEventId != "" && !streamIdPattern.MatchString(lastEventId) {
+		_ = c.Error(errs.Invalid("last event id: %s is not a stream id like <ms>-<seq>", lastEventId))
+		return
+	}
+	var types []events.Type
+	for _, t := range strings.Split(c.Query("types"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, events.Type(t))
+		}
+	}
+	entries, err := eh.svc.Subscribe(ctx, interactionId, lastEventId, types)
+	if err != nil {
+		_ = c.Error(errs.Unavailable(err))
+		return
+	}
+
+	// the server write timeout would cut the stream
+	if err = http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		eh.log.Warnf("Error while clearing write deadline of event stream: %v", err)
+	}
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	heartbeat := time.NewTicker(eh.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case entry, ok := <-entries:
+			if !ok {
+				return
+			}
+			c.Render(-1, sse.Event{
+				Id:    entry.StreamId,
+				Event: string(entry.Event.Type),
+				Data:  entry.Event,
+			})
+		case <-heartbeat.C:
+			if _, err = io.WriteString(c.Writer, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func NewEventHandler(log *logger.Logger, tr trace.Tracer, svc svc.EventStreamService, interactionSvc svc.InteractionService) *EventHandler {
+	return &EventHandler{
+		log:            log,
+		tr:             tr,
+		svc:            svc,
+		interactionSvc: interactionSvc,
+		heartbeat:      settings.GetEvents().Heartbeat,
+	}
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/gql"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// graphqlProtocol is the websocket subprotocol of graphql subscriptions, graphql-transport-ws
+const graphqlProtocol = "graphql-transport-ws"
+
+const (
+	gqlConnectionInit = "connection_init"
+	gqlConnectionAck  = "connection_ack"
+	gqlPing           = "ping"
+	gqlPong           = "pong"
+	gqlSubscribe      = "subscribe"
+	gqlNext           = "next"
+	gqlError          = "error"
+	gqlComplete       = "complete"
+)
+
+// gqlMessage is a message of the graphql-transport-ws protocol
+type gqlMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type GraphQLHandler struct {
+	log      *logger.Logger
+	tr       trace.Tracer
+	schema   *gql.Schema
+	cfg      settings.Subscriptions
+	upgrader websocket.Upgrader
+}
+
+// GraphQLEndpoints document the routes of the GraphQLHandler
+var GraphQLEndpoints = struct {
+	Query openapi.Endpoint
+}{
+	Query: openapi.Endpoint{Id: "graphql", Tag: "graphql", Summary: "Run a graphql query, a websocket upgrade of the GET serves subscriptions",
+		Body: gql.Request{}, Response: graphql.Response{}},
+}
+
+// QueryHandler runs a query posted as json, or given by the query, operationName and variables query
+// parameters of a GET. A GET asking for a websocket upgrade is a subscription connection.
+func (gh *GraphQLHandler) QueryHandler(c *gin.Context) {
+	if c.Request.Method == http.MethodGet && websocket.IsWebSocketUpgrade(c.Request) {
+		gh.subscribe(c)
+		return
+	}
+	var req gql.Request
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+		if variables := c.Query("variables"); variables != "" {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				_ = c.Error(invalid(err))
+				return
+			}
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		gh.log.Errorf("Error while binding request data to graphql request: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	c.JSON(http.StatusOK, gh.schema.Exec(c.Request.Context(), &req))
+}
+
+// subscribe serves the subscriptions of a client over one websocket. Every subscribe message starts
+// a subscription under the id chosen by the client, it runs until the client completes it or the
+// connection closes.
+func (gh *GraphQLHandler) subscribe(c *gin.Context) {
+	conn, err := gh.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		gh.log.Errorf("Error while upgrading graphql connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	out := make(chan gqlMessage, 16)
+	go gh.write(ctx, conn, out)
+	send := func(msg gqlMessage) bool {
+		select {
+		case out <- msg:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	var mu sync.Mutex
+	running := make(map[string]context.CancelFunc)
+	start := func(id string) (context.Context, bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, exists := running[id]; exists {
+			return nil, false
+		}
+		subCtx, cancelSub := context.WithCancel(ctx)
+		running[id] = cancelSub
+		return subCtx, true
+	}
+	stop := func(id string) {
+		mu.Lock()
+		defer mu.Unlock()
+		if cancelSub, ok := running[id]; ok {
+			cancelSub()
+			delete(running, id)
+		}
+	}
+
+	deadline := 2 * gh.cfg.PingInterval
+	_ = conn.SetReadDeadline(time.Now().Add(deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+	initialized := false
+	for {
+		var msg gqlMessage
+		if err = conn.ReadJSON(&msg); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				gh.log.Warnf("Error while reading graphql message: %v", err)
+			}
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(deadline))
+		switch msg.Type {
+		case gqlConnectionInit:
+			initialized = true
+			send(gqlMessage{Type: gqlConnectionAck})
+		case gqlPing:
+			send(gqlMessage{Type: gqlPong})
+		case gqlPong:
+		case gqlSubscribe:
+			if !initialized {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(4401, "Unauthorized"), time.Now().Add(time.Second))
+				return
+			}
+			var req gql.Request
+			if err = json.Unmarshal(msg.Payload, &req); err != nil || msg.ID == "" {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(4400, "Invalid subscribe message"), time.Now().Add(time.Second))
+				return
+			}
+			subCtx, ok := start(msg.ID)
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(4409, "Subscriber for "+msg.ID+" already exists"), time.Now().Add(time.Second))
+				return
+			}
+			go func(id string) {
+				defer stop(id)
+				gh.run(subCtx, id, &req, send)
+			}(msg.ID)
+		case gqlComplete:
+			stop(msg.ID)
+		default:
+			_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(4400, "Unknown message type: "+msg.Type), time.Now().Add(time.Second))
+			return
+		}
+	}
+}
+
+// run sends the results of a subscription until it ends, then completes it. A subscription that
+// ended because the client completed it is not completed again.
+func (gh *GraphQLHandler) run(ctx context.Context, id string, req *gql.Request, send func(gqlMessage) bool) {
+	results, err := gh.schema.Subscribe(ctx, req)
+	if err != nil {
+		payload, _ := json.Marshal([]map[string]string{{"message": err.Error()}})
+		send(gqlMessage{ID: id, Type: gqlError, Payload: payload})
+		return
+	}
+	for result := range results {
+		payload, err := json.Marshal(result)
+		if err != nil {
+			gh.log.Errorf("Error while encoding graphql result of subscription: %s by error: %v", id, err)
+			continue
+		}
+		if !send(gqlMessage{ID: id, Type: gqlNext, Payload: payload}) {
+			return
+		}
+	}
+	if ctx.Err() == nil {
+		send(gqlMessage{ID: id, Type: gqlComplete})
+	}
+}
+
+// write is the single writer of the connection, it also pings the client
+func (gh *GraphQLHandler) write(ctx context.Context, conn *websocket.Conn, out <-chan gqlMessage) {
+	ping := time.NewTicker(gh.cfg.PingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(gh.cfg.PingInterval)); err != nil {
+				return
+			}
+		case msg := <-out:
+			// a stalled client fails the write instead of blocking the connection forever
+			_ = conn.SetWriteDeadline(time.Now().Add(gh.cfg.PingInterval))
+			if err := conn.WriteJSON(msg); err != nil {
+				_ = conn.Close()
+				return
+			}
+		}
+	}
+}
+
+func NewGraphQLHandler(log *logger.Logger, tr trace.Tracer, schema *gql.Schema) *GraphQLHandler {
+	cfg := settings.GetSubscriptions()
+	return &GraphQLHandler{
+		log:    log,
+		tr:     tr,
+		schema: schema,
+		cfg:    cfg,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			Subprotocols:    []string{graphqlProtocol},
+			CheckOrigin:     allowOrigins(cfg.AllowedOrigins),
+		},
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	// HeaderIdempotentReplayed marks a response that was stored for an earlier request
+	HeaderIdempotentReplayed = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+type IdempotencyHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.IdempotencyService
+}
+
+// Idempotent is the middleware of create routes clients retry. The first successful response to a
+// request with an Idempotency-Key header is stored and replayed for retries with the same key,
+// method, path and body. A failed request releases the key so that the retry is served again.
+func (idh *IdempotencyHandler) Idempotent() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			_ = c.Error(errs.Invalid("%s must be at most %d characters", HeaderIdempotencyKey, maxIdempotencyKeyLength))
+			c.Abort()
+			return
+		}
+		body, err := c.GetRawData()
+		if err != nil {
+			_ = c.Error(invalid(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		hash := requestHash(c.Request.Method, c.Request.URL.Path, body)
+		stored, err := idh.svc.Begin(ctx, key, hash)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if stored != nil {
+			c.Header(HeaderIdempotentReplayed, "true")
+			c.Data(stored.Status, stored.ContentType, stored.Body)
+			c.Abort()
+			return
+		}
+
+		rec := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = rec
+		c.Next()
+
+		// the outcome is kept even when the client went away, it is the one retrying
+		ctx = context.WithoutCancel(ctx)
+		status := c.Writer.Status()
+		if len(c.Errors) > 0 || !c.Writer.Written() || status < http.StatusOK || status >= http.StatusMultipleChoices {
+			_ = idh.svc.Abandon(ctx, key)
+			return
+		}
+		_ = idh.svc.Complete(ctx, &model.IdempotentResponse{
+			Key:         key,
+			RequestHash: hash,
+			Status:      status,
+			ContentType: c.Writer.Header().Get("Content-Type"),
+			Body:        rec.body.Bytes(),
+			CreatedAt:   time.Now().UTC(),
+		})
+	}
+}
+
+// requestHash identifies a request by its method, path and body. A json body is compared by its
+// content, so a retry that encodes the same document differently still matches.
+func requestHash(method, path string, body []byte) string {
+	var doc any
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err == nil {
+		if canonical, err := json.Marshal(doc); err == nil {
+			body = canonical
+		}
+	}
+	h := sha256.New()
+	h.Write([]byte(method + " " + path + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body written through it
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func NewIdempotencyHandler(log *logger.Logger, tr trace.Tracer, svc svc.IdempotencyService) *IdempotencyHandler {
+	return &IdempotencyHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+	}
+}
@@ -1,12 +1,20 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
 	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -14,32 +22,127 @@ type InteractionHandler struct {
 	log *logger.Logger
 	tr  trace.Tracer
 	svc svc.InteractionService
+	v   *validation.Validator
 }
 
+// InteractionEndpoints document the routes of the InteractionHandler
+var InteractionEndpoints = struct {
+	CreateInteraction, GetInteraction, UpdateInteraction, PatchInteraction, DeleteInteraction, GetHistory, GetSnapshot, UpdateWorkflow, UpdateExecutionGraph openapi.Endpoint
+}{
+	CreateInteraction: openapi.Endpoint{Id: "createInteraction", Tag: "interactions", Summary: "Create an interaction",
+		Headers: []openapi.Param{idempotencyKey}, Body: runtime.Interaction{}, Status: http.StatusCreated, Response: runtime.Interaction{}},
+	GetInteraction: openapi.Endpoint{Id: "getInteraction", Tag: "interactions", Summary: "Get an interaction, or its version at a time",
+		Query:    []openapi.Param{{Name: "at", Description: "RFC 3339 timestamp or unix seconds"}},
+		Response: runtime.Interaction{}, ResponseHeaders: []openapi.Param{etag}},
+	UpdateInteraction: openapi.Endpoint{Id: "updateInteraction", Tag: "interactions", Summary: "Replace an interaction",
+		Body: runtime.Interaction{}, Response: runtime.Interaction{}},
+	PatchInteraction: openapi.Endpoint{Id: "patchInteraction", Tag: "interactions", Summary: "Patch an interaction with a merge patch or a JSON patch",
+		Headers: []openapi.Param{ifMatch}, Body: map[string]any{}, BodyTypes: patchTypes, Response: runtime.Interaction{}, ResponseHeaders: []openapi.Param{etag}},
+	DeleteInteraction: openapi.Endpoint{Id: "deleteInteraction", Tag: "interactions", Summary: "Delete an interaction",
+		Status: http.StatusNoContent},
+	GetHistory: openapi.Endpoint{Id: "getInteractionHistory", Tag: "interactions", Summary: "List the changes of an interaction",
+		Response: []model.HistoryRecord{}},
+	GetSnapshot: openapi.Endpoint{Id: "getInteractionSnapshot", Tag: "interactions", Summary: "Get an interaction with the steps of its workflows as they were at a time",
+		Query:    []openapi.Param{{Name: "at", Description: "RFC 3339 timestamp or unix seconds, now when missing"}},
+		Response: model.InteractionSnapshot{}},
+	UpdateWorkflow: openapi.Endpoint{Id: "updateWorkflow", Tag: "interactions", Summary: "Replace the execution flow of an interaction",
+		Body: runtime.ExecutionFlow{}, Response: runtime.Interaction{}},
+	UpdateExecutionGraph: openapi.Endpoint{Id: "updateExecutionGraph", Tag: "interactions", Summary: "Replace the execution graph of a workflow",
+		Body: runtime.ExecutionGraph{}, Response: runtime.Interaction{}},
+}
+
+// GetInteractionHandler returns the interaction, or with the at query parameter the interaction as
+// it was at that time. at is an RFC 3339 timestamp or unix seconds.
 func (ih *InteractionHandler) GetInteractionHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
-	interaction, err := ih.svc.GetById(ctx, interactionId)
+	var interaction *runtime.Interaction
+	var err error
+	if at := c.Query("at"); at != "" {
+		ts, perr := parseTime(at)
+		if perr != nil {
+			_ = c.Error(invalid(perr))
+			return
+		}
+		var snapshot *model.InteractionSnapshot
+		if snapshot, err = ih.svc.GetAt(ctx, interactionId, ts); err == nil {
+			interaction = snapshot.Interaction
+		}
+	} else {
+		interaction, err = ih.svc.GetById(ctx, interactionId)
+		if revision, rerr := ih.svc.Revision(ctx, interactionId); err == nil && rerr == nil {
+			setRevision(c, revision)
+		}
+	}
 	if err != nil {
 		ih.log.Errorf("Error while getting interaction by id: %v", err)
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, interaction)
 }
 
+func (ih *InteractionHandler) GetHistoryHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	records, err := ih.svc.History(ctx, interactionId)
+	if err != nil {
+		ih.log.Errorf("Error while getting history of interaction id: %s by error: %v", interactionId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// GetSnapshotHandler returns the interaction and its steps as they were at the time of the at query
+// parameter, or as they are now without it
+func (ih *InteractionHandler) GetSnapshotHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	ts := time.Now()
+	if at := c.Query("at"); at != "" {
+		var err error
+		if ts, err = parseTime(at); err != nil {
+			_ = c.Error(invalid(err))
+			return
+		}
+	}
+	snapshot, err := ih.svc.GetAt(ctx, interactionId, ts)
+	if err != nil {
+		ih.log.Errorf("Error while getting snapshot of interaction id: %s by error: %v", interactionId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+func parseTime(value string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	ts, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q, expected RFC 3339 or unix seconds", value)
+	}
+	return ts, nil
+}
+
 func (ih *InteractionHandler) CreateInteractionHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	var req runtime.Interaction
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ih.log.Errorf("Error while binding request data: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := ih.v.Interaction(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	interaction, err := ih.svc.Create(ctx, &req)
 	if err != nil {
 		ih.log.Errorf("Error while creating interaction: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, interaction)
@@ -51,75 +154,80 @@ func (ih *InteractionHandler) UpdateInteractionHandler(c *gin.Context) {
 	var req runtime.Interaction
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ih.log.Errorf("Error while binding request data: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := ih.v.Interaction(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	if interactionId != req.ID {
 		ih.log.Errorf("Invalid interactionId: %s and Interaction json ID: %s", interactionId, req.ID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid interaction id"})
+		_ = c.Error(errs.Invalid("Invalid interaction id"))
 		return
 	}
 	interaction, err := ih.svc.Update(ctx, &req)
 	if err != nil {
 		ih.log.Errorf("Error while updating interaction: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, interaction)
 }
 
-func (ih *InteractionHandler) DeleteInteractionHandler(c *gin.Context) {
+// PatchInteractionHandler applies an application/merge-patch+json or application/json-patch+json
+// body to the interaction, an If-Match header restricts it to that revision
+func (ih *InteractionHandler) PatchInteractionHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
-	if err := ih.svc.DeleteById(ctx, interactionId); err != nil {
-		ih.log.Errorf("Error while deleting interaction: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	req, err := patchRequest(c)
+	if err != nil {
+		_ = c.Error(invalid(err))
 		return
 	}
-	c.Status(http.StatusNoContent)
+	interaction, revision, err := ih.svc.Patch(ctx, interactionId, req)
+	if err != nil {
+		ih.log.Errorf("Error while patching interaction id: %s by error: %v", interactionId, err)
+		_ = c.Error(err)
+		return
+	}
+	setRevision(c, revision)
+	c.JSON(http.StatusOK, interaction)
 }
 
-func (ih *InteractionHandler) UpdatePlanHandler(c *gin.Context) {
+func (ih *InteractionHandler) DeleteInteractionHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
-	planId := c.Param("planId")
-	var req runtime.Plan
-	if err := c.ShouldBindJSON(&req); err != nil {
-		ih.log.Errorf("Error while binding request data to Plan: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-	if planId != req.ID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid plan id"})
-		return
-	}
-	interaction, err := ih.svc.UpdatePlan(ctx, interactionId, req.ID, &req)
-	if err != nil {
-		ih.log.Errorf("Error while updating plan: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := ih.svc.DeleteById(ctx, interactionId); err != nil {
+		ih.log.Errorf("Error while deleting interaction: %v", err)
+		_ = c.Error(err)
 		return
 	}
-	c.JSON(http.StatusOK, interaction)
+	c.Status(http.StatusNoContent)
 }
 
 func (ih *InteractionHandler) UpdateWorkflowHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
-	workflowId := c.Param("executionFlowId")
+	workflowId := c.Param("workflowId")
 	var req runtime.ExecutionFlow
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ih.log.Errorf("Error while binding request data to ExecutionFlow: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := ih.v.ExecutionFlow(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	if workflowId != req.ID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid workflow id"})
+		_ = c.Error(errs.Invalid("Invalid workflow id"))
 		return
 	}
 	interaction, err := ih.svc.UpdateExecutionFlow(ctx, interactionId, req.ID, &req)
 	if err != nil {
 		ih.log.Errorf("Error while updating workflow: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, interaction)
@@ -128,22 +236,26 @@ func (ih *InteractionHandler) UpdateWorkflowHandler(c *gin.Context) {
 func (ih *InteractionHandler) UpdateExecutionGraphHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
-	workflowId := c.Param("executionFlowId")
-	executionId := c.Param("executionGraphId")
+	workflowId := c.Param("workflowId")
+	executionId := c.Param("executionId")
 	var req runtime.ExecutionGraph
 	if err := c.ShouldBindJSON(&req); err != nil {
 		ih.log.Errorf("Error while binding request data to ExecutionGraph: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := ih.v.ExecutionGraph(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	if executionId != req.ID {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid execution id"})
+		_ = c.Error(errs.Invalid("Invalid execution id"))
 		return
 	}
 	interaction, err := ih.svc.UpdateExecutionGraph(ctx, interactionId, workflowId, executionId, &req)
 	if err != nil {
 		ih.log.Errorf("Error while updating execution graph: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, interaction)
@@ -154,5 +266,6 @@ func NewInteractionHandler(log *logger.Logger, tr trace.Tracer, svc svc.Interact
 		log: log,
 		tr:  tr,
 		svc: svc,
+		v:   validation.New(settings.GetValidation()),
 	}
 }
@@ -6,14 +6,49 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
 	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type McpHandler struct {
-	log *logger.Logger
-	tr  trace.Tracer
-	svc svc.McpService
+	log       *logger.Logger
+	tr        trace.Tracer
+	svc       svc.McpService
+	discovery svc.McpDiscoveryService
+	v         *validation.Validator
+}
+
+// McpEndpoints document the routes of the McpHandler
+var McpEndpoints = struct {
+	CreateMcp, GetMcp, UpdateMcp, PatchMcp, DeleteMcp, AddTool, SyncTools, GetConnection, UpdateConnection, DeleteConnection, GetHistory openapi.Endpoint
+}{
+	CreateMcp: openapi.Endpoint{Id: "createMcp", Tag: "mcps", Summary: "Create an mcp of a workflow",
+		Headers: []openapi.Param{idempotencyKey}, Body: runtime.MCP{}, Status: http.StatusCreated, Response: runtime.MCP{}},
+	GetMcp: openapi.Endpoint{Id: "getMcp", Tag: "mcps", Summary: "Get an mcp",
+		Response: runtime.MCP{}},
+	UpdateMcp: openapi.Endpoint{Id: "updateMcp", Tag: "mcps", Summary: "Replace an mcp",
+		Body: runtime.MCP{}, Response: runtime.MCP{}},
+	PatchMcp: openapi.Endpoint{Id: "patchMcp", Tag: "mcps", Summary: "Patch an mcp with a merge patch or a JSON patch",
+		Headers: []openapi.Param{ifMatch}, Body: map[string]any{}, BodyTypes: patchTypes, Response: runtime.MCP{}, ResponseHeaders: []openapi.Param{etag}},
+	DeleteMcp: openapi.Endpoint{Id: "deleteMcp", Tag: "mcps", Summary: "Delete an mcp",
+		Status: http.StatusNoContent},
+	AddTool: openapi.Endpoint{Id: "addTool", Tag: "mcps", Summary: "Add a tool to an mcp",
+		Body: runtime.Tool{}, Response: runtime.MCP{}},
+	SyncTools: openapi.Endpoint{Id: "syncTools", Tag: "mcps", Summary: "Replace the tools of an mcp with the tools its server lists",
+		Response: model.McpConnection{}},
+	GetConnection: openapi.Endpoint{Id: "getMcpConnection", Tag: "mcps", Summary: "Get the endpoint and status of an mcp server",
+		Response: model.McpConnection{}},
+	UpdateConnection: openapi.Endpoint{Id: "updateMcpConnection", Tag: "mcps", Summary: "Set the endpoint of an mcp server",
+		Body: model.McpEndpoint{}, Response: model.McpConnection{}},
+	DeleteConnection: openapi.Endpoint{Id: "deleteMcpConnection", Tag: "mcps", Summary: "Remove the endpoint of an mcp server",
+		Status: http.StatusNoContent},
+	GetHistory: openapi.Endpoint{Id: "getMcpHistory", Tag: "mcps", Summary: "List the changes of an mcp",
+		Response: []model.HistoryRecord{}},
 }
 
 func (mh *McpHandler) GetMcpHandler(c *gin.Context) {
@@ -23,12 +58,26 @@ func (mh *McpHandler) GetMcpHandler(c *gin.Context) {
 	mcpId := c.Param("mcpId")
 	mcp, err := mh.svc.GetByInteractionIdAndWorkflowIdAndId(ctx, interactionId, workflowId, mcpId)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, mcp)
 }
 
+func (mh *McpHandler) GetHistoryHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	mcpId := c.Param("mcpId")
+	records, err := mh.svc.History(ctx, interactionId, workflowId, mcpId)
+	if err != nil {
+		mh.log.Errorf("Error while getting history of mcp id: %s by error: %v", mcpId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
 func (mh *McpHandler) CreateMcpHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
@@ -36,13 +85,17 @@ func (mh *McpHandler) CreateMcpHandler(c *gin.Context) {
 	var req runtime.MCP
 	if err := c.ShouldBindJSON(&req); err != nil {
 		mh.log.Errorf("Error while binding request data to MCP: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := mh.v.Mcp(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	mcp, err := mh.svc.CreateByInteractionIdAndWorkflowId(ctx, interactionId, workflowId, &req)
 	if err != nil {
 		mh.log.Errorf("Error while creating MCP: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, mcp)
@@ -56,20 +109,46 @@ func (mh *McpHandler) UpdateMcpHandler(c *gin.Context) {
 	var req runtime.MCP
 	if err := c.ShouldBindJSON(&req); err != nil {
 		mh.log.Errorf("Error while binding request data to MCP: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := mh.v.Mcp(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	if mcpId != req.ID {
 		mh.log.Errorf("Invalid mcp id: %s and MCP json ID: %s", mcpId, req.ID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid mcp id"})
+		_ = c.Error(errs.Invalid("Invalid mcp id"))
 		return
 	}
 	mcp, err := mh.svc.UpdateByInteractionIdAndWorkflowId(ctx, interactionId, workflowId, &req)
 	if err != nil {
 		mh.log.Errorf("Error while updating MCP: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, mcp)
+}
+
+// PatchMcpHandler applies an application/merge-patch+json or application/json-patch+json body to
+// the mcp, an If-Match header restricts it to that revision
+func (mh *McpHandler) PatchMcpHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	mcpId := c.Param("mcpId")
+	req, err := patchRequest(c)
+	if err != nil {
+		_ = c.Error(invalid(err))
+		return
+	}
+	mcp, revision, err := mh.svc.PatchByInteractionIdAndWorkflowIdAndId(ctx, interactionId, workflowId, mcpId, req)
+	if err != nil {
+		mh.log.Errorf("Error while patching MCP: %s by error: %v", mcpId, err)
+		_ = c.Error(err)
 		return
 	}
+	setRevision(c, revision)
 	c.JSON(http.StatusOK, mcp)
 }
 
@@ -81,13 +160,17 @@ func (mh *McpHandler) AddToolHandler(c *gin.Context) {
 	var req runtime.Tool
 	if err := c.ShouldBindJSON(&req); err != nil {
 		mh.log.Errorf("Error while binding request data to Tool: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := mh.v.Tool(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	mcp, err := mh.svc.AddTool(ctx, interactionId, workflowId, mcpId, &req)
 	if err != nil {
 		mh.log.Errorf("Error while adding tool to MCP: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, mcp)
@@ -100,16 +183,82 @@ func (mh *McpHandler) DeleteMcpHandler(c *gin.Context) {
 	mcpId := c.Param("mcpId")
 	if err := mh.svc.DeleteByInteractionIdAndWorkflowIdAndId(ctx, interactionId, workflowId, mcpId); err != nil {
 		mh.log.Errorf("Error while deleting MCP: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
-func NewMcpHandler(log *logger.Logger, tr trace.Tracer, svc svc.McpService) *McpHandler {
+func (mh *McpHandler) GetConnectionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	mcpId := c.Param("mcpId")
+	conn, err := mh.discovery.GetConnection(ctx, interactionId, workflowId, mcpId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, conn)
+}
+
+func (mh *McpHandler) UpdateConnectionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	mcpId := c.Param("mcpId")
+	var req model.McpEndpoint
+	if err := c.ShouldBindJSON(&req); err != nil {
+		mh.log.Errorf("Error while binding request data to McpEndpoint: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if req.Transport != model.McpTransportStdio && req.Transport != model.McpTransportHttp {
+		_ = c.Error(errs.Invalid("Invalid mcp transport"))
+		return
+	}
+	conn, err := mh.discovery.SetEndpoint(ctx, interactionId, workflowId, mcpId, &req)
+	if err != nil {
+		mh.log.Errorf("Error while setting MCP endpoint: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, conn)
+}
+
+func (mh *McpHandler) DeleteConnectionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	mcpId := c.Param("mcpId")
+	if err := mh.discovery.RemoveEndpoint(ctx, interactionId, workflowId, mcpId); err != nil {
+		mh.log.Errorf("Error while removing MCP endpoint: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (mh *McpHandler) SyncToolsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	mcpId := c.Param("mcpId")
+	conn, err := mh.discovery.Sync(ctx, interactionId, workflowId, mcpId)
+	if err != nil {
+		mh.log.Errorf("Error while syncing MCP tools: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, conn)
+}
+
+func NewMcpHandler(log *logger.Logger, tr trace.Tracer, svc svc.McpService, discovery svc.McpDiscoveryService) *McpHandler {
 	return &McpHandler{
-		log: log,
-		tr:  tr,
-		svc: svc,
+		log:       log,
+		tr:        tr,
+		svc:       svc,
+		discovery: discovery,
+		v:         validation.New(settings.GetValidation()),
 	}
 }
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/gql"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/patch"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	idempotencyKey = openapi.Param{Name: HeaderIdempotencyKey, Description: "Replays the stored response of an earlier request with the key"}
+	ifMatch        = openapi.Param{Name: "If-Match", Description: "Revision the patch applies to, from the ETag of the document"}
+	etag           = openapi.Param{Name: "ETag", Description: "Revision of the document"}
+	patchTypes     = []string{patch.MergePatchType, patch.JSONPatchType}
+)
+
+type OpenAPIHandler struct {
+	log    *logger.Logger
+	tr     trace.Tracer
+	routes *openapi.Routes
+	once   sync.Once
+	spec   []byte
+	err    error
+}
+
+// OpenAPIEndpoints document the routes of the OpenAPIHandler
+var OpenAPIEndpoints = struct {
+	Spec openapi.Endpoint
+}{
+	Spec: openapi.Endpoint{Id: "getOpenAPI", Tag: "openapi", Summary: "Get this document",
+		Response: map[string]any{}},
+}
+
+// SpecHandler serves the OpenAPI document of the routes. It is generated on the first request,
+// when the router is complete.
+func (oh *OpenAPIHandler) SpecHandler(c *gin.Context) {
+	oh.once.Do(func() {
+		gen := openapi.NewGenerator("state-service", "v1", Problem{})
+		gen.Name(gql.Request{}, "GraphQLRequest")
+		gen.Name(graphql.Response{}, "GraphQLResponse")
+		gen.Name(events.Type(""), "EventType")
+		gen.Enum(runtime.StatusPending, runtime.StatusRunning, runtime.StatusSuccess, runtime.StatusError, runtime.StatusStop)
+		gen.Enum(model.BatchCreateStep, model.BatchUpdateStep, model.BatchPatchStep, model.BatchUpdateStepStatus,
+			model.BatchAddArtifact, model.BatchRecordToolInvocation, model.BatchPatchInteraction)
+		gen.Enum(model.McpTransportStdio, model.McpTransportHttp)
+		gen.Enum(model.McpUnknown, model.McpReachable, model.McpUnreachable)
+		gen.Enum(model.DeliveryPending, model.DeliverySucceeded, model.DeliveryFailed)
+		gen.Enum(model.HistorySet, model.HistoryDelete)
+		gen.Enum(events.InteractionCreated, events.InteractionUpdated, events.InteractionDeleted, events.InteractionCompleted,
+			events.PlanRevised, events.ExecutionFlowUpdated, events.WorkflowCreated, events.WorkflowActivated, events.WorkflowDeleted,
+			events.ExecutionGraphUpdated,
+			events.StepCreated, events.StepUpdated, events.StepStatusChanged, events.StepFailed, events.StepDeleted, events.ChildCreated,
+			events.ToolInvoked, events.MessageAdded, events.ArtifactAdded,
+			events.McpCreated, events.McpUpdated, events.McpDeleted, events.McpToolsChanged)
+		oh.spec, oh.err = json.Marshal(gen.Generate(oh.routes.List()))
+	})
+	if oh.err != nil {
+		oh.log.Errorf("Error while generating openapi document: %v", oh.err)
+		_ = c.Error(oh.err)
+		return
+	}
+	c.Data(http.StatusOK, openapi.JSONType, oh.spec)
+}
+
+func NewOpenAPIHandler(log *logger.Logger, tr trace.Tracer, routes *openapi.Routes) *OpenAPIHandler {
+	return &OpenAPIHandler{
+		log:    log,
+		tr:     tr,
+		routes: routes,
+	}
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type OutboxHandler struct {
+	log   *logger.Logger
+	tr    trace.Tracer
+	relay svc.OutboxRelay
+}
+
+// OutboxEndpoints document the routes of the OutboxHandler
+var OutboxEndpoints = struct {
+	GetStats openapi.Endpoint
+}{
+	GetStats: openapi.Endpoint{Id: "getOutboxStats", Tag: "outbox", Summary: "Get the backlog of the event outbox",
+		Response: model.OutboxStats{}},
+}
+
+func (oh *OutboxHandler) GetStatsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	stats, err := oh.relay.Stats(ctx)
+	if err != nil {
+		oh.log.Errorf("Error while reading outbox stats: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+func NewOutboxHandler(log *logger.Logger, tr trace.Tracer, relay svc.OutboxRelay) *OutboxHandler {
+	return &OutboxHandler{
+		log:   log,
+		tr:    tr,
+		relay: relay,
+	}
+}
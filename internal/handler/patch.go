@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/svc"
+)
+
+// patchRequest reads a PATCH body with its media type and the revision of the If-Match header
+func patchRequest(c *gin.Context) (svc.PatchRequest, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return svc.PatchRequest{}, errs.Invalid("%v", err)
+	}
+	req := svc.PatchRequest{ContentType: c.ContentType(), Body: body}
+	if ifMatch := c.GetHeader("If-Match"); ifMatch != "" && ifMatch != "*" {
+		tag := strings.Trim(strings.TrimPrefix(ifMatch, "W/"), `"`)
+		if req.IfMatch, err = strconv.ParseInt(tag, 10, 64); err != nil || req.IfMatch <= 0 {
+			return svc.PatchRequest{}, errs.Invalid("invalid If-Match revision %q", ifMatch)
+		}
+	}
+	return req, nil
+}
+
+// setRevision sends the revision of the returned document as its entity tag for If-Match
+func setRevision(c *gin.Context, revision int64) {
+	c.Header("ETag", `"`+strconv.FormatInt(revision, 10)+`"`)
+}
@@ -0,0 +1,177 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type PlanHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.PlanService
+}
+
+// PlanEndpoints document the routes of the PlanHandler
+var PlanEndpoints = struct {
+	Replan, UpdatePlan, CompilePlan, ListRevisions, GetRevision, DiffRevisions, Reconcile openapi.Endpoint
+}{
+	Replan: openapi.Endpoint{Id: "replan", Tag: "plans", Summary: "Replace the plan of an interaction with a new revision",
+		Query: []openapi.Param{{Name: "reason"}}, Body: runtime.Plan{}, Status: http.StatusCreated, Response: runtime.Interaction{}},
+	UpdatePlan: openapi.Endpoint{Id: "updatePlan", Tag: "plans", Summary: "Update the plan of an interaction",
+		Query: []openapi.Param{{Name: "reason"}}, Body: runtime.Plan{}, Response: runtime.Interaction{}},
+	CompilePlan: openapi.Endpoint{Id: "compilePlan", Tag: "plans", Summary: "Compile the plan into an execution flow",
+		Query: []openapi.Param{{Name: "mode"}}, Status: http.StatusCreated, Response: runtime.Interaction{}},
+	ListRevisions: openapi.Endpoint{Id: "listPlanRevisions", Tag: "plans", Summary: "List the plan revisions of an interaction",
+		Response: []model.PlanRevision{}},
+	GetRevision: openapi.Endpoint{Id: "getPlanRevision", Tag: "plans", Summary: "Get a plan revision",
+		Response: model.PlanRevision{}},
+	DiffRevisions: openapi.Endpoint{Id: "diffPlanRevisions", Tag: "plans", Summary: "Diff two plan revisions",
+		Query: []openapi.Param{
+			{Name: "from", Type: "integer", Required: true},
+			{Name: "to", Type: "integer", Description: "Latest revision when 0 or absent"},
+		},
+		Response: model.PlanDiff{}},
+	Reconcile: openapi.Endpoint{Id: "reconcilePlan", Tag: "plans", Summary: "Reconcile the execution graph with a plan revision",
+		Query: []openapi.Param{{Name: "revision", Type: "integer", Description: "Latest revision when 0 or absent"}}, Response: model.ReconcileResult{}},
+}
+
+func (ph *PlanHandler) UpdatePlanHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	planId := c.Param("planId")
+	reason := c.Query("reason")
+	var req runtime.Plan
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ph.log.Errorf("Error while binding request data to Plan: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if planId != req.ID {
+		_ = c.Error(errs.Invalid("Invalid plan id"))
+		return
+	}
+	interaction, err := ph.svc.Update(ctx, interactionId, planId, &req, reason)
+	if err != nil {
+		ph.log.Errorf("Error while updating plan: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, interaction)
+}
+
+func (ph *PlanHandler) ReplanHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	reason := c.Query("reason")
+	var req runtime.Plan
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ph.log.Errorf("Error while binding request data to Plan: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	interaction, err := ph.svc.Replan(ctx, interactionId, &req, reason)
+	if err != nil {
+		ph.log.Errorf("Error while replanning: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, interaction)
+}
+
+func (ph *PlanHandler) ListRevisionsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	revisions, err := ph.svc.Revisions(ctx, interactionId)
+	if err != nil {
+		ph.log.Errorf("Error while listing plan revisions: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, revisions)
+}
+
+func (ph *PlanHandler) GetRevisionHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	revision, err := strconv.Atoi(c.Param("revision"))
+	if err != nil || revision < 1 {
+		_ = c.Error(errs.Invalid("Invalid plan revision"))
+		return
+	}
+	rev, err := ph.svc.Revision(ctx, interactionId, revision)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, rev)
+}
+
+func (ph *PlanHandler) DiffRevisionsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	from, err := strconv.Atoi(c.Query("from"))
+	if err != nil || from < 1 {
+		_ = c.Error(errs.Invalid("Invalid from revision"))
+		return
+	}
+	to, err := strconv.Atoi(c.DefaultQuery("to", "0"))
+	if err != nil || to < 0 {
+		_ = c.Error(errs.Invalid("Invalid to revision"))
+		return
+	}
+	diff, err := ph.svc.Diff(ctx, interactionId, from, to)
+	if err != nil {
+		ph.log.Errorf("Error while diffing plan revisions: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+func (ph *PlanHandler) ReconcileHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	revision, err := strconv.Atoi(c.DefaultQuery("revision", "0"))
+	if err != nil || revision < 0 {
+		_ = c.Error(errs.Invalid("Invalid plan revision"))
+		return
+	}
+	result, err := ph.svc.Reconcile(ctx, interactionId, revision)
+	if err != nil {
+		ph.log.Errorf("Error while reconciling execution graph with plan: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+func (ph *PlanHandler) CompilePlanHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	planId := c.Param("planId")
+	mode := c.Query("mode")
+	interaction, err := ph.svc.Compile(ctx, interactionId, planId, mode)
+	if err != nil {
+		ph.log.Errorf("Error while compiling plan: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, interaction)
+}
+
+func NewPlanHandler(log *logger.Logger, tr trace.Tracer, svc svc.PlanService) *PlanHandler {
+	return &PlanHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+	}
+}
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/patch"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+)
+
+const (
+	RequestIdKey    = "requestId"
+	HeaderRequestId = "X-Request-ID"
+	ProblemType     = "application/problem+json"
+)
+
+// Problem is an RFC 7807 problem details body
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	RequestId string `json:"requestId,omitempty"`
+	// Errors lists every invalid field of a rejected payload
+	Errors validation.Errors `json:"errors,omitempty"`
+}
+
+// RequestID takes the request id of the X-Request-ID header or generates one, and echoes it on
+// the response so that a client can quote it when reporting a failure
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(HeaderRequestId)
+		if id == "" {
+			id = uuid.NewString()
+		}
+		c.Set(RequestIdKey, id)
+		c.Header(HeaderRequestId, id)
+		c.Next()
+	}
+}
+
+// serverErrorDetail is the detail of every 5xx problem, the request id finds the cause in the log
+const serverErrorDetail = "the request could not be served, report its request id to find the cause"
+
+// Problems writes the last error a handler added with c.Error as a problem details response. It
+// has to run after the audit middleware, so that the audit entry sees the final status.
+func Problems(log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		err := c.Errors.Last()
+		if err == nil || c.Writer.Written() {
+			return
+		}
+		status := statusOf(err.Err)
+		detail := err.Err.Error()
+		// the cause of a server error names backing services and keys, it stays in the log
+		if status >= http.StatusInternalServerError {
+			log.Errorf("Error while serving %s %s request id: %s by error: %v", c.Request.Method, c.FullPath(), c.GetString(RequestIdKey), err.Err)
+			detail = serverErrorDetail
+		}
+		var fields validation.Errors
+		errors.As(err.Err, &fields)
+		writeProblem(c, status, detail, fields)
+	}
+}
+
+// NoRoute answers unknown paths with a problem details body instead of the plain text default
+func NoRoute(c *gin.Context) {
+	writeProblem(c, http.StatusNotFound, "no route for "+c.Request.Method+" "+c.Request.URL.Path, nil)
+}
+
+func writeProblem(c *gin.Context, status int, detail string, fields validation.Errors) {
+	// gin keeps a content type that is already set
+	c.Header("Content-Type", ProblemType)
+	c.JSON(status, Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestId: c.GetString(RequestIdKey),
+		Errors:    fields,
+	})
+}
+
+// statusOf maps an error to its response status, errors without a kind are internal errors
+func statusOf(err error) int {
+	switch {
+	case errors.Is(err, patch.ErrUnsupportedType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, patch.ErrInvalidPatch), errors.Is(err, patch.ErrTestFailed), errors.Is(err, svc.ErrIdempotencyKeyReused):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, svc.ErrPreconditionFailed):
+		return http.StatusPreconditionFailed
+	case errors.Is(err, errs.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, errs.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, errs.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, errs.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, errs.ErrInternal):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// invalid marks an error of reading the request, like a body that does not bind, as Invalid
+func invalid(err error) error {
+	if errs.Typed(err) {
+		return err
+	}
+	return errs.Invalid("%v", err)
+}
@@ -6,7 +6,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
 	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
 	"go.opentelemetry.io/otel/trace"
 )
 
@@ -14,6 +19,31 @@ type StepHandler struct {
 	log *logger.Logger
 	tr  trace.Tracer
 	svc svc.StepService
+	v   *validation.Validator
+}
+
+// StepEndpoints document the routes of the StepHandler
+var StepEndpoints = struct {
+	CreateStep, GetStep, UpdateStep, PatchStep, UpdateStatus, AddToolInvocation, AddArtifact, DeleteStep, GetHistory openapi.Endpoint
+}{
+	CreateStep: openapi.Endpoint{Id: "createStep", Tag: "steps", Summary: "Create a step",
+		Headers: []openapi.Param{idempotencyKey}, Body: runtime.Step{}, Status: http.StatusCreated, Response: runtime.Step{}},
+	GetStep: openapi.Endpoint{Id: "getStep", Tag: "steps", Summary: "Get a step",
+		Response: runtime.Step{}},
+	UpdateStep: openapi.Endpoint{Id: "updateStep", Tag: "steps", Summary: "Replace a step",
+		Body: runtime.Step{}, Response: runtime.Step{}},
+	PatchStep: openapi.Endpoint{Id: "patchStep", Tag: "steps", Summary: "Patch a step with a merge patch or a JSON patch",
+		Headers: []openapi.Param{ifMatch}, Body: map[string]any{}, BodyTypes: patchTypes, Response: runtime.Step{}, ResponseHeaders: []openapi.Param{etag}},
+	UpdateStatus: openapi.Endpoint{Id: "updateStepStatus", Tag: "steps", Summary: "Set the status of a step, from the status query parameter or a json string body",
+		Query: []openapi.Param{{Name: "status"}}, Body: runtime.StatusPending, Response: runtime.Step{}},
+	AddToolInvocation: openapi.Endpoint{Id: "addToolInvocation", Tag: "steps", Summary: "Record a tool invocation of a step",
+		Body: runtime.McpToolInvocation{}, Response: runtime.Step{}},
+	AddArtifact: openapi.Endpoint{Id: "addArtifact", Tag: "steps", Summary: "Add an artifact to a step",
+		Body: runtime.Artifact{}, Response: runtime.Step{}},
+	DeleteStep: openapi.Endpoint{Id: "deleteStep", Tag: "steps", Summary: "Delete a step",
+		Status: http.StatusNoContent},
+	GetHistory: openapi.Endpoint{Id: "getStepHistory", Tag: "steps", Summary: "List the changes of a step",
+		Response: []model.HistoryRecord{}},
 }
 
 func (sh *StepHandler) GetStepHandler(c *gin.Context) {
@@ -25,12 +55,30 @@ func (sh *StepHandler) GetStepHandler(c *gin.Context) {
 	step, err := sh.svc.GetByInteractionIdAndExecutionIdAndId(ctx, interactionId, workflowId, executionId, stepId)
 	if err != nil {
 		sh.log.Errorf("Error while getting step: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
+	if revision, err := sh.svc.Revision(ctx, interactionId, workflowId, executionId, stepId); err == nil {
+		setRevision(c, revision)
+	}
 	c.JSON(http.StatusOK, step)
 }
 
+func (sh *StepHandler) GetHistoryHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	executionId := c.Param("executionId")
+	stepId := c.Param("stepId")
+	records, err := sh.svc.History(ctx, interactionId, workflowId, executionId, stepId)
+	if err != nil {
+		sh.log.Errorf("Error while getting history of step id: %s by error: %v", stepId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
 func (sh *StepHandler) CreateStepHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
@@ -39,13 +87,17 @@ func (sh *StepHandler) CreateStepHandler(c *gin.Context) {
 	var req runtime.Step
 	if err := c.ShouldBindJSON(&req); err != nil {
 		sh.log.Errorf("Error while binding request data: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := sh.v.Step(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	step, err := sh.svc.CreateByInteractionIdAndExecutionId(ctx, interactionId, workflowId, executionId, &req)
 	if err != nil {
 		sh.log.Errorf("Error while creating step: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusCreated, step)
@@ -60,41 +112,123 @@ func (sh *StepHandler) UpdateStepHandler(c *gin.Context) {
 	var req runtime.Step
 	if err := c.ShouldBindJSON(&req); err != nil {
 		sh.log.Errorf("Error while binding request data: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := sh.v.Step(&req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 	if stepId != req.ID {
 		sh.log.Errorf("Invalid step id: %s and Step json ID: %s", stepId, req.ID)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid step id"})
+		_ = c.Error(errs.Invalid("Invalid step id"))
 		return
 	}
 	step, err := sh.svc.UpdateByInteractionIdAndExecutionId(ctx, interactionId, workflowId, executionId, &req)
 	if err != nil {
 		sh.log.Errorf("Error while updating step: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, step)
 }
 
+// PatchStepHandler applies an application/merge-patch+json or application/json-patch+json body to
+// the step, an If-Match header restricts it to that revision
+func (sh *StepHandler) PatchStepHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	executionId := c.Param("executionId")
+	stepId := c.Param("stepId")
+	req, err := patchRequest(c)
+	if err != nil {
+		_ = c.Error(invalid(err))
+		return
+	}
+	step, revision, err := sh.svc.PatchByInteractionIdAndExecutionIdAndId(ctx, interactionId, workflowId, executionId, stepId, req)
+	if err != nil {
+		sh.log.Errorf("Error while patching step: %s by error: %v", stepId, err)
+		_ = c.Error(err)
+		return
+	}
+	setRevision(c, revision)
+	c.JSON(http.StatusOK, step)
+}
+
 func (sh *StepHandler) UpdateStatusHandler(c *gin.Context) {
 	ctx := c.Request.Context()
 	interactionId := c.Param("interactionId")
 	workflowId := c.Param("workflowId")
 	executionId := c.Param("executionId")
 	stepId := c.Param("stepId")
-	status := c.Query("status")
-	var req runtime.Status
-	if err := c.ShouldBindJSON(&req); err != nil {
-		sh.log.Errorf("Error while binding request data: %v", err)
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// the status is either the status query parameter or a json string body
+	status := runtime.Status(c.Query("status"))
+	if status == "" {
+		if err := c.ShouldBindJSON(&status); err != nil {
+			sh.log.Errorf("Error while binding request data: %v", err)
+			_ = c.Error(invalid(err))
+			return
+		}
+	}
+	if err := sh.v.Status(status); err != nil {
+		_ = c.Error(err)
 		return
 	}
-	s := runtime.Status(status)
-	step, err := sh.svc.UpdateStatusByInteractionIdAndExecutionIdAndId(ctx, interactionId, workflowId, executionId, stepId, s)
+	step, err := sh.svc.UpdateStatusByInteractionIdAndExecutionIdAndId(ctx, interactionId, workflowId, executionId, stepId, status)
 	if err != nil {
 		sh.log.Errorf("Error while updating step status: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, step)
+}
+
+func (sh *StepHandler) AddToolInvocationHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	executionId := c.Param("executionId")
+	stepId := c.Param("stepId")
+	var req runtime.McpToolInvocation
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sh.log.Errorf("Error while binding request data to McpToolInvocation: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := sh.v.ToolInvocation(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	step, err := sh.svc.AddToolInvocation(ctx, interactionId, workflowId, executionId, stepId, &req)
+	if err != nil {
+		sh.log.Errorf("Error while recording tool invocation: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, step)
+}
+
+func (sh *StepHandler) AddArtifactHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	executionId := c.Param("executionId")
+	stepId := c.Param("stepId")
+	var req runtime.Artifact
+	if err := c.ShouldBindJSON(&req); err != nil {
+		sh.log.Errorf("Error while binding request data to Artifact: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := sh.v.Artifact(&req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	step, err := sh.svc.AddArtifact(ctx, interactionId, workflowId, executionId, stepId, &req)
+	if err != nil {
+		sh.log.Errorf("Error while adding artifact: %v", err)
+		_ = c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, step)
@@ -108,16 +242,39 @@ func (sh *StepHandler) DeleteStepHandler(c *gin.Context) {
 	stepId := c.Param("stepId")
 	if err := sh.svc.DeleteByInteractionIdAndExecutionIdAndId(ctx, interactionId, workflowId, executionId, stepId); err != nil {
 		sh.log.Errorf("Error while deleting step: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
 
+// LocateStep is the middleware of the flattened step routes, which address a step by its id and
+// optionally its interaction. It sets the ids the step is stored under as the path parameters the
+// step handlers read, so the handlers serve the nested and the flattened routes alike.
+func (sh *StepHandler) LocateStep() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		interactionId := c.Param("interactionId")
+		location, err := sh.svc.Locate(c.Request.Context(), interactionId, c.Param("stepId"))
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+		if interactionId == "" {
+			c.Params = append(c.Params, gin.Param{Key: "interactionId", Value: location.InteractionId})
+		}
+		c.Params = append(c.Params,
+			gin.Param{Key: "workflowId", Value: location.WorkflowId},
+			gin.Param{Key: "executionId", Value: location.ExecutionId})
+		c.Next()
+	}
+}
+
 func NewStepHandler(log *logger.Logger, tr trace.Tracer, svc svc.StepService) *StepHandler {
 	return &StepHandler{
 		log: log,
 		tr:  tr,
 		svc: svc,
+		v:   validation.New(settings.GetValidation()),
 	}
 }
@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	actionSubscribe   = "subscribe"
+	actionUnsubscribe = "unsubscribe"
+)
+
+// subscriptionRequest is sent by the client to add or remove a subscription, the id is chosen by
+// the client and tags the events delivered for the subscription
+type subscriptionRequest struct {
+	Action string                   `json:"action"`
+	ID     string                   `json:"id"`
+	Filter model.SubscriptionFilter `json:"filter"`
+}
+
+type subscriptionMessage struct {
+	Type          string        `json:"type"`
+	ID            string        `json:"id,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	Subscriptions []string      `json:"subscriptions,omitempty"`
+	Event         *events.Event `json:"event,omitempty"`
+	Dropped       int64         `json:"dropped,omitempty"`
+}
+
+type SubscriptionHandler struct {
+	log      *logger.Logger
+	tr       trace.Tracer
+	hub      svc.SubscriptionHub
+	cfg      settings.Subscriptions
+	upgrader websocket.Upgrader
+}
+
+// SubscriptionEndpoints document the routes of the SubscriptionHandler
+var SubscriptionEndpoints = struct {
+	Subscribe openapi.Endpoint
+}{
+	Subscribe: openapi.Endpoint{Id: "subscribe", Tag: "events", Summary: "Websocket carrying the events of filtered subscriptions",
+		Status: http.StatusSwitchingProtocols},
+}
+
+// SubscribeHandler upgrades to a websocket carrying the events of every subscription of the
+// client. A client too slow to keep up gets a dropped message with the number of events it missed
+// and is disconnected once it misses more than the configured maximum.
+func (sh *SubscriptionHandler) SubscribeHandler(c *gin.Context) {
+	conn, err := sh.upgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		sh.log.Errorf("Error while upgrading subscription connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	subscriber := sh.hub.Attach()
+	defer sh.hub.Detach(subscriber)
+
+	replies := make(chan subscriptionMessage, 16)
+	closed := make(chan struct{})
+	done := make(chan struct{})
+	defer close(done)
+	go sh.readRequests(conn, subscriber, replies, closed, done)
+
+	ping := time.NewTicker(sh.cfg.PingInterval)
+	defer ping.Stop()
+	var missed int64
+	for {
+		var msg subscriptionMessage
+		select {
+		case <-closed:
+			return
+		case <-ping.C:
+			if err = conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(sh.cfg.PingInterval)); err != nil {
+				return
+			}
+			continue
+		case msg = <-replies:
+		case delivery := <-subscriber.Deliveries():
+			msg = subscriptionMessage{Type: "event", Subscriptions: delivery.Subscriptions, Event: &delivery.Event}
+		}
+		// a stalled client fails the write instead of blocking the connection forever
+		_ = conn.SetWriteDeadline(time.Now().Add(sh.cfg.PingInterval))
+		if dropped := subscriber.Dropped(); dropped > 0 {
+			missed += dropped
+			if missed > sh.cfg.MaxDropped {
+				sh.log.Warnf("Closing subscription connection after %d dropped events", missed)
+				_ = conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too slow, events dropped"),
+					time.Now().Add(time.Second))
+				return
+			}
+			if err = conn.WriteJSON(subscriptionMessage{Type: "dropped", Dropped: dropped}); err != nil {
+				return
+			}
+		}
+		if err = conn.WriteJSON(msg); err != nil {
+			return
+		}
+	}
+}
+
+// readRequests applies the requests of the client until the connection closes, it only reads so
+// the handler stays the single writer of the connection
+func (sh *SubscriptionHandler) readRequests(conn *websocket.Conn, subscriber *svc.Subscriber, replies chan<- subscriptionMessage, closed chan<- struct{}, done <-chan struct{}) {
+	defer close(closed)
+	deadline := 2 * sh.cfg.PingInterval
+	_ = conn.SetReadDeadline(time.Now().Add(deadline))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(deadline))
+	})
+	for {
+		var req subscriptionRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			var closeErr *websocket.CloseError
+			if !errors.As(err, &closeErr) {
+				sh.log.Warnf("Error while reading subscription request: %v", err)
+			}
+			return
+		}
+		_ = conn.SetReadDeadline(time.Now().Add(deadline))
+		reply := subscriptionMessage{ID: req.ID}
+		switch {
+		case req.ID == "":
+			reply.Type, reply.Error = "error", "subscription id is required"
+		case req.Action == actionSubscribe:
+			subscriber.Subscribe(req.ID, req.Filter)
+			reply.Type = "subscribed"
+		case req.Action == actionUnsubscribe:
+			if subscriber.Unsubscribe(req.ID) {
+				reply.Type = "unsubscribed"
+			} else {
+				reply.Type, reply.Error = "error", "unknown subscription id"
+			}
+		default:
+			reply.Type, reply.Error = "error", "unknown action: "+req.Action
+		}
+		select {
+		case replies <- reply:
+		case <-done:
+			return
+		}
+	}
+}
+
+func NewSubscriptionHandler(log *logger.Logger, tr trace.Tracer, hub svc.SubscriptionHub) *SubscriptionHandler {
+	return &SubscriptionHandler{
+		log: log,
+		tr:  tr,
+		hub: hub,
+		cfg: settings.GetSubscriptions(),
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			CheckOrigin:     allowOrigins(settings.GetSubscriptions().AllowedOrigins),
+		},
+	}
+}
+
+// allowOrigins accepts websocket upgrades from clients that send no origin, like other services,
+// from the service's own host and from the allowed origins
+func allowOrigins(allowed []string) func(r *http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			return true
+		}
+		if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+			return true
+		}
+		return slices.ContainsFunc(allowed, func(a string) bool {
+			return a == "*" || strings.EqualFold(strings.TrimSuffix(a, "/"), origin)
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type TemplateHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.TemplateService
+}
+
+// TemplateEndpoints document the routes of the TemplateHandler
+var TemplateEndpoints = struct {
+	CreateTemplate, ListTemplates, GetTemplate, UpdateTemplate, DeleteTemplate, Instantiate openapi.Endpoint
+}{
+	CreateTemplate: openapi.Endpoint{Id: "createTemplate", Tag: "templates", Summary: "Create a workflow template",
+		Body: model.WorkflowTemplate{}, Status: http.StatusCreated, Response: model.WorkflowTemplate{}},
+	ListTemplates: openapi.Endpoint{Id: "listTemplates", Tag: "templates", Summary: "List the workflow templates",
+		Response: []model.WorkflowTemplate{}},
+	GetTemplate: openapi.Endpoint{Id: "getTemplate", Tag: "templates", Summary: "Get a workflow template",
+		Response: model.WorkflowTemplate{}},
+	UpdateTemplate: openapi.Endpoint{Id: "updateTemplate", Tag: "templates", Summary: "Replace a workflow template",
+		Body: model.WorkflowTemplate{}, Response: model.WorkflowTemplate{}},
+	DeleteTemplate: openapi.Endpoint{Id: "deleteTemplate", Tag: "templates", Summary: "Delete a workflow template",
+		Status: http.StatusNoContent},
+	Instantiate: openapi.Endpoint{Id: "instantiateTemplate", Tag: "templates", Summary: "Create an interaction from a workflow template",
+		Body: model.InstantiateRequest{}, Status: http.StatusCreated, Response: runtime.Interaction{}},
+}
+
+func (th *TemplateHandler) ListTemplatesHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	templates, err := th.svc.List(ctx)
+	if err != nil {
+		th.log.Errorf("Error while listing templates: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, templates)
+}
+
+func (th *TemplateHandler) GetTemplateHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	templateId := c.Param("templateId")
+	template, err := th.svc.GetById(ctx, templateId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+func (th *TemplateHandler) CreateTemplateHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req model.WorkflowTemplate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		th.log.Errorf("Error while binding request data to WorkflowTemplate: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	template, err := th.svc.Create(ctx, &req)
+	if err != nil {
+		th.log.Errorf("Error while creating template: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, template)
+}
+
+func (th *TemplateHandler) UpdateTemplateHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	templateId := c.Param("templateId")
+	var req model.WorkflowTemplate
+	if err := c.ShouldBindJSON(&req); err != nil {
+		th.log.Errorf("Error while binding request data to WorkflowTemplate: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if templateId != req.ID {
+		th.log.Errorf("Invalid template id: %s and WorkflowTemplate json ID: %s", templateId, req.ID)
+		_ = c.Error(errs.Invalid("Invalid template id"))
+		return
+	}
+	template, err := th.svc.Update(ctx, &req)
+	if err != nil {
+		th.log.Errorf("Error while updating template: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, template)
+}
+
+func (th *TemplateHandler) DeleteTemplateHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	templateId := c.Param("templateId")
+	if err := th.svc.DeleteById(ctx, templateId); err != nil {
+		th.log.Errorf("Error while deleting template: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (th *TemplateHandler) InstantiateHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	templateId := c.Param("templateId")
+	var req model.InstantiateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		th.log.Errorf("Error while binding request data to InstantiateRequest: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	interaction, err := th.svc.Instantiate(ctx, templateId, &req)
+	if err != nil {
+		th.log.Errorf("Error while instantiating template: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, interaction)
+}
+
+func NewTemplateHandler(log *logger.Logger, tr trace.Tracer, svc svc.TemplateService) *TemplateHandler {
+	return &TemplateHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type WebhookHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.WebhookService
+}
+
+// WebhookEndpoints document the routes of the WebhookHandler
+var WebhookEndpoints = struct {
+	CreateWebhook, ListWebhooks, GetWebhook, UpdateWebhook, DeleteWebhook, ListDeliveries, GetDelivery, Redeliver openapi.Endpoint
+}{
+	CreateWebhook: openapi.Endpoint{Id: "createWebhook", Tag: "webhooks", Summary: "Create a webhook",
+		Body: model.Webhook{}, Status: http.StatusCreated, Response: model.Webhook{}},
+	ListWebhooks: openapi.Endpoint{Id: "listWebhooks", Tag: "webhooks", Summary: "List the webhooks",
+		Response: []model.Webhook{}},
+	GetWebhook: openapi.Endpoint{Id: "getWebhook", Tag: "webhooks", Summary: "Get a webhook",
+		Response: model.Webhook{}},
+	UpdateWebhook: openapi.Endpoint{Id: "updateWebhook", Tag: "webhooks", Summary: "Replace a webhook",
+		Body: model.Webhook{}, Response: model.Webhook{}},
+	DeleteWebhook: openapi.Endpoint{Id: "deleteWebhook", Tag: "webhooks", Summary: "Delete a webhook",
+		Status: http.StatusNoContent},
+	ListDeliveries: openapi.Endpoint{Id: "listWebhookDeliveries", Tag: "webhooks", Summary: "List the deliveries of a webhook",
+		Response: []model.WebhookDelivery{}},
+	GetDelivery: openapi.Endpoint{Id: "getWebhookDelivery", Tag: "webhooks", Summary: "Get a delivery of a webhook",
+		Response: model.WebhookDelivery{}},
+	Redeliver: openapi.Endpoint{Id: "redeliverWebhookDelivery", Tag: "webhooks", Summary: "Send a delivery again",
+		Status: http.StatusAccepted, Response: model.WebhookDelivery{}},
+}
+
+func (wh *WebhookHandler) ListWebhooksHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	webhooks, err := wh.svc.List(ctx)
+	if err != nil {
+		wh.log.Errorf("Error while listing webhooks: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, webhooks)
+}
+
+func (wh *WebhookHandler) GetWebhookHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	webhookId := c.Param("webhookId")
+	webhook, err := wh.svc.GetById(ctx, webhookId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+// CreateWebhookHandler creates the webhook and returns it with its secret, the only response that
+// carries it
+func (wh *WebhookHandler) CreateWebhookHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	var req model.Webhook
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wh.log.Errorf("Error while binding request data to Webhook: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	webhook, err := wh.svc.Create(ctx, &req)
+	if err != nil {
+		wh.log.Errorf("Error while creating webhook: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, webhook)
+}
+
+func (wh *WebhookHandler) UpdateWebhookHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	webhookId := c.Param("webhookId")
+	var req model.Webhook
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wh.log.Errorf("Error while binding request data to Webhook: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if webhookId != req.ID {
+		wh.log.Errorf("Invalid webhook id: %s and Webhook json ID: %s", webhookId, req.ID)
+		_ = c.Error(errs.Invalid("Invalid webhook id"))
+		return
+	}
+	webhook, err := wh.svc.Update(ctx, &req)
+	if err != nil {
+		wh.log.Errorf("Error while updating webhook: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, webhook)
+}
+
+func (wh *WebhookHandler) DeleteWebhookHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	webhookId := c.Param("webhookId")
+	if err := wh.svc.DeleteById(ctx, webhookId); err != nil {
+		wh.log.Errorf("Error while deleting webhook: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (wh *WebhookHandler) ListDeliveriesHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	webhookId := c.Param("webhookId")
+	deliveries, err := wh.svc.Deliveries(ctx, webhookId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, deliveries)
+}
+
+func (wh *WebhookHandler) GetDeliveryHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	delivery, err := wh.svc.GetDelivery(ctx, c.Param("webhookId"), c.Param("deliveryId"))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, delivery)
+}
+
+// RedeliverHandler queues the payload of a past delivery again and returns the new delivery
+func (wh *WebhookHandler) RedeliverHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	delivery, err := wh.svc.Redeliver(ctx, c.Param("webhookId"), c.Param("deliveryId"))
+	if err != nil {
+		wh.log.Errorf("Error while redelivering webhook delivery: %v", err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusAccepted, delivery)
+}
+
+func NewWebhookHandler(log *logger.Logger, tr trace.Tracer, svc svc.WebhookService) *WebhookHandler {
+	return &WebhookHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+	}
+}
@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type WorkflowHandler struct {
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.WorkflowService
+	v   *validation.Validator
+}
+
+// WorkflowEndpoints document the routes of the WorkflowHandler
+var WorkflowEndpoints = struct {
+	ListWorkflows, CreateWorkflow, GetWorkflow, DeleteWorkflow, ActivateWorkflow openapi.Endpoint
+}{
+	ListWorkflows: openapi.Endpoint{Id: "listWorkflows", Tag: "workflows", Summary: "List the workflows of an interaction, the active one first",
+		Response: []model.Workflow{}},
+	CreateWorkflow: openapi.Endpoint{Id: "createWorkflow", Tag: "workflows", Summary: "Add a workflow to an interaction",
+		Headers: []openapi.Param{idempotencyKey}, Body: model.CreateWorkflowRequest{}, Status: http.StatusCreated, Response: model.Workflow{}},
+	GetWorkflow: openapi.Endpoint{Id: "getWorkflow", Tag: "workflows", Summary: "Get a workflow of an interaction",
+		Response: model.Workflow{}},
+	DeleteWorkflow: openapi.Endpoint{Id: "deleteWorkflow", Tag: "workflows", Summary: "Delete a workflow that is not the active one",
+		Status: http.StatusNoContent},
+	ActivateWorkflow: openapi.Endpoint{Id: "activateWorkflow", Tag: "workflows", Summary: "Make a workflow the execution flow of its interaction",
+		Response: runtime.Interaction{}},
+}
+
+func (wh *WorkflowHandler) ListWorkflowsHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflows, err := wh.svc.List(ctx, interactionId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, workflows)
+}
+
+func (wh *WorkflowHandler) GetWorkflowHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	workflow, err := wh.svc.Get(ctx, interactionId, workflowId)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, workflow)
+}
+
+func (wh *WorkflowHandler) CreateWorkflowHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	var req model.CreateWorkflowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		wh.log.Errorf("Error while binding request data to workflow: %v", err)
+		_ = c.Error(invalid(err))
+		return
+	}
+	if err := wh.v.ExecutionFlow(&req.Workflow); err != nil {
+		_ = c.Error(err)
+		return
+	}
+	workflow, err := wh.svc.Create(ctx, interactionId, &req.Workflow, req.Activate)
+	if err != nil {
+		wh.log.Errorf("Error while creating workflow of interaction id: %s by error: %v", interactionId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, workflow)
+}
+
+// ActivateWorkflowHandler makes the workflow the ExecutionFlow of the interaction and returns the
+// interaction
+func (wh *WorkflowHandler) ActivateWorkflowHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	interaction, err := wh.svc.Activate(ctx, interactionId, workflowId)
+	if err != nil {
+		wh.log.Errorf("Error while activating workflow: %s by error: %v", workflowId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, interaction)
+}
+
+func (wh *WorkflowHandler) DeleteWorkflowHandler(c *gin.Context) {
+	ctx := c.Request.Context()
+	interactionId := c.Param("interactionId")
+	workflowId := c.Param("workflowId")
+	if err := wh.svc.Delete(ctx, interactionId, workflowId); err != nil {
+		wh.log.Errorf("Error while deleting workflow: %s by error: %v", workflowId, err)
+		_ = c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func NewWorkflowHandler(log *logger.Logger, tr trace.Tracer, svc svc.WorkflowService) *WorkflowHandler {
+	return &WorkflowHandler{
+		log: log,
+		tr:  tr,
+		svc: svc,
+		v:   validation.New(settings.GetValidation()),
+	}
+}
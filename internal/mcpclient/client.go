@@ -0,0 +1,155 @@
+package mcpclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+
+	"github.com/mangudaigb/state-service/internal/model"
+)
+
+const (
+	ProtocolVersion = "2025-06-18"
+	clientName      = "state-service"
+	clientVersion   = "0.0.1"
+)
+
+// ToolDescriptor is a tool as returned by an MCP server on tools/list.
+type ToolDescriptor struct {
+	Name         string         `json:"name"`
+	Title        string         `json:"title,omitempty"`
+	Description  string         `json:"description,omitempty"`
+	InputSchema  map[string]any `json:"inputSchema,omitempty"`
+	OutputSchema map[string]any `json:"outputSchema,omitempty"`
+}
+
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type Client interface {
+	Initialize(ctx context.Context) (*ServerInfo, error)
+	ListTools(ctx context.Context) ([]ToolDescriptor, error)
+	Close() error
+}
+
+// transport sends a single json-rpc message. For requests it returns the matching response,
+// for notifications the returned response is nil.
+type transport interface {
+	send(ctx context.Context, msg *rpcMessage) (*rpcMessage, error)
+	close() error
+}
+
+type rpcMessage struct {
+	JsonRpc string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+type client struct {
+	t      transport
+	nextId atomic.Int64
+}
+
+func (c *client) call(ctx context.Context, method string, params any, result any) error {
+	id := c.nextId.Add(1)
+	resp, err := c.t.send(ctx, &rpcMessage{JsonRpc: "2.0", ID: &id, Method: method, Params: params})
+	if err != nil {
+		return err
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(resp.Result, result)
+}
+
+func (c *client) notify(ctx context.Context, method string) error {
+	_, err := c.t.send(ctx, &rpcMessage{JsonRpc: "2.0", Method: method})
+	return err
+}
+
+func (c *client) Initialize(ctx context.Context) (*ServerInfo, error) {
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]string{"name": clientName, "version": clientVersion},
+	}
+	var result struct {
+		ProtocolVersion string     `json:"protocolVersion"`
+		ServerInfo      ServerInfo `json:"serverInfo"`
+	}
+	if err := c.call(ctx, "initialize", params, &result); err != nil {
+		return nil, fmt.Errorf("initialize: %w", err)
+	}
+	if err := c.notify(ctx, "notifications/initialized"); err != nil {
+		return nil, fmt.Errorf("initialized notification: %w", err)
+	}
+	return &result.ServerInfo, nil
+}
+
+// ListTools follows nextCursor until the server has returned every page.
+func (c *client) ListTools(ctx context.Context) ([]ToolDescriptor, error) {
+	var tools []ToolDescriptor
+	cursor := ""
+	for {
+		var params any
+		if cursor != "" {
+			params = map[string]string{"cursor": cursor}
+		}
+		var result struct {
+			Tools      []ToolDescriptor `json:"tools"`
+			NextCursor string           `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, fmt.Errorf("tools/list: %w", err)
+		}
+		tools = append(tools, result.Tools...)
+		if result.NextCursor == "" {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+func (c *client) Close() error {
+	return c.t.close()
+}
+
+// New starts the transport declared by the endpoint. Streamable http servers are reached with
+// httpClient, or a default client when it is nil. The caller still has to Initialize.
+func New(ctx context.Context, endpoint model.McpEndpoint, httpClient *http.Client) (Client, error) {
+	var (
+		t   transport
+		err error
+	)
+	switch endpoint.Transport {
+	case model.McpTransportStdio:
+		t, err = newStdioTransport(ctx, endpoint)
+	case model.McpTransportHttp:
+		t, err = newHttpTransport(endpoint, httpClient)
+	default:
+		return nil, fmt.Errorf("unsupported mcp transport: %q", endpoint.Transport)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &client{t: t}, nil
+}
@@ -0,0 +1,204 @@
+package mcpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mangudaigb/state-service/internal/model"
+)
+
+// stubServer answers initialize and tools/list like an MCP server with two pages of tools
+func stubServer(msg *rpcMessage) *rpcMessage {
+	if msg.ID == nil {
+		return nil
+	}
+	resp := &rpcMessage{JsonRpc: "2.0", ID: msg.ID}
+	switch msg.Method {
+	case "initialize":
+		resp.Result, _ = json.Marshal(map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"serverInfo":      ServerInfo{Name: "stub", Version: "1.0.0"},
+		})
+	case "tools/list":
+		page := map[string]any{"tools": []ToolDescriptor{{Name: "search"}}, "nextCursor": "2"}
+		if params, _ := msg.Params.(map[string]any); params["cursor"] == "2" {
+			page = map[string]any{"tools": []ToolDescriptor{{Name: "fetch"}}}
+		}
+		resp.Result, _ = json.Marshal(page)
+	default:
+		resp.Error = &rpcError{Code: -32601, Message: "method not found: " + msg.Method}
+	}
+	return resp
+}
+
+func discover(t *testing.T, endpoint model.McpEndpoint) (*ServerInfo, []ToolDescriptor) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	c, err := New(ctx, endpoint, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer func() {
+		if err := c.Close(); err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	}()
+	info, err := c.Initialize(ctx)
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatalf("ListTools: %v", err)
+	}
+	return info, tools
+}
+
+func assertDiscovered(t *testing.T, info *ServerInfo, tools []ToolDescriptor) {
+	t.Helper()
+	if info.Name != "stub" || info.Version != "1.0.0" {
+		t.Errorf("server info = %+v, want stub 1.0.0", info)
+	}
+	if len(tools) != 2 || tools[0].Name != "search" || tools[1].Name != "fetch" {
+		t.Errorf("tools = %+v, want search and fetch", tools)
+	}
+}
+
+func newHttpStub(t *testing.T, stream bool) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		var msg rpcMessage
+		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if msg.Method == "initialize" {
+			w.Header().Set(headerSessionId, "session-1")
+		} else if r.Header.Get(headerSessionId) != "session-1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		resp := stubServer(&msg)
+		if resp == nil {
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		payload, _ := json.Marshal(resp)
+		if !stream {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(payload)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		// a notification ahead of the response is skipped
+		_, _ = fmt.Fprintf(w, "data: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\"}\n\n")
+		_, _ = fmt.Fprintf(w, "data: %s\n\n", payload)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestHttpTransport(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		t.Run(fmt.Sprintf("stream=%v", stream), func(t *testing.T) {
+			srv := newHttpStub(t, stream)
+			info, tools := discover(t, model.McpEndpoint{Transport: model.McpTransportHttp, URL: srv.URL})
+			assertDiscovered(t, info, tools)
+		})
+	}
+}
+
+func TestHttpTransportError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	c, err := New(context.Background(), model.McpEndpoint{Transport: model.McpTransportHttp, URL: srv.URL}, nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+	if _, err = c.Initialize(context.Background()); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("Initialize error = %v, want the 503 of the server", err)
+	}
+}
+
+// TestMain runs the test binary as a stdio MCP server when the stub mode is set in its environment
+func TestMain(m *testing.M) {
+	switch os.Getenv("MCP_STUB") {
+	case "serve":
+		serveStdio()
+		os.Exit(0)
+	case "flood":
+		// writes far more than the transport buffers without reading a request
+		for i := 0; ; i++ {
+			fmt.Printf("{\"jsonrpc\":\"2.0\",\"method\":\"notifications/message\",\"params\":{\"n\":%d}}\n", i)
+		}
+	}
+	os.Exit(m.Run())
+}
+
+func serveStdio() {
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			continue
+		}
+		// log lines on stdout are skipped by the client
+		fmt.Println("stub: received", msg.Method)
+		if resp := stubServer(&msg); resp != nil {
+			payload, _ := json.Marshal(resp)
+			fmt.Println(string(payload))
+		}
+	}
+}
+
+func stdioStub(mode string) model.McpEndpoint {
+	return model.McpEndpoint{
+		Transport: model.McpTransportStdio,
+		Command:   os.Args[0],
+		Env:       []string{"MCP_STUB=" + mode},
+	}
+}
+
+func TestStdioTransport(t *testing.T) {
+	info, tools := discover(t, stdioStub("serve"))
+	assertDiscovered(t, info, tools)
+}
+
+func TestStdioTransportCloseWhileServerWrites(t *testing.T) {
+	c, err := New(context.Background(), stdioStub("flood"), nil)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	st := c.(*client).t.(*stdioTransport)
+	// let the reader fill the buffer and block on it
+	deadline := time.Now().Add(5 * time.Second)
+	for len(st.messages) < cap(st.messages) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	closed := make(chan error, 1)
+	go func() { closed <- c.Close() }()
+	select {
+	case err = <-closed:
+		if err != nil {
+			t.Fatalf("Close: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("close is blocked by the reader")
+	}
+}
@@ -0,0 +1,142 @@
+package mcpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/mangudaigb/state-service/internal/model"
+)
+
+const (
+	headerSessionId       = "Mcp-Session-Id"
+	headerProtocolVersion = "MCP-Protocol-Version"
+)
+
+// httpTransport implements the streamable http transport: every message is POSTed and the
+// server answers with either a json body or an event stream carrying the response.
+type httpTransport struct {
+	url       string
+	headers   map[string]string
+	client    *http.Client
+	sessionId string
+}
+
+func newHttpTransport(endpoint model.McpEndpoint, client *http.Client) (*httpTransport, error) {
+	if endpoint.URL == "" {
+		return nil, errors.New("http mcp endpoint requires a url")
+	}
+	if client == nil {
+		client = &http.Client{}
+	}
+	return &httpTransport{
+		url:     endpoint.URL,
+		headers: endpoint.Headers,
+		client:  client,
+	}, nil
+}
+
+func (ht *httpTransport) newRequest(ctx context.Context, method string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, ht.url, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range ht.headers {
+		req.Header.Set(k, v)
+	}
+	if ht.sessionId != "" {
+		req.Header.Set(headerSessionId, ht.sessionId)
+		req.Header.Set(headerProtocolVersion, ProtocolVersion)
+	}
+	return req, nil
+}
+
+func (ht *httpTransport) send(ctx context.Context, msg *rpcMessage) (*rpcMessage, error) {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	req, err := ht.newRequest(ctx, http.MethodPost, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := ht.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if sid := resp.Header.Get(headerSessionId); sid != "" {
+		ht.sessionId = sid
+	}
+	if resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("mcp server responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		return readEventStream(resp.Body, *msg.ID)
+	}
+	var out rpcMessage
+	if err = json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decoding mcp response: %w", err)
+	}
+	return &out, nil
+}
+
+// readEventStream returns the first event whose data is the response to id.
+func readEventStream(body io.Reader, id int64) (*rpcMessage, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxStdioMessageSize)
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		case line == "":
+			if data.Len() == 0 {
+				continue
+			}
+			var msg rpcMessage
+			err := json.Unmarshal([]byte(data.String()), &msg)
+			data.Reset()
+			if err == nil && msg.ID != nil && *msg.ID == id && msg.Method == "" {
+				return &msg, nil
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, errors.New("mcp event stream ended without a response")
+}
+
+func (ht *httpTransport) close() error {
+	if ht.sessionId == "" {
+		return nil
+	}
+	req, err := ht.newRequest(context.Background(), http.MethodDelete, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := ht.client.Do(req)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
@@ -0,0 +1,123 @@
+package mcpclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/mangudaigb/state-service/internal/model"
+)
+
+const maxStdioMessageSize = 4 << 20
+
+// stdioTransport speaks newline delimited json-rpc with a spawned MCP server process.
+type stdioTransport struct {
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	messages chan *rpcMessage
+	done     chan struct{}
+	stopped  chan struct{}
+	readErr  error
+	mu       sync.Mutex
+	once     sync.Once
+}
+
+func newStdioTransport(ctx context.Context, endpoint model.McpEndpoint) (*stdioTransport, error) {
+	if endpoint.Command == "" {
+		return nil, errors.New("stdio mcp endpoint requires a command")
+	}
+	cmd := exec.Command(endpoint.Command, endpoint.Args...)
+	// the server gets the endpoint env on top of the search path only, not the service secrets
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + os.Getenv("HOME")}, endpoint.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err = cmd.Start(); err != nil {
+		return nil, fmt.Errorf("starting mcp server %s: %w", endpoint.Command, err)
+	}
+	st := &stdioTransport{
+		cmd:      cmd,
+		stdin:    stdin,
+		messages: make(chan *rpcMessage, 16),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go st.read(stdout)
+	return st, nil
+}
+
+func (st *stdioTransport) read(stdout io.Reader) {
+	defer close(st.stopped)
+	defer close(st.messages)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), maxStdioMessageSize)
+	for scanner.Scan() {
+		var msg rpcMessage
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			// servers may log to stdout, anything that is not json-rpc is skipped
+			continue
+		}
+		// nobody reads the messages after close, the server may still be writing
+		select {
+		case st.messages <- &msg:
+		case <-st.done:
+			return
+		}
+	}
+	st.readErr = scanner.Err()
+}
+
+func (st *stdioTransport) send(ctx context.Context, msg *rpcMessage) (*rpcMessage, error) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = st.stdin.Write(append(payload, '\n')); err != nil {
+		return nil, fmt.Errorf("writing to mcp server: %w", err)
+	}
+	if msg.ID == nil {
+		return nil, nil
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case resp, ok := <-st.messages:
+			if !ok {
+				if st.readErr != nil {
+					return nil, fmt.Errorf("reading from mcp server: %w", st.readErr)
+				}
+				return nil, errors.New("mcp server closed stdout")
+			}
+			// server initiated requests and notifications are not answered during discovery
+			if resp.ID == nil || *resp.ID != *msg.ID || resp.Method != "" {
+				continue
+			}
+			return resp, nil
+		}
+	}
+}
+
+func (st *stdioTransport) close() error {
+	st.once.Do(func() { close(st.done) })
+	_ = st.stdin.Close()
+	if st.cmd.Process != nil {
+		_ = st.cmd.Process.Kill()
+	}
+	_ = st.cmd.Wait()
+	<-st.stopped
+	return nil
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+const agentRefSeparator = "@"
+
+// AgentVersion is an immutable snapshot of an agent definition. Digest is the sha256 of the
+// definition so an answer can be traced back to the exact prompt that produced it.
+type AgentVersion struct {
+	AgentId   string        `json:"agent_id"`
+	Version   int           `json:"version"`
+	Agent     runtime.Agent `json:"agent"`
+	Digest    string        `json:"digest"`
+	Comment   string        `json:"comment,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+type AgentHead struct {
+	ID            string    `json:"id"`
+	LatestVersion int       `json:"latest_version"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+type AgentIndex struct {
+	Ids []string `json:"ids"`
+}
+
+// ParseAgentRef splits an `agentId@version` reference. A reference without a version returns 0.
+func ParseAgentRef(ref string) (string, int, bool) {
+	id, ver, found := strings.Cut(ref, agentRefSeparator)
+	if id == "" {
+		return "", 0, false
+	}
+	if !found {
+		return id, 0, true
+	}
+	version, err := strconv.Atoi(ver)
+	if err != nil || version < 1 {
+		return "", 0, false
+	}
+	return id, version, true
+}
+
+func FormatAgentRef(agentId string, version int) string {
+	return agentId + agentRefSeparator + strconv.Itoa(version)
+}
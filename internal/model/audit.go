@@ -0,0 +1,44 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// FieldChange is a member of a document that differs between the version before and after a write,
+// Path is a JSON pointer. A missing Before or After means the member was added or removed.
+type FieldChange struct {
+	Path   string          `json:"path"`
+	Before json.RawMessage `json:"before,omitempty"`
+	After  json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditChange is one document written by an audited operation
+type AuditChange struct {
+	Key    string        `json:"key"`
+	Op     HistoryOp     `json:"op"`
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// AuditEntry records who performed a write, through which operation, on which target and what it
+// changed. Path is the request path, or the topic for commands received on the bus.
+type AuditEntry struct {
+	ID            string        `json:"id"`
+	Time          time.Time     `json:"time"`
+	Actor         string        `json:"actor"`
+	Operation     string        `json:"operation"`
+	Method        string        `json:"method,omitempty"`
+	Path          string        `json:"path"`
+	InteractionId string        `json:"interaction_id,omitempty"`
+	Status        int           `json:"status,omitempty"`
+	Error         string        `json:"error,omitempty"`
+	Changes       []AuditChange `json:"changes,omitempty"`
+}
+
+type AuditQuery struct {
+	InteractionId string
+	Actor         string
+	From          time.Time
+	To            time.Time
+	Limit         int
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+type BatchOp string
+
+const (
+	BatchCreateStep           BatchOp = "create_step"
+	BatchUpdateStep           BatchOp = "update_step"
+	BatchPatchStep            BatchOp = "patch_step"
+	BatchUpdateStepStatus     BatchOp = "update_step_status"
+	BatchAddArtifact          BatchOp = "add_artifact"
+	BatchRecordToolInvocation BatchOp = "record_tool_invocation"
+	BatchPatchInteraction     BatchOp = "patch_interaction"
+)
+
+// BatchOperation is one write of a batch. Step operations name the step by WorkflowId, ExecutionId
+// and StepId and carry the field of their op: a Step, an Artifact, an Invocation or a Status. Patch
+// is a merge patch object or a JSON patch array.
+type BatchOperation struct {
+	Op          BatchOp                    `json:"op"`
+	WorkflowId  string                     `json:"workflow_id,omitempty"`
+	ExecutionId string                     `json:"execution_id,omitempty"`
+	StepId      string                     `json:"step_id,omitempty"`
+	Step        *runtime.Step              `json:"step,omitempty"`
+	Artifact    *runtime.Artifact          `json:"artifact,omitempty"`
+	Invocation  *runtime.McpToolInvocation `json:"invocation,omitempty"`
+	Status      runtime.Status             `json:"status,omitempty"`
+	Patch       json.RawMessage            `json:"patch,omitempty"`
+}
+
+// BatchRequest is an ordered list of writes on one interaction that is committed as a whole
+type BatchRequest struct {
+	InteractionId string           `json:"interaction_id"`
+	Operations    []BatchOperation `json:"operations"`
+}
+
+// BatchResult is the document an operation of a committed batch left behind
+type BatchResult struct {
+	Index  int     `json:"index"`
+	Op     BatchOp `json:"op"`
+	Result any     `json:"result"`
+}
+
+type BatchResponse struct {
+	InteractionId string        `json:"interaction_id"`
+	Results       []BatchResult `json:"results"`
+}
@@ -0,0 +1,41 @@
+package model
+
+import (
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+// ChildLink ties a child interaction to the node of the parent graph that runs it. The node is an
+// ExecutionNode like the ones of steps, its StepId is NodeId and no step document exists for it.
+type ChildLink struct {
+	ParentId    string    `json:"parent_id"`
+	WorkflowId  string    `json:"workflow_id"`
+	ExecutionId string    `json:"execution_id"`
+	NodeId      string    `json:"node_id"`
+	ChildId     string    `json:"child_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ChildSet holds the links of the children of an interaction, in the order they were created
+type ChildSet struct {
+	InteractionId string      `json:"interaction_id"`
+	Children      []ChildLink `json:"children"`
+}
+
+// CreateChildRequest creates a child interaction under a new node of the parent graph. NodeId
+// defaults to the id of the child interaction and Name to NodeId.
+type CreateChildRequest struct {
+	NodeId      string               `json:"node_id,omitempty"`
+	Name        string               `json:"name,omitempty"`
+	Interaction *runtime.Interaction `json:"interaction"`
+}
+
+// InteractionTree is an interaction with its children, Link is the node of the parent it runs under
+// and is empty at the root. Status is the status the interaction gives the node of its parent.
+type InteractionTree struct {
+	Interaction *runtime.Interaction `json:"interaction"`
+	Link        *ChildLink           `json:"link,omitempty"`
+	Status      runtime.Status       `json:"status"`
+	Children    []*InteractionTree   `json:"children"`
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type CommandType string
+
+const (
+	CommandCreateInteraction    CommandType = "create_interaction"
+	CommandAddStep              CommandType = "add_step"
+	CommandUpdateStepStatus     CommandType = "update_step_status"
+	CommandRecordToolInvocation CommandType = "record_tool_invocation"
+)
+
+// Command is a state mutation received on the state topic. Payload holds the runtime type the
+// command carries: an Interaction, a Step, a Status or a McpToolInvocation.
+type Command struct {
+	ID            string          `json:"id"`
+	Type          CommandType     `json:"type"`
+	InteractionId string          `json:"interaction_id,omitempty"`
+	WorkflowId    string          `json:"workflow_id,omitempty"`
+	ExecutionId   string          `json:"execution_id,omitempty"`
+	StepId        string          `json:"step_id,omitempty"`
+	Actor         string          `json:"actor,omitempty"`
+	Payload       json.RawMessage `json:"payload"`
+	IssuedAt      time.Time       `json:"issued_at,omitempty"`
+}
+
+// ProcessedCommand records a handled command id so redelivered commands are skipped.
+type ProcessedCommand struct {
+	ID          string      `json:"id"`
+	Type        CommandType `json:"type"`
+	ProcessedAt time.Time   `json:"processed_at"`
+}
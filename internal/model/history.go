@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+type HistoryOp string
+
+const (
+	HistorySet    HistoryOp = "set"
+	HistoryDelete HistoryOp = "delete"
+)
+
+// HistoryRecord is one entry of the append-only mutation log of a document. A base record holds
+// the whole document in Patch, it starts the log of a key and recurs every few versions so that
+// the log can be trimmed in front of one. Other records hold the JSON patch from the previous
+// version in Ops, or in Patch the merge patch of the records written before JSON patches were.
+// Replaying the records in order from a base rebuilds any version. Unit is the unit of work
+// spread over several cluster slots the write was committed in, if any.
+type HistoryRecord struct {
+	Version int64           `json:"version"`
+	Time    time.Time       `json:"time"`
+	Op      HistoryOp       `json:"op"`
+	Events  []string        `json:"events,omitempty"`
+	Base    bool            `json:"base,omitempty"`
+	Patch   json.RawMessage `json:"patch,omitempty"`
+	Ops     json.RawMessage `json:"ops,omitempty"`
+	Unit    string          `json:"unit,omitempty"`
+}
+
+// InteractionSnapshot is the state of an interaction at a point in time, with the steps of its
+// workflows as they were then
+type InteractionSnapshot struct {
+	At          time.Time            `json:"at"`
+	Interaction *runtime.Interaction `json:"interaction"`
+	Steps       []*runtime.Step      `json:"steps"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// IdempotentResponse is the response to the first request made with an idempotency key. While the
+// request is still being served Status is 0. RequestHash identifies the method, path and body, a
+// retry has to match it to get the response replayed.
+type IdempotentResponse struct {
+	Key         string    `json:"key"`
+	RequestHash string    `json:"requestHash"`
+	Status      int       `json:"status,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Body        []byte    `json:"body,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// Pending reports whether the first request is still being served
+func (r *IdempotentResponse) Pending() bool {
+	return r.Status == 0
+}
@@ -0,0 +1,61 @@
+package model
+
+import "time"
+
+type McpTransport string
+
+const (
+	McpTransportStdio McpTransport = "stdio"
+	McpTransportHttp  McpTransport = "http"
+)
+
+type McpReachability string
+
+const (
+	McpUnknown     McpReachability = "unknown"
+	McpReachable   McpReachability = "reachable"
+	McpUnreachable McpReachability = "unreachable"
+)
+
+// McpEndpoint declares how the service reaches a live MCP server. Stdio servers are spawned
+// from Command/Args/Env, streamable http servers are addressed by URL with optional Headers.
+type McpEndpoint struct {
+	Transport McpTransport      `json:"transport"`
+	Command   string            `json:"command,omitempty"`
+	Args      []string          `json:"args,omitempty"`
+	Env       []string          `json:"env,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+}
+
+type McpStatus struct {
+	Reachability  McpReachability `json:"reachability"`
+	ServerName    string          `json:"server_name,omitempty"`
+	ServerVersion string          `json:"server_version,omitempty"`
+	ToolCount     int             `json:"tool_count"`
+	LastCheckedAt time.Time       `json:"last_checked_at,omitempty"`
+	LastSyncedAt  time.Time       `json:"last_synced_at,omitempty"`
+	Error         string          `json:"error,omitempty"`
+}
+
+// McpConnection is kept next to the runtime.MCP document, runtime.MCP is owned by dhauli-base
+// and only carries the declared tools.
+type McpConnection struct {
+	InteractionId string      `json:"interaction_id"`
+	WorkflowId    string      `json:"workflow_id"`
+	McpId         string      `json:"mcp_id"`
+	Endpoint      McpEndpoint `json:"endpoint"`
+	Status        McpStatus   `json:"status"`
+}
+
+type McpConnectionRef struct {
+	InteractionId string `json:"interaction_id"`
+	WorkflowId    string `json:"workflow_id"`
+	McpId         string `json:"mcp_id"`
+}
+
+// McpConnectionIndex is the document that listed every MCP with a declared endpoint before the refs
+// moved to a set, it is only read to migrate them.
+type McpConnectionIndex struct {
+	Refs []McpConnectionRef `json:"refs"`
+}
@@ -0,0 +1,15 @@
+package model
+
+import "time"
+
+// OutboxStats describes how far the outbox relay is behind the state writes. Lag is the age of the
+// oldest event still waiting to be published.
+type OutboxStats struct {
+	Pending         int64      `json:"pending"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+	LagSeconds      float64    `json:"lag_seconds"`
+	Published       int64      `json:"published"`
+	Failed          int64      `json:"failed"`
+	LastRelayAt     time.Time  `json:"last_relay_at,omitempty"`
+	LastError       string     `json:"last_error,omitempty"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+type PlanRevision struct {
+	Revision  int          `json:"revision"`
+	Plan      runtime.Plan `json:"plan"`
+	Reason    string       `json:"reason,omitempty"`
+	CreatedAt time.Time    `json:"created_at"`
+}
+
+// PlanHistory keeps every revision of the interaction plan. StepBindings maps plan step ids to
+// the ids of the steps compiled from them so a new revision can be reconciled with the graph.
+type PlanHistory struct {
+	InteractionId string            `json:"interaction_id"`
+	Revisions     []PlanRevision    `json:"revisions"`
+	StepBindings  map[string]string `json:"step_bindings,omitempty"`
+}
+
+func (ph *PlanHistory) Latest() *PlanRevision {
+	if len(ph.Revisions) == 0 {
+		return nil
+	}
+	return &ph.Revisions[len(ph.Revisions)-1]
+}
+
+func (ph *PlanHistory) Get(revision int) *PlanRevision {
+	for i := range ph.Revisions {
+		if ph.Revisions[i].Revision == revision {
+			return &ph.Revisions[i]
+		}
+	}
+	return nil
+}
+
+type PlanStepChange struct {
+	Before runtime.PlanStep `json:"before"`
+	After  runtime.PlanStep `json:"after"`
+}
+
+type PlanDiff struct {
+	From      int                `json:"from"`
+	To        int                `json:"to"`
+	Added     []runtime.PlanStep `json:"added"`
+	Removed   []runtime.PlanStep `json:"removed"`
+	Changed   []PlanStepChange   `json:"changed"`
+	Unchanged []string           `json:"unchanged"`
+}
+
+// ReconcileResult lists the step ids touched when the execution graph was aligned with a plan.
+type ReconcileResult struct {
+	Revision    int                  `json:"revision"`
+	Added       []string             `json:"added"`
+	Cancelled   []string             `json:"cancelled"`
+	Kept        []string             `json:"kept"`
+	Interaction *runtime.Interaction `json:"interaction"`
+}
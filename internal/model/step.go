@@ -0,0 +1,15 @@
+package model
+
+// StepLocation is the interaction, workflow and execution a step is stored under, the ids of the
+// nested step routes
+type StepLocation struct {
+	InteractionId string `json:"interaction_id"`
+	WorkflowId    string `json:"workflow_id"`
+	ExecutionId   string `json:"execution_id"`
+}
+
+// StepIndex holds the locations of a step id. A step id is unique within its execution only, the
+// steps instantiated from a template share their ids across interactions.
+type StepIndex struct {
+	Locations []StepLocation `json:"locations"`
+}
@@ -0,0 +1,10 @@
+package model
+
+// SubscriptionFilter selects the events a websocket subscription receives. Every non empty field
+// has to match: the interaction id is one of InteractionIds, the interaction carries one of Tags
+// and the event type is one of Types.
+type SubscriptionFilter struct {
+	InteractionIds []string `json:"interaction_ids,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Types          []string `json:"types,omitempty"`
+}
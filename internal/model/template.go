@@ -0,0 +1,40 @@
+package model
+
+import (
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Default     string `json:"default,omitempty"`
+	Required    bool   `json:"required,omitempty"`
+}
+
+// WorkflowTemplate is a parameterized interaction. Strings anywhere in the template may contain
+// `{{variable}}` placeholders which are substituted when the template is instantiated. Graph
+// nodes reference Steps by id and AvailableMcpRefs reference Mcps by id.
+type WorkflowTemplate struct {
+	ID            string                `json:"id"`
+	Name          string                `json:"name"`
+	Description   string                `json:"description,omitempty"`
+	Variables     []TemplateVariable    `json:"variables,omitempty"`
+	BaseQuery     *runtime.Query        `json:"base_query,omitempty"`
+	BaseContext   *runtime.Context      `json:"base_context,omitempty"`
+	ExecutionFlow runtime.ExecutionFlow `json:"workflow"`
+	Steps         []runtime.Step        `json:"steps,omitempty"`
+	Mcps          []runtime.MCP         `json:"mcps,omitempty"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+type TemplateIndex struct {
+	Ids []string `json:"ids"`
+}
+
+type InstantiateRequest struct {
+	InteractionId string            `json:"interaction_id,omitempty"`
+	Variables     map[string]string `json:"variables"`
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Webhook is an outbound subscription. Events whose type is in EventTypes, all events when it is
+// empty, are posted to URL signed with Secret.
+type Webhook struct {
+	ID             string    `json:"id"`
+	URL            string    `json:"url"`
+	Secret         string    `json:"secret,omitempty"`
+	Description    string    `json:"description,omitempty"`
+	EventTypes     []string  `json:"event_types,omitempty"`
+	InteractionIds []string  `json:"interaction_ids,omitempty"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type WebhookIndex struct {
+	Ids []string `json:"ids"`
+}
+
+type DeliveryAttempt struct {
+	At         time.Time     `json:"at"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// WebhookDelivery is the log entry of one event sent to one webhook, Payload is the posted body
+type WebhookDelivery struct {
+	ID            string            `json:"id"`
+	WebhookId     string            `json:"webhook_id"`
+	EventId       string            `json:"event_id"`
+	EventType     string            `json:"event_type"`
+	InteractionId string            `json:"interaction_id"`
+	RedeliveryOf  string            `json:"redelivery_of,omitempty"`
+	Payload       json.RawMessage   `json:"payload"`
+	Status        DeliveryStatus    `json:"status"`
+	Attempts      []DeliveryAttempt `json:"attempts,omitempty"`
+	NextAttemptAt time.Time         `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	UpdatedAt     time.Time         `json:"updated_at"`
+}
+
+// DeliveryLog holds the ids of the latest deliveries of a webhook, newest first, the way they were
+// logged before the log became a sorted set
+type DeliveryLog struct {
+	Ids []string `json:"ids"`
+}
@@ -0,0 +1,23 @@
+package model
+
+import "github.com/mangudaigb/dhauli-base/types/runtime"
+
+// WorkflowSet holds the workflows of an interaction besides its active one, which is the
+// ExecutionFlow of the interaction itself
+type WorkflowSet struct {
+	InteractionId string                  `json:"interaction_id"`
+	Workflows     []runtime.ExecutionFlow `json:"workflows"`
+}
+
+// Workflow is a workflow of an interaction as the api lists it, telling the active one apart
+type Workflow struct {
+	runtime.ExecutionFlow
+	Active bool `json:"active"`
+}
+
+// CreateWorkflowRequest adds a workflow to an interaction, Activate makes it the active workflow.
+// The first workflow of an interaction is always active.
+type CreateWorkflowRequest struct {
+	Workflow runtime.ExecutionFlow `json:"workflow"`
+	Activate bool                  `json:"activate,omitempty"`
+}
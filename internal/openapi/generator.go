@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	JSONType    = "application/json"
+	ProblemType = "application/problem+json"
+)
+
+// Endpoint describes what the handler of a route reads and writes. Body and Response are values of
+// the go types of the request and response bodies, nil when there is none.
+type Endpoint struct {
+	Id      string
+	Summary string
+	Tag     string
+	Query   []Param
+	Headers []Param
+	Body    any
+	// BodyTypes are the media types of the request body, json when empty
+	BodyTypes []string
+	// Status is the success status, 200 when zero
+	Status   int
+	Response any
+	// ResponseType is the media type of the response, json when empty
+	ResponseType string
+	// ResponseHeaders are the headers set on the success response
+	ResponseHeaders []Param
+}
+
+// Param is a query or header parameter, a string unless Type says otherwise
+type Param struct {
+	Name        string
+	Description string
+	Type        string
+	Required    bool
+}
+
+type Generator struct {
+	info    Info
+	schemas *schemas
+	// problem is the go type of the error responses
+	problem any
+}
+
+// Enum declares the values of a string or number type. values have to be of the same type.
+func (g *Generator) Enum(values ...any) {
+	if len(values) == 0 {
+		return
+	}
+	g.schemas.enums[reflect.TypeOf(values[0])] = values
+}
+
+// Name names the component of the type of value, for types whose own name is too generic
+func (g *Generator) Name(value any, name string) {
+	g.schemas.names[reflect.TypeOf(value)] = name
+}
+
+// Generate builds the document of the routes registered through a Router
+func (g *Generator) Generate(routes []Route) *Document {
+	doc := &Document{
+		OpenAPI:    Version,
+		Info:       g.info,
+		Paths:      map[string]*PathItem{},
+		Components: Components{Schemas: g.schemas.components},
+	}
+	sort.SliceStable(routes, func(i, j int) bool { return routes[i].Path < routes[j].Path })
+	ids := map[string]int{}
+	for _, route := range routes {
+		op := g.operation(route.Endpoint)
+		// an endpoint registered on several routes gets an id per route
+		if n := ids[op.OperationId]; n > 0 {
+			op.OperationId += strconv.Itoa(n + 1)
+		}
+		ids[route.Endpoint.Id]++
+
+		p, params := pathOf(route.Path)
+		op.Parameters = append(params, op.Parameters...)
+		item, ok := doc.Paths[p]
+		if !ok {
+			item = &PathItem{}
+			doc.Paths[p] = item
+		}
+		(*item)[strings.ToLower(route.Method)] = op
+	}
+	return doc
+}
+
+func (g *Generator) operation(endpoint Endpoint) *Operation {
+	op := &Operation{
+		OperationId: endpoint.Id,
+		Summary:     endpoint.Summary,
+		Responses:   map[string]*Response{},
+	}
+	if endpoint.Tag != "" {
+		op.Tags = []string{endpoint.Tag}
+	}
+	for _, param := range endpoint.Query {
+		op.Parameters = append(op.Parameters, parameter("query", param))
+	}
+	for _, param := range endpoint.Headers {
+		op.Parameters = append(op.Parameters, parameter("header", param))
+	}
+	if endpoint.Body != nil {
+		schema := g.schemas.of(reflect.TypeOf(endpoint.Body))
+		body := &RequestBody{Required: true, Content: map[string]*MediaType{}}
+		types := endpoint.BodyTypes
+		if len(types) == 0 {
+			types = []string{JSONType}
+		}
+		for _, t := range types {
+			body.Content[t] = &MediaType{Schema: schema}
+		}
+		op.RequestBody = body
+	}
+
+	status := endpoint.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	resp := &Response{Description: http.StatusText(status)}
+	if endpoint.Response != nil {
+		t := endpoint.ResponseType
+		if t == "" {
+			t = JSONType
+		}
+		resp.Content = map[string]*MediaType{t: {Schema: g.schemas.of(reflect.TypeOf(endpoint.Response))}}
+	}
+	for _, param := range endpoint.ResponseHeaders {
+		if resp.Headers == nil {
+			resp.Headers = map[string]*Header{}
+		}
+		resp.Headers[param.Name] = &Header{Description: param.Description, Schema: typeSchema(param.Type)}
+	}
+	op.Responses[strconv.Itoa(status)] = resp
+	op.Responses["default"] = &Response{
+		Description: "Problem details of a failed request",
+		Content:     map[string]*MediaType{ProblemType: {Schema: g.schemas.of(reflect.TypeOf(g.problem))}},
+	}
+	return op
+}
+
+func parameter(in string, param Param) *Parameter {
+	return &Parameter{
+		Name:        param.Name,
+		In:          in,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      typeSchema(param.Type),
+	}
+}
+
+func typeSchema(t string) *Schema {
+	switch t {
+	case "", "string":
+		return &Schema{Type: "string"}
+	case "date-time":
+		return &Schema{Type: "string", Format: "date-time"}
+	default:
+		return &Schema{Type: t}
+	}
+}
+
+// pathOf converts the gin parameters of a path to OpenAPI parameters, /a/:id becomes /a/{id}
+func pathOf(route string) (string, []*Parameter) {
+	segments := strings.Split(route, "/")
+	var params []*Parameter
+	for i, segment := range segments {
+		if len(segment) > 1 && (segment[0] == ':' || segment[0] == '*') {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// NewGenerator returns a generator of documents describing errors as values of problem's type
+func NewGenerator(title, version string, problem any) *Generator {
+	return &Generator{
+		info: Info{Title: title, Version: version},
+		schemas: &schemas{
+			components: map[string]*Schema{},
+			names:      map[reflect.Type]string{},
+			enums:      map[reflect.Type][]any{},
+		},
+		problem: problem,
+	}
+}
@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Route is a registered route with the endpoint describing it
+type Route struct {
+	Method   string
+	Path     string
+	Endpoint Endpoint
+}
+
+// Routes collects the routes registered through its routers
+type Routes struct {
+	mu     sync.Mutex
+	routes []Route
+}
+
+func NewRoutes() *Routes {
+	return &Routes{}
+}
+
+// Router returns a router registering on the gin group
+func (rs *Routes) Router(group *gin.RouterGroup) *Router {
+	return &Router{group: group, routes: rs}
+}
+
+// List returns the routes registered so far
+func (rs *Routes) List() []Route {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	return append([]Route(nil), rs.routes...)
+}
+
+func (rs *Routes) add(route Route) {
+	rs.mu.Lock()
+	defer rs.mu.Unlock()
+	rs.routes = append(rs.routes, route)
+}
+
+// Router registers routes on a gin router group like the group itself, along with the endpoint
+// of every route
+type Router struct {
+	group  *gin.RouterGroup
+	routes *Routes
+}
+
+// Group returns a router of the sub group at path
+func (r *Router) Group(path string, handlers ...gin.HandlerFunc) *Router {
+	return &Router{group: r.group.Group(path, handlers...), routes: r.routes}
+}
+
+func (r *Router) Handle(method, path string, endpoint Endpoint, handlers ...gin.HandlerFunc) {
+	r.group.Handle(method, path, handlers...)
+	r.routes.add(Route{Method: method, Path: joinPath(r.group.BasePath(), path), Endpoint: endpoint})
+}
+
+func (r *Router) GET(path string, endpoint Endpoint, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, path, endpoint, handlers...)
+}
+
+func (r *Router) POST(path string, endpoint Endpoint, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, path, endpoint, handlers...)
+}
+
+func (r *Router) PUT(path string, endpoint Endpoint, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, path, endpoint, handlers...)
+}
+
+func (r *Router) PATCH(path string, endpoint Endpoint, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPatch, path, endpoint, handlers...)
+}
+
+func (r *Router) DELETE(path string, endpoint Endpoint, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, path, endpoint, handlers...)
+}
+
+// joinPath joins the paths the way gin does for a route of a group
+func joinPath(base, path string) string {
+	if path == "" {
+		return base
+	}
+	joined := strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+	if strings.HasSuffix(path, "/") && !strings.HasSuffix(joined, "/") {
+		return joined + "/"
+	}
+	return joined
+}
@@ -0,0 +1,148 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas builds the schemas of go types the way encoding/json encodes them. Named structs and
+// enums become components that are referenced, everything else is inlined.
+type schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	enums      map[reflect.Type][]any
+}
+
+func (s *schemas) of(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &Schema{}
+	}
+	if values, ok := s.enums[t]; ok {
+		return s.ref(t, func() *Schema {
+			schema := s.basic(t)
+			schema.Enum = values
+			return schema
+		})
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t, func() *Schema { return s.object(t) })
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	default:
+		return s.basic(t)
+	}
+}
+
+func (s *schemas) basic(t reflect.Type) *Schema {
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	default:
+		return &Schema{}
+	}
+}
+
+// ref returns a reference to the component of t, building the component on first use. The component
+// is registered before it is built so that recursive types refer to themselves.
+func (s *schemas) ref(t reflect.Type, build func() *Schema) *Schema {
+	name, ok := s.names[t]
+	if !ok {
+		name = s.name(t)
+		s.names[t] = name
+	}
+	if _, built := s.components[name]; !built {
+		schema := &Schema{}
+		s.components[name] = schema
+		*schema = *build()
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// name is the type name, qualified by its package when another package has a type of that name
+func (s *schemas) name(t reflect.Type) string {
+	name := exported(t.Name())
+	if _, taken := s.components[name]; taken {
+		name = exported(path.Base(t.PkgPath())) + name
+	}
+	return name
+}
+
+// object is the schema of a struct. No field is required, like for encoding/json a missing field
+// is the zero value.
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.fields(t, schema)
+	return schema
+}
+
+func (s *schemas) fields(t reflect.Type, schema *Schema) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		ft := field.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if field.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			s.fields(ft, schema)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if strings.Contains(opts, "string") {
+			schema.Properties[name] = &Schema{Type: "string"}
+		} else {
+			schema.Properties[name] = s.of(field.Type)
+		}
+	}
+}
+
+func exported(name string) string {
+	if name == "" {
+		return name
+	}
+	r := []rune(name)
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
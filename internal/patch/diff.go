@@ -0,0 +1,134 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrNotObjects is returned by Diff for documents that are not both json objects
+var ErrNotObjects = errors.New("diff needs two json objects")
+
+// Diff returns the JSON patch turning original into modified. Members are added, removed and
+// replaced one by one, and elements appended to an array are added at its end, so a document
+// growing a long array records the new elements only. Null members are left out like in
+// MergeDiff.
+func Diff(original, modified []byte) (json.RawMessage, error) {
+	a, err := decode(original)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decode(modified)
+	if err != nil {
+		return nil, err
+	}
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if !aok || !bok {
+		return nil, ErrNotObjects
+	}
+	ops := []Operation{}
+	if err = diffMembers(&ops, "", am, bm); err != nil {
+		return nil, err
+	}
+	return json.Marshal(ops)
+}
+
+func diffMembers(ops *[]Operation, path string, a, b map[string]any) error {
+	for _, k := range sortedKeys(a) {
+		if bv, ok := b[k]; (!ok || bv == nil) && a[k] != nil {
+			*ops = append(*ops, Operation{Op: "remove", Path: path + "/" + escape(k)})
+		}
+	}
+	for _, k := range sortedKeys(b) {
+		bv := b[k]
+		if bv == nil {
+			continue
+		}
+		av, ok := a[k]
+		if !ok || av == nil {
+			if err := addOp(ops, "add", path+"/"+escape(k), bv); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := diffValues(ops, path+"/"+escape(k), av, bv); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func diffValues(ops *[]Operation, path string, a, b any) error {
+	switch bt := b.(type) {
+	case map[string]any:
+		if at, ok := a.(map[string]any); ok {
+			return diffMembers(ops, path, at, bt)
+		}
+	case []any:
+		if at, ok := a.([]any); ok {
+			return diffElements(ops, path, at, bt)
+		}
+	}
+	if reflect.DeepEqual(a, b) {
+		return nil
+	}
+	return addOp(ops, "replace", path, b)
+}
+
+// diffElements appends the new elements of an array that grew, diffs the elements of an array of
+// the same length in place and replaces any other array whole
+func diffElements(ops *[]Operation, path string, a, b []any) error {
+	switch {
+	case len(b) > len(a) && reflect.DeepEqual(a, b[:len(a)]):
+		for _, v := range b[len(a):] {
+			if err := addOp(ops, "add", path+"/-", v); err != nil {
+				return err
+			}
+		}
+		return nil
+	case len(b) == len(a):
+		for i := range b {
+			if a[i] == nil || b[i] == nil {
+				if !reflect.DeepEqual(a[i], b[i]) {
+					if err := addOp(ops, "replace", path+"/"+strconv.Itoa(i), b[i]); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := diffValues(ops, path+"/"+strconv.Itoa(i), a[i], b[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return addOp(ops, "replace", path, b)
+	}
+}
+
+func addOp(ops *[]Operation, op, path string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	*ops = append(*ops, Operation{Op: op, Path: path, Value: data})
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// escape turns a member name into a JSON pointer reference token
+func escape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,279 @@
+package patch
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/mangudaigb/state-service/internal/errs"
+)
+
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+var (
+	ErrUnsupportedType = errs.Invalid("unsupported patch media type")
+	ErrInvalidPatch    = errs.Invalid("invalid patch")
+	ErrTestFailed      = errs.Invalid("patch test failed")
+)
+
+// Operation is one operation of a JSON patch as defined by RFC 6902
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyType applies a patch of the given media type to doc
+func ApplyType(contentType string, doc, patch []byte) (json.RawMessage, error) {
+	switch contentType {
+	case MergePatchType:
+		out, err := MergeApply(doc, patch)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		return out, nil
+	case JSONPatchType:
+		return Apply(doc, patch)
+	default:
+		return nil, fmt.Errorf("%w: %q, expected %s or %s", ErrUnsupportedType, contentType, MergePatchType, JSONPatchType)
+	}
+}
+
+// Apply applies the JSON patch operations to doc. The operations are applied in order and either
+// all of them apply or an error is returned.
+func Apply(doc, patch []byte) (json.RawMessage, error) {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range ops {
+		if target, err = applyOperation(target, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(target)
+}
+
+func applyOperation(doc any, op Operation) (any, error) {
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+		value, err := decode(op.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(doc, op.Path, value)
+		case "replace":
+			if doc, _, err = remove(doc, op.Path); err != nil {
+				return nil, err
+			}
+			return add(doc, op.Path, value)
+		default:
+			current, err := get(doc, op.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w: value at %s differs", ErrTestFailed, op.Path)
+			}
+			return doc, nil
+		}
+	case "remove":
+		doc, _, err := remove(doc, op.Path)
+		return doc, err
+	case "move":
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move %s into itself", ErrInvalidPatch, op.From)
+		}
+		doc, value, err := remove(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	case "copy":
+		value, err := get(doc, op.From)
+		if err != nil {
+			return nil, err
+		}
+		// the copy must not share maps or slices with the source
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if value, err = decode(data); err != nil {
+			return nil, err
+		}
+		return add(doc, op.Path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// tokens splits a JSON pointer into its unescaped reference tokens
+func tokens(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: pointer %q must start with /", ErrInvalidPatch, pointer)
+	}
+	parts := strings.Split(pointer[1:], "/")
+	for i, part := range parts {
+		parts[i] = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+	}
+	return parts, nil
+}
+
+func index(token string, length int, appending bool) (int, error) {
+	if appending && token == "-" {
+		return length, nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && strings.HasPrefix(token, "0")) {
+		return 0, fmt.Errorf("%w: invalid array index %q", ErrInvalidPatch, token)
+	}
+	limit := length - 1
+	if appending {
+		limit = length
+	}
+	if i > limit {
+		return 0, fmt.Errorf("%w: array index %d out of range", ErrInvalidPatch, i)
+	}
+	return i, nil
+}
+
+func get(doc any, pointer string) (any, error) {
+	parts, err := tokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+	current := doc
+	for _, token := range parts {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidPatch, pointer)
+			}
+			current = value
+		case []any:
+			i, err := index(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidPatch, pointer)
+		}
+	}
+	return current, nil
+}
+
+// update replaces the container holding the last token of pointer with the result of fn, walking
+// down from doc and rebuilding the parents so array resizes are kept
+func update(doc any, parts []string, fn func(parent any, token string) (any, error)) (any, error) {
+	if len(parts) == 1 {
+		return fn(doc, parts[0])
+	}
+	switch node := doc.(type) {
+	case map[string]any:
+		child, ok := node[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("%w: /%s does not exist", ErrInvalidPatch, parts[0])
+		}
+		updated, err := update(child, parts[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[parts[0]] = updated
+		return node, nil
+	case []any:
+		i, err := index(parts[0], len(node), false)
+		if err != nil {
+			return nil, err
+		}
+		updated, err := update(node[i], parts[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		node[i] = updated
+		return node, nil
+	default:
+		return nil, fmt.Errorf("%w: cannot traverse into a scalar at %s", ErrInvalidPatch, parts[0])
+	}
+}
+
+func add(doc any, pointer string, value any) (any, error) {
+	parts, err := tokens(pointer)
+	if err != nil {
+		return nil, err
+	}
+	if len(parts) == 0 {
+		return value, nil
+	}
+	return update(doc, parts, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			node[token] = value
+			return node, nil
+		case []any:
+			i, err := index(token, len(node), true)
+			if err != nil {
+				return nil, err
+			}
+			node = append(node, nil)
+			copy(node[i+1:], node[i:])
+			node[i] = value
+			return node, nil
+		default:
+			return nil, fmt.Errorf("%w: cannot add to a scalar at %s", ErrInvalidPatch, pointer)
+		}
+	})
+}
+
+func remove(doc any, pointer string) (any, any, error) {
+	parts, err := tokens(pointer)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(parts) == 0 {
+		return nil, doc, nil
+	}
+	var removed any
+	doc, err = update(doc, parts, func(parent any, token string) (any, error) {
+		switch node := parent.(type) {
+		case map[string]any:
+			value, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidPatch, pointer)
+			}
+			removed = value
+			delete(node, token)
+			return node, nil
+		case []any:
+			i, err := index(token, len(node), false)
+			if err != nil {
+				return nil, err
+			}
+			removed = node[i]
+			return append(node[:i], node[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("%w: %s does not exist", ErrInvalidPatch, pointer)
+		}
+	})
+	return doc, removed, err
+}
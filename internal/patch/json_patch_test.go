@@ -0,0 +1,201 @@
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func equalJSON(t *testing.T, got []byte, want string) bool {
+	t.Helper()
+	var g, w any
+	if err := json.Unmarshal(got, &g); err != nil {
+		t.Fatalf("result %s is not json: %v", got, err)
+	}
+	if err := json.Unmarshal([]byte(want), &w); err != nil {
+		t.Fatalf("want %s is not json: %v", want, err)
+	}
+	return reflect.DeepEqual(g, w)
+}
+
+func TestApply(t *testing.T) {
+	doc := `{"a":{"b":1},"list":[1,2,3],"a/b":"slash","m~n":"tilde"}`
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+		err   error
+	}{
+		{
+			name:  "add a member",
+			patch: `[{"op":"add","path":"/a/c","value":2}]`,
+			want:  `{"a":{"b":1,"c":2},"list":[1,2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "add inserts at an index",
+			patch: `[{"op":"add","path":"/list/1","value":9}]`,
+			want:  `{"a":{"b":1},"list":[1,9,2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "add appends at -",
+			patch: `[{"op":"add","path":"/list/-","value":4}]`,
+			want:  `{"a":{"b":1},"list":[1,2,3,4],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "add past the end",
+			patch: `[{"op":"add","path":"/list/5","value":4}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "remove a member",
+			patch: `[{"op":"remove","path":"/a/b"}]`,
+			want:  `{"a":{},"list":[1,2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "remove an element",
+			patch: `[{"op":"remove","path":"/list/0"}]`,
+			want:  `{"a":{"b":1},"list":[2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "remove a missing member",
+			patch: `[{"op":"remove","path":"/missing"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "replace a member",
+			patch: `[{"op":"replace","path":"/a/b","value":"one"}]`,
+			want:  `{"a":{"b":"one"},"list":[1,2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "replace escapes / as ~1",
+			patch: `[{"op":"replace","path":"/a~1b","value":"changed"}]`,
+			want:  `{"a":{"b":1},"list":[1,2,3],"a/b":"changed","m~n":"tilde"}`,
+		},
+		{
+			name:  "replace escapes ~ as ~0",
+			patch: `[{"op":"replace","path":"/m~0n","value":"changed"}]`,
+			want:  `{"a":{"b":1},"list":[1,2,3],"a/b":"slash","m~n":"changed"}`,
+		},
+		{
+			name:  "test passes",
+			patch: `[{"op":"test","path":"/a","value":{"b":1}},{"op":"remove","path":"/a"}]`,
+			want:  `{"list":[1,2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "test fails and nothing applies",
+			patch: `[{"op":"remove","path":"/a"},{"op":"test","path":"/list/0","value":2}]`,
+			err:   ErrTestFailed,
+		},
+		{
+			name:  "move a member",
+			patch: `[{"op":"move","from":"/a/b","path":"/b"}]`,
+			want:  `{"a":{},"b":1,"list":[1,2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "move an element",
+			patch: `[{"op":"move","from":"/list/0","path":"/list/-"}]`,
+			want:  `{"a":{"b":1},"list":[2,3,1],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "copy a member",
+			patch: `[{"op":"copy","from":"/a","path":"/copied"}]`,
+			want:  `{"a":{"b":1},"copied":{"b":1},"list":[1,2,3],"a/b":"slash","m~n":"tilde"}`,
+		},
+		{
+			name:  "copy from a missing member",
+			patch: `[{"op":"copy","from":"/missing","path":"/copied"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "unknown operation",
+			patch: `[{"op":"rename","path":"/a"}]`,
+			err:   ErrInvalidPatch,
+		},
+		{
+			name:  "not a list of operations",
+			patch: `{"op":"remove","path":"/a"}`,
+			err:   ErrInvalidPatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(doc), []byte(tt.patch))
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !equalJSON(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeApply(t *testing.T) {
+	doc := `{"a":{"b":1,"c":2},"list":[1,2],"name":"x"}`
+	tests := []struct {
+		name  string
+		patch string
+		want  string
+	}{
+		{
+			name:  "null deletes a member",
+			patch: `{"name":null}`,
+			want:  `{"a":{"b":1,"c":2},"list":[1,2]}`,
+		},
+		{
+			name:  "null deletes a nested member",
+			patch: `{"a":{"b":null}}`,
+			want:  `{"a":{"c":2},"list":[1,2],"name":"x"}`,
+		},
+		{
+			name:  "objects merge",
+			patch: `{"a":{"d":3}}`,
+			want:  `{"a":{"b":1,"c":2,"d":3},"list":[1,2],"name":"x"}`,
+		},
+		{
+			name:  "arrays are replaced",
+			patch: `{"list":[3]}`,
+			want:  `{"a":{"b":1,"c":2},"list":[3],"name":"x"}`,
+		},
+		{
+			name:  "null of a missing member is ignored",
+			patch: `{"missing":null}`,
+			want:  doc,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := MergeApply([]byte(doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("MergeApply: %v", err)
+			}
+			if !equalJSON(t, got, tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyType(t *testing.T) {
+	doc := []byte(`{"a":1}`)
+	if _, err := ApplyType("application/json", doc, []byte(`{"a":2}`)); !errors.Is(err, ErrUnsupportedType) {
+		t.Errorf("error = %v, want an unsupported type", err)
+	}
+	if _, err := ApplyType(MergePatchType, doc, []byte(`{`)); !errors.Is(err, ErrInvalidPatch) {
+		t.Errorf("error = %v, want an invalid patch", err)
+	}
+	got, err := ApplyType(JSONPatchType, doc, []byte(`[{"op":"replace","path":"/a","value":2}]`))
+	if err != nil {
+		t.Fatalf("ApplyType: %v", err)
+	}
+	if !equalJSON(t, got, `{"a":2}`) {
+		t.Errorf("got %s, want a replaced", got)
+	}
+}
@@ -0,0 +1,104 @@
+// Package patch implements the JSON merge patch of RFC 7386, used to record and replay document
+// history, and the JSON patch of RFC 6902 for partial updates.
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+)
+
+func decode(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// MergeDiff returns the merge patch turning original into modified. An empty original yields the
+// whole modified document. Null members of modified are left out, they read back as absent which
+// decodes to the same zero value.
+func MergeDiff(original, modified []byte) (json.RawMessage, error) {
+	a, err := decode(original)
+	if err != nil {
+		return nil, err
+	}
+	b, err := decode(modified)
+	if err != nil {
+		return nil, err
+	}
+	am, aok := a.(map[string]any)
+	bm, bok := b.(map[string]any)
+	if !aok || !bok {
+		return json.Marshal(b)
+	}
+	return json.Marshal(diffObjects(am, bm))
+}
+
+func diffObjects(a, b map[string]any) map[string]any {
+	out := make(map[string]any)
+	for k := range a {
+		if _, ok := b[k]; !ok {
+			out[k] = nil
+		}
+	}
+	for k, bv := range b {
+		av, ok := a[k]
+		if !ok {
+			if bv != nil {
+				out[k] = bv
+			}
+			continue
+		}
+		am, aok := av.(map[string]any)
+		bm, bok := bv.(map[string]any)
+		if aok && bok {
+			if d := diffObjects(am, bm); len(d) > 0 {
+				out[k] = d
+			}
+			continue
+		}
+		if !reflect.DeepEqual(av, bv) {
+			out[k] = bv
+		}
+	}
+	return out
+}
+
+// MergeApply applies a merge patch to doc, an empty doc is treated as absent
+func MergeApply(doc, patch []byte) (json.RawMessage, error) {
+	target, err := decode(doc)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(mergeValue(target, p))
+}
+
+func mergeValue(target, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = make(map[string]any)
+	}
+	for k, v := range pm {
+		if v == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = mergeValue(tm[k], v)
+	}
+	return tm
+}
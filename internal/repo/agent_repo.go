@@ -0,0 +1,162 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/db"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/audit"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	agentIdsKey = "agents:ids"
+	// agentIndexKey held the agent ids as one document before they moved to a set
+	agentIndexKey = "agents"
+)
+
+type AgentRepo interface {
+	GetHead(ctx context.Context, agentId string) (*model.AgentHead, error)
+	SaveHead(ctx context.Context, head *model.AgentHead) error
+	GetVersion(ctx context.Context, agentId string, version int) (*model.AgentVersion, error)
+	// SaveVersion stores a new version, a Conflict when the version was stored already
+	SaveVersion(ctx context.Context, version *model.AgentVersion) error
+	List(ctx context.Context) ([]string, error)
+	Close()
+}
+
+// RedisAgentRepo keeps the ids of the agents in a redis set and stores each version only when its
+// key is free, so concurrent writers of the same version or of different agents never overwrite
+// each other
+type RedisAgentRepo struct {
+	cfg        *config.Config
+	log        *logger.Logger
+	tr         trace.Tracer
+	headStore  db.RedisStore[model.AgentHead]
+	indexStore db.RedisStore[model.AgentIndex]
+	client     redis.UniversalClient
+}
+
+func agentVersionKey(agentId string, version int) string {
+	return "agent:" + agentId + ":version:" + strconv.Itoa(version)
+}
+
+func (ar *RedisAgentRepo) GetHead(ctx context.Context, agentId string) (*model.AgentHead, error) {
+	head, err := ar.headStore.Get(ctx, "agent:"+agentId)
+	return found(head, err, "agent id: %s not found", agentId)
+}
+
+func (ar *RedisAgentRepo) SaveHead(ctx context.Context, head *model.AgentHead) error {
+	if err := ar.headStore.Set(ctx, "agent:"+head.ID, head); err != nil {
+		return stored(err)
+	}
+	return stored(ar.client.SAdd(ctx, agentIdsKey, head.ID).Err())
+}
+
+func (ar *RedisAgentRepo) GetVersion(ctx context.Context, agentId string, version int) (*model.AgentVersion, error) {
+	data, err := ar.client.Get(ctx, agentVersionKey(agentId, version)).Bytes()
+	if err != nil {
+		return found[model.AgentVersion](nil, err, "agent id: %s has no version: %d", agentId, version)
+	}
+	var av model.AgentVersion
+	if err = json.Unmarshal(data, &av); err != nil {
+		return nil, errs.Internal(err)
+	}
+	return &av, nil
+}
+
+func (ar *RedisAgentRepo) SaveVersion(ctx context.Context, version *model.AgentVersion) error {
+	data, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	saved, err := ar.client.SetNX(ctx, agentVersionKey(version.AgentId, version.Version), data, 0).Result()
+	if err != nil {
+		return stored(err)
+	}
+	if !saved {
+		return errs.Conflict("agent id: %s version: %d already exists", version.AgentId, version.Version)
+	}
+	audit.Record(ctx, agentVersionKey(version.AgentId, version.Version), nil, data)
+	return nil
+}
+
+func (ar *RedisAgentRepo) List(ctx context.Context) ([]string, error) {
+	ids, err := ar.client.SMembers(ctx, agentIdsKey).Result()
+	if err != nil {
+		return nil, stored(err)
+	}
+	return ids, nil
+}
+
+// migrate moves the ids of the index document into the id set and drops the document
+func (ar *RedisAgentRepo) migrate(ctx context.Context) error {
+	index, err := ar.indexStore.Get(ctx, agentIndexKey)
+	if errors.Is(err, redis.Nil) || (err == nil && index == nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	members := make([]any, 0, len(index.Ids))
+	for _, id := range index.Ids {
+		members = append(members, id)
+	}
+	if len(members) > 0 {
+		if err = ar.client.SAdd(ctx, agentIdsKey, members...).Err(); err != nil {
+			return err
+		}
+	}
+	return ar.indexStore.Delete(ctx, agentIndexKey)
+}
+
+func (ar *RedisAgentRepo) Close() {
+	if err := ar.headStore.Close(); err != nil {
+		ar.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := ar.indexStore.Close(); err != nil {
+		ar.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := ar.client.Close(); err != nil {
+		ar.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewAgentRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (AgentRepo, error) {
+	headStore, err := db.NewRedisStore[model.AgentHead](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating agent redis store: %v", err)
+		return nil, err
+	}
+	indexStore, err := db.NewRedisStore[model.AgentIndex](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating agent index redis store: %v", err)
+		return nil, err
+	}
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting agent redis client: %v", err)
+		return nil, err
+	}
+
+	ar := &RedisAgentRepo{
+		cfg:        cfg,
+		log:        log,
+		tr:         tr,
+		headStore:  audited(headStore),
+		indexStore: indexStore,
+		client:     client,
+	}
+	if err = ar.migrate(ctx); err != nil {
+		log.Errorf("Error while migrating agent index: %v", err)
+		return nil, err
+	}
+	return ar, nil
+}
@@ -0,0 +1,157 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	auditIndexKey = "audit:entries"
+
+	auditPageSize = 200
+)
+
+// AuditRepo stores audit entries with a time to live of the retention. Entries are indexed by
+// time in sorted sets, one for all entries and one per interaction and per actor, which are
+// trimmed to the retention on every write.
+type AuditRepo interface {
+	Save(ctx context.Context, entry *model.AuditEntry, retention time.Duration) error
+	Query(ctx context.Context, query model.AuditQuery) ([]*model.AuditEntry, error)
+	Close()
+}
+
+type RedisAuditRepo struct {
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	client redis.UniversalClient
+}
+
+func auditEntryKey(id string) string {
+	return "audit:entry:" + id
+}
+
+func auditInteractionKey(interactionId string) string {
+	return "audit:interaction:" + interactionId
+}
+
+func auditActorKey(actor string) string {
+	return "audit:actor:" + actor
+}
+
+func (ar *RedisAuditRepo) Save(ctx context.Context, entry *model.AuditEntry, retention time.Duration) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	score := float64(entry.Time.UnixMilli())
+	expired := strconv.FormatInt(entry.Time.Add(-retention).UnixMilli(), 10)
+	indexes := []string{auditIndexKey, auditActorKey(entry.Actor)}
+	if entry.InteractionId != "" {
+		indexes = append(indexes, auditInteractionKey(entry.InteractionId))
+	}
+	// the keys live in different slots, so they are pipelined rather than written in a transaction
+	_, err = ar.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, auditEntryKey(entry.ID), data, retention)
+		for _, index := range indexes {
+			pipe.ZAdd(ctx, index, redis.Z{Score: score, Member: entry.ID})
+			pipe.ZRemRangeByScore(ctx, index, "-inf", "("+expired)
+			if index != auditIndexKey {
+				pipe.Expire(ctx, index, retention)
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// Query returns the entries matching the query, newest first. The most selective index is walked
+// backwards from To and the entries are filtered on the other criteria until Limit are found.
+func (ar *RedisAuditRepo) Query(ctx context.Context, query model.AuditQuery) ([]*model.AuditEntry, error) {
+	index := auditIndexKey
+	switch {
+	case query.InteractionId != "":
+		index = auditInteractionKey(query.InteractionId)
+	case query.Actor != "":
+		index = auditActorKey(query.Actor)
+	}
+	rng := &redis.ZRangeBy{Min: "-inf", Max: "+inf", Count: auditPageSize}
+	if !query.From.IsZero() {
+		rng.Min = strconv.FormatInt(query.From.UnixMilli(), 10)
+	}
+	if !query.To.IsZero() {
+		rng.Max = strconv.FormatInt(query.To.UnixMilli(), 10)
+	}
+
+	entries := make([]*model.AuditEntry, 0)
+	for len(entries) < query.Limit {
+		ids, err := ar.client.ZRevRangeByScore(ctx, index, rng).Result()
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			entry, err := ar.get(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			if entry == nil || (query.Actor != "" && entry.Actor != query.Actor) {
+				continue
+			}
+			if entries = append(entries, entry); len(entries) == query.Limit {
+				break
+			}
+		}
+		if len(ids) < auditPageSize {
+			break
+		}
+		rng.Offset += auditPageSize
+	}
+	return entries, nil
+}
+
+// get returns nil when the entry has expired while its id is still indexed
+func (ar *RedisAuditRepo) get(ctx context.Context, id string) (*model.AuditEntry, error) {
+	data, err := ar.client.Get(ctx, auditEntryKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry model.AuditEntry
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (ar *RedisAuditRepo) Close() {
+	err := ar.client.Close()
+	if err != nil {
+		ar.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewAuditRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (AuditRepo, error) {
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting audit redis client: %v", err)
+		return nil, err
+	}
+
+	return &RedisAuditRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		client: client,
+	}, nil
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/mangudaigb/dhauli-base/db"
+	"github.com/mangudaigb/state-service/internal/audit"
+	"github.com/redis/go-redis/v9"
+)
+
+// auditedStore records the writes of a store that is not written through the outbox in the audit
+// recorder of the operation, with the document each write replaced. Outside an audited operation
+// it writes like the store it wraps.
+type auditedStore[T any] struct {
+	db.RedisStore[T]
+}
+
+func audited[T any](store db.RedisStore[T]) db.RedisStore[T] {
+	if store == nil {
+		return nil
+	}
+	return &auditedStore[T]{RedisStore: store}
+}
+
+func (s *auditedStore[T]) Set(ctx context.Context, key string, v *T) error {
+	if audit.FromContext(ctx) == nil {
+		return s.RedisStore.Set(ctx, key, v)
+	}
+	before, err := s.current(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err = s.RedisStore.Set(ctx, key, v); err != nil {
+		return err
+	}
+	after, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	audit.Record(ctx, key, before, after)
+	return nil
+}
+
+func (s *auditedStore[T]) Delete(ctx context.Context, key string) error {
+	if audit.FromContext(ctx) == nil {
+		return s.RedisStore.Delete(ctx, key)
+	}
+	before, err := s.current(ctx, key)
+	if err != nil {
+		return err
+	}
+	if err = s.RedisStore.Delete(ctx, key); err != nil {
+		return err
+	}
+	if before != nil {
+		audit.Record(ctx, key, before, nil)
+	}
+	return nil
+}
+
+// current returns the json of the stored document, nil when there is none
+func (s *auditedStore[T]) current(ctx context.Context, key string) ([]byte, error) {
+	v, err := s.RedisStore.Get(ctx, key)
+	if errors.Is(err, redis.Nil) || (err == nil && v == nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
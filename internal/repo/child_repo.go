@@ -0,0 +1,76 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ChildRepo stores the links between interactions and their child interactions, under the parent
+// for the tree and under the child for the way back to the node it runs. Both are written through
+// the outbox so that a link is staged with the child and the parent graph in a unit of work.
+type ChildRepo interface {
+	Children(ctx context.Context, interactionId string) (*model.ChildSet, error)
+	Parent(ctx context.Context, interactionId string) (*model.ChildLink, error)
+	Link(ctx context.Context, link *model.ChildLink, evts ...*events.Event) error
+	Close()
+}
+
+func ChildSetKey(interactionId string) string {
+	return InteractionKey(interactionId) + ":children"
+}
+
+func ParentLinkKey(interactionId string) string {
+	return InteractionKey(interactionId) + ":parent"
+}
+
+type RedisChildRepo struct {
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	outbox OutboxRepo
+}
+
+// Children returns the child set of the interaction, an empty one when it has no children
+func (cr *RedisChildRepo) Children(ctx context.Context, interactionId string) (*model.ChildSet, error) {
+	set, err := load[model.ChildSet](ctx, cr.outbox, ChildSetKey(interactionId), "no children for interaction id: %s", interactionId)
+	if errors.Is(err, errs.ErrNotFound) {
+		return &model.ChildSet{InteractionId: interactionId}, nil
+	}
+	return set, err
+}
+
+// Parent returns the link of the interaction to its parent, a NotFound when it is not a child
+func (cr *RedisChildRepo) Parent(ctx context.Context, interactionId string) (*model.ChildLink, error) {
+	return load[model.ChildLink](ctx, cr.outbox, ParentLinkKey(interactionId), "interaction id: %s has no parent", interactionId)
+}
+
+// Link adds the child to the child set of the parent and records the parent of the child
+func (cr *RedisChildRepo) Link(ctx context.Context, link *model.ChildLink, evts ...*events.Event) error {
+	set, err := cr.Children(ctx, link.ParentId)
+	if err != nil {
+		return err
+	}
+	set.Children = append(set.Children, *link)
+	if err = cr.outbox.Set(ctx, ChildSetKey(link.ParentId), set); err != nil {
+		return err
+	}
+	return cr.outbox.Set(ctx, ParentLinkKey(link.ChildId), link, evts...)
+}
+
+func (cr *RedisChildRepo) Close() {}
+
+func NewChildRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer, outbox OutboxRepo) (ChildRepo, error) {
+	return &RedisChildRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		outbox: outbox,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/db"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	commandTTLDoneKey = "migration:command-ttl"
+	commandTTLLockKey = "migration:command-ttl:lock"
+)
+
+// CommandRepo marks the processed commands for as long as the topic may redeliver them
+type CommandRepo interface {
+	Get(ctx context.Context, commandId string) (*model.ProcessedCommand, error)
+	Save(ctx context.Context, cmd *model.ProcessedCommand) error
+	Close()
+}
+
+type RedisCommandRepo struct {
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	store  db.RedisStore[model.ProcessedCommand]
+	client redis.UniversalClient
+	ttl    time.Duration
+}
+
+func commandKey(commandId string) string {
+	return "command:" + commandId
+}
+
+func (cr *RedisCommandRepo) Get(ctx context.Context, commandId string) (*model.ProcessedCommand, error) {
+	cmd, err := cr.store.Get(ctx, commandKey(commandId))
+	return found(cmd, err, "command id: %s not processed", commandId)
+}
+
+func (cr *RedisCommandRepo) Save(ctx context.Context, cmd *model.ProcessedCommand) error {
+	if err := cr.store.Set(ctx, commandKey(cmd.ID), cmd); err != nil {
+		return stored(err)
+	}
+	return stored(cr.client.Expire(ctx, commandKey(cmd.ID), cr.ttl).Err())
+}
+
+// expire gives the marks saved before they had a ttl one
+func (cr *RedisCommandRepo) expire(ctx context.Context) error {
+	done, err := cr.client.Exists(ctx, commandTTLDoneKey).Result()
+	if err != nil || done > 0 {
+		return err
+	}
+	locked, err := cr.client.SetNX(ctx, commandTTLLockKey, time.Now().UTC().Format(time.RFC3339), 10*time.Minute).Result()
+	if err != nil || !locked {
+		return err
+	}
+	defer cr.client.Del(context.WithoutCancel(ctx), commandTTLLockKey)
+
+	err = scanKeys(ctx, cr.client, commandKey("*"), func(key string) error {
+		// NX leaves the marks saved with a ttl alone
+		return cr.client.ExpireNX(ctx, key, cr.ttl).Err()
+	})
+	if err != nil {
+		return err
+	}
+	return cr.client.Set(ctx, commandTTLDoneKey, time.Now().UTC().Format(time.RFC3339), 0).Err()
+}
+
+func (cr *RedisCommandRepo) Close() {
+	if err := cr.store.Close(); err != nil {
+		cr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := cr.client.Close(); err != nil {
+		cr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewCommandRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (CommandRepo, error) {
+	commandStore, err := db.NewRedisStore[model.ProcessedCommand](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating command redis store: %v", err)
+		return nil, err
+	}
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting command redis client: %v", err)
+		return nil, err
+	}
+
+	cr := &RedisCommandRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		store:  commandStore,
+		client: client,
+		ttl:    settings.GetKafka().ProcessedTTL,
+	}
+	if err = cr.expire(ctx); err != nil {
+		log.Errorf("Error while expiring processed commands: %v", err)
+		return nil, err
+	}
+	return cr, nil
+}
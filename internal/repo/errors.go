@@ -0,0 +1,48 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/redis/go-redis/v9"
+)
+
+// found returns the document read from a store. A missing key, which the store reports either as
+// redis.Nil or as a nil document, is a NotFound, a failure to reach redis is Unavailable and
+// anything else, like a document that does not decode, is Internal.
+func found[T any](v *T, err error, format string, args ...any) (*T, error) {
+	switch {
+	case err == nil && v != nil:
+		return v, nil
+	case err == nil, errors.Is(err, redis.Nil):
+		return nil, errs.NotFound(format, args...)
+	case errs.Typed(err):
+		return nil, err
+	case outage(err):
+		return nil, errs.Unavailable(err)
+	default:
+		return nil, errs.Internal(err)
+	}
+}
+
+// stored classifies the error of a write, errors of a kind are kept and anything else is an outage
+func stored(err error) error {
+	if err == nil || errs.Typed(err) {
+		return err
+	}
+	return errs.Unavailable(err)
+}
+
+func outage(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) ||
+		errors.Is(err, redis.ErrClosed) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF)
+}
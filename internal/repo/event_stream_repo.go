@@ -0,0 +1,186 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const liveChannel = "events:live"
+
+// appendScript adds an event to the stream unless its id was appended before, the outbox retries
+// an event that failed on another publisher and a stream reader must not see it twice. The seen
+// key expires with the stream.
+var appendScript = redis.NewScript(`
+if not redis.call("SET", KEYS[2], 1, "NX", "EX", ARGV[3]) then
+	return 0
+end
+redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[2], "*", "event", ARGV[1])
+redis.call("EXPIRE", KEYS[1], ARGV[3])
+return 1
+`)
+
+// EventStreamRepo keeps the recent events of every interaction in a redis stream, so any replica
+// can serve live updates and clients can resume from the last stream id they received. Events are
+// also broadcast on a pub/sub channel for subscribers watching many interactions, those get only
+// what is published while they listen.
+type EventStreamRepo interface {
+	Append(ctx context.Context, evt *events.Event) error
+	Last(ctx context.Context, interactionId string) (string, error)
+	Read(ctx context.Context, interactionId, after string, block time.Duration) ([]events.StreamEntry, error)
+	Broadcast(ctx context.Context, evt *events.Event) error
+	Listen(ctx context.Context) <-chan events.Event
+	Close()
+}
+
+type RedisEventStreamRepo struct {
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	client redis.UniversalClient
+	// reader serves the blocking reads of the subscribers, which hold a connection each until the
+	// block ends and would otherwise starve the appends of the pool
+	reader redis.UniversalClient
+	events settings.Events
+}
+
+func streamKey(interactionId string) string {
+	return InteractionKey(interactionId) + ":events"
+}
+
+func seenKey(interactionId, eventId string) string {
+	return streamKey(interactionId) + ":seen:" + eventId
+}
+
+// Append adds the event to the stream of its interaction once, the stream is capped and expires
+// when the interaction goes quiet
+func (esr *RedisEventStreamRepo) Append(ctx context.Context, evt *events.Event) error {
+	value, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	keys := []string{streamKey(evt.InteractionId), seenKey(evt.InteractionId, evt.ID)}
+	ttl := int64(esr.events.StreamTTL / time.Second)
+	return appendScript.Run(ctx, esr.client, keys, value, esr.events.StreamMaxLen, ttl).Err()
+}
+
+// Last returns the id of the newest entry, reading after it yields only events appended later
+func (esr *RedisEventStreamRepo) Last(ctx context.Context, interactionId string) (string, error) {
+	entries, err := esr.client.XRevRangeN(ctx, streamKey(interactionId), "+", "-", 1).Result()
+	if err != nil || len(entries) == 0 {
+		return "0-0", err
+	}
+	return entries[0].ID, nil
+}
+
+// Read waits up to block for entries after the given stream id
+func (esr *RedisEventStreamRepo) Read(ctx context.Context, interactionId, after string, block time.Duration) ([]events.StreamEntry, error) {
+	streams, err := esr.reader.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{streamKey(interactionId), after},
+		Count:   100,
+		Block:   block,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []events.StreamEntry
+	for _, stream := range streams {
+		for _, msg := range stream.Messages {
+			value, _ := msg.Values["event"].(string)
+			var evt events.Event
+			if err = json.Unmarshal([]byte(value), &evt); err != nil {
+				esr.log.Warnf("Skipping malformed stream entry: %s of interaction id: %s", msg.ID, interactionId)
+				continue
+			}
+			entries = append(entries, events.StreamEntry{StreamId: msg.ID, Event: evt})
+		}
+	}
+	return entries, nil
+}
+
+func (esr *RedisEventStreamRepo) Broadcast(ctx context.Context, evt *events.Event) error {
+	value, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	return esr.client.Publish(ctx, liveChannel, value).Err()
+}
+
+// Listen receives the broadcast events until ctx is cancelled
+func (esr *RedisEventStreamRepo) Listen(ctx context.Context) <-chan events.Event {
+	pubsub := esr.client.Subscribe(ctx, liveChannel)
+	out := make(chan events.Event)
+	go func() {
+		defer close(out)
+		defer func() {
+			if err := pubsub.Close(); err != nil {
+				esr.log.Warnf("Error while closing live event subscription: %v", err)
+			}
+		}()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				var evt events.Event
+				if err := json.Unmarshal([]byte(msg.Payload), &evt); err != nil {
+					esr.log.Warnf("Skipping malformed live event: %v", err)
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- evt:
+				}
+			}
+		}
+	}()
+	return out
+}
+
+func (esr *RedisEventStreamRepo) Close() {
+	if err := esr.reader.Close(); err != nil {
+		esr.log.Errorf("Error while closing redis reader client: %v", err)
+	}
+	if err := esr.client.Close(); err != nil {
+		esr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewEventStreamRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (EventStreamRepo, error) {
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting event stream redis client: %v", err)
+		return nil, err
+	}
+	reader, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting event stream redis reader client: %v", err)
+		_ = client.Close()
+		return nil, err
+	}
+
+	return &RedisEventStreamRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		client: client,
+		reader: reader,
+		events: settings.GetEvents(),
+	}, nil
+}
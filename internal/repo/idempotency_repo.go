@@ -0,0 +1,103 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// reserveAttempts bounds the retries of a reservation racing with the expiry of the existing one
+const reserveAttempts = 3
+
+// IdempotencyRepo stores the responses of requests made with an idempotency key. A key is reserved
+// with a pending response before the request is served, so that a concurrent retry finds it.
+type IdempotencyRepo interface {
+	// Reserve stores a pending response for the key unless the key is taken, in which case the
+	// stored response is returned instead
+	Reserve(ctx context.Context, pending *model.IdempotentResponse, ttl time.Duration) (*model.IdempotentResponse, bool, error)
+	Complete(ctx context.Context, response *model.IdempotentResponse, ttl time.Duration) error
+	Release(ctx context.Context, key string) error
+	Close()
+}
+
+type RedisIdempotencyRepo struct {
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	client redis.UniversalClient
+}
+
+func idempotencyKey(key string) string {
+	return "idempotency:" + key
+}
+
+func (ir *RedisIdempotencyRepo) Reserve(ctx context.Context, pending *model.IdempotentResponse, ttl time.Duration) (*model.IdempotentResponse, bool, error) {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return nil, false, err
+	}
+	for range reserveAttempts {
+		reserved, err := ir.client.SetNX(ctx, idempotencyKey(pending.Key), data, ttl).Result()
+		if err != nil {
+			return nil, false, stored(err)
+		}
+		if reserved {
+			return pending, true, nil
+		}
+		raw, err := ir.client.Get(ctx, idempotencyKey(pending.Key)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			// the existing reservation expired in between
+			continue
+		}
+		if err != nil {
+			return nil, false, stored(err)
+		}
+		var existing model.IdempotentResponse
+		if err = json.Unmarshal(raw, &existing); err != nil {
+			return nil, false, err
+		}
+		return &existing, false, nil
+	}
+	return nil, false, stored(errors.New("idempotency key reservation kept racing with its expiry"))
+}
+
+func (ir *RedisIdempotencyRepo) Complete(ctx context.Context, response *model.IdempotentResponse, ttl time.Duration) error {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return stored(ir.client.Set(ctx, idempotencyKey(response.Key), data, ttl).Err())
+}
+
+func (ir *RedisIdempotencyRepo) Release(ctx context.Context, key string) error {
+	return stored(ir.client.Del(ctx, idempotencyKey(key)).Err())
+}
+
+func (ir *RedisIdempotencyRepo) Close() {
+	err := ir.client.Close()
+	if err != nil {
+		ir.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewIdempotencyRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (IdempotencyRepo, error) {
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting idempotency redis client: %v", err)
+		return nil, err
+	}
+
+	return &RedisIdempotencyRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		client: client,
+	}, nil
+}
@@ -2,63 +2,84 @@ package repo
 
 import (
 	"context"
+	"strings"
 
 	"github.com/mangudaigb/dhauli-base/config"
-	"github.com/mangudaigb/dhauli-base/db"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type InteractionRepo interface {
 	Get(ctx context.Context, iid string) (*runtime.Interaction, error)
-	Save(ctx context.Context, interaction *runtime.Interaction) error
-	Update(ctx context.Context, interaction *runtime.Interaction) error
-	Delete(ctx context.Context, iid string) error
+	Save(ctx context.Context, interaction *runtime.Interaction, evts ...*events.Event) error
+	Update(ctx context.Context, interaction *runtime.Interaction, evts ...*events.Event) error
+	Delete(ctx context.Context, iid string, evts ...*events.Event) error
+	History(ctx context.Context, iid string) ([]model.HistoryRecord, error)
+	Revision(ctx context.Context, iid string) (int64, error)
 	Close()
 }
 
+// InteractionKey is the key of the interaction document and the prefix of every other key of the
+// interaction. The id is a hash tag, so on a cluster all keys of an interaction share a slot and a
+// unit of work within one interaction commits in a single transaction.
+func InteractionKey(iid string) string {
+	return "interaction:{" + iid + "}"
+}
+
+// InteractionOf returns the id of the interaction a key belongs to
+func InteractionOf(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, "interaction:{")
+	if !ok {
+		return "", false
+	}
+	iid, _, ok := strings.Cut(rest, "}")
+	return iid, ok
+}
+
 type RedisInteractionRepo struct {
-	cfg   *config.Config
-	log   *logger.Logger
-	tr    trace.Tracer
-	store db.RedisStore[runtime.Interaction]
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	outbox OutboxRepo
 }
 
 func (ir *RedisInteractionRepo) Get(ctx context.Context, iid string) (*runtime.Interaction, error) {
-	return ir.store.Get(ctx, "interaction:"+iid)
+	return load[runtime.Interaction](ctx, ir.outbox, InteractionKey(iid), "interaction id: %s not found", iid)
 }
 
-func (ir *RedisInteractionRepo) Save(ctx context.Context, interaction *runtime.Interaction) error {
-	return ir.store.Set(ctx, "interaction:"+interaction.ID, interaction)
+// Save writes the interaction through the outbox, which commits the events passed along and
+// records the write in the history of the interaction
+func (ir *RedisInteractionRepo) Save(ctx context.Context, interaction *runtime.Interaction, evts ...*events.Event) error {
+	return ir.outbox.Set(ctx, InteractionKey(interaction.ID), interaction, evts...)
 }
 
-func (ir *RedisInteractionRepo) Update(ctx context.Context, interaction *runtime.Interaction) error {
-	return ir.store.Set(ctx, "interaction:"+interaction.ID, interaction)
+func (ir *RedisInteractionRepo) Update(ctx context.Context, interaction *runtime.Interaction, evts ...*events.Event) error {
+	return ir.outbox.Set(ctx, InteractionKey(interaction.ID), interaction, evts...)
 }
 
-func (ir *RedisInteractionRepo) Delete(ctx context.Context, iid string) error {
-	return ir.store.Delete(ctx, "interaction:"+iid)
+func (ir *RedisInteractionRepo) Delete(ctx context.Context, iid string, evts ...*events.Event) error {
+	return ir.outbox.Delete(ctx, InteractionKey(iid), evts...)
 }
 
-func (ir *RedisInteractionRepo) Close() {
-	err := ir.store.Close()
-	if err != nil {
-		ir.log.Errorf("Error while closing redis store: %v", err)
-	}
+// History returns every recorded write of the interaction document, oldest first
+func (ir *RedisInteractionRepo) History(ctx context.Context, iid string) ([]model.HistoryRecord, error) {
+	return ir.outbox.History(ctx, InteractionKey(iid))
 }
 
-func NewInteractionRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (InteractionRepo, error) {
-	interactionStore, err := db.NewRedisStore[runtime.Interaction](ctx, cfg, log)
-	if err != nil {
-		log.Errorf("Error while creating interaction redis store: %v", err)
-		return nil, err
-	}
+func (ir *RedisInteractionRepo) Revision(ctx context.Context, iid string) (int64, error) {
+	return ir.outbox.Revision(ctx, InteractionKey(iid))
+}
+
+func (ir *RedisInteractionRepo) Close() {}
 
+func NewInteractionRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer, outbox OutboxRepo) (InteractionRepo, error) {
 	return &RedisInteractionRepo{
-		cfg:   cfg,
-		log:   log,
-		tr:    tr,
-		store: interactionStore,
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		outbox: outbox,
 	}, nil
 }
@@ -0,0 +1,159 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	keyLayoutDoneKey = "migration:interaction-hash-tag"
+	keyLayoutLockKey = "migration:interaction-hash-tag:lock"
+)
+
+// taggedKey returns the name a key written before the interaction id became a hash tag has now, ok
+// is false for a key that is not one of those
+func taggedKey(key string) (string, bool) {
+	for _, wrap := range []func(string) string{outboxKey, historyKey} {
+		prefix := strings.TrimSuffix(wrap(""), "}")
+		if rest, ok := strings.CutPrefix(key, prefix); ok {
+			inner, ok := strings.CutSuffix(rest, "}")
+			if !ok {
+				return "", false
+			}
+			tagged, ok := taggedKey(inner)
+			if !ok {
+				return "", false
+			}
+			return wrap(tagged), true
+		}
+	}
+	rest, ok := strings.CutPrefix(key, "interaction:")
+	if !ok || strings.HasPrefix(rest, "{") {
+		return "", false
+	}
+	iid, tail, found := strings.Cut(rest, ":")
+	if found {
+		tail = ":" + tail
+	}
+	return InteractionKey(iid) + tail, true
+}
+
+// migrateKeyLayout renames the keys of the interactions written before the interaction id became a
+// hash tag, with their outbox and history lists and the outbox index. The first replica to start
+// runs it and marks it done, replicas writing the old layout have to be stopped before.
+func (obr *RedisOutboxRepo) migrateKeyLayout(ctx context.Context) error {
+	done, err := obr.client.Exists(ctx, keyLayoutDoneKey).Result()
+	if err != nil || done > 0 {
+		return err
+	}
+	locked, err := obr.client.SetNX(ctx, keyLayoutLockKey, time.Now().UTC().Format(time.RFC3339), 10*time.Minute).Result()
+	if err != nil || !locked {
+		return err
+	}
+	defer obr.client.Del(context.WithoutCancel(ctx), keyLayoutLockKey)
+
+	moved := 0
+	for _, pattern := range []string{"interaction:*", "outbox:{interaction:*", "history:{interaction:*"} {
+		err = scanKeys(ctx, obr.client, pattern, func(key string) error {
+			tagged, ok := taggedKey(key)
+			if !ok {
+				return nil
+			}
+			if err := obr.move(ctx, key, tagged); err != nil {
+				return err
+			}
+			moved++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	okeys, err := obr.client.SMembers(ctx, outboxIndexKey).Result()
+	if err != nil {
+		return err
+	}
+	for _, okey := range okeys {
+		if tagged, ok := taggedKey(okey); ok {
+			if err = obr.client.SAdd(ctx, outboxIndexKey, tagged).Err(); err != nil {
+				return err
+			}
+			if err = obr.client.SRem(ctx, outboxIndexKey, okey).Err(); err != nil {
+				return err
+			}
+		}
+	}
+	if moved > 0 {
+		obr.log.Infof("Moved %d interaction keys to the hash tagged layout", moved)
+	}
+	return obr.client.Set(ctx, keyLayoutDoneKey, time.Now().UTC().Format(time.RFC3339), 0).Err()
+}
+
+// scanKeys calls fn with every key matching pattern, on every master of a cluster
+func scanKeys(ctx context.Context, client redis.UniversalClient, pattern string, fn func(key string) error) error {
+	cluster, ok := client.(*redis.ClusterClient)
+	if !ok {
+		return scanNode(ctx, client, pattern, fn)
+	}
+	var mu sync.Mutex
+	return cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		return scanNode(ctx, node, pattern, func(key string) error {
+			mu.Lock()
+			defer mu.Unlock()
+			return fn(key)
+		})
+	})
+}
+
+func scanNode(ctx context.Context, client redis.Cmdable, pattern string, fn func(key string) error) error {
+	iter := client.Scan(ctx, 0, pattern, 500).Iterator()
+	for iter.Next(ctx) {
+		if err := fn(iter.Val()); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+// move renames the key, keeping its ttl. A key already written under the new name is kept and the
+// old one left for inspection. On a cluster the names are in different slots, the key is copied to
+// the new name and deleted instead.
+func (obr *RedisOutboxRepo) move(ctx context.Context, from, to string) error {
+	if !obr.cluster {
+		renamed, err := obr.client.RenameNX(ctx, from, to).Result()
+		if err != nil && strings.Contains(err.Error(), "no such key") {
+			return nil
+		}
+		if err == nil && !renamed {
+			obr.log.Warnf("Keeping key: %s, it was already written as: %s", from, to)
+		}
+		return err
+	}
+	dump, err := obr.client.Dump(ctx, from).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ttl, err := obr.client.PTTL(ctx, from).Result()
+	if err != nil {
+		return err
+	}
+	if ttl < 0 {
+		ttl = 0
+	}
+	if err = obr.client.Restore(ctx, to, ttl, dump).Err(); err != nil {
+		if strings.HasPrefix(err.Error(), "BUSYKEY") {
+			obr.log.Warnf("Keeping key: %s, it was already written as: %s", from, to)
+			return nil
+		}
+		return err
+	}
+	return obr.client.Del(ctx, from).Err()
+}
@@ -0,0 +1,152 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/db"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	mcpConnectionRefsKey = "mcp:connection:refs"
+	// mcpConnectionIndexKey held the refs as one document before they moved to a set
+	mcpConnectionIndexKey = "mcp:connections"
+)
+
+type McpConnectionRepo interface {
+	Get(ctx context.Context, interactionId, workflowId, mcpId string) (*model.McpConnection, error)
+	Save(ctx context.Context, conn *model.McpConnection) error
+	Delete(ctx context.Context, interactionId, workflowId, mcpId string) error
+	List(ctx context.Context) ([]model.McpConnectionRef, error)
+	Close()
+}
+
+// RedisMcpConnectionRepo keeps every connection in its own document and the refs of all of them
+// in a redis set, so concurrent saves and deletes of different connections never overwrite each
+// other's entry
+type RedisMcpConnectionRepo struct {
+	cfg        *config.Config
+	log        *logger.Logger
+	tr         trace.Tracer
+	store      db.RedisStore[model.McpConnection]
+	indexStore db.RedisStore[model.McpConnectionIndex]
+	client     redis.UniversalClient
+}
+
+func mcpConnectionKey(interactionId, workflowId, mcpId string) string {
+	return InteractionKey(interactionId) + ":workflow:" + workflowId + ":mcp:" + mcpId + ":connection"
+}
+
+// refMember is the member of a connection in the ref set, the json of its ref
+func refMember(interactionId, workflowId, mcpId string) string {
+	member, _ := json.Marshal(model.McpConnectionRef{InteractionId: interactionId, WorkflowId: workflowId, McpId: mcpId})
+	return string(member)
+}
+
+func (cr *RedisMcpConnectionRepo) Get(ctx context.Context, interactionId, workflowId, mcpId string) (*model.McpConnection, error) {
+	conn, err := cr.store.Get(ctx, mcpConnectionKey(interactionId, workflowId, mcpId))
+	return found(conn, err, "no connection for mcp id: %s", mcpId)
+}
+
+func (cr *RedisMcpConnectionRepo) Save(ctx context.Context, conn *model.McpConnection) error {
+	if err := cr.store.Set(ctx, mcpConnectionKey(conn.InteractionId, conn.WorkflowId, conn.McpId), conn); err != nil {
+		return stored(err)
+	}
+	return stored(cr.client.SAdd(ctx, mcpConnectionRefsKey, refMember(conn.InteractionId, conn.WorkflowId, conn.McpId)).Err())
+}
+
+// Delete removes the connection, deleting one that does not exist is not an error
+func (cr *RedisMcpConnectionRepo) Delete(ctx context.Context, interactionId, workflowId, mcpId string) error {
+	if err := cr.store.Delete(ctx, mcpConnectionKey(interactionId, workflowId, mcpId)); err != nil && !errors.Is(err, redis.Nil) {
+		return stored(err)
+	}
+	return stored(cr.client.SRem(ctx, mcpConnectionRefsKey, refMember(interactionId, workflowId, mcpId)).Err())
+}
+
+func (cr *RedisMcpConnectionRepo) List(ctx context.Context) ([]model.McpConnectionRef, error) {
+	members, err := cr.client.SMembers(ctx, mcpConnectionRefsKey).Result()
+	if err != nil {
+		return nil, stored(err)
+	}
+	refs := make([]model.McpConnectionRef, 0, len(members))
+	for _, member := range members {
+		var ref model.McpConnectionRef
+		if err = json.Unmarshal([]byte(member), &ref); err != nil {
+			cr.log.Warnf("Skipping malformed mcp connection ref: %s", member)
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// migrate moves the refs of the index document into the ref set and drops the document
+func (cr *RedisMcpConnectionRepo) migrate(ctx context.Context) error {
+	index, err := cr.indexStore.Get(ctx, mcpConnectionIndexKey)
+	if errors.Is(err, redis.Nil) || (err == nil && index == nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	members := make([]any, 0, len(index.Refs))
+	for _, ref := range index.Refs {
+		members = append(members, refMember(ref.InteractionId, ref.WorkflowId, ref.McpId))
+	}
+	if len(members) > 0 {
+		if err = cr.client.SAdd(ctx, mcpConnectionRefsKey, members...).Err(); err != nil {
+			return err
+		}
+	}
+	return cr.indexStore.Delete(ctx, mcpConnectionIndexKey)
+}
+
+func (cr *RedisMcpConnectionRepo) Close() {
+	if err := cr.store.Close(); err != nil {
+		cr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := cr.indexStore.Close(); err != nil {
+		cr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := cr.client.Close(); err != nil {
+		cr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewMcpConnectionRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (McpConnectionRepo, error) {
+	connStore, err := db.NewRedisStore[model.McpConnection](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating mcp connection redis store: %v", err)
+		return nil, err
+	}
+	indexStore, err := db.NewRedisStore[model.McpConnectionIndex](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating mcp connection index redis store: %v", err)
+		return nil, err
+	}
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting mcp connection redis client: %v", err)
+		return nil, err
+	}
+
+	cr := &RedisMcpConnectionRepo{
+		cfg:        cfg,
+		log:        log,
+		tr:         tr,
+		store:      audited(connStore),
+		indexStore: indexStore,
+		client:     client,
+	}
+	if err = cr.migrate(ctx); err != nil {
+		log.Errorf("Error while migrating mcp connection index: %v", err)
+		return nil, err
+	}
+	return cr, nil
+}
@@ -4,61 +4,76 @@ import (
 	"context"
 
 	"github.com/mangudaigb/dhauli-base/config"
-	"github.com/mangudaigb/dhauli-base/db"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type MCPRepo interface {
 	Get(ctx context.Context, interactionId, workflowId string, mcpId string) (*runtime.MCP, error)
-	Save(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) error
-	Update(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) error
-	Delete(ctx context.Context, interactionId, workflowId, mcpId string) error
+	GetAll(ctx context.Context, interactionId, workflowId string, mcpIds []string) ([]*runtime.MCP, error)
+	Save(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP, evts ...*events.Event) error
+	Update(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP, evts ...*events.Event) error
+	Delete(ctx context.Context, interactionId, workflowId, mcpId string, evts ...*events.Event) error
+	Revision(ctx context.Context, interactionId, workflowId, mcpId string) (int64, error)
+	History(ctx context.Context, interactionId, workflowId, mcpId string) ([]model.HistoryRecord, error)
 	Close()
 }
 
+func McpKey(interactionId, workflowId, mcpId string) string {
+	return InteractionKey(interactionId) + ":workflow:" + workflowId + ":mcp:" + mcpId
+}
+
 type RedisMCPRepo struct {
-	cfg   *config.Config
-	log   *logger.Logger
-	tr    trace.Tracer
-	store db.RedisStore[runtime.MCP]
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	outbox OutboxRepo
 }
 
 func (mr *RedisMCPRepo) Get(ctx context.Context, interactionId string, workflowId string, mcpId string) (*runtime.MCP, error) {
-	return mr.store.Get(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":mcp:"+mcpId)
+	return load[runtime.MCP](ctx, mr.outbox, McpKey(interactionId, workflowId, mcpId), "mcp id: %s not found", mcpId)
 }
 
-func (mr *RedisMCPRepo) Save(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) error {
-	return mr.store.Set(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":mcp:"+mcp.ID, mcp)
+// GetAll reads the mcps in one round trip, in the order of mcpIds with nil for a missing mcp
+func (mr *RedisMCPRepo) GetAll(ctx context.Context, interactionId, workflowId string, mcpIds []string) ([]*runtime.MCP, error) {
+	keys := make([]string, len(mcpIds))
+	for i, mcpId := range mcpIds {
+		keys[i] = McpKey(interactionId, workflowId, mcpId)
+	}
+	return loadAll[runtime.MCP](ctx, mr.outbox, keys)
 }
 
-func (mr *RedisMCPRepo) Update(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) error {
-	return mr.store.Set(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":mcp:"+mcp.ID, mcp)
+func (mr *RedisMCPRepo) Save(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP, evts ...*events.Event) error {
+	return mr.outbox.Set(ctx, McpKey(interactionId, workflowId, mcp.ID), mcp, evts...)
 }
 
-func (mr *RedisMCPRepo) Delete(ctx context.Context, interactionId, workflowId string, mcpId string) error {
-	return mr.store.Delete(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":mcp:"+mcpId)
+func (mr *RedisMCPRepo) Update(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP, evts ...*events.Event) error {
+	return mr.outbox.Set(ctx, McpKey(interactionId, workflowId, mcp.ID), mcp, evts...)
 }
 
-func (mr *RedisMCPRepo) Close() {
-	err := mr.store.Close()
-	if err != nil {
-		mr.log.Errorf("Error while closing redis store: %v", err)
-	}
+func (mr *RedisMCPRepo) Delete(ctx context.Context, interactionId, workflowId string, mcpId string, evts ...*events.Event) error {
+	return mr.outbox.Delete(ctx, McpKey(interactionId, workflowId, mcpId), evts...)
 }
 
-func NewMcpRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (MCPRepo, error) {
-	mcpStore, err := db.NewRedisStore[runtime.MCP](ctx, cfg, log)
-	if err != nil {
-		log.Errorf("Error while creating mcp redis store: %v", err)
-		return nil, err
-	}
+func (mr *RedisMCPRepo) Revision(ctx context.Context, interactionId, workflowId, mcpId string) (int64, error) {
+	return mr.outbox.Revision(ctx, McpKey(interactionId, workflowId, mcpId))
+}
+
+// History returns the recorded writes of the mcp, oldest first
+func (mr *RedisMCPRepo) History(ctx context.Context, interactionId, workflowId, mcpId string) ([]model.HistoryRecord, error) {
+	return mr.outbox.History(ctx, McpKey(interactionId, workflowId, mcpId))
+}
+
+func (mr *RedisMCPRepo) Close() {}
 
+func NewMcpRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer, outbox OutboxRepo) (MCPRepo, error) {
 	return &RedisMCPRepo{
-		cfg:   cfg,
-		log:   log,
-		tr:    tr,
-		store: mcpStore,
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		outbox: outbox,
 	}, nil
 }
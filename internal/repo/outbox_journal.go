@@ -0,0 +1,264 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
+)
+
+const unitIndexKey = "outbox:units"
+
+func unitKey(id string) string {
+	return "outbox:unit:{" + id + "}"
+}
+
+// unitJournal records a unit of work spread over several cluster slots while it commits. It holds
+// the documents the keys had before the unit and the events of the writes, so that Recover can
+// finish or undo a unit whose committer died on the way.
+type unitJournal struct {
+	Id     string         `json:"id"`
+	Time   time.Time      `json:"time"`
+	Writes []journalWrite `json:"writes"`
+}
+
+type journalWrite struct {
+	Key      string            `json:"key"`
+	Revision int64             `json:"revision"`
+	Previous []byte            `json:"previous"`
+	Events   []json.RawMessage `json:"events,omitempty"`
+}
+
+// commitSpread commits a unit of work whose keys live in several slots of a cluster, which no single
+// transaction can write. The documents of the keys at the expected revisions are read first and kept
+// in a journal with the events. Every key is then committed on its own expecting its revision with
+// its events held back, so a unit failing on a later key is rolled back before any of its events
+// reach the relay. Once every key is written the events go to their outboxes and the journal is
+// dropped. Readers may see the keys written so far while the unit commits.
+func (obr *RedisOutboxRepo) commitSpread(ctx context.Context, writes []*keyWrite) error {
+	journal := &unitJournal{Id: uuid.NewString(), Time: time.Now().UTC()}
+	if err := obr.snapshot(ctx, journal, writes); err != nil {
+		return err
+	}
+	data, err := json.Marshal(journal)
+	if err != nil {
+		return err
+	}
+	_, err = obr.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, unitKey(journal.Id), data, 0)
+		pipe.SAdd(ctx, unitIndexKey, journal.Id)
+		return nil
+	})
+	if err != nil {
+		return stored(err)
+	}
+	written := make([]bool, len(writes))
+	for i, write := range writes {
+		if obr.beforeSpreadWrite != nil {
+			obr.beforeSpreadWrite(write.key)
+		}
+		if err = obr.commit(ctx, journal.Id, false, write); err != nil {
+			obr.undo(ctx, journal, written)
+			obr.drop(ctx, journal.Id)
+			return err
+		}
+		written[i] = true
+	}
+	// a unit that took longer than the recovery timeout may have been settled by the relay already
+	exists, err := obr.client.Exists(ctx, unitKey(journal.Id)).Result()
+	if err != nil {
+		return stored(err)
+	}
+	if exists == 0 {
+		return errs.Conflict("unit of work: %s was recovered while it committed", journal.Id)
+	}
+	if err = obr.publish(ctx, journal); err != nil {
+		// the state is committed, the journal stays for Recover to publish the events
+		obr.log.Errorf("Error while publishing the events of unit of work: %s by error: %v", journal.Id, err)
+	}
+	return nil
+}
+
+// snapshot checks every key is still at the revision of its write and records its document in the
+// journal. The document and revision of a key are read in one transaction of its slot.
+func (obr *RedisOutboxRepo) snapshot(ctx context.Context, journal *unitJournal, writes []*keyWrite) error {
+	docs := make([]*redis.StringCmd, len(writes))
+	heads := make([]*redis.StringCmd, len(writes))
+	_, err := obr.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, write := range writes {
+			docs[i] = pipe.Get(ctx, write.key)
+			heads[i] = pipe.LIndex(ctx, historyKey(write.key), -1)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return stored(err)
+	}
+	for i, write := range writes {
+		revision, err := versionOf(heads[i])
+		if err != nil {
+			return stored(err)
+		}
+		if revision != write.revision {
+			return ErrRevisionConflict
+		}
+		previous, err := docs[i].Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return stored(err)
+		}
+		events := make([]json.RawMessage, len(write.values))
+		for j, value := range write.values {
+			events[j] = value.([]byte)
+		}
+		journal.Writes = append(journal.Writes, journalWrite{Key: write.key, Revision: write.revision, Previous: previous, Events: events})
+	}
+	return nil
+}
+
+// publish appends the held back events of the unit to the outboxes of their keys and drops the
+// journal. Events appended before a failure are appended again when the unit is recovered, the
+// relay delivers at least once anyway.
+func (obr *RedisOutboxRepo) publish(ctx context.Context, journal *unitJournal) error {
+	var outboxes []string
+	_, err := obr.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, write := range journal.Writes {
+			if len(write.Events) == 0 {
+				continue
+			}
+			values := make([]any, len(write.Events))
+			for i, value := range write.Events {
+				values[i] = []byte(value)
+			}
+			pipe.RPush(ctx, outboxKey(write.Key), values...)
+			outboxes = append(outboxes, outboxKey(write.Key))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err = obr.index(ctx, outboxes); err != nil {
+		return err
+	}
+	obr.drop(ctx, journal.Id)
+	return nil
+}
+
+// undo writes the keys the unit wrote back to their previous documents, newest first. A key that
+// was written again since is left alone.
+func (obr *RedisOutboxRepo) undo(ctx context.Context, journal *unitJournal, written []bool) {
+	for i := len(journal.Writes) - 1; i >= 0; i-- {
+		if !written[i] {
+			continue
+		}
+		write := journal.Writes[i]
+		restore := &keyWrite{key: write.Key, data: write.Previous, expect: true, revision: write.Revision + 1}
+		if err := obr.commit(ctx, "", false, restore); err != nil {
+			obr.log.Errorf("Error while rolling back key: %s of unit of work: %s by error: %v", write.Key, journal.Id, err)
+		}
+	}
+}
+
+func (obr *RedisOutboxRepo) drop(ctx context.Context, id string) {
+	_, err := obr.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, unitKey(id))
+		pipe.SRem(ctx, unitIndexKey, id)
+		return nil
+	})
+	if err != nil {
+		obr.log.Warnf("Error while dropping journal of unit of work: %s by error: %v", id, err)
+	}
+}
+
+// Recover settles the units of work spread over several slots that were left unfinished for longer
+// than olderThan, when their committer died. A unit whose keys were all written gets its events
+// published, a unit that stopped half way is rolled back.
+func (obr *RedisOutboxRepo) Recover(ctx context.Context, olderThan time.Duration) error {
+	ids, err := obr.client.SMembers(ctx, unitIndexKey).Result()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		data, err := obr.client.Get(ctx, unitKey(id)).Bytes()
+		if errors.Is(err, redis.Nil) {
+			obr.drop(ctx, id)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		var journal unitJournal
+		if err = json.Unmarshal(data, &journal); err != nil {
+			obr.log.Errorf("Dropping malformed journal of unit of work: %s: %v", id, err)
+			obr.drop(ctx, id)
+			continue
+		}
+		if time.Since(journal.Time) < olderThan {
+			continue
+		}
+		written := make([]bool, len(journal.Writes))
+		complete := true
+		for i, write := range journal.Writes {
+			record, err := obr.record(ctx, write.Key, write.Revision+1)
+			if err != nil {
+				return err
+			}
+			written[i] = record != nil && record.Unit == journal.Id
+			complete = complete && written[i]
+		}
+		if complete {
+			obr.log.Warnf("Publishing the events of unit of work: %s left unpublished since %v", id, journal.Time)
+			if err = obr.publish(ctx, &journal); err != nil {
+				return err
+			}
+			continue
+		}
+		obr.log.Warnf("Rolling back unit of work: %s left unfinished since %v", id, journal.Time)
+		obr.undo(ctx, &journal, written)
+		obr.drop(ctx, id)
+	}
+	return nil
+}
+
+// record returns the history record of the key at version, nil when the history does not hold it
+func (obr *RedisOutboxRepo) record(ctx context.Context, key string, version int64) (*model.HistoryRecord, error) {
+	first, err := versionOf(obr.client.LIndex(ctx, historyKey(key), 0))
+	if err != nil || first == 0 || version < first {
+		return nil, err
+	}
+	value, err := obr.client.LIndex(ctx, historyKey(key), version-first).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var record model.HistoryRecord
+	if err = json.Unmarshal(value, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// versionOf reads the version of the history record a command returned, 0 when there is none
+func versionOf(cmd *redis.StringCmd) (int64, error) {
+	value, err := cmd.Bytes()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var record struct {
+		Version int64 `json:"version"`
+	}
+	if err = json.Unmarshal(value, &record); err != nil {
+		return 0, err
+	}
+	return record.Version, nil
+}
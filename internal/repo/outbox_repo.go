@@ -0,0 +1,581 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/audit"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/patch"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	outboxIndexKey = "outbox:keys"
+	outboxLockKey  = "outbox:relay:lock"
+
+	maxCommitAttempts = 10
+)
+
+// OutboxRepo commits a state write together with the events it produces and a record of the write
+// in the history log of the key. The outbox and history lists of a key share its hash tag, or are
+// tagged with the key when it has none, so a key and its lists land in the same slot and are written
+// in one MULTI/EXEC even on a cluster. The outbox lists holding events are tracked in an index set
+// that the relay walks. The repos writing through the outbox read through it as well, and leave
+// closing it to its owner.
+type OutboxRepo interface {
+	Set(ctx context.Context, key string, value any, evts ...*events.Event) error
+	Delete(ctx context.Context, key string, evts ...*events.Event) error
+	Commit(ctx context.Context, uow *UnitOfWork) error
+	Recover(ctx context.Context, olderThan time.Duration) error
+	History(ctx context.Context, key string) ([]model.HistoryRecord, error)
+	Revision(ctx context.Context, key string) (int64, error)
+	Load(ctx context.Context, keys ...string) ([][]byte, error)
+	Keys(ctx context.Context) ([]string, error)
+	Peek(ctx context.Context, outboxKey string, n int) ([]events.Event, error)
+	Ack(ctx context.Context, outboxKey string, n int) error
+	Release(ctx context.Context, outboxKey string) error
+	Size(ctx context.Context, outboxKey string) (int64, error)
+	Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, owner string) error
+	Close()
+}
+
+type RedisOutboxRepo struct {
+	cfg     *config.Config
+	log     *logger.Logger
+	tr      trace.Tracer
+	client  redis.UniversalClient
+	cluster bool
+	history settings.History
+	// beforeSpreadWrite runs before each key of a unit spread over several slots is committed
+	beforeSpreadWrite func(key string)
+}
+
+// ErrRevisionConflict is returned when a write expecting a revision finds the key at another one
+var ErrRevisionConflict = errs.Conflict("revision conflict")
+
+type revisionKey struct{ key string }
+
+// ExpectRevision makes the next commit of key fail with ErrRevisionConflict unless the key is still
+// at revision. The revision of a key is the version of the last write in its history.
+func ExpectRevision(ctx context.Context, key string, revision int64) context.Context {
+	return context.WithValue(ctx, revisionKey{key}, revision)
+}
+
+func outboxKey(key string) string {
+	if hashTag(key) != key {
+		return "outbox:" + key
+	}
+	return "outbox:{" + key + "}"
+}
+
+func historyKey(key string) string {
+	if hashTag(key) != key {
+		return "history:" + key
+	}
+	return "history:{" + key + "}"
+}
+
+// hashTag returns the part of the key a cluster hashes, the first non-empty {...} or the whole key
+func hashTag(key string) string {
+	if start := strings.IndexByte(key, '{'); start >= 0 {
+		if end := strings.IndexByte(key[start+1:], '}'); end > 0 {
+			return key[start+1 : start+1+end]
+		}
+	}
+	return key
+}
+
+// hashSlot is the cluster slot of the key, the CRC16 of its hash tag
+func hashSlot(key string) uint16 {
+	var crc uint16
+	tag := hashTag(key)
+	for i := 0; i < len(tag); i++ {
+		crc ^= uint16(tag[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc % 16384
+}
+
+func encodeEvents(evts []*events.Event) ([]any, error) {
+	values := make([]any, 0, len(evts))
+	for _, evt := range evts {
+		value, err := json.Marshal(evt)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// keyWrite is the write of one key in a commit, expect says whether the key has to be at revision
+type keyWrite struct {
+	key      string
+	data     []byte
+	events   []*events.Event
+	values   []any
+	expect   bool
+	revision int64
+	previous []byte
+}
+
+func newKeyWrite(ctx context.Context, key string, data []byte, evts []*events.Event) (*keyWrite, error) {
+	values, err := encodeEvents(evts)
+	if err != nil {
+		return nil, err
+	}
+	write := &keyWrite{key: key, data: data, events: evts, values: values}
+	write.revision, write.expect = ctx.Value(revisionKey{key}).(int64)
+	return write, nil
+}
+
+// Set writes value as json under key and appends the events to the outbox of the key in the same
+// transaction. Within a unit of work the write is staged.
+func (obr *RedisOutboxRepo) Set(ctx context.Context, key string, value any, evts ...*events.Event) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	if uow := unitOfWork(ctx); uow != nil {
+		return uow.stage(ctx, obr, key, data, evts)
+	}
+	write, err := newKeyWrite(ctx, key, data, evts)
+	if err != nil {
+		return err
+	}
+	return obr.commit(ctx, "", true, write)
+}
+
+func (obr *RedisOutboxRepo) Delete(ctx context.Context, key string, evts ...*events.Event) error {
+	if uow := unitOfWork(ctx); uow != nil {
+		return uow.stage(ctx, obr, key, nil, evts)
+	}
+	write, err := newKeyWrite(ctx, key, nil, evts)
+	if err != nil {
+		return err
+	}
+	return obr.commit(ctx, "", true, write)
+}
+
+// Commit writes the keys staged in the unit of work, each expecting the revision the unit of work
+// saw it at. When the keys can share a transaction, which they always can on a single redis and on
+// a cluster when they live in one slot, like the keys of one interaction, they are written in one
+// MULTI/EXEC with their events and history. Otherwise the unit is spread over several slots and
+// committed through a journal, see commitSpread.
+func (obr *RedisOutboxRepo) Commit(ctx context.Context, uow *UnitOfWork) error {
+	ctx = WithUnitOfWork(ctx, nil)
+	uow.mu.Lock()
+	writes := make([]*keyWrite, 0, len(uow.order))
+	for _, key := range uow.order {
+		staged := uow.writes[key]
+		values, err := encodeEvents(staged.events)
+		if err != nil {
+			uow.mu.Unlock()
+			return err
+		}
+		writes = append(writes, &keyWrite{
+			key:      key,
+			data:     staged.data,
+			events:   staged.events,
+			values:   values,
+			expect:   true,
+			revision: uow.revisions[key],
+		})
+	}
+	uow.mu.Unlock()
+	if len(writes) == 0 {
+		return nil
+	}
+	if !obr.cluster || sameSlot(writes) {
+		return obr.commit(ctx, "", true, writes...)
+	}
+	return obr.commitSpread(ctx, writes)
+}
+
+func sameSlot(writes []*keyWrite) bool {
+	for _, write := range writes[1:] {
+		if hashSlot(write.key) != hashSlot(writes[0].key) {
+			return false
+		}
+	}
+	return true
+}
+
+// commit writes the data of every write under its key, or deletes the key when the data is nil, in
+// one transaction, which needs the keys to share a slot on a cluster. The keys and their history
+// are watched so the recorded patches are taken against the versions being replaced, a concurrent
+// write retries the commit. The events go to the outboxes of their keys in the same transaction
+// when publish is set. The history records name the unit the writes belong to, if any.
+func (obr *RedisOutboxRepo) commit(ctx context.Context, unit string, publish bool, writes ...*keyWrite) error {
+	watched := make([]string, 0, 2*len(writes))
+	var outboxes []string
+	for _, write := range writes {
+		watched = append(watched, write.key, historyKey(write.key))
+		if publish && len(write.values) > 0 {
+			outboxes = append(outboxes, outboxKey(write.key))
+		}
+	}
+	// the outboxes are indexed before and after the transaction, the relay may drop an outbox from
+	// the index in between when it finds it empty
+	if err := obr.index(ctx, outboxes); err != nil {
+		return stored(err)
+	}
+	records := make([][]byte, len(writes))
+	trims := make([]int64, len(writes))
+	txf := func(tx *redis.Tx) error {
+		for i, write := range writes {
+			current, err := tx.Get(ctx, write.key).Bytes()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			version, err := revisionOf(ctx, tx, write.key)
+			if err != nil {
+				return err
+			}
+			if write.expect && write.revision != version {
+				return ErrRevisionConflict
+			}
+			write.previous = current
+			base := version == 0 || current == nil || (obr.history.BaseEvery > 0 && (version+1)%obr.history.BaseEvery == 0)
+			if records[i], base, err = historyRecord(version, current, write.data, write.events, unit, base); err != nil {
+				return err
+			}
+			if trims[i], err = obr.trimStart(ctx, tx, write.key, version+1, base); err != nil {
+				return err
+			}
+		}
+		_, err := tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			for i, write := range writes {
+				if write.data != nil {
+					pipe.Set(ctx, write.key, write.data, 0)
+				} else {
+					pipe.Del(ctx, write.key)
+				}
+				if publish && len(write.values) > 0 {
+					pipe.RPush(ctx, outboxKey(write.key), write.values...)
+				}
+				pipe.RPush(ctx, historyKey(write.key), records[i])
+				if trims[i] > 0 {
+					pipe.LTrim(ctx, historyKey(write.key), trims[i], -1)
+				}
+				switch {
+				case write.data == nil && obr.history.DeletedTTL > 0:
+					pipe.Expire(ctx, historyKey(write.key), obr.history.DeletedTTL)
+				case write.data != nil && write.previous == nil:
+					// a document written again keeps the log it had when it was deleted
+					pipe.Persist(ctx, historyKey(write.key))
+				}
+			}
+			return nil
+		})
+		return err
+	}
+	var err error
+	for attempt := 0; attempt < maxCommitAttempts; attempt++ {
+		if err = obr.client.Watch(ctx, txf, watched...); !errors.Is(err, redis.TxFailedErr) {
+			break
+		}
+	}
+	if errors.Is(err, redis.TxFailedErr) {
+		err = errs.Conflict("key: %s kept changing during %d commit attempts", writes[0].key, maxCommitAttempts)
+	}
+	if err != nil {
+		obr.log.Errorf("Error while committing %d keys from key: %s by error: %v", len(writes), writes[0].key, err)
+		return stored(err)
+	}
+	for _, write := range writes {
+		audit.Record(ctx, write.key, write.previous, write.data)
+	}
+	if err = obr.index(ctx, outboxes); err != nil {
+		obr.log.Warnf("Error while indexing outboxes: %v by error: %v", outboxes, err)
+	}
+	return nil
+}
+
+func (obr *RedisOutboxRepo) index(ctx context.Context, outboxes []string) error {
+	if len(outboxes) == 0 {
+		return nil
+	}
+	members := make([]any, len(outboxes))
+	for i, okey := range outboxes {
+		members[i] = okey
+	}
+	return obr.client.SAdd(ctx, outboxIndexKey, members...).Err()
+}
+
+// revisionOf returns the version of the last history record of the key, 0 for a key never written
+func revisionOf(ctx context.Context, cmd redis.Cmdable, key string) (int64, error) {
+	return versionOf(cmd.LIndex(ctx, historyKey(key), -1))
+}
+
+// historyRecord encodes the history entry of a write over previous at version, a base entry holding
+// the whole document when base is set or the documents are not objects a JSON patch can diff. The
+// first entry of a key is a base, keys written before history was kept start their log from it.
+func historyRecord(version int64, previous, data []byte, evts []*events.Event, unit string, base bool) ([]byte, bool, error) {
+	var err error
+	record := model.HistoryRecord{Version: version + 1, Time: time.Now().UTC(), Op: model.HistoryDelete, Unit: unit}
+	for _, evt := range evts {
+		record.Events = append(record.Events, string(evt.Type))
+	}
+	if data != nil {
+		record.Op = model.HistorySet
+		if !base {
+			record.Ops, err = patch.Diff(previous, data)
+			if errors.Is(err, patch.ErrNotObjects) {
+				base, err = true, nil
+			}
+			if err != nil {
+				return nil, false, err
+			}
+		}
+		if base {
+			record.Base = true
+			if record.Patch, err = patch.MergeDiff(nil, data); err != nil {
+				return nil, false, err
+			}
+		}
+	}
+	value, err := json.Marshal(record)
+	return value, record.Base, err
+}
+
+// trimStart returns the index the history of the key is trimmed from once the record at version
+// is appended, 0 when nothing is trimmed. The history keeps at least MaxRecords records and
+// always starts at a base record.
+func (obr *RedisOutboxRepo) trimStart(ctx context.Context, tx *redis.Tx, key string, version int64, base bool) (int64, error) {
+	if obr.history.MaxRecords <= 0 {
+		return 0, nil
+	}
+	first, err := versionOf(tx.LIndex(ctx, historyKey(key), 0))
+	if err != nil || first == 0 || version-first+1 <= obr.history.MaxRecords {
+		return 0, err
+	}
+	start := version - obr.history.MaxRecords + 1
+	if obr.history.BaseEvery > 0 {
+		start -= start % obr.history.BaseEvery
+	}
+	if start <= first {
+		return 0, nil
+	}
+	if start == version {
+		if !base {
+			return 0, nil
+		}
+		return start - first, nil
+	}
+	value, err := tx.LIndex(ctx, historyKey(key), start-first).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	var record model.HistoryRecord
+	if err = json.Unmarshal(value, &record); err != nil {
+		return 0, err
+	}
+	// records written before base records recurred may not start a log
+	if !record.Base || record.Version != start {
+		return 0, nil
+	}
+	return start - first, nil
+}
+
+// History returns the mutation log of the key, oldest first
+func (obr *RedisOutboxRepo) History(ctx context.Context, key string) ([]model.HistoryRecord, error) {
+	values, err := obr.client.LRange(ctx, historyKey(key), 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]model.HistoryRecord, 0, len(values))
+	for _, value := range values {
+		var record model.HistoryRecord
+		if err = json.Unmarshal([]byte(value), &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (obr *RedisOutboxRepo) Revision(ctx context.Context, key string) (int64, error) {
+	return revisionOf(ctx, obr.client, key)
+}
+
+// Load reads the documents of keys in one pipeline, a missing key has a nil document. The keys may
+// live in different slots, a cluster client sends the reads to their nodes.
+func (obr *RedisOutboxRepo) Load(ctx context.Context, keys ...string) ([][]byte, error) {
+	cmds := make([]*redis.StringCmd, len(keys))
+	_, err := obr.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			cmds[i] = pipe.Get(ctx, key)
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, stored(err)
+	}
+	docs := make([][]byte, len(keys))
+	for i, cmd := range cmds {
+		data, err := cmd.Bytes()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return nil, stored(err)
+		}
+		docs[i] = data
+	}
+	return docs, nil
+}
+
+// loadAll reads the documents of keys through the outbox, a missing key is a nil document
+func loadAll[T any](ctx context.Context, outbox OutboxRepo, keys []string) ([]*T, error) {
+	docs, err := outbox.Load(ctx, keys...)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]*T, len(docs))
+	for i, data := range docs {
+		if data == nil {
+			continue
+		}
+		var v T
+		if err = json.Unmarshal(data, &v); err != nil {
+			return nil, errs.Internal(err)
+		}
+		values[i] = &v
+	}
+	return values, nil
+}
+
+// load reads a document written through the outbox, the staged one within a unit of work. The
+// outbox owns the encoding of what it writes, so its documents are read back through it.
+func load[T any](ctx context.Context, outbox OutboxRepo, key, format string, args ...any) (*T, error) {
+	if v, ok, err := stagedGet[T](ctx, outbox, key, format, args...); ok || err != nil {
+		return v, err
+	}
+	docs, err := loadAll[T](ctx, outbox, []string{key})
+	if err != nil {
+		return nil, err
+	}
+	return found(docs[0], nil, format, args...)
+}
+
+func (obr *RedisOutboxRepo) Keys(ctx context.Context) ([]string, error) {
+	return obr.client.SMembers(ctx, outboxIndexKey).Result()
+}
+
+// Peek returns up to n of the oldest events of the outbox without removing them
+func (obr *RedisOutboxRepo) Peek(ctx context.Context, outboxKey string, n int) ([]events.Event, error) {
+	values, err := obr.client.LRange(ctx, outboxKey, 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+	evts := make([]events.Event, 0, len(values))
+	for _, value := range values {
+		var evt events.Event
+		if err = json.Unmarshal([]byte(value), &evt); err != nil {
+			return nil, err
+		}
+		evts = append(evts, evt)
+	}
+	return evts, nil
+}
+
+// Ack removes the n oldest events of the outbox once they were published
+func (obr *RedisOutboxRepo) Ack(ctx context.Context, outboxKey string, n int) error {
+	return obr.client.LTrim(ctx, outboxKey, int64(n), -1).Err()
+}
+
+// Release drops an empty outbox from the index, it is put back if an event arrived meanwhile
+func (obr *RedisOutboxRepo) Release(ctx context.Context, outboxKey string) error {
+	if err := obr.client.SRem(ctx, outboxIndexKey, outboxKey).Err(); err != nil {
+		return err
+	}
+	size, err := obr.Size(ctx, outboxKey)
+	if err != nil || size == 0 {
+		return err
+	}
+	return obr.client.SAdd(ctx, outboxIndexKey, outboxKey).Err()
+}
+
+func (obr *RedisOutboxRepo) Size(ctx context.Context, outboxKey string) (int64, error) {
+	return obr.client.LLen(ctx, outboxKey).Result()
+}
+
+// lockScript takes the lock for the owner or extends it when the owner holds it already
+var lockScript = redis.NewScript(`
+if redis.call("SET", KEYS[1], ARGV[1], "NX", "PX", ARGV[2]) then
+	return 1
+end
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	redis.call("PEXPIRE", KEYS[1], ARGV[2])
+	return 1
+end
+return 0`)
+
+// unlockScript releases the lock when the owner still holds it
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// Lock takes or extends the relay lock for owner, only one replica drains the outbox at a time
+func (obr *RedisOutboxRepo) Lock(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	locked, err := lockScript.Run(ctx, obr.client, []string{outboxLockKey}, owner, ttl.Milliseconds()).Int()
+	return locked == 1, err
+}
+
+// Unlock releases the relay lock if owner holds it, so another replica can take over right away
+func (obr *RedisOutboxRepo) Unlock(ctx context.Context, owner string) error {
+	return unlockScript.Run(ctx, obr.client, []string{outboxLockKey}, owner).Err()
+}
+
+func (obr *RedisOutboxRepo) Close() {
+	err := obr.client.Close()
+	if err != nil {
+		obr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewOutboxRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (OutboxRepo, error) {
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting outbox redis client: %v", err)
+		return nil, err
+	}
+
+	_, cluster := client.(*redis.ClusterClient)
+	obr := &RedisOutboxRepo{
+		cfg:     cfg,
+		log:     log,
+		tr:      tr,
+		client:  client,
+		cluster: cluster,
+		history: settings.GetHistory(),
+	}
+	if err = obr.migrateKeyLayout(ctx); err != nil {
+		log.Errorf("Error while moving interaction keys to the hash tagged layout: %v", err)
+		return nil, err
+	}
+	return obr, nil
+}
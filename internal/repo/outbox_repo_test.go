@@ -0,0 +1,313 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"runtime"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/redis/go-redis/v9"
+)
+
+type doc struct {
+	Name string `json:"name"`
+}
+
+func newTestOutbox(t *testing.T, cluster bool) (*RedisOutboxRepo, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	log, err := logger.NewLogger(&config.Config{})
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return &RedisOutboxRepo{log: log, client: client, cluster: cluster}, mr
+}
+
+// stageTwo stages a write with an event to each key in a new unit of work
+func stageTwo(t *testing.T, obr *RedisOutboxRepo, first, second string) *UnitOfWork {
+	t.Helper()
+	uow := NewUnitOfWork()
+	ctx := WithUnitOfWork(context.Background(), uow)
+	for _, key := range []string{first, second} {
+		if err := obr.Set(ctx, key, doc{Name: "new " + key}, events.New(events.InteractionUpdated, key, nil)); err != nil {
+			t.Fatalf("Set %s: %v", key, err)
+		}
+	}
+	return uow
+}
+
+func getDoc(t *testing.T, mr *miniredis.Miniredis, key string) string {
+	t.Helper()
+	value, err := mr.Get(key)
+	if errors.Is(err, miniredis.ErrKeyNotFound) {
+		return ""
+	}
+	if err != nil {
+		t.Fatalf("Get %s: %v", key, err)
+	}
+	var d doc
+	if err = json.Unmarshal([]byte(value), &d); err != nil {
+		t.Fatalf("decoding %s: %v", key, err)
+	}
+	return d.Name
+}
+
+func outboxLen(t *testing.T, obr *RedisOutboxRepo, key string) int64 {
+	t.Helper()
+	n, err := obr.Size(context.Background(), outboxKey(key))
+	if err != nil {
+		t.Fatalf("Size: %v", err)
+	}
+	return n
+}
+
+func TestHashSlot(t *testing.T) {
+	if slot := hashSlot("foo"); slot != 12182 {
+		t.Errorf("hashSlot(foo) = %d, want 12182", slot)
+	}
+	if hashSlot("{user1000}.following") != hashSlot("{user1000}.followers") {
+		t.Error("keys with the same hash tag are in different slots")
+	}
+	key := StepKey("i1", "w1", "e1", "s1")
+	for _, other := range []string{InteractionKey("i1"), outboxKey(key), historyKey(key), WorkflowSetKey("i1")} {
+		if hashSlot(other) != hashSlot(key) {
+			t.Errorf("%s is not in the slot of %s", other, key)
+		}
+	}
+}
+
+func TestCommitWritesUnitInOneTransaction(t *testing.T) {
+	ctx := context.Background()
+	obr, mr := newTestOutbox(t, false)
+	a, b := InteractionKey("a"), InteractionKey("b")
+	if err := obr.Commit(ctx, stageTwo(t, obr, a, b)); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	for _, key := range []string{a, b} {
+		if name := getDoc(t, mr, key); name != "new "+key {
+			t.Errorf("%s = %q, want the staged document", key, name)
+		}
+		if n := outboxLen(t, obr, key); n != 1 {
+			t.Errorf("outbox of %s holds %d events, want 1", key, n)
+		}
+		if rev, _ := obr.Revision(ctx, key); rev != 1 {
+			t.Errorf("revision of %s = %d, want 1", key, rev)
+		}
+	}
+}
+
+func TestCommitConflictWritesNothing(t *testing.T) {
+	ctx := context.Background()
+	obr, mr := newTestOutbox(t, false)
+	a, b := InteractionKey("a"), InteractionKey("b")
+	uow := stageTwo(t, obr, a, b)
+	// b moves on after the unit of work saw it
+	if err := obr.Set(ctx, b, doc{Name: "concurrent"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if err := obr.Commit(ctx, uow); !errors.Is(err, ErrRevisionConflict) {
+		t.Fatalf("Commit error = %v, want a revision conflict", err)
+	}
+	if name := getDoc(t, mr, a); name != "" {
+		t.Errorf("%s = %q, want it unwritten", a, name)
+	}
+	if name := getDoc(t, mr, b); name != "concurrent" {
+		t.Errorf("%s = %q, want the concurrent write", b, name)
+	}
+	if n := outboxLen(t, obr, a) + outboxLen(t, obr, b); n != 0 {
+		t.Errorf("outboxes hold %d events, want none", n)
+	}
+	if rev, _ := obr.Revision(ctx, a); rev != 0 {
+		t.Errorf("revision of %s = %d, want 0", a, rev)
+	}
+}
+
+func TestCommitSpreadHoldsEventsUntilAllKeysAreWritten(t *testing.T) {
+	ctx := context.Background()
+	obr, mr := newTestOutbox(t, true)
+	a, b := InteractionKey("a"), InteractionKey("b")
+	if hashSlot(a) == hashSlot(b) {
+		t.Fatal("test keys share a slot")
+	}
+	obr.beforeSpreadWrite = func(key string) {
+		if key == b && outboxLen(t, obr, a) != 0 {
+			t.Errorf("events of %s were published before %s was written", a, b)
+		}
+	}
+	if err := obr.Commit(ctx, stageTwo(t, obr, a, b)); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	for _, key := range []string{a, b} {
+		if name := getDoc(t, mr, key); name != "new "+key {
+			t.Errorf("%s = %q, want the staged document", key, name)
+		}
+		if n := outboxLen(t, obr, key); n != 1 {
+			t.Errorf("outbox of %s holds %d events, want 1", key, n)
+		}
+	}
+	if mr.Exists(unitIndexKey) {
+		t.Error("journal of the unit was not dropped")
+	}
+}
+
+func TestCommitSpreadRollsBackWhenALaterKeyFails(t *testing.T) {
+	ctx := context.Background()
+	obr, mr := newTestOutbox(t, true)
+	a, b := InteractionKey("a"), InteractionKey("b")
+	if err := obr.Set(ctx, a, doc{Name: "old"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	uow := stageTwo(t, obr, a, b)
+	obr.beforeSpreadWrite = func(key string) {
+		if key == b {
+			if err := obr.Set(ctx, b, doc{Name: "concurrent"}); err != nil {
+				t.Errorf("Set: %v", err)
+			}
+		}
+	}
+	if err := obr.Commit(ctx, uow); !errors.Is(err, ErrRevisionConflict) {
+		t.Fatalf("Commit error = %v, want a revision conflict", err)
+	}
+	if name := getDoc(t, mr, a); name != "old" {
+		t.Errorf("%s = %q, want it rolled back", a, name)
+	}
+	if name := getDoc(t, mr, b); name != "concurrent" {
+		t.Errorf("%s = %q, want the concurrent write", b, name)
+	}
+	if n := outboxLen(t, obr, a) + outboxLen(t, obr, b); n != 0 {
+		t.Errorf("outboxes hold %d events of the failed unit, want none", n)
+	}
+	if mr.Exists(unitIndexKey) {
+		t.Error("journal of the failed unit was not dropped")
+	}
+}
+
+func TestRecoverRollsBackAnAbandonedUnit(t *testing.T) {
+	ctx := context.Background()
+	obr, mr := newTestOutbox(t, true)
+	a, b := InteractionKey("a"), InteractionKey("b")
+	if err := obr.Set(ctx, a, doc{Name: "old"}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	uow := stageTwo(t, obr, a, b)
+	// the committer dies after writing a
+	obr.beforeSpreadWrite = func(key string) {
+		if key == b {
+			runtime.Goexit()
+		}
+	}
+	died := make(chan struct{})
+	go func() {
+		defer close(died)
+		_ = obr.Commit(ctx, uow)
+	}()
+	<-died
+	if name := getDoc(t, mr, a); name != "new "+a {
+		t.Fatalf("%s = %q, want the write of the unit before recovery", a, name)
+	}
+	obr.beforeSpreadWrite = nil
+	if err := obr.Recover(ctx, 0); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	if name := getDoc(t, mr, a); name != "old" {
+		t.Errorf("%s = %q, want it rolled back", a, name)
+	}
+	if name := getDoc(t, mr, b); name != "" {
+		t.Errorf("%s = %q, want it unwritten", b, name)
+	}
+	if n := outboxLen(t, obr, a) + outboxLen(t, obr, b); n != 0 {
+		t.Errorf("outboxes hold %d events of the abandoned unit, want none", n)
+	}
+	if mr.Exists(unitIndexKey) {
+		t.Error("journal of the abandoned unit was not dropped")
+	}
+}
+
+func TestRecoverPublishesAWrittenUnit(t *testing.T) {
+	ctx := context.Background()
+	obr, mr := newTestOutbox(t, true)
+	a, b := InteractionKey("a"), InteractionKey("b")
+	uow := stageTwo(t, obr, a, b)
+	writes := []*keyWrite{
+		{key: a, data: uow.writes[a].data, events: uow.writes[a].events, expect: true},
+		{key: b, data: uow.writes[b].data, events: uow.writes[b].events, expect: true},
+	}
+	for _, write := range writes {
+		write.values, _ = encodeEvents(write.events)
+	}
+	// the committer dies after writing every key, before the events reach the outboxes
+	journal := &unitJournal{Id: "unit-1"}
+	if err := obr.snapshot(ctx, journal, writes); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	data, _ := json.Marshal(journal)
+	obr.client.Set(ctx, unitKey(journal.Id), data, 0)
+	obr.client.SAdd(ctx, unitIndexKey, journal.Id)
+	for _, write := range writes {
+		if err := obr.commit(ctx, journal.Id, false, write); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+	}
+	if n := outboxLen(t, obr, a) + outboxLen(t, obr, b); n != 0 {
+		t.Fatalf("outboxes hold %d events before recovery, want none", n)
+	}
+	if err := obr.Recover(ctx, 0); err != nil {
+		t.Fatalf("Recover: %v", err)
+	}
+	for _, key := range []string{a, b} {
+		if name := getDoc(t, mr, key); name != "new "+key {
+			t.Errorf("%s = %q, want the write of the unit", key, name)
+		}
+		if n := outboxLen(t, obr, key); n != 1 {
+			t.Errorf("outbox of %s holds %d events, want 1", key, n)
+		}
+	}
+	if mr.Exists(unitIndexKey) {
+		t.Error("journal of the recovered unit was not dropped")
+	}
+}
+
+func TestMigrateKeyLayout(t *testing.T) {
+	ctx := context.Background()
+	obr, mr := newTestOutbox(t, false)
+	mr.Set("interaction:a", `{"name":"a"}`)
+	mr.Set("interaction:a:workflows", `{"name":"set"}`)
+	mr.RPush("history:{interaction:a}", `{"version":1}`)
+	mr.RPush("outbox:{interaction:a}", `{"id":"e1"}`)
+	mr.SAdd(outboxIndexKey, "outbox:{interaction:a}")
+	mr.Set("template:t", `{"name":"t"}`)
+	if err := obr.migrateKeyLayout(ctx); err != nil {
+		t.Fatalf("migrateKeyLayout: %v", err)
+	}
+	key := InteractionKey("a")
+	if name := getDoc(t, mr, key); name != "a" {
+		t.Errorf("%s = %q, want the moved document", key, name)
+	}
+	if name := getDoc(t, mr, WorkflowSetKey("a")); name != "set" {
+		t.Errorf("%s = %q, want the moved document", WorkflowSetKey("a"), name)
+	}
+	if rev, _ := obr.Revision(ctx, key); rev != 1 {
+		t.Errorf("revision of %s = %d, want the moved history", key, rev)
+	}
+	if n := outboxLen(t, obr, key); n != 1 {
+		t.Errorf("outbox of %s holds %d events, want the moved event", key, n)
+	}
+	if ok, _ := mr.SIsMember(outboxIndexKey, outboxKey(key)); !ok {
+		t.Error("the moved outbox is not indexed")
+	}
+	for _, legacy := range []string{"interaction:a", "interaction:a:workflows", "history:{interaction:a}", "outbox:{interaction:a}"} {
+		if mr.Exists(legacy) {
+			t.Errorf("%s was not moved", legacy)
+		}
+	}
+	if !mr.Exists("template:t") {
+		t.Error("a key of no interaction was moved")
+	}
+}
@@ -0,0 +1,53 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// PlanRepo stores the plan history of an interaction. The history is written through the outbox
+// like the interaction holding the current plan, so that both are written in one unit of work.
+type PlanRepo interface {
+	GetHistory(ctx context.Context, interactionId string) (*model.PlanHistory, error)
+	SaveHistory(ctx context.Context, history *model.PlanHistory) error
+	DeleteHistory(ctx context.Context, interactionId string) error
+	Close()
+}
+
+func PlanHistoryKey(interactionId string) string {
+	return InteractionKey(interactionId) + ":plans"
+}
+
+type RedisPlanRepo struct {
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	outbox OutboxRepo
+}
+
+func (pr *RedisPlanRepo) GetHistory(ctx context.Context, interactionId string) (*model.PlanHistory, error) {
+	return load[model.PlanHistory](ctx, pr.outbox, PlanHistoryKey(interactionId), "no plan history for interaction id: %s", interactionId)
+}
+
+func (pr *RedisPlanRepo) SaveHistory(ctx context.Context, history *model.PlanHistory) error {
+	return pr.outbox.Set(ctx, PlanHistoryKey(history.InteractionId), history)
+}
+
+func (pr *RedisPlanRepo) DeleteHistory(ctx context.Context, interactionId string) error {
+	return pr.outbox.Delete(ctx, PlanHistoryKey(interactionId))
+}
+
+func (pr *RedisPlanRepo) Close() {}
+
+func NewPlanRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer, outbox OutboxRepo) (PlanRepo, error) {
+	return &RedisPlanRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		outbox: outbox,
+	}, nil
+}
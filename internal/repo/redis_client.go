@@ -0,0 +1,32 @@
+package repo
+
+import (
+	"context"
+	"crypto/tls"
+
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/redis/go-redis/v9"
+)
+
+// newRedisClient connects to the configured redis for repos that need commands db.RedisStore does
+// not expose, like transactions and streams. Several hosts make it a cluster client.
+func newRedisClient(ctx context.Context) (redis.UniversalClient, error) {
+	rc := settings.GetRedis()
+	opts := &redis.UniversalOptions{
+		Addrs:        rc.Addrs,
+		Username:     rc.Username,
+		Password:     rc.Password,
+		DialTimeout:  rc.Timeout,
+		ReadTimeout:  rc.Timeout,
+		WriteTimeout: rc.Timeout,
+	}
+	if rc.UseTLS {
+		opts.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	client := redis.NewUniversalClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+	return client, nil
+}
@@ -2,63 +2,247 @@ package repo
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"time"
 
 	"github.com/mangudaigb/dhauli-base/config"
-	"github.com/mangudaigb/dhauli-base/db"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type StepRepo interface {
 	Get(ctx context.Context, interactionId, workflowId, executionId, stepId string) (*runtime.Step, error)
-	Save(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) error
-	Update(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) error
-	Delete(ctx context.Context, interactionId, workflowId, executionId string, stepId string) error
+	GetAll(ctx context.Context, interactionId, workflowId, executionId string, stepIds []string) ([]*runtime.Step, error)
+	Save(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step, evts ...*events.Event) error
+	Update(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step, evts ...*events.Event) error
+	Delete(ctx context.Context, interactionId, workflowId, executionId string, stepId string, evts ...*events.Event) error
+	Revision(ctx context.Context, interactionId, workflowId, executionId, stepId string) (int64, error)
+	History(ctx context.Context, interactionId, workflowId, executionId, stepId string) ([]model.HistoryRecord, error)
+	Locate(ctx context.Context, stepId string) ([]model.StepLocation, error)
 	Close()
 }
 
+func StepKey(interactionId, workflowId, executionId, stepId string) string {
+	return InteractionKey(interactionId) + ":workflow:" + workflowId + ":execution:" + executionId + ":step:" + stepId
+}
+
+// StepIndexKey is the key of the locations of a step id
+func StepIndexKey(stepId string) string {
+	return "step:" + stepId
+}
+
+const (
+	stepIndexDoneKey = "migration:step-index"
+	stepIndexLockKey = "migration:step-index:lock"
+
+	// stepWriteAttempts is how often a step write is retried when its index moved on meanwhile
+	stepWriteAttempts = 3
+)
+
+// RedisStepRepo writes the steps through the outbox and keeps the locations of every step id in
+// an index, written in the same unit of work as the step
 type RedisStepRepo struct {
-	cfg   *config.Config
-	log   *logger.Logger
-	tr    trace.Tracer
-	store db.RedisStore[runtime.Step]
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	outbox OutboxRepo
+	client redis.UniversalClient
 }
 
 func (sr *RedisStepRepo) Get(ctx context.Context, interactionId, workflowId, executionId, stepId string) (*runtime.Step, error) {
-	return sr.store.Get(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":execution:"+executionId+":step:"+stepId)
+	return load[runtime.Step](ctx, sr.outbox, StepKey(interactionId, workflowId, executionId, stepId), "step id: %s not found", stepId)
 }
 
-func (sr *RedisStepRepo) Save(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) error {
-	return sr.store.Set(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":execution:"+executionId+":step:"+step.ID, step)
+// GetAll reads the steps in one round trip, in the order of stepIds with nil for a missing step
+func (sr *RedisStepRepo) GetAll(ctx context.Context, interactionId, workflowId, executionId string, stepIds []string) ([]*runtime.Step, error) {
+	keys := make([]string, len(stepIds))
+	for i, stepId := range stepIds {
+		keys[i] = StepKey(interactionId, workflowId, executionId, stepId)
+	}
+	return loadAll[runtime.Step](ctx, sr.outbox, keys)
 }
 
-func (sr *RedisStepRepo) Update(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) error {
-	return sr.store.Set(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":execution:"+executionId+":step:"+step.ID, step)
+func (sr *RedisStepRepo) Save(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step, evts ...*events.Event) error {
+	return sr.write(ctx, func(ctx context.Context) error {
+		if err := sr.outbox.Set(ctx, StepKey(interactionId, workflowId, executionId, step.ID), step, evts...); err != nil {
+			return err
+		}
+		return sr.index(ctx, step.ID, model.StepLocation{InteractionId: interactionId, WorkflowId: workflowId, ExecutionId: executionId})
+	})
 }
 
-func (sr *RedisStepRepo) Delete(ctx context.Context, interactionId, workflowId, executionId string, stepId string) error {
-	return sr.store.Delete(ctx, "interaction:"+interactionId+":workflow:"+workflowId+":execution:"+executionId+":step:"+stepId)
+// Update indexes the step as well, as a put may create it
+func (sr *RedisStepRepo) Update(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step, evts ...*events.Event) error {
+	return sr.Save(ctx, interactionId, workflowId, executionId, step, evts...)
 }
 
-func (sr *RedisStepRepo) Close() {
-	err := sr.store.Close()
-	if err != nil {
-		sr.log.Errorf("Error while closing redis store: %v", err)
+func (sr *RedisStepRepo) Delete(ctx context.Context, interactionId, workflowId, executionId string, stepId string, evts ...*events.Event) error {
+	return sr.write(ctx, func(ctx context.Context) error {
+		if err := sr.outbox.Delete(ctx, StepKey(interactionId, workflowId, executionId, stepId), evts...); err != nil {
+			return err
+		}
+		index, err := sr.loadIndex(ctx, stepId)
+		if err != nil {
+			return err
+		}
+		removed := model.StepLocation{InteractionId: interactionId, WorkflowId: workflowId, ExecutionId: executionId}
+		locations := index.Locations[:0]
+		for _, location := range index.Locations {
+			if location != removed {
+				locations = append(locations, location)
+			}
+		}
+		if len(locations) == len(index.Locations) {
+			return nil
+		}
+		if len(locations) == 0 {
+			return sr.outbox.Delete(ctx, StepIndexKey(stepId))
+		}
+		index.Locations = locations
+		return sr.outbox.Set(ctx, StepIndexKey(stepId), index)
+	})
+}
+
+// write commits a write of a step together with the change of its index in a unit of work, which
+// expects the index at the revision it was read at. Within the unit of work of the context both are
+// staged in it.
+func (sr *RedisStepRepo) write(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InUnitOfWork(ctx) {
+		return fn(ctx)
+	}
+	for attempt := 1; ; attempt++ {
+		uow := NewUnitOfWork()
+		err := fn(WithUnitOfWork(ctx, uow))
+		if err == nil {
+			err = sr.outbox.Commit(ctx, uow)
+		}
+		if !errors.Is(err, ErrRevisionConflict) || attempt == stepWriteAttempts {
+			return err
+		}
 	}
 }
 
-func NewStepRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (StepRepo, error) {
-	stepStore, err := db.NewRedisStore[runtime.Step](ctx, cfg, log)
+// Locate returns the locations of the steps with the id, a NotFound when there are none
+func (sr *RedisStepRepo) Locate(ctx context.Context, stepId string) ([]model.StepLocation, error) {
+	index, err := sr.loadIndex(ctx, stepId)
 	if err != nil {
-		log.Errorf("Error while creating step redis store: %v", err)
 		return nil, err
 	}
+	if len(index.Locations) == 0 {
+		return nil, errs.NotFound("step id: %s not found", stepId)
+	}
+	return index.Locations, nil
+}
+
+// index adds the location to the locations of the step id, staged with the step in its unit of work
+func (sr *RedisStepRepo) index(ctx context.Context, stepId string, location model.StepLocation) error {
+	index, err := sr.loadIndex(ctx, stepId)
+	if err != nil {
+		return err
+	}
+	for _, known := range index.Locations {
+		if known == location {
+			return nil
+		}
+	}
+	index.Locations = append(index.Locations, location)
+	return sr.outbox.Set(ctx, StepIndexKey(stepId), index)
+}
+
+// loadIndex reads the locations of a step id, an id that was never indexed has none
+func (sr *RedisStepRepo) loadIndex(ctx context.Context, stepId string) (*model.StepIndex, error) {
+	index, err := load[model.StepIndex](ctx, sr.outbox, StepIndexKey(stepId), "step id: %s not found", stepId)
+	if errors.Is(err, errs.ErrNotFound) {
+		return &model.StepIndex{}, nil
+	}
+	return index, err
+}
+
+func (sr *RedisStepRepo) Revision(ctx context.Context, interactionId, workflowId, executionId, stepId string) (int64, error) {
+	return sr.outbox.Revision(ctx, StepKey(interactionId, workflowId, executionId, stepId))
+}
 
-	return &RedisStepRepo{
-		cfg:   cfg,
-		log:   log,
-		tr:    tr,
-		store: stepStore,
-	}, nil
+// History returns the recorded writes of the step, oldest first
+func (sr *RedisStepRepo) History(ctx context.Context, interactionId, workflowId, executionId, stepId string) ([]model.HistoryRecord, error) {
+	return sr.outbox.History(ctx, StepKey(interactionId, workflowId, executionId, stepId))
+}
+
+// backfill indexes the steps saved before the step index existed, so that they are located by
+// their id like the steps saved since. The first replica to start runs it and marks it done.
+func (sr *RedisStepRepo) backfill(ctx context.Context) error {
+	done, err := sr.client.Exists(ctx, stepIndexDoneKey).Result()
+	if err != nil || done > 0 {
+		return err
+	}
+	locked, err := sr.client.SetNX(ctx, stepIndexLockKey, time.Now().UTC().Format(time.RFC3339), 10*time.Minute).Result()
+	if err != nil || !locked {
+		return err
+	}
+	defer sr.client.Del(context.WithoutCancel(ctx), stepIndexLockKey)
+
+	indexed := 0
+	err = scanKeys(ctx, sr.client, StepKey("*", "*", "*", "*"), func(key string) error {
+		stepId, location, ok := stepLocationOf(key)
+		if !ok {
+			return nil
+		}
+		indexed++
+		return sr.write(ctx, func(ctx context.Context) error {
+			return sr.index(ctx, stepId, location)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if indexed > 0 {
+		sr.log.Infof("Indexed the locations of %d steps", indexed)
+	}
+	return sr.client.Set(ctx, stepIndexDoneKey, time.Now().UTC().Format(time.RFC3339), 0).Err()
+}
+
+// stepLocationOf parses a step key, ok is false for a key that is not one
+func stepLocationOf(key string) (string, model.StepLocation, bool) {
+	rest, ok := strings.CutPrefix(key, "interaction:{")
+	if !ok {
+		return "", model.StepLocation{}, false
+	}
+	interactionId, rest, _ := strings.Cut(rest, "}:workflow:")
+	workflowId, rest, _ := strings.Cut(rest, ":execution:")
+	executionId, stepId, _ := strings.Cut(rest, ":step:")
+	if StepKey(interactionId, workflowId, executionId, stepId) != key {
+		return "", model.StepLocation{}, false
+	}
+	return stepId, model.StepLocation{InteractionId: interactionId, WorkflowId: workflowId, ExecutionId: executionId}, true
+}
+
+func (sr *RedisStepRepo) Close() {
+	if err := sr.client.Close(); err != nil {
+		sr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewStepRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer, outbox OutboxRepo) (StepRepo, error) {
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting step redis client: %v", err)
+		return nil, err
+	}
+	sr := &RedisStepRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		outbox: outbox,
+		client: client,
+	}
+	if err = sr.backfill(ctx); err != nil {
+		log.Errorf("Error while indexing the steps saved before the step index: %v", err)
+		return nil, err
+	}
+	return sr, nil
 }
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/db"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	templateIdsKey = "templates:ids"
+	// templateIndexKey held the template ids as one document before they moved to a set
+	templateIndexKey = "templates"
+)
+
+type TemplateRepo interface {
+	Get(ctx context.Context, templateId string) (*model.WorkflowTemplate, error)
+	// Save stores a new template, a Conflict when a template with the id exists
+	Save(ctx context.Context, template *model.WorkflowTemplate) error
+	Update(ctx context.Context, template *model.WorkflowTemplate) error
+	Delete(ctx context.Context, templateId string) error
+	List(ctx context.Context) ([]string, error)
+	Close()
+}
+
+// RedisTemplateRepo keeps the ids of the templates in a redis set. Adding the id to the set
+// reserves it, so of two creates of the same id only one stores its template.
+type RedisTemplateRepo struct {
+	cfg        *config.Config
+	log        *logger.Logger
+	tr         trace.Tracer
+	store      db.RedisStore[model.WorkflowTemplate]
+	indexStore db.RedisStore[model.TemplateIndex]
+	client     redis.UniversalClient
+}
+
+func (tpr *RedisTemplateRepo) Get(ctx context.Context, templateId string) (*model.WorkflowTemplate, error) {
+	template, err := tpr.store.Get(ctx, "template:"+templateId)
+	return found(template, err, "template id: %s not found", templateId)
+}
+
+func (tpr *RedisTemplateRepo) Save(ctx context.Context, template *model.WorkflowTemplate) error {
+	added, err := tpr.client.SAdd(ctx, templateIdsKey, template.ID).Result()
+	if err != nil {
+		return stored(err)
+	}
+	if added == 0 {
+		return errs.Conflict("template id: %s already exists", template.ID)
+	}
+	if err = tpr.store.Set(ctx, "template:"+template.ID, template); err != nil {
+		if err := tpr.client.SRem(context.WithoutCancel(ctx), templateIdsKey, template.ID).Err(); err != nil {
+			tpr.log.Errorf("Error while releasing template id: %s by error: %v", template.ID, err)
+		}
+		return stored(err)
+	}
+	return nil
+}
+
+func (tpr *RedisTemplateRepo) Update(ctx context.Context, template *model.WorkflowTemplate) error {
+	return stored(tpr.store.Set(ctx, "template:"+template.ID, template))
+}
+
+func (tpr *RedisTemplateRepo) Delete(ctx context.Context, templateId string) error {
+	if err := tpr.store.Delete(ctx, "template:"+templateId); err != nil && !errors.Is(err, redis.Nil) {
+		return stored(err)
+	}
+	return stored(tpr.client.SRem(ctx, templateIdsKey, templateId).Err())
+}
+
+func (tpr *RedisTemplateRepo) List(ctx context.Context) ([]string, error) {
+	ids, err := tpr.client.SMembers(ctx, templateIdsKey).Result()
+	if err != nil {
+		return nil, stored(err)
+	}
+	return ids, nil
+}
+
+// migrate moves the ids of the index document into the id set and drops the document
+func (tpr *RedisTemplateRepo) migrate(ctx context.Context) error {
+	index, err := tpr.indexStore.Get(ctx, templateIndexKey)
+	if errors.Is(err, redis.Nil) || (err == nil && index == nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	members := make([]any, 0, len(index.Ids))
+	for _, id := range index.Ids {
+		members = append(members, id)
+	}
+	if len(members) > 0 {
+		if err = tpr.client.SAdd(ctx, templateIdsKey, members...).Err(); err != nil {
+			return err
+		}
+	}
+	return tpr.indexStore.Delete(ctx, templateIndexKey)
+}
+
+func (tpr *RedisTemplateRepo) Close() {
+	if err := tpr.store.Close(); err != nil {
+		tpr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := tpr.indexStore.Close(); err != nil {
+		tpr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := tpr.client.Close(); err != nil {
+		tpr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewTemplateRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (TemplateRepo, error) {
+	templateStore, err := db.NewRedisStore[model.WorkflowTemplate](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating template redis store: %v", err)
+		return nil, err
+	}
+	indexStore, err := db.NewRedisStore[model.TemplateIndex](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating template index redis store: %v", err)
+		return nil, err
+	}
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting template redis client: %v", err)
+		return nil, err
+	}
+
+	tpr := &RedisTemplateRepo{
+		cfg:        cfg,
+		log:        log,
+		tr:         tr,
+		store:      audited(templateStore),
+		indexStore: indexStore,
+		client:     client,
+	}
+	if err = tpr.migrate(ctx); err != nil {
+		log.Errorf("Error while migrating template index: %v", err)
+		return nil, err
+	}
+	return tpr, nil
+}
@@ -0,0 +1,140 @@
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+)
+
+// UnitOfWork collects the writes of several operations so that they are committed together or not
+// at all. While a unit of work is in the context the outbox stages writes in it instead of
+// committing them, and the repos read staged documents before the store, so an operation sees the
+// writes of the operations before it. The revision of every key is taken when the key is first
+// read or written, the commit fails with ErrRevisionConflict if a key moved on since.
+type UnitOfWork struct {
+	mu        sync.Mutex
+	order     []string
+	writes    map[string]*stagedWrite
+	revisions map[string]int64
+}
+
+type stagedWrite struct {
+	data   []byte
+	events []*events.Event
+}
+
+type unitOfWorkKey struct{}
+
+func NewUnitOfWork() *UnitOfWork {
+	return &UnitOfWork{
+		writes:    make(map[string]*stagedWrite),
+		revisions: make(map[string]int64),
+	}
+}
+
+func WithUnitOfWork(ctx context.Context, uow *UnitOfWork) context.Context {
+	return context.WithValue(ctx, unitOfWorkKey{}, uow)
+}
+
+// InUnitOfWork reports whether the writes of ctx are staged in a unit of work
+func InUnitOfWork(ctx context.Context) bool {
+	return unitOfWork(ctx) != nil
+}
+
+func unitOfWork(ctx context.Context) *UnitOfWork {
+	uow, _ := ctx.Value(unitOfWorkKey{}).(*UnitOfWork)
+	return uow
+}
+
+// Len returns the number of keys written in the unit of work
+func (uow *UnitOfWork) Len() int {
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+	return len(uow.order)
+}
+
+// observe records the revision a key was first seen at
+func (uow *UnitOfWork) observe(ctx context.Context, outbox OutboxRepo, key string) error {
+	uow.mu.Lock()
+	_, seen := uow.revisions[key]
+	uow.mu.Unlock()
+	if seen {
+		return nil
+	}
+	revision, err := outbox.Revision(ctx, key)
+	if err != nil {
+		return stored(err)
+	}
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+	if _, seen = uow.revisions[key]; !seen {
+		uow.revisions[key] = revision
+	}
+	return nil
+}
+
+// stage keeps the latest document of key, nil for a delete, and appends the events of the write.
+// A revision expected by the write has to be the one the key was first seen at.
+func (uow *UnitOfWork) stage(ctx context.Context, outbox OutboxRepo, key string, data []byte, evts []*events.Event) error {
+	if expected, ok := ctx.Value(revisionKey{key}).(int64); ok {
+		uow.mu.Lock()
+		if _, seen := uow.revisions[key]; !seen {
+			uow.revisions[key] = expected
+		}
+		revision := uow.revisions[key]
+		uow.mu.Unlock()
+		if revision != expected {
+			return ErrRevisionConflict
+		}
+	} else if err := uow.observe(ctx, outbox, key); err != nil {
+		return err
+	}
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+	write, ok := uow.writes[key]
+	if !ok {
+		write = &stagedWrite{}
+		uow.writes[key] = write
+		uow.order = append(uow.order, key)
+	}
+	write.data = data
+	write.events = append(write.events, evts...)
+	return nil
+}
+
+// staged returns the document staged for key, ok is false when the key was not written in the
+// unit of work. A staged delete is a NotFound.
+func (uow *UnitOfWork) staged(key string) (data []byte, ok bool) {
+	uow.mu.Lock()
+	defer uow.mu.Unlock()
+	write, ok := uow.writes[key]
+	if !ok {
+		return nil, false
+	}
+	return write.data, true
+}
+
+// stagedGet reads a document through the unit of work of the context. Without one, or when the
+// key was not written in it, ok is false and the caller reads the store, the revision of the key
+// is recorded before that read.
+func stagedGet[T any](ctx context.Context, outbox OutboxRepo, key, format string, args ...any) (doc *T, ok bool, err error) {
+	uow := unitOfWork(ctx)
+	if uow == nil {
+		return nil, false, nil
+	}
+	data, ok := uow.staged(key)
+	if !ok {
+		return nil, false, uow.observe(ctx, outbox, key)
+	}
+	if data == nil {
+		return nil, true, errs.NotFound(format, args...)
+	}
+	var v T
+	if err = json.Unmarshal(data, &v); err != nil {
+		return nil, true, errs.Internal(err)
+	}
+	return &v, true, nil
+}
@@ -0,0 +1,250 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/db"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const deliveryQueueKey = "webhook:deliveries:due"
+
+// claimScript moves a due member of the queue to the lease deadline, so only one worker claims it
+// and it becomes due again if that worker dies before finishing the attempt.
+var claimScript = redis.NewScript(`
+local score = redis.call("ZSCORE", KEYS[1], ARGV[1])
+if score and tonumber(score) <= tonumber(ARGV[2]) then
+	redis.call("ZADD", KEYS[1], ARGV[3], ARGV[1])
+	return 1
+end
+return 0
+`)
+
+// WebhookDeliveryRepo stores the delivery log of every webhook and the queue of deliveries due for
+// an attempt. The queue is a sorted set of "<webhookId>/<deliveryId>" scored by the due time, the
+// log of a webhook a sorted set of its delivery ids scored by their creation time.
+type WebhookDeliveryRepo interface {
+	Get(ctx context.Context, webhookId, deliveryId string) (*model.WebhookDelivery, error)
+	Save(ctx context.Context, delivery *model.WebhookDelivery) error
+	Update(ctx context.Context, delivery *model.WebhookDelivery) error
+	List(ctx context.Context, webhookId string) ([]string, error)
+	Schedule(ctx context.Context, delivery *model.WebhookDelivery) error
+	Claim(ctx context.Context, now time.Time, lease time.Duration, n int) ([]*model.WebhookDelivery, error)
+	Unschedule(ctx context.Context, delivery *model.WebhookDelivery) error
+	Close()
+}
+
+type RedisWebhookDeliveryRepo struct {
+	cfg     *config.Config
+	log     *logger.Logger
+	tr      trace.Tracer
+	store   db.RedisStore[model.WebhookDelivery]
+	logs    db.RedisStore[model.DeliveryLog]
+	client  redis.UniversalClient
+	logSize int
+}
+
+func deliveryKey(webhookId, deliveryId string) string {
+	return "webhook:" + webhookId + ":delivery:" + deliveryId
+}
+
+func deliveryLogKey(webhookId string) string {
+	return "webhook:" + webhookId + ":deliveries"
+}
+
+func (dr *RedisWebhookDeliveryRepo) Get(ctx context.Context, webhookId, deliveryId string) (*model.WebhookDelivery, error) {
+	delivery, err := dr.store.Get(ctx, deliveryKey(webhookId, deliveryId))
+	return found(delivery, err, "delivery id: %s not found", deliveryId)
+}
+
+// Save stores a new delivery and adds it to the webhook log, the deliveries falling off the end of
+// the log are removed once they are no longer pending
+func (dr *RedisWebhookDeliveryRepo) Save(ctx context.Context, delivery *model.WebhookDelivery) error {
+	if err := dr.store.Set(ctx, deliveryKey(delivery.WebhookId, delivery.ID), delivery); err != nil {
+		return stored(err)
+	}
+	logKey := deliveryLogKey(delivery.WebhookId)
+	err := dr.client.ZAdd(ctx, logKey, redis.Z{Score: float64(delivery.CreatedAt.UnixMilli()), Member: delivery.ID}).Err()
+	if err != nil {
+		return stored(err)
+	}
+	dr.prune(ctx, delivery.WebhookId)
+	return nil
+}
+
+// prune removes the oldest deliveries beyond the log size that have completed, a pending delivery
+// stays in the log until its last attempt
+func (dr *RedisWebhookDeliveryRepo) prune(ctx context.Context, webhookId string) {
+	logKey := deliveryLogKey(webhookId)
+	ids, err := dr.client.ZRange(ctx, logKey, 0, int64(-dr.logSize-1)).Result()
+	if err != nil {
+		dr.log.Warnf("Error while reading the delivery log of webhook id: %s by error: %v", webhookId, err)
+		return
+	}
+	for _, id := range ids {
+		delivery, err := dr.Get(ctx, webhookId, id)
+		if err == nil && delivery.Status == model.DeliveryPending {
+			continue
+		}
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			dr.log.Warnf("Error while reading delivery: %s of webhook id: %s by error: %v", id, webhookId, err)
+			continue
+		}
+		if err = dr.client.ZRem(ctx, logKey, id).Err(); err != nil {
+			dr.log.Warnf("Error while removing delivery: %s of webhook id: %s by error: %v", id, webhookId, err)
+			continue
+		}
+		if err = dr.store.Delete(ctx, deliveryKey(webhookId, id)); err != nil && !errors.Is(err, redis.Nil) {
+			dr.log.Warnf("Error while removing delivery: %s of webhook id: %s by error: %v", id, webhookId, err)
+		}
+	}
+}
+
+func (dr *RedisWebhookDeliveryRepo) Update(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return stored(dr.store.Set(ctx, deliveryKey(delivery.WebhookId, delivery.ID), delivery))
+}
+
+// List returns the ids of the logged deliveries of the webhook, newest first
+func (dr *RedisWebhookDeliveryRepo) List(ctx context.Context, webhookId string) ([]string, error) {
+	ids, err := dr.client.ZRevRange(ctx, deliveryLogKey(webhookId), 0, -1).Result()
+	if err != nil {
+		return nil, stored(err)
+	}
+	return ids, nil
+}
+
+// migrate turns the delivery log documents written before the logs became sorted sets into sorted
+// sets of the deliveries that are still stored
+func (dr *RedisWebhookDeliveryRepo) migrate(ctx context.Context) error {
+	return scanKeys(ctx, dr.client, deliveryLogKey("*"), func(key string) error {
+		kind, err := dr.client.Type(ctx, key).Result()
+		if err != nil || kind != "string" {
+			return err
+		}
+		entries, err := dr.logs.Get(ctx, key)
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return err
+		}
+		webhookId := strings.TrimSuffix(strings.TrimPrefix(key, "webhook:"), ":deliveries")
+		var members []redis.Z
+		if entries != nil {
+			for _, id := range entries.Ids {
+				delivery, err := dr.Get(ctx, webhookId, id)
+				if err != nil {
+					continue
+				}
+				members = append(members, redis.Z{Score: float64(delivery.CreatedAt.UnixMilli()), Member: id})
+			}
+		}
+		if err = dr.client.Del(ctx, key).Err(); err != nil || len(members) == 0 {
+			return err
+		}
+		return dr.client.ZAdd(ctx, key, members...).Err()
+	})
+}
+
+func queueMember(webhookId, deliveryId string) string {
+	return webhookId + "/" + deliveryId
+}
+
+// Schedule queues the delivery for an attempt at its NextAttemptAt
+func (dr *RedisWebhookDeliveryRepo) Schedule(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return dr.client.ZAdd(ctx, deliveryQueueKey, redis.Z{
+		Score:  float64(delivery.NextAttemptAt.UnixMilli()),
+		Member: queueMember(delivery.WebhookId, delivery.ID),
+	}).Err()
+}
+
+// Unschedule removes a delivery that needs no further attempt from the queue
+func (dr *RedisWebhookDeliveryRepo) Unschedule(ctx context.Context, delivery *model.WebhookDelivery) error {
+	return dr.client.ZRem(ctx, deliveryQueueKey, queueMember(delivery.WebhookId, delivery.ID)).Err()
+}
+
+// Claim leases up to n deliveries due at now to the caller until now+lease, a delivery claimed by
+// another replica first is skipped
+func (dr *RedisWebhookDeliveryRepo) Claim(ctx context.Context, now time.Time, lease time.Duration, n int) ([]*model.WebhookDelivery, error) {
+	due := strconv.FormatInt(now.UnixMilli(), 10)
+	members, err := dr.client.ZRangeByScore(ctx, deliveryQueueKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   due,
+		Count: int64(n),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+	deadline := now.Add(lease).UnixMilli()
+	var claimed []*model.WebhookDelivery
+	for _, member := range members {
+		ok, err := claimScript.Run(ctx, dr.client, []string{deliveryQueueKey}, member, due, deadline).Int()
+		if err != nil {
+			return claimed, err
+		}
+		if ok == 0 {
+			continue
+		}
+		webhookId, deliveryId, _ := strings.Cut(member, "/")
+		delivery, err := dr.Get(ctx, webhookId, deliveryId)
+		if err != nil || delivery == nil {
+			dr.log.Warnf("Dropping queued delivery: %s without a log entry", member)
+			_ = dr.client.ZRem(ctx, deliveryQueueKey, member).Err()
+			continue
+		}
+		claimed = append(claimed, delivery)
+	}
+	return claimed, nil
+}
+
+func (dr *RedisWebhookDeliveryRepo) Close() {
+	if err := dr.store.Close(); err != nil {
+		dr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := dr.logs.Close(); err != nil {
+		dr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := dr.client.Close(); err != nil {
+		dr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewWebhookDeliveryRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (WebhookDeliveryRepo, error) {
+	deliveryStore, err := db.NewRedisStore[model.WebhookDelivery](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating webhook delivery redis store: %v", err)
+		return nil, err
+	}
+	logStore, err := db.NewRedisStore[model.DeliveryLog](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating webhook delivery log redis store: %v", err)
+		return nil, err
+	}
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting webhook delivery redis client: %v", err)
+		return nil, err
+	}
+
+	dr := &RedisWebhookDeliveryRepo{
+		cfg:     cfg,
+		log:     log,
+		tr:      tr,
+		store:   deliveryStore,
+		logs:    logStore,
+		client:  client,
+		logSize: settings.GetWebhooks().LogSize,
+	}
+	if err = dr.migrate(ctx); err != nil {
+		log.Errorf("Error while migrating webhook delivery logs: %v", err)
+		return nil, err
+	}
+	return dr, nil
+}
@@ -0,0 +1,147 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/db"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	webhookIdsKey = "webhooks:ids"
+	// webhookIndexKey held the webhook ids as one document before they moved to a set
+	webhookIndexKey = "webhooks"
+)
+
+type WebhookRepo interface {
+	Get(ctx context.Context, webhookId string) (*model.Webhook, error)
+	// Save stores a new webhook, a Conflict when a webhook with the id exists
+	Save(ctx context.Context, webhook *model.Webhook) error
+	Update(ctx context.Context, webhook *model.Webhook) error
+	Delete(ctx context.Context, webhookId string) error
+	List(ctx context.Context) ([]string, error)
+	Close()
+}
+
+// RedisWebhookRepo keeps the ids of the webhooks in a redis set, adding the id reserves it for
+// the create that added it
+type RedisWebhookRepo struct {
+	cfg        *config.Config
+	log        *logger.Logger
+	tr         trace.Tracer
+	store      db.RedisStore[model.Webhook]
+	indexStore db.RedisStore[model.WebhookIndex]
+	client     redis.UniversalClient
+}
+
+func (wr *RedisWebhookRepo) Get(ctx context.Context, webhookId string) (*model.Webhook, error) {
+	webhook, err := wr.store.Get(ctx, "webhook:"+webhookId)
+	return found(webhook, err, "webhook id: %s not found", webhookId)
+}
+
+func (wr *RedisWebhookRepo) Save(ctx context.Context, webhook *model.Webhook) error {
+	added, err := wr.client.SAdd(ctx, webhookIdsKey, webhook.ID).Result()
+	if err != nil {
+		return stored(err)
+	}
+	if added == 0 {
+		return errs.Conflict("webhook id: %s already exists", webhook.ID)
+	}
+	if err = wr.store.Set(ctx, "webhook:"+webhook.ID, webhook); err != nil {
+		if err := wr.client.SRem(context.WithoutCancel(ctx), webhookIdsKey, webhook.ID).Err(); err != nil {
+			wr.log.Errorf("Error while releasing webhook id: %s by error: %v", webhook.ID, err)
+		}
+		return stored(err)
+	}
+	return nil
+}
+
+func (wr *RedisWebhookRepo) Update(ctx context.Context, webhook *model.Webhook) error {
+	return stored(wr.store.Set(ctx, "webhook:"+webhook.ID, webhook))
+}
+
+func (wr *RedisWebhookRepo) Delete(ctx context.Context, webhookId string) error {
+	if err := wr.store.Delete(ctx, "webhook:"+webhookId); err != nil && !errors.Is(err, redis.Nil) {
+		return stored(err)
+	}
+	return stored(wr.client.SRem(ctx, webhookIdsKey, webhookId).Err())
+}
+
+func (wr *RedisWebhookRepo) List(ctx context.Context) ([]string, error) {
+	ids, err := wr.client.SMembers(ctx, webhookIdsKey).Result()
+	if err != nil {
+		return nil, stored(err)
+	}
+	return ids, nil
+}
+
+// migrate moves the ids of the index document into the id set and drops the document
+func (wr *RedisWebhookRepo) migrate(ctx context.Context) error {
+	index, err := wr.indexStore.Get(ctx, webhookIndexKey)
+	if errors.Is(err, redis.Nil) || (err == nil && index == nil) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	members := make([]any, 0, len(index.Ids))
+	for _, id := range index.Ids {
+		members = append(members, id)
+	}
+	if len(members) > 0 {
+		if err = wr.client.SAdd(ctx, webhookIdsKey, members...).Err(); err != nil {
+			return err
+		}
+	}
+	return wr.indexStore.Delete(ctx, webhookIndexKey)
+}
+
+func (wr *RedisWebhookRepo) Close() {
+	if err := wr.store.Close(); err != nil {
+		wr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := wr.indexStore.Close(); err != nil {
+		wr.log.Errorf("Error while closing redis store: %v", err)
+	}
+	if err := wr.client.Close(); err != nil {
+		wr.log.Errorf("Error while closing redis client: %v", err)
+	}
+}
+
+func NewWebhookRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer) (WebhookRepo, error) {
+	webhookStore, err := db.NewRedisStore[model.Webhook](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating webhook redis store: %v", err)
+		return nil, err
+	}
+	indexStore, err := db.NewRedisStore[model.WebhookIndex](ctx, cfg, log)
+	if err != nil {
+		log.Errorf("Error while creating webhook index redis store: %v", err)
+		return nil, err
+	}
+	client, err := newRedisClient(ctx)
+	if err != nil {
+		log.Errorf("Error while connecting webhook redis client: %v", err)
+		return nil, err
+	}
+
+	wr := &RedisWebhookRepo{
+		cfg:        cfg,
+		log:        log,
+		tr:         tr,
+		store:      audited(webhookStore),
+		indexStore: indexStore,
+		client:     client,
+	}
+	if err = wr.migrate(ctx); err != nil {
+		log.Errorf("Error while migrating webhook index: %v", err)
+		return nil, err
+	}
+	return wr, nil
+}
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// WorkflowRepo stores the workflows of an interaction that are not active. The set is written
+// through the outbox like the interaction, so that it is staged with it in a unit of work.
+type WorkflowRepo interface {
+	Get(ctx context.Context, interactionId string) (*model.WorkflowSet, error)
+	Save(ctx context.Context, set *model.WorkflowSet, evts ...*events.Event) error
+	Revision(ctx context.Context, interactionId string) (int64, error)
+	History(ctx context.Context, interactionId string) ([]model.HistoryRecord, error)
+	Delete(ctx context.Context, interactionId string, evts ...*events.Event) error
+	Close()
+}
+
+func WorkflowSetKey(interactionId string) string {
+	return InteractionKey(interactionId) + ":workflows"
+}
+
+type RedisWorkflowRepo struct {
+	cfg    *config.Config
+	log    *logger.Logger
+	tr     trace.Tracer
+	outbox OutboxRepo
+}
+
+// Get returns the workflow set of the interaction, an empty one when it has no other workflows
+func (wr *RedisWorkflowRepo) Get(ctx context.Context, interactionId string) (*model.WorkflowSet, error) {
+	set, err := load[model.WorkflowSet](ctx, wr.outbox, WorkflowSetKey(interactionId), "no workflows for interaction id: %s", interactionId)
+	if errors.Is(err, errs.ErrNotFound) {
+		return &model.WorkflowSet{InteractionId: interactionId}, nil
+	}
+	return set, err
+}
+
+func (wr *RedisWorkflowRepo) Save(ctx context.Context, set *model.WorkflowSet, evts ...*events.Event) error {
+	return wr.outbox.Set(ctx, WorkflowSetKey(set.InteractionId), set, evts...)
+}
+
+func (wr *RedisWorkflowRepo) Revision(ctx context.Context, interactionId string) (int64, error) {
+	return wr.outbox.Revision(ctx, WorkflowSetKey(interactionId))
+}
+
+// History returns the recorded writes of the workflow set, oldest first
+func (wr *RedisWorkflowRepo) History(ctx context.Context, interactionId string) ([]model.HistoryRecord, error) {
+	return wr.outbox.History(ctx, WorkflowSetKey(interactionId))
+}
+
+func (wr *RedisWorkflowRepo) Delete(ctx context.Context, interactionId string, evts ...*events.Event) error {
+	return wr.outbox.Delete(ctx, WorkflowSetKey(interactionId), evts...)
+}
+
+func (wr *RedisWorkflowRepo) Close() {}
+
+func NewWorkflowRepo(ctx context.Context, cfg *config.Config, log *logger.Logger, tr trace.Tracer, outbox OutboxRepo) (WorkflowRepo, error) {
+	return &RedisWorkflowRepo{
+		cfg:    cfg,
+		log:    log,
+		tr:     tr,
+		outbox: outbox,
+	}, nil
+}
@@ -2,44 +2,127 @@ package internal
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/state-service/internal/handler"
+	"github.com/mangudaigb/state-service/internal/openapi"
 )
 
-func SetupRouter(ge *gin.Engine, ih *handler.InteractionHandler, sh *handler.StepHandler, mh *handler.McpHandler) {
-	v1 := ge.Group("/api/v1")
+// SetupRouter registers the routes of the api, each with the endpoint describing it in the openapi
+// document
+func SetupRouter(ge *gin.Engine, routes *openapi.Routes, log *logger.Logger, ih *handler.InteractionHandler, sh *handler.StepHandler, mh *handler.McpHandler, ah *handler.AgentHandler, ph *handler.PlanHandler, th *handler.TemplateHandler, oh *handler.OutboxHandler, eh *handler.EventHandler, subh *handler.SubscriptionHandler, wh *handler.WebhookHandler, auh *handler.AuditHandler, idh *handler.IdempotencyHandler, bh *handler.BatchHandler, wfh *handler.WorkflowHandler, chh *handler.ChildHandler, gqh *handler.GraphQLHandler, oah *handler.OpenAPIHandler) {
+	ge.Use(handler.RequestID())
+	ge.NoRoute(handler.NoRoute)
+	// problems are written inside the audit middleware so that entries record the error status
+	v1 := routes.Router(ge.Group("/api/v1", auh.RecordWrites(), handler.Problems(log)))
+	// graphql only reads, its posted queries are kept out of the audit log
+	graphql := routes.Router(ge.Group("/api/v1/graphql", handler.Problems(log)))
 	{
+		graphql.POST("", handler.GraphQLEndpoints.Query, gqh.QueryHandler)
+		graphql.GET("", handler.GraphQLEndpoints.Query, gqh.QueryHandler)
+	}
+	{
+		v1.GET("/audit", handler.AuditEndpoints.ListEntries, auh.ListEntriesHandler)
+		v1.GET("/openapi.json", handler.OpenAPIEndpoints.Spec, oah.SpecHandler)
+		v1.POST("/batch", handler.BatchEndpoints.ExecuteBatch, idh.Idempotent(), bh.ExecuteBatchHandler)
+		v1.GET("/outbox/stats", handler.OutboxEndpoints.GetStats, oh.GetStatsHandler)
+		v1.GET("/subscriptions", handler.SubscriptionEndpoints.Subscribe, subh.SubscribeHandler)
+
+		// the flattened step routes find the workflow and execution of a step by its id
+		locatedStepRouter := v1.Group("/steps/:stepId", sh.LocateStep())
+		{
+			stepRoutes(locatedStepRouter, sh, "ById")
+		}
+
+		agentRouter := v1.Group("/agents")
+		{
+			agentRouter.POST("", handler.AgentEndpoints.CreateAgent, ah.CreateAgentHandler)
+			agentRouter.GET("", handler.AgentEndpoints.ListAgents, ah.ListAgentsHandler)
+			agentRouter.GET("/:agentId", handler.AgentEndpoints.GetAgent, ah.GetAgentHandler)
+			agentRouter.POST("/:agentId/versions", handler.AgentEndpoints.CreateVersion, ah.CreateVersionHandler)
+			agentRouter.GET("/:agentId/versions/:version", handler.AgentEndpoints.GetVersion, ah.GetVersionHandler)
+		}
+
+		templateRouter := v1.Group("/templates")
+		{
+			templateRouter.POST("", handler.TemplateEndpoints.CreateTemplate, th.CreateTemplateHandler)
+			templateRouter.GET("", handler.TemplateEndpoints.ListTemplates, th.ListTemplatesHandler)
+			templateRouter.GET("/:templateId", handler.TemplateEndpoints.GetTemplate, th.GetTemplateHandler)
+			templateRouter.PUT("/:templateId", handler.TemplateEndpoints.UpdateTemplate, th.UpdateTemplateHandler)
+			templateRouter.DELETE("/:templateId", handler.TemplateEndpoints.DeleteTemplate, th.DeleteTemplateHandler)
+			templateRouter.POST("/:templateId/instantiate", handler.TemplateEndpoints.Instantiate, th.InstantiateHandler)
+		}
+
+		webhookRouter := v1.Group("/webhooks")
+		{
+			webhookRouter.POST("", handler.WebhookEndpoints.CreateWebhook, wh.CreateWebhookHandler)
+			webhookRouter.GET("", handler.WebhookEndpoints.ListWebhooks, wh.ListWebhooksHandler)
+			webhookRouter.GET("/:webhookId", handler.WebhookEndpoints.GetWebhook, wh.GetWebhookHandler)
+			webhookRouter.PUT("/:webhookId", handler.WebhookEndpoints.UpdateWebhook, wh.UpdateWebhookHandler)
+			webhookRouter.DELETE("/:webhookId", handler.WebhookEndpoints.DeleteWebhook, wh.DeleteWebhookHandler)
+			webhookRouter.GET("/:webhookId/deliveries", handler.WebhookEndpoints.ListDeliveries, wh.ListDeliveriesHandler)
+			webhookRouter.GET("/:webhookId/deliveries/:deliveryId", handler.WebhookEndpoints.GetDelivery, wh.GetDeliveryHandler)
+			webhookRouter.POST("/:webhookId/deliveries/:deliveryId/redeliver", handler.WebhookEndpoints.Redeliver, wh.RedeliverHandler)
+		}
+
 		interactionRouter := v1.Group("/interactions")
 		{
-			interactionRouter.POST("", ih.CreateInteractionHandler)
-			interactionRouter.GET("/:interactionId", ih.GetInteractionHandler)
-			interactionRouter.PUT("/:interactionId", ih.UpdateInteractionHandler)
-			interactionRouter.DELETE("/:interactionId", ih.DeleteInteractionHandler)
+			interactionRouter.POST("", handler.InteractionEndpoints.CreateInteraction, idh.Idempotent(), ih.CreateInteractionHandler)
+			interactionRouter.GET("/:interactionId", handler.InteractionEndpoints.GetInteraction, ih.GetInteractionHandler)
+			interactionRouter.PUT("/:interactionId", handler.InteractionEndpoints.UpdateInteraction, ih.UpdateInteractionHandler)
+			interactionRouter.PATCH("/:interactionId", handler.InteractionEndpoints.PatchInteraction, ih.PatchInteractionHandler)
+			interactionRouter.DELETE("/:interactionId", handler.InteractionEndpoints.DeleteInteraction, ih.DeleteInteractionHandler)
+			interactionRouter.GET("/:interactionId/history", handler.InteractionEndpoints.GetHistory, ih.GetHistoryHandler)
+			interactionRouter.GET("/:interactionId/snapshot", handler.InteractionEndpoints.GetSnapshot, ih.GetSnapshotHandler)
+			interactionRouter.GET("/:interactionId/events", handler.EventEndpoints.StreamEvents, eh.StreamEventsHandler)
+			interactionRouter.GET("/:interactionId/children", handler.ChildEndpoints.ListChildren, chh.ListChildrenHandler)
+			interactionRouter.GET("/:interactionId/tree", handler.ChildEndpoints.GetTree, chh.GetTreeHandler)
+
+			interactionStepRouter := interactionRouter.Group("/:interactionId/steps/:stepId", sh.LocateStep())
+			{
+				stepRoutes(interactionStepRouter, sh, "OfInteraction")
+			}
 
 			planRouter := interactionRouter.Group("/:interactionId/plans")
 			{
-				planRouter.PUT("/:planId", ih.UpdatePlanHandler)
+				planRouter.POST("", handler.PlanEndpoints.Replan, ph.ReplanHandler)
+				planRouter.PUT("/:planId", handler.PlanEndpoints.UpdatePlan, ph.UpdatePlanHandler)
+				planRouter.POST("/:planId/compile", handler.PlanEndpoints.CompilePlan, ph.CompilePlanHandler)
+				planRouter.GET("/revisions", handler.PlanEndpoints.ListRevisions, ph.ListRevisionsHandler)
+				planRouter.GET("/revisions/:revision", handler.PlanEndpoints.GetRevision, ph.GetRevisionHandler)
+				planRouter.GET("/diff", handler.PlanEndpoints.DiffRevisions, ph.DiffRevisionsHandler)
+				planRouter.POST("/reconcile", handler.PlanEndpoints.Reconcile, ph.ReconcileHandler)
 			}
 
 			workflowRouter := interactionRouter.Group("/:interactionId/workflows")
 			{
-				workflowRouter.PUT("/:workflowId", ih.UpdateWorkflowHandler)
+				workflowRouter.GET("", handler.WorkflowEndpoints.ListWorkflows, wfh.ListWorkflowsHandler)
+				workflowRouter.POST("", handler.WorkflowEndpoints.CreateWorkflow, idh.Idempotent(), wfh.CreateWorkflowHandler)
+				workflowRouter.GET("/:workflowId", handler.WorkflowEndpoints.GetWorkflow, wfh.GetWorkflowHandler)
+				workflowRouter.PUT("/:workflowId", handler.InteractionEndpoints.UpdateWorkflow, ih.UpdateWorkflowHandler)
+				workflowRouter.DELETE("/:workflowId", handler.WorkflowEndpoints.DeleteWorkflow, wfh.DeleteWorkflowHandler)
+				workflowRouter.POST("/:workflowId/activate", handler.WorkflowEndpoints.ActivateWorkflow, wfh.ActivateWorkflowHandler)
 				mcpRouter := workflowRouter.Group("/:workflowId/mcps")
 				{
-					mcpRouter.POST("", mh.CreateMcpHandler)
-					mcpRouter.PUT("/:mcpId", mh.UpdateMcpHandler)
-					mcpRouter.DELETE("/:mcpId", mh.DeleteMcpHandler)
-					mcpRouter.POST("/:mcpId/tools", mh.AddToolHandler)
+					mcpRouter.POST("", handler.McpEndpoints.CreateMcp, idh.Idempotent(), mh.CreateMcpHandler)
+					mcpRouter.GET("/:mcpId", handler.McpEndpoints.GetMcp, mh.GetMcpHandler)
+					mcpRouter.PUT("/:mcpId", handler.McpEndpoints.UpdateMcp, mh.UpdateMcpHandler)
+					mcpRouter.PATCH("/:mcpId", handler.McpEndpoints.PatchMcp, mh.PatchMcpHandler)
+					mcpRouter.DELETE("/:mcpId", handler.McpEndpoints.DeleteMcp, mh.DeleteMcpHandler)
+					mcpRouter.POST("/:mcpId/tools", handler.McpEndpoints.AddTool, mh.AddToolHandler)
+					mcpRouter.POST("/:mcpId/tools/sync", handler.McpEndpoints.SyncTools, mh.SyncToolsHandler)
+					mcpRouter.GET("/:mcpId/connection", handler.McpEndpoints.GetConnection, mh.GetConnectionHandler)
+					mcpRouter.PUT("/:mcpId/connection", handler.McpEndpoints.UpdateConnection, mh.UpdateConnectionHandler)
+					mcpRouter.DELETE("/:mcpId/connection", handler.McpEndpoints.DeleteConnection, mh.DeleteConnectionHandler)
+					mcpRouter.GET("/:mcpId/history", handler.McpEndpoints.GetHistory, mh.GetHistoryHandler)
 				}
 				executionRouter := workflowRouter.Group("/:workflowId/executions")
 				{
-					executionRouter.PUT("/:executionId", ih.UpdateExecutionGraphHandler)
+					executionRouter.PUT("/:executionId", handler.InteractionEndpoints.UpdateExecutionGraph, ih.UpdateExecutionGraphHandler)
+					executionRouter.POST("/:executionId/children", handler.ChildEndpoints.CreateChild, idh.Idempotent(), chh.CreateChildHandler)
 					stepRouter := executionRouter.Group("/:executionId/steps")
 					{
-						stepRouter.POST("", sh.CreateStepHandler)
-						stepRouter.GET("/:stepId", sh.GetStepHandler)
-						stepRouter.PUT("/:stepId", sh.UpdateStepHandler)
-						stepRouter.POST("/:stepId/status", sh.UpdateStatusHandler)
-						stepRouter.DELETE("/:stepId", sh.DeleteStepHandler)
+						stepRouter.POST("", handler.StepEndpoints.CreateStep, idh.Idempotent(), sh.CreateStepHandler)
+						stepRoutes(stepRouter.Group("/:stepId"), sh, "")
 					}
 				}
 			}
@@ -63,3 +146,24 @@ func SetupRouter(ge *gin.Engine, ih *handler.InteractionHandler, sh *handler.Ste
 		}
 	}
 }
+
+// stepRoutes are the routes of a step, under the nested path of its execution and under the
+// flattened paths that locate it by id. The flattened routes serve the operations of the nested
+// routes under the ids with the suffix of their scope.
+func stepRoutes(stepRouter *openapi.Router, sh *handler.StepHandler, scope string) {
+	located := func(endpoint openapi.Endpoint) openapi.Endpoint {
+		if scope != "" {
+			endpoint.Id += scope
+			endpoint.Summary += ", located by its id"
+		}
+		return endpoint
+	}
+	stepRouter.GET("", located(handler.StepEndpoints.GetStep), sh.GetStepHandler)
+	stepRouter.PUT("", located(handler.StepEndpoints.UpdateStep), sh.UpdateStepHandler)
+	stepRouter.PATCH("", located(handler.StepEndpoints.PatchStep), sh.PatchStepHandler)
+	stepRouter.POST("/status", located(handler.StepEndpoints.UpdateStatus), sh.UpdateStatusHandler)
+	stepRouter.POST("/tools", located(handler.StepEndpoints.AddToolInvocation), sh.AddToolInvocationHandler)
+	stepRouter.POST("/artifacts", located(handler.StepEndpoints.AddArtifact), sh.AddArtifactHandler)
+	stepRouter.DELETE("", located(handler.StepEndpoints.DeleteStep), sh.DeleteStepHandler)
+	stepRouter.GET("/history", located(handler.StepEndpoints.GetHistory), sh.GetHistoryHandler)
+}
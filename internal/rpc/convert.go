@@ -0,0 +1,501 @@
+package rpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	statev1 "github.com/mangudaigb/state-service/api/state/v1"
+	"github.com/mangudaigb/state-service/internal/events"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// The conversions between the runtime types and their protobuf messages. A nil message converts to
+// a nil value and the other way around, a zero time is an unset timestamp.
+
+var statuses = map[runtime.Status]statev1.Status{
+	runtime.StatusPending: statev1.Status_STATUS_PENDING,
+	runtime.StatusRunning: statev1.Status_STATUS_RUNNING,
+	runtime.StatusSuccess: statev1.Status_STATUS_SUCCESS,
+	runtime.StatusError:   statev1.Status_STATUS_ERROR,
+	runtime.StatusStop:    statev1.Status_STATUS_STOP,
+}
+
+func toStatus(s runtime.Status) statev1.Status {
+	return statuses[s]
+}
+
+func fromStatus(s statev1.Status) runtime.Status {
+	for status, v := range statuses {
+		if v == s {
+			return status
+		}
+	}
+	return ""
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func fromTimestamp(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+// toStruct converts a json object, values that are not plain json types, like typed slices, go
+// through their json encoding
+func toStruct(m map[string]any) *structpb.Struct {
+	if m == nil {
+		return nil
+	}
+	s, err := structpb.NewStruct(m)
+	if err == nil {
+		return s
+	}
+	s = &structpb.Struct{}
+	if b, err := json.Marshal(m); err == nil {
+		_ = protojson.Unmarshal(b, s)
+	}
+	return s
+}
+
+func fromStruct(s *structpb.Struct) map[string]any {
+	if s == nil {
+		return nil
+	}
+	return s.AsMap()
+}
+
+func toQuery(q *runtime.Query) *statev1.Query {
+	if q == nil {
+		return nil
+	}
+	return &statev1.Query{
+		Id:        q.ID,
+		Content:   q.Content,
+		Tags:      q.Tags,
+		Metadata:  q.Metadata,
+		Timestamp: toTimestamp(q.Timestamp),
+	}
+}
+
+func fromQuery(q *statev1.Query) *runtime.Query {
+	if q == nil {
+		return nil
+	}
+	return &runtime.Query{
+		ID:        q.Id,
+		Content:   q.Content,
+		Tags:      q.Tags,
+		Metadata:  q.Metadata,
+		Timestamp: fromTimestamp(q.Timestamp),
+	}
+}
+
+func toAnswer(a *runtime.Answer) *statev1.Answer {
+	if a == nil {
+		return nil
+	}
+	return &statev1.Answer{Id: a.ID, Content: a.Content, Timestamp: toTimestamp(a.Timestamp)}
+}
+
+func fromAnswer(a *statev1.Answer) *runtime.Answer {
+	if a == nil {
+		return nil
+	}
+	return &runtime.Answer{ID: a.Id, Content: a.Content, Timestamp: fromTimestamp(a.Timestamp)}
+}
+
+func toMessage(m *runtime.Message) *statev1.Message {
+	return &statev1.Message{
+		Id:        m.ID,
+		Role:      m.Role,
+		Query:     toQuery(m.Query),
+		Answer:    toAnswer(m.Answer),
+		Timestamp: toTimestamp(m.Timestamp),
+	}
+}
+
+func fromMessage(m *statev1.Message) runtime.Message {
+	return runtime.Message{
+		ID:        m.Id,
+		Role:      m.Role,
+		Query:     fromQuery(m.Query),
+		Answer:    fromAnswer(m.Answer),
+		Timestamp: fromTimestamp(m.Timestamp),
+	}
+}
+
+func toContext(c *runtime.Context) *statev1.Context {
+	if c == nil {
+		return nil
+	}
+	return &statev1.Context{Id: c.ID, Content: c.Content, Workspace: c.Workspace, Knowledge: c.Knowledge}
+}
+
+func fromContext(c *statev1.Context) *runtime.Context {
+	if c == nil {
+		return nil
+	}
+	return &runtime.Context{ID: c.Id, Content: c.Content, Workspace: c.Workspace, Knowledge: c.Knowledge}
+}
+
+func toAgent(a *runtime.Agent) *statev1.Agent {
+	if a == nil {
+		return nil
+	}
+	return &statev1.Agent{
+		Id:            a.ID,
+		Name:          a.Name,
+		Description:   a.Description,
+		Model:         a.Model,
+		Role:          a.Role,
+		SystemPrompt:  a.SystemPrompt,
+		UserPrompt:    a.UserPrompt,
+		Capabilities:  a.Capabilities,
+		Parameters:    toStruct(a.Parameters),
+		LastUpdatedAt: toTimestamp(a.LastUpdatedAt),
+	}
+}
+
+func fromAgent(a *statev1.Agent) *runtime.Agent {
+	if a == nil {
+		return nil
+	}
+	return &runtime.Agent{
+		ID:            a.Id,
+		Name:          a.Name,
+		Description:   a.Description,
+		Model:         a.Model,
+		Role:          a.Role,
+		SystemPrompt:  a.SystemPrompt,
+		UserPrompt:    a.UserPrompt,
+		Capabilities:  a.Capabilities,
+		Parameters:    fromStruct(a.Parameters),
+		LastUpdatedAt: fromTimestamp(a.LastUpdatedAt),
+	}
+}
+
+func toTool(t *runtime.Tool) *statev1.Tool {
+	return &statev1.Tool{
+		Name:         t.Name,
+		Description:  t.Description,
+		InputSchema:  toStruct(t.InputSchema),
+		OutputSchema: toStruct(t.OutputSchema),
+	}
+}
+
+func fromTool(t *statev1.Tool) *runtime.Tool {
+	if t == nil {
+		return nil
+	}
+	return &runtime.Tool{
+		Name:         t.Name,
+		Description:  t.Description,
+		InputSchema:  fromStruct(t.InputSchema),
+		OutputSchema: fromStruct(t.OutputSchema),
+	}
+}
+
+func toMcp(m *runtime.MCP) *statev1.Mcp {
+	if m == nil {
+		return nil
+	}
+	mcp := &statev1.Mcp{Id: m.ID, Name: m.Name}
+	for i := range m.Tools {
+		mcp.Tools = append(mcp.Tools, toTool(&m.Tools[i]))
+	}
+	return mcp
+}
+
+func fromMcp(m *statev1.Mcp) *runtime.MCP {
+	if m == nil {
+		return nil
+	}
+	mcp := &runtime.MCP{ID: m.Id, Name: m.Name}
+	for _, t := range m.Tools {
+		mcp.Tools = append(mcp.Tools, *fromTool(t))
+	}
+	return mcp
+}
+
+func toInvocation(i *runtime.McpToolInvocation) *statev1.McpToolInvocation {
+	return &statev1.McpToolInvocation{
+		Id:         i.ID,
+		ToolName:   i.ToolName,
+		McpId:      i.MCPID,
+		Category:   i.Category,
+		Input:      toStruct(i.Input),
+		Output:     toStruct(i.Output),
+		Status:     toStatus(i.Status),
+		AgentId:    i.AgentID,
+		StepId:     i.StepID,
+		StartedAt:  toTimestamp(i.StartedAt),
+		FinishedAt: toTimestamp(i.FinishedAt),
+		Error:      i.Error,
+	}
+}
+
+func fromInvocation(i *statev1.McpToolInvocation) *runtime.McpToolInvocation {
+	if i == nil {
+		return nil
+	}
+	return &runtime.McpToolInvocation{
+		ID:         i.Id,
+		ToolName:   i.ToolName,
+		MCPID:      i.McpId,
+		Category:   i.Category,
+		Input:      fromStruct(i.Input),
+		Output:     fromStruct(i.Output),
+		Status:     fromStatus(i.Status),
+		AgentID:    i.AgentId,
+		StepID:     i.StepId,
+		StartedAt:  fromTimestamp(i.StartedAt),
+		FinishedAt: fromTimestamp(i.FinishedAt),
+		Error:      i.Error,
+	}
+}
+
+func toArtifact(a *runtime.Artifact) *statev1.Artifact {
+	return &statev1.Artifact{
+		Id:              a.ID,
+		Name:            a.Name,
+		Path:            a.Path,
+		Type:            a.Type,
+		Content:         toStruct(a.Content),
+		CreatedByStepId: a.CreatedByStepID,
+		CreatedAt:       toTimestamp(a.CreatedAt),
+	}
+}
+
+func fromArtifact(a *statev1.Artifact) *runtime.Artifact {
+	if a == nil {
+		return nil
+	}
+	return &runtime.Artifact{
+		ID:              a.Id,
+		Name:            a.Name,
+		Path:            a.Path,
+		Type:            a.Type,
+		Content:         fromStruct(a.Content),
+		CreatedByStepID: a.CreatedByStepId,
+		CreatedAt:       fromTimestamp(a.CreatedAt),
+	}
+}
+
+func toPlan(p *runtime.Plan) *statev1.Plan {
+	if p == nil {
+		return nil
+	}
+	plan := &statev1.Plan{Id: p.ID}
+	for _, s := range p.Steps {
+		plan.Steps = append(plan.Steps, &statev1.PlanStep{Id: s.ID, Name: s.Name, Description: s.Description, DependsOn: s.DependsOn})
+	}
+	return plan
+}
+
+func fromPlan(p *statev1.Plan) *runtime.Plan {
+	if p == nil {
+		return nil
+	}
+	plan := &runtime.Plan{ID: p.Id}
+	for _, s := range p.Steps {
+		plan.Steps = append(plan.Steps, runtime.PlanStep{ID: s.Id, Name: s.Name, Description: s.Description, DependsOn: s.DependsOn})
+	}
+	return plan
+}
+
+func toGraph(g *runtime.ExecutionGraph) *statev1.ExecutionGraph {
+	if g == nil {
+		return nil
+	}
+	graph := &statev1.ExecutionGraph{Id: g.ID}
+	for _, n := range g.Nodes {
+		graph.Nodes = append(graph.Nodes, &statev1.ExecutionNode{StepId: n.StepId, Name: n.Name, Status: toStatus(n.Status)})
+	}
+	for _, e := range g.Edges {
+		graph.Edges = append(graph.Edges, &statev1.Edge{From: e.From, To: e.To, Type: e.Type, Label: e.Label})
+	}
+	return graph
+}
+
+func fromGraph(g *statev1.ExecutionGraph) *runtime.ExecutionGraph {
+	if g == nil {
+		return nil
+	}
+	graph := &runtime.ExecutionGraph{ID: g.Id}
+	for _, n := range g.Nodes {
+		graph.Nodes = append(graph.Nodes, runtime.ExecutionNode{StepId: n.StepId, Name: n.Name, Status: fromStatus(n.Status)})
+	}
+	for _, e := range g.Edges {
+		graph.Edges = append(graph.Edges, runtime.Edge{From: e.From, To: e.To, Type: e.Type, Label: e.Label})
+	}
+	return graph
+}
+
+func toFlow(f *runtime.ExecutionFlow) *statev1.ExecutionFlow {
+	if f == nil {
+		return nil
+	}
+	flow := &statev1.ExecutionFlow{
+		Id:               f.ID,
+		Name:             f.Name,
+		Description:      f.Description,
+		Variables:        f.Variables,
+		Mode:             f.Mode,
+		Graph:            toGraph(f.ExecutionGraph),
+		AvailableMcpRefs: f.AvailableMcpRefs,
+		PlanId:           f.PlanID,
+	}
+	for _, a := range f.Agents {
+		flow.Agents = append(flow.Agents, &statev1.AgentRef{Id: a.ID, Role: a.Role})
+	}
+	return flow
+}
+
+func fromFlow(f *statev1.ExecutionFlow) *runtime.ExecutionFlow {
+	if f == nil {
+		return nil
+	}
+	flow := &runtime.ExecutionFlow{
+		ID:               f.Id,
+		Name:             f.Name,
+		Description:      f.Description,
+		Variables:        f.Variables,
+		Mode:             f.Mode,
+		ExecutionGraph:   fromGraph(f.Graph),
+		AvailableMcpRefs: f.AvailableMcpRefs,
+		PlanID:           f.PlanId,
+	}
+	for _, a := range f.Agents {
+		flow.Agents = append(flow.Agents, runtime.AgentRef{ID: a.Id, Role: a.Role})
+	}
+	return flow
+}
+
+func toStep(s *runtime.Step) *statev1.Step {
+	if s == nil {
+		return nil
+	}
+	step := &statev1.Step{
+		Id:            s.ID,
+		Index:         int32(s.Index),
+		Name:          s.Name,
+		Status:        toStatus(s.Status),
+		Error:         s.Error,
+		Agent:         toAgent(s.Agent),
+		InputContext:  toContext(s.InputContext),
+		OutputContext: toContext(s.OutputContext),
+		Query:         toQuery(s.Query),
+		Answer:        toAnswer(s.Answer),
+		InputStepId:   s.InputStepID,
+		StartedAt:     toTimestamp(s.StartedAt),
+		FinishedAt:    toTimestamp(s.FinishedAt),
+	}
+	for i := range s.Artifacts {
+		step.Artifacts = append(step.Artifacts, toArtifact(&s.Artifacts[i]))
+	}
+	for i := range s.CuratedTools {
+		step.CuratedTools = append(step.CuratedTools, toInvocation(&s.CuratedTools[i]))
+	}
+	return step
+}
+
+func fromStep(s *statev1.Step) *runtime.Step {
+	if s == nil {
+		return nil
+	}
+	step := &runtime.Step{
+		ID:            s.Id,
+		Index:         int(s.Index),
+		Name:          s.Name,
+		Status:        fromStatus(s.Status),
+		Error:         s.Error,
+		Agent:         fromAgent(s.Agent),
+		InputContext:  fromContext(s.InputContext),
+		OutputContext: fromContext(s.OutputContext),
+		Query:         fromQuery(s.Query),
+		Answer:        fromAnswer(s.Answer),
+		InputStepID:   s.InputStepId,
+		StartedAt:     fromTimestamp(s.StartedAt),
+		FinishedAt:    fromTimestamp(s.FinishedAt),
+	}
+	for _, a := range s.Artifacts {
+		step.Artifacts = append(step.Artifacts, *fromArtifact(a))
+	}
+	for _, i := range s.CuratedTools {
+		step.CuratedTools = append(step.CuratedTools, *fromInvocation(i))
+	}
+	return step
+}
+
+func toInteraction(i *runtime.Interaction) *statev1.Interaction {
+	if i == nil {
+		return nil
+	}
+	interaction := &statev1.Interaction{
+		Id:          i.ID,
+		BaseQuery:   toQuery(i.BaseQuery),
+		BaseContext: toContext(i.BaseContext),
+		Plan:        toPlan(i.Plan),
+		Workflow:    toFlow(i.ExecutionFlow),
+		Summary:     i.Summary,
+		CreatedAt:   toTimestamp(i.CreatedAt),
+		CompletedAt: toTimestamp(i.CompletedAt),
+	}
+	for j := range i.Messages {
+		interaction.Messages = append(interaction.Messages, toMessage(&i.Messages[j]))
+	}
+	return interaction
+}
+
+func fromInteraction(i *statev1.Interaction) *runtime.Interaction {
+	if i == nil {
+		return nil
+	}
+	interaction := &runtime.Interaction{
+		ID:            i.Id,
+		BaseQuery:     fromQuery(i.BaseQuery),
+		BaseContext:   fromContext(i.BaseContext),
+		Plan:          fromPlan(i.Plan),
+		ExecutionFlow: fromFlow(i.Workflow),
+		Summary:       i.Summary,
+		CreatedAt:     fromTimestamp(i.CreatedAt),
+		CompletedAt:   fromTimestamp(i.CompletedAt),
+	}
+	for _, m := range i.Messages {
+		interaction.Messages = append(interaction.Messages, fromMessage(m))
+	}
+	return interaction
+}
+
+// toEvent converts an entry of the event stream, data that is not json is left out
+func toEvent(entry *events.StreamEntry) *statev1.InteractionEvent {
+	evt := &entry.Event
+	out := &statev1.InteractionEvent{
+		StreamId:      entry.StreamId,
+		Id:            evt.ID,
+		Type:          string(evt.Type),
+		InteractionId: evt.InteractionId,
+		WorkflowId:    evt.WorkflowId,
+		ExecutionId:   evt.ExecutionId,
+		StepId:        evt.StepId,
+		McpId:         evt.McpId,
+		Time:          toTimestamp(evt.Time),
+	}
+	if len(evt.Data) > 0 {
+		data := &structpb.Value{}
+		if err := protojson.Unmarshal(evt.Data, data); err == nil {
+			out.Data = data
+		}
+	}
+	return out
+}
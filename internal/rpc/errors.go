@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/patch"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeOf maps an error to its status code the way the REST API maps it to a response status,
+// errors without a kind are internal errors
+func codeOf(err error) codes.Code {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return codes.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return codes.DeadlineExceeded
+	case errors.Is(err, patch.ErrUnsupportedType), errors.Is(err, patch.ErrInvalidPatch):
+		return codes.InvalidArgument
+	case errors.Is(err, patch.ErrTestFailed), errors.Is(err, svc.ErrPreconditionFailed):
+		return codes.FailedPrecondition
+	case errors.Is(err, errs.ErrNotFound):
+		return codes.NotFound
+	case errors.Is(err, errs.ErrConflict):
+		return codes.Aborted
+	case errors.Is(err, errs.ErrInvalid):
+		return codes.InvalidArgument
+	case errors.Is(err, errs.ErrUnavailable):
+		return codes.Unavailable
+	case errors.Is(err, errs.ErrInternal):
+		return codes.Internal
+	default:
+		return codes.Internal
+	}
+}
+
+// statusOf converts an error of the services to a status error, the invalid fields of a rejected
+// payload are attached as a BadRequest detail
+func statusOf(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	st := status.New(codeOf(err), err.Error())
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		br := &errdetails.BadRequest{}
+		for _, f := range fields {
+			br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Message})
+		}
+		if detailed, derr := st.WithDetails(br); derr == nil {
+			st = detailed
+		}
+	}
+	return st.Err()
+}
@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	statev1 "github.com/mangudaigb/state-service/api/state/v1"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type InteractionServer struct {
+	statev1.UnimplementedInteractionServiceServer
+	log     *logger.Logger
+	tr      trace.Tracer
+	svc     svc.InteractionService
+	streams svc.EventStreamService
+	v       *validation.Validator
+	// quit is closed when the server shuts down, it ends the open watches
+	quit <-chan struct{}
+}
+
+func (is *InteractionServer) GetInteraction(ctx context.Context, req *statev1.GetInteractionRequest) (*statev1.Interaction, error) {
+	interaction, err := is.svc.GetById(ctx, req.InteractionId)
+	if err == nil && interaction == nil {
+		err = errs.NotFound("interaction id: %s not found", req.InteractionId)
+	}
+	if err != nil {
+		is.log.Errorf("Error while getting interaction by id: %v", err)
+		return nil, err
+	}
+	return toInteraction(interaction), nil
+}
+
+func (is *InteractionServer) CreateInteraction(ctx context.Context, req *statev1.CreateInteractionRequest) (*statev1.Interaction, error) {
+	if req.Interaction == nil {
+		return nil, errs.Invalid("interaction is required")
+	}
+	interaction := fromInteraction(req.Interaction)
+	if err := is.v.Interaction(interaction); err != nil {
+		return nil, err
+	}
+	interaction, err := is.svc.Create(ctx, interaction)
+	if err != nil {
+		is.log.Errorf("Error while creating interaction: %v", err)
+		return nil, err
+	}
+	return toInteraction(interaction), nil
+}
+
+func (is *InteractionServer) UpdateInteraction(ctx context.Context, req *statev1.UpdateInteractionRequest) (*statev1.Interaction, error) {
+	if req.Interaction == nil {
+		return nil, errs.Invalid("interaction is required")
+	}
+	interaction := fromInteraction(req.Interaction)
+	if err := is.v.Interaction(interaction); err != nil {
+		return nil, err
+	}
+	interaction, err := is.svc.Update(ctx, interaction)
+	if err != nil {
+		is.log.Errorf("Error while updating interaction: %v", err)
+		return nil, err
+	}
+	return toInteraction(interaction), nil
+}
+
+func (is *InteractionServer) DeleteInteraction(ctx context.Context, req *statev1.DeleteInteractionRequest) (*emptypb.Empty, error) {
+	if err := is.svc.DeleteById(ctx, req.InteractionId); err != nil {
+		is.log.Errorf("Error while deleting interaction: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (is *InteractionServer) UpdateExecutionFlow(ctx context.Context, req *statev1.UpdateExecutionFlowRequest) (*statev1.Interaction, error) {
+	if req.Workflow == nil {
+		return nil, errs.Invalid("workflow is required")
+	}
+	flow := fromFlow(req.Workflow)
+	if err := is.v.ExecutionFlow(flow); err != nil {
+		return nil, err
+	}
+	if req.WorkflowId != flow.ID {
+		return nil, errs.Invalid("Invalid workflow id")
+	}
+	interaction, err := is.svc.UpdateExecutionFlow(ctx, req.InteractionId, flow.ID, flow)
+	if err != nil {
+		is.log.Errorf("Error while updating workflow: %v", err)
+		return nil, err
+	}
+	return toInteraction(interaction), nil
+}
+
+func (is *InteractionServer) UpdateExecutionGraph(ctx context.Context, req *statev1.UpdateExecutionGraphRequest) (*statev1.Interaction, error) {
+	if req.Graph == nil {
+		return nil, errs.Invalid("graph is required")
+	}
+	graph := fromGraph(req.Graph)
+	if err := is.v.ExecutionGraph(graph); err != nil {
+		return nil, err
+	}
+	if req.ExecutionId != graph.ID {
+		return nil, errs.Invalid("Invalid execution id")
+	}
+	interaction, err := is.svc.UpdateExecutionGraph(ctx, req.InteractionId, req.WorkflowId, graph.ID, graph)
+	if err != nil {
+		is.log.Errorf("Error while updating execution graph: %v", err)
+		return nil, err
+	}
+	return toInteraction(interaction), nil
+}
+
+// WatchInteraction sends the events of the interaction as they are published, starting after
+// last_event_id like the server-sent events stream. It ends when the client cancels or the server
+// shuts down.
+func (is *InteractionServer) WatchInteraction(req *statev1.WatchInteractionRequest, stream statev1.InteractionService_WatchInteractionServer) error {
+	ctx := stream.Context()
+	if _, err := is.GetInteraction(ctx, &statev1.GetInteractionRequest{InteractionId: req.InteractionId}); err != nil {
+		return err
+	}
+	types := make([]events.Type, 0, len(req.Types))
+	for _, t := range req.Types {
+		types = append(types, events.Type(t))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	entries, err := is.streams.Subscribe(ctx, req.InteractionId, req.LastEventId, types)
+	if err != nil {
+		return errs.Unavailable(err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-is.quit:
+			return errs.Unavailable(errServerStopping)
+		case entry, ok := <-entries:
+			if !ok {
+				return nil
+			}
+			if err = stream.Send(toEvent(&entry)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func NewInteractionServer(log *logger.Logger, tr trace.Tracer, svc svc.InteractionService, streams svc.EventStreamService, v *validation.Validator, quit <-chan struct{}) *InteractionServer {
+	return &InteractionServer{
+		log:     log,
+		tr:      tr,
+		svc:     svc,
+		streams: streams,
+		v:       v,
+		quit:    quit,
+	}
+}
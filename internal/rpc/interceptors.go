@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	statev1 "github.com/mangudaigb/state-service/api/state/v1"
+	"github.com/mangudaigb/state-service/internal/audit"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// MetadataActor is the metadata key carrying the caller identity, like the X-Actor header
+	MetadataActor = "x-actor"
+
+	anonymousActor = "anonymous"
+)
+
+// readOnly are the method name prefixes of the operations that do not write
+var readOnly = []string{"Get", "List", "Watch"}
+
+// unaryErrors converts the errors of the services to status errors and logs the internal ones
+func unaryErrors(log *logger.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if err != nil {
+			err = statusOf(err)
+			if status.Code(err) == codes.Internal {
+				log.Errorf("Error while serving %s by error: %v", info.FullMethod, err)
+			}
+		}
+		return resp, err
+	}
+}
+
+func streamErrors(log *logger.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		err := statusOf(handler(srv, ss))
+		if status.Code(err) == codes.Internal {
+			log.Errorf("Error while serving %s by error: %v", info.FullMethod, err)
+		}
+		return err
+	}
+}
+
+// unaryAudit records the writes of every write operation in the audit log, the way the REST API
+// audits its write requests. The method is the path and the operation is the method name.
+func unaryAudit(auditSvc svc.AuditService) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		operation := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		for _, prefix := range readOnly {
+			if strings.HasPrefix(operation, prefix) {
+				return handler(ctx, req)
+			}
+		}
+		rec := audit.NewRecorder()
+		resp, err := handler(audit.WithRecorder(ctx, rec), req)
+
+		entry := &model.AuditEntry{
+			Actor:     actorOf(ctx),
+			Operation: operation,
+			Method:    "gRPC",
+			Path:      info.FullMethod,
+			Changes:   rec.Changes(),
+		}
+		if r, ok := req.(interface{ GetInteractionId() string }); ok {
+			entry.InteractionId = r.GetInteractionId()
+		}
+		if interaction, ok := resp.(*statev1.Interaction); ok && entry.InteractionId == "" {
+			entry.InteractionId = interaction.GetId()
+		}
+		if err != nil {
+			entry.Error = err.Error()
+		}
+		// the entry is written even when the client went away before the response
+		_ = auditSvc.Record(context.WithoutCancel(ctx), entry)
+		return resp, err
+	}
+}
+
+func actorOf(ctx context.Context) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, actor := range md.Get(MetadataActor) {
+		if actor = strings.TrimSpace(actor); actor != "" {
+			return actor
+		}
+	}
+	return anonymousActor
+}
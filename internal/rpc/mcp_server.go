@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	statev1 "github.com/mangudaigb/state-service/api/state/v1"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type McpServer struct {
+	statev1.UnimplementedMcpServiceServer
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.McpService
+	v   *validation.Validator
+}
+
+func (ms *McpServer) GetMcp(ctx context.Context, req *statev1.GetMcpRequest) (*statev1.Mcp, error) {
+	mcp, err := ms.svc.GetByInteractionIdAndWorkflowIdAndId(ctx, req.InteractionId, req.WorkflowId, req.McpId)
+	if err != nil {
+		ms.log.Errorf("Error while getting mcp: %v", err)
+		return nil, err
+	}
+	return toMcp(mcp), nil
+}
+
+func (ms *McpServer) CreateMcp(ctx context.Context, req *statev1.CreateMcpRequest) (*statev1.Mcp, error) {
+	if req.Mcp == nil {
+		return nil, errs.Invalid("mcp is required")
+	}
+	mcp := fromMcp(req.Mcp)
+	if err := ms.v.Mcp(mcp); err != nil {
+		return nil, err
+	}
+	mcp, err := ms.svc.CreateByInteractionIdAndWorkflowId(ctx, req.InteractionId, req.WorkflowId, mcp)
+	if err != nil {
+		ms.log.Errorf("Error while creating mcp: %v", err)
+		return nil, err
+	}
+	return toMcp(mcp), nil
+}
+
+func (ms *McpServer) UpdateMcp(ctx context.Context, req *statev1.UpdateMcpRequest) (*statev1.Mcp, error) {
+	if req.Mcp == nil {
+		return nil, errs.Invalid("mcp is required")
+	}
+	mcp := fromMcp(req.Mcp)
+	if err := ms.v.Mcp(mcp); err != nil {
+		return nil, err
+	}
+	mcp, err := ms.svc.UpdateByInteractionIdAndWorkflowId(ctx, req.InteractionId, req.WorkflowId, mcp)
+	if err != nil {
+		ms.log.Errorf("Error while updating mcp: %v", err)
+		return nil, err
+	}
+	return toMcp(mcp), nil
+}
+
+func (ms *McpServer) DeleteMcp(ctx context.Context, req *statev1.DeleteMcpRequest) (*emptypb.Empty, error) {
+	if err := ms.svc.DeleteByInteractionIdAndWorkflowIdAndId(ctx, req.InteractionId, req.WorkflowId, req.McpId); err != nil {
+		ms.log.Errorf("Error while deleting mcp: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (ms *McpServer) AddTool(ctx context.Context, req *statev1.AddToolRequest) (*statev1.Mcp, error) {
+	if req.Tool == nil {
+		return nil, errs.Invalid("tool is required")
+	}
+	tool := fromTool(req.Tool)
+	if err := ms.v.Tool(tool); err != nil {
+		return nil, err
+	}
+	mcp, err := ms.svc.AddTool(ctx, req.InteractionId, req.WorkflowId, req.McpId, tool)
+	if err != nil {
+		ms.log.Errorf("Error while adding tool: %v", err)
+		return nil, err
+	}
+	return toMcp(mcp), nil
+}
+
+func NewMcpServer(log *logger.Logger, tr trace.Tracer, svc svc.McpService, v *validation.Validator) *McpServer {
+	return &McpServer{
+		log: log,
+		tr:  tr,
+		svc: svc,
+		v:   v,
+	}
+}
@@ -0,0 +1,87 @@
+// Package rpc serves the gRPC API of api/state/v1. The servers convert between the protobuf
+// messages and the runtime types and call the same services as the REST handlers, with the same
+// validation, audit and error kinds.
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	statev1 "github.com/mangudaigb/state-service/api/state/v1"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+var errServerStopping = errors.New("server is shutting down")
+
+type Server struct {
+	log    *logger.Logger
+	tr     trace.Tracer
+	grpc   *grpc.Server
+	health *health.Server
+	quit   chan struct{}
+	once   sync.Once
+}
+
+// Serve accepts connections on lis until Shutdown, it returns nil once the server is stopped
+func (s *Server) Serve(lis net.Listener) error {
+	if err := s.grpc.Serve(lis); err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown ends the open watches and waits for the running calls to complete. The connections are
+// closed at once when ctx is done first.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.once.Do(func() {
+		s.health.Shutdown()
+		close(s.quit)
+	})
+	stopped := make(chan struct{})
+	go func() {
+		s.grpc.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		return nil
+	case <-ctx.Done():
+		s.grpc.Stop()
+		return ctx.Err()
+	}
+}
+
+func NewServer(log *logger.Logger, tr trace.Tracer, interactionSvc svc.InteractionService, stepSvc svc.StepService, mcpSvc svc.McpService, streams svc.EventStreamService, auditSvc svc.AuditService) *Server {
+	cfg := settings.GetGrpc()
+	s := &Server{
+		log:    log,
+		tr:     tr,
+		health: health.NewServer(),
+		quit:   make(chan struct{}),
+		grpc: grpc.NewServer(
+			grpc.MaxRecvMsgSize(cfg.MaxRecvMsgSize),
+			grpc.ChainUnaryInterceptor(unaryErrors(log), unaryAudit(auditSvc)),
+			grpc.ChainStreamInterceptor(streamErrors(log)),
+		),
+	}
+	v := validation.New(settings.GetValidation())
+	statev1.RegisterInteractionServiceServer(s.grpc, NewInteractionServer(log, tr, interactionSvc, streams, v, s.quit))
+	statev1.RegisterStepServiceServer(s.grpc, NewStepServer(log, tr, stepSvc, v))
+	statev1.RegisterToolInvocationServiceServer(s.grpc, NewToolInvocationServer(log, tr, stepSvc, v))
+	statev1.RegisterMcpServiceServer(s.grpc, NewMcpServer(log, tr, mcpSvc, v))
+	healthpb.RegisterHealthServer(s.grpc, s.health)
+	if cfg.Reflection {
+		reflection.Register(s.grpc)
+	}
+	return s
+}
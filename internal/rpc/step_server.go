@@ -0,0 +1,108 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	statev1 "github.com/mangudaigb/state-service/api/state/v1"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type StepServer struct {
+	statev1.UnimplementedStepServiceServer
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.StepService
+	v   *validation.Validator
+}
+
+func (ss *StepServer) GetStep(ctx context.Context, req *statev1.GetStepRequest) (*statev1.Step, error) {
+	step, err := ss.svc.GetByInteractionIdAndExecutionIdAndId(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, req.StepId)
+	if err != nil {
+		ss.log.Errorf("Error while getting step: %v", err)
+		return nil, err
+	}
+	return toStep(step), nil
+}
+
+func (ss *StepServer) CreateStep(ctx context.Context, req *statev1.CreateStepRequest) (*statev1.Step, error) {
+	if req.Step == nil {
+		return nil, errs.Invalid("step is required")
+	}
+	step := fromStep(req.Step)
+	if err := ss.v.Step(step); err != nil {
+		return nil, err
+	}
+	step, err := ss.svc.CreateByInteractionIdAndExecutionId(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, step)
+	if err != nil {
+		ss.log.Errorf("Error while creating step: %v", err)
+		return nil, err
+	}
+	return toStep(step), nil
+}
+
+func (ss *StepServer) UpdateStep(ctx context.Context, req *statev1.UpdateStepRequest) (*statev1.Step, error) {
+	if req.Step == nil {
+		return nil, errs.Invalid("step is required")
+	}
+	step := fromStep(req.Step)
+	if err := ss.v.Step(step); err != nil {
+		return nil, err
+	}
+	step, err := ss.svc.UpdateByInteractionIdAndExecutionId(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, step)
+	if err != nil {
+		ss.log.Errorf("Error while updating step: %v", err)
+		return nil, err
+	}
+	return toStep(step), nil
+}
+
+func (ss *StepServer) UpdateStepStatus(ctx context.Context, req *statev1.UpdateStepStatusRequest) (*statev1.Step, error) {
+	status := fromStatus(req.Status)
+	if err := ss.v.Status(status); err != nil {
+		return nil, err
+	}
+	step, err := ss.svc.UpdateStatusByInteractionIdAndExecutionIdAndId(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, req.StepId, status)
+	if err != nil {
+		ss.log.Errorf("Error while updating step status: %v", err)
+		return nil, err
+	}
+	return toStep(step), nil
+}
+
+func (ss *StepServer) DeleteStep(ctx context.Context, req *statev1.DeleteStepRequest) (*emptypb.Empty, error) {
+	if err := ss.svc.DeleteByInteractionIdAndExecutionIdAndId(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, req.StepId); err != nil {
+		ss.log.Errorf("Error while deleting step: %v", err)
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (ss *StepServer) AddArtifact(ctx context.Context, req *statev1.AddArtifactRequest) (*statev1.Step, error) {
+	if req.Artifact == nil {
+		return nil, errs.Invalid("artifact is required")
+	}
+	artifact := fromArtifact(req.Artifact)
+	if err := ss.v.Artifact(artifact); err != nil {
+		return nil, err
+	}
+	step, err := ss.svc.AddArtifact(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, req.StepId, artifact)
+	if err != nil {
+		ss.log.Errorf("Error while adding artifact: %v", err)
+		return nil, err
+	}
+	return toStep(step), nil
+}
+
+func NewStepServer(log *logger.Logger, tr trace.Tracer, svc svc.StepService, v *validation.Validator) *StepServer {
+	return &StepServer{
+		log: log,
+		tr:  tr,
+		svc: svc,
+		v:   v,
+	}
+}
@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	statev1 "github.com/mangudaigb/state-service/api/state/v1"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/internal/validation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ToolInvocationServer records the tool invocations of a step, they are kept as the curated tools
+// of the step
+type ToolInvocationServer struct {
+	statev1.UnimplementedToolInvocationServiceServer
+	log *logger.Logger
+	tr  trace.Tracer
+	svc svc.StepService
+	v   *validation.Validator
+}
+
+func (ts *ToolInvocationServer) RecordToolInvocation(ctx context.Context, req *statev1.RecordToolInvocationRequest) (*statev1.Step, error) {
+	if req.Invocation == nil {
+		return nil, errs.Invalid("invocation is required")
+	}
+	invocation := fromInvocation(req.Invocation)
+	if err := ts.v.ToolInvocation(invocation); err != nil {
+		return nil, err
+	}
+	step, err := ts.svc.AddToolInvocation(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, req.StepId, invocation)
+	if err != nil {
+		ts.log.Errorf("Error while recording tool invocation: %v", err)
+		return nil, err
+	}
+	return toStep(step), nil
+}
+
+func (ts *ToolInvocationServer) ListToolInvocations(ctx context.Context, req *statev1.ListToolInvocationsRequest) (*statev1.ListToolInvocationsResponse, error) {
+	step, err := ts.svc.GetByInteractionIdAndExecutionIdAndId(ctx, req.InteractionId, req.WorkflowId, req.ExecutionId, req.StepId)
+	if err != nil {
+		ts.log.Errorf("Error while getting step: %v", err)
+		return nil, err
+	}
+	resp := &statev1.ListToolInvocationsResponse{}
+	for i := range step.CuratedTools {
+		resp.Invocations = append(resp.Invocations, toInvocation(&step.CuratedTools[i]))
+	}
+	return resp, nil
+}
+
+func NewToolInvocationServer(log *logger.Logger, tr trace.Tracer, svc svc.StepService, v *validation.Validator) *ToolInvocationServer {
+	return &ToolInvocationServer{
+		log: log,
+		tr:  tr,
+		svc: svc,
+		v:   v,
+	}
+}
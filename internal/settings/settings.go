@@ -0,0 +1,340 @@
+// Package settings reads service specific keys from the application config that the shared
+// dhauli-base config.Config does not model. config.GetConfig loads the files into viper, so the
+// values are looked up there with a default for every key.
+package settings
+
+import (
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func seconds(key string, def int) time.Duration {
+	viper.SetDefault(key, def)
+	return time.Duration(viper.GetInt(key)) * time.Second
+}
+
+func millis(key string, def int) time.Duration {
+	viper.SetDefault(key, def)
+	return time.Duration(viper.GetInt(key)) * time.Millisecond
+}
+
+// list splits a comma separated value like the redis hosts or kafka brokers
+func list(key string) []string {
+	var values []string
+	for _, v := range strings.Split(viper.GetString(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+type McpDiscovery struct {
+	RefreshInterval time.Duration
+	Timeout         time.Duration
+	// Commands are the stdio server commands an endpoint may run, none when empty
+	Commands []string
+	// AllowPrivateNetworks lets http endpoints reach loopback, private and link local addresses
+	AllowPrivateNetworks bool
+}
+
+func GetMcpDiscovery() McpDiscovery {
+	viper.SetDefault("mcp.allowPrivateNetworks", false)
+	return McpDiscovery{
+		RefreshInterval:      seconds("mcp.refreshInterval", 300),
+		Timeout:              seconds("mcp.timeout", 15),
+		Commands:             list("mcp.commands"),
+		AllowPrivateNetworks: viper.GetBool("mcp.allowPrivateNetworks"),
+	}
+}
+
+// Kafka holds the settings of the state consumer beyond the brokers, group and topic of the
+// service config
+type Kafka struct {
+	DeadLetterTopic string
+	MaxRetries      int
+	RetryBackoff    time.Duration
+	// ProcessedTTL is how long a processed command id is remembered to skip its redelivery
+	ProcessedTTL time.Duration
+}
+
+func GetKafka() Kafka {
+	topic := viper.GetString("kafka.topic")
+	viper.SetDefault("kafka.deadLetterTopic", topic+".dlq")
+	viper.SetDefault("kafka.maxRetries", 3)
+	return Kafka{
+		DeadLetterTopic: viper.GetString("kafka.deadLetterTopic"),
+		MaxRetries:      viper.GetInt("kafka.maxRetries"),
+		RetryBackoff:    millis("kafka.retryBackoffMs", 200),
+		ProcessedTTL:    seconds("kafka.processedTtl", 604800),
+	}
+}
+
+type Events struct {
+	Broker       string
+	Topic        string
+	Protocol     string
+	Source       string
+	StreamMaxLen int64
+	StreamTTL    time.Duration
+	Heartbeat    time.Duration
+}
+
+// GetEvents reads the event bus settings, broker is either kafka or memory
+func GetEvents() Events {
+	viper.SetDefault("events.broker", "kafka")
+	viper.SetDefault("events.topic", "state.events")
+	viper.SetDefault("discovery.id", "state-service")
+	viper.SetDefault("events.streamMaxLen", 1000)
+	heartbeat := seconds("events.heartbeat", 15)
+	if heartbeat <= 0 {
+		// the heartbeat is also the block of the stream reads, where 0 blocks forever
+		heartbeat = 15 * time.Second
+	}
+	return Events{
+		Broker:       viper.GetString("events.broker"),
+		Topic:        viper.GetString("events.topic"),
+		Protocol:     viper.GetString("discovery.protocol"),
+		Source:       viper.GetString("discovery.id"),
+		StreamMaxLen: viper.GetInt64("events.streamMaxLen"),
+		StreamTTL:    seconds("events.streamTtl", 86400),
+		Heartbeat:    heartbeat,
+	}
+}
+
+type Subscriptions struct {
+	Buffer       int
+	MaxDropped   int64
+	PingInterval time.Duration
+	// TagCacheSize and TagCacheTTL bound the tags of interactions the hub keeps to match tag filters
+	TagCacheSize int
+	TagCacheTTL  time.Duration
+	// AllowedOrigins are the browser origins that may open a websocket besides the service's own,
+	// "*" allows any
+	AllowedOrigins []string
+}
+
+// GetSubscriptions reads the limits of websocket subscribers, a client that falls MaxDropped
+// events behind is disconnected
+func GetSubscriptions() Subscriptions {
+	viper.SetDefault("subscriptions.buffer", 256)
+	viper.SetDefault("subscriptions.maxDropped", 1000)
+	viper.SetDefault("subscriptions.tagCacheSize", 10000)
+	return Subscriptions{
+		Buffer:         viper.GetInt("subscriptions.buffer"),
+		MaxDropped:     viper.GetInt64("subscriptions.maxDropped"),
+		PingInterval:   seconds("subscriptions.pingInterval", 30),
+		TagCacheSize:   viper.GetInt("subscriptions.tagCacheSize"),
+		TagCacheTTL:    seconds("subscriptions.tagCacheTtl", 600),
+		AllowedOrigins: list("subscriptions.allowedOrigins"),
+	}
+}
+
+type Webhooks struct {
+	Timeout      time.Duration
+	MaxAttempts  int
+	BackoffBase  time.Duration
+	MaxBackoff   time.Duration
+	PollInterval time.Duration
+	LogSize      int
+	// CacheTTL is how long the dispatcher matches events against the webhooks it read, a write on
+	// another replica is seen after it at the latest
+	CacheTTL time.Duration
+	// AllowPrivateNetworks lets webhooks post to loopback, private and link local addresses
+	AllowPrivateNetworks bool
+}
+
+// GetWebhooks reads the delivery settings, the n-th retry waits BackoffBase*2^(n-1) capped at
+// MaxBackoff
+func GetWebhooks() Webhooks {
+	viper.SetDefault("webhooks.maxAttempts", 8)
+	viper.SetDefault("webhooks.logSize", 100)
+	viper.SetDefault("webhooks.allowPrivateNetworks", false)
+	return Webhooks{
+		Timeout:      seconds("webhooks.timeout", 10),
+		MaxAttempts:  viper.GetInt("webhooks.maxAttempts"),
+		BackoffBase:  seconds("webhooks.backoffBase", 2),
+		MaxBackoff:   seconds("webhooks.maxBackoff", 3600),
+		PollInterval: millis("webhooks.pollIntervalMs", 1000),
+		LogSize:      viper.GetInt("webhooks.logSize"),
+		CacheTTL:     seconds("webhooks.cacheTtl", 30),
+
+		AllowPrivateNetworks: viper.GetBool("webhooks.allowPrivateNetworks"),
+	}
+}
+
+type Audit struct {
+	Retention  time.Duration
+	QueryLimit int
+}
+
+// GetAudit reads the audit log settings, entries older than the retention are dropped
+func GetAudit() Audit {
+	viper.SetDefault("audit.retentionDays", 90)
+	viper.SetDefault("audit.queryLimit", 100)
+	return Audit{
+		Retention:  time.Duration(viper.GetInt("audit.retentionDays")) * 24 * time.Hour,
+		QueryLimit: viper.GetInt("audit.queryLimit"),
+	}
+}
+
+type Idempotency struct {
+	TTL     time.Duration
+	LockTTL time.Duration
+}
+
+// GetIdempotency reads how long responses are kept for retries with the same idempotency key and
+// how long a key stays reserved by a request that is still being served
+func GetIdempotency() Idempotency {
+	return Idempotency{
+		TTL:     seconds("idempotency.ttl", 86400),
+		LockTTL: seconds("idempotency.lockTtl", 60),
+	}
+}
+
+type Batch struct {
+	MaxOperations int
+	MaxAttempts   int
+}
+
+// GetBatch reads the batch limits, a batch that conflicts with a concurrent write is run again up
+// to MaxAttempts times
+func GetBatch() Batch {
+	viper.SetDefault("batch.maxOperations", 100)
+	viper.SetDefault("batch.maxAttempts", 3)
+	return Batch{
+		MaxOperations: viper.GetInt("batch.maxOperations"),
+		MaxAttempts:   viper.GetInt("batch.maxAttempts"),
+	}
+}
+
+type Grpc struct {
+	Port       int
+	Reflection bool
+	// MaxRecvMsgSize is the largest request in bytes the server accepts
+	MaxRecvMsgSize int
+}
+
+// GetGrpc reads the gRPC server settings, they sit next to server.port since the gRPC server runs
+// alongside the REST one
+func GetGrpc() Grpc {
+	viper.SetDefault("server.grpcPort", 9083)
+	viper.SetDefault("server.grpcReflection", false)
+	viper.SetDefault("server.grpcMaxRecvMsgSize", 4<<20)
+	return Grpc{
+		Port:           viper.GetInt("server.grpcPort"),
+		Reflection:     viper.GetBool("server.grpcReflection"),
+		MaxRecvMsgSize: viper.GetInt("server.grpcMaxRecvMsgSize"),
+	}
+}
+
+type GraphQL struct {
+	MaxDepth       int
+	MaxParallelism int
+	// LoaderWait is how long a lookup waits for others to read them together
+	LoaderWait  time.Duration
+	LoaderBatch int
+}
+
+// GetGraphQL reads the limits of the graphql endpoint and the batching of its step and mcp lookups
+func GetGraphQL() GraphQL {
+	viper.SetDefault("graphql.maxDepth", 12)
+	viper.SetDefault("graphql.maxParallelism", 10)
+	viper.SetDefault("graphql.loaderBatch", 100)
+	return GraphQL{
+		MaxDepth:       viper.GetInt("graphql.maxDepth"),
+		MaxParallelism: viper.GetInt("graphql.maxParallelism"),
+		LoaderWait:     millis("graphql.loaderWaitMs", 2),
+		LoaderBatch:    viper.GetInt("graphql.loaderBatch"),
+	}
+}
+
+type Validation struct {
+	MaxIdLength   int
+	MaxNameLength int
+	MaxTextLength int
+	MaxItems      int
+	ClockSkew     time.Duration
+	ArtifactTypes []string
+}
+
+// GetValidation reads the limits request payloads are checked against. Timestamps may be up to
+// ClockSkew ahead of the server clock.
+func GetValidation() Validation {
+	viper.SetDefault("validation.maxIdLength", 128)
+	viper.SetDefault("validation.maxNameLength", 256)
+	viper.SetDefault("validation.maxTextLength", 65536)
+	viper.SetDefault("validation.maxItems", 1000)
+	viper.SetDefault("validation.artifactTypes", "log_snippet,query_result,doc_summary,root_cause,remediation")
+	return Validation{
+		MaxIdLength:   viper.GetInt("validation.maxIdLength"),
+		MaxNameLength: viper.GetInt("validation.maxNameLength"),
+		MaxTextLength: viper.GetInt("validation.maxTextLength"),
+		MaxItems:      viper.GetInt("validation.maxItems"),
+		ClockSkew:     seconds("validation.clockSkew", 60),
+		ArtifactTypes: list("validation.artifactTypes"),
+	}
+}
+
+type Redis struct {
+	Addrs    []string
+	Username string
+	Password string
+	UseTLS   bool
+	Timeout  time.Duration
+}
+
+// GetRedis reads the redis connection for clients that need more than db.RedisStore offers
+func GetRedis() Redis {
+	return Redis{
+		Addrs:    list("redis.host"),
+		Username: viper.GetString("redis.username"),
+		Password: viper.GetString("redis.password"),
+		UseTLS:   viper.GetBool("redis.useTLS"),
+		Timeout:  seconds("redis.timeout", 30),
+	}
+}
+
+type Outbox struct {
+	Interval   time.Duration
+	BatchSize  int
+	MaxBackoff time.Duration
+	LockTTL    time.Duration
+	// UnitTimeout is how long a unit of work spread over several slots may take to commit before
+	// the relay takes it for abandoned and settles it
+	UnitTimeout time.Duration
+}
+
+// History bounds the mutation log kept for every document written through the outbox
+type History struct {
+	// MaxRecords is the number of records kept per document, older ones are trimmed up to a base
+	// record. 0 keeps every record.
+	MaxRecords int64
+	// BaseEvery is how many versions apart the base records holding the whole document are
+	BaseEvery int64
+	// DeletedTTL is how long the log of a deleted document is kept, 0 keeps it
+	DeletedTTL time.Duration
+}
+
+func GetHistory() History {
+	viper.SetDefault("history.maxRecords", 1000)
+	viper.SetDefault("history.baseEvery", 100)
+	return History{
+		MaxRecords: viper.GetInt64("history.maxRecords"),
+		BaseEvery:  viper.GetInt64("history.baseEvery"),
+		DeletedTTL: seconds("history.deletedTtl", 86400),
+	}
+}
+
+func GetOutbox() Outbox {
+	viper.SetDefault("outbox.batchSize", 100)
+	return Outbox{
+		Interval:    millis("outbox.intervalMs", 500),
+		BatchSize:   viper.GetInt("outbox.batchSize"),
+		MaxBackoff:  seconds("outbox.maxBackoff", 30),
+		LockTTL:     seconds("outbox.lockTtl", 10),
+		UnitTimeout: seconds("outbox.unitTimeout", 60),
+	}
+}
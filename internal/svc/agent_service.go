@@ -0,0 +1,248 @@
+package svc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type AgentService interface {
+	Create(ctx context.Context, agent *runtime.Agent, comment string) (*model.AgentVersion, error)
+	AddVersion(ctx context.Context, agentId string, agent *runtime.Agent, comment string) (*model.AgentVersion, error)
+	GetHead(ctx context.Context, agentId string) (*model.AgentHead, error)
+	GetVersion(ctx context.Context, agentId string, version int) (*model.AgentVersion, error)
+	List(ctx context.Context) ([]*model.AgentHead, error)
+
+	Resolve(ctx context.Context, ref string) (*runtime.Agent, error)
+	PinStep(ctx context.Context, step *runtime.Step) error
+	ResolveStep(ctx context.Context, step *runtime.Step) error
+	PinExecutionFlow(ctx context.Context, flow *runtime.ExecutionFlow) error
+	ResolveExecutionFlow(ctx context.Context, flow *runtime.ExecutionFlow) error
+}
+
+type agentService struct {
+	log  *logger.Logger
+	tr   trace.Tracer
+	repo repo.AgentRepo
+}
+
+func (as *agentService) Create(ctx context.Context, agent *runtime.Agent, comment string) (*model.AgentVersion, error) {
+	if agent.ID == "" {
+		agent.ID = uuid.NewString()
+	}
+	if strings.Contains(agent.ID, "@") {
+		return nil, errs.Invalid("invalid agent id: %s", agent.ID)
+	}
+	if head, err := as.repo.GetHead(ctx, agent.ID); err == nil && head != nil {
+		return nil, errs.Conflict("agent id: %s already exists", agent.ID)
+	}
+	now := time.Now()
+	head := &model.AgentHead{ID: agent.ID, CreatedAt: now}
+	return as.saveVersion(ctx, head, agent, comment, now)
+}
+
+// AddVersion stores the definition as the next version, earlier versions are never rewritten
+func (as *agentService) AddVersion(ctx context.Context, agentId string, agent *runtime.Agent, comment string) (*model.AgentVersion, error) {
+	head, err := as.repo.GetHead(ctx, agentId)
+	if err != nil {
+		as.log.Errorf("Error while getting agent id: %s by error: %v", agentId, err)
+		return nil, err
+	}
+	agent.ID = agentId
+	return as.saveVersion(ctx, head, agent, comment, time.Now())
+}
+
+func (as *agentService) saveVersion(ctx context.Context, head *model.AgentHead, agent *runtime.Agent, comment string, now time.Time) (*model.AgentVersion, error) {
+	// a concurrent writer of the same version makes SaveVersion a Conflict
+	version := head.LatestVersion + 1
+	agent.LastUpdatedAt = now
+	av := &model.AgentVersion{
+		AgentId:   head.ID,
+		Version:   version,
+		Agent:     *agent,
+		Digest:    agentDigest(agent),
+		Comment:   comment,
+		CreatedAt: now,
+	}
+	if err := as.repo.SaveVersion(ctx, av); err != nil {
+		as.log.Errorf("Error while saving agent id: %s version: %d by error: %v", head.ID, version, err)
+		return nil, err
+	}
+	head.LatestVersion = version
+	head.UpdatedAt = now
+	if err := as.repo.SaveHead(ctx, head); err != nil {
+		as.log.Errorf("Error while saving agent id: %s by error: %v", head.ID, err)
+		return nil, err
+	}
+	return av, nil
+}
+
+func (as *agentService) GetHead(ctx context.Context, agentId string) (*model.AgentHead, error) {
+	return as.repo.GetHead(ctx, agentId)
+}
+
+// GetVersion returns the requested version, version 0 returns the latest one
+func (as *agentService) GetVersion(ctx context.Context, agentId string, version int) (*model.AgentVersion, error) {
+	if version == 0 {
+		head, err := as.repo.GetHead(ctx, agentId)
+		if err != nil {
+			return nil, err
+		}
+		version = head.LatestVersion
+	}
+	return as.repo.GetVersion(ctx, agentId, version)
+}
+
+func (as *agentService) List(ctx context.Context) ([]*model.AgentHead, error) {
+	ids, err := as.repo.List(ctx)
+	if err != nil {
+		as.log.Errorf("Error while listing agents: %v", err)
+		return nil, err
+	}
+	heads := make([]*model.AgentHead, 0, len(ids))
+	for _, id := range ids {
+		head, err := as.repo.GetHead(ctx, id)
+		if err != nil {
+			as.log.Warnf("Skipping agent id: %s listed in index: %v", id, err)
+			continue
+		}
+		heads = append(heads, head)
+	}
+	return heads, nil
+}
+
+// Resolve returns the definition behind an `agentId@version` reference. The returned agent keeps
+// the reference as its id so readers can tell which version produced the step.
+func (as *agentService) Resolve(ctx context.Context, ref string) (*runtime.Agent, error) {
+	agentId, version, ok := model.ParseAgentRef(ref)
+	if !ok {
+		return nil, errs.Invalid("invalid agent reference: %s", ref)
+	}
+	av, err := as.GetVersion(ctx, agentId, version)
+	if err != nil {
+		return nil, err
+	}
+	agent := av.Agent
+	agent.ID = model.FormatAgentRef(av.AgentId, av.Version)
+	return &agent, nil
+}
+
+// pin turns a registry agent id into a versioned reference to its latest version. Ids unknown
+// to the registry are left as they are, those agents are embedded the old way.
+func (as *agentService) pin(ctx context.Context, ref string) (string, bool, error) {
+	agentId, version, ok := model.ParseAgentRef(ref)
+	if !ok {
+		return ref, false, nil
+	}
+	head, err := as.repo.GetHead(ctx, agentId)
+	if err != nil || head == nil {
+		if version > 0 {
+			return "", false, errs.Invalid("unknown agent reference: %s", ref)
+		}
+		return ref, false, nil
+	}
+	if version == 0 {
+		version = head.LatestVersion
+	}
+	if version > head.LatestVersion {
+		return "", false, errs.Invalid("unknown agent reference: %s", ref)
+	}
+	return model.FormatAgentRef(agentId, version), true, nil
+}
+
+// PinStep replaces a registry agent on the step with a pinned reference, the definition itself
+// is resolved again on read.
+func (as *agentService) PinStep(ctx context.Context, step *runtime.Step) error {
+	if step.Agent == nil || step.Agent.ID == "" {
+		return nil
+	}
+	ref, registered, err := as.pin(ctx, step.Agent.ID)
+	if err != nil {
+		return err
+	}
+	if registered {
+		if inline := *step.Agent; agentDigest(&inline) != agentDigest(&runtime.Agent{ID: inline.ID}) {
+			as.log.Warnf("Dropping the inline definition of agent: %s on step id: %s for the registered %s", inline.ID, step.ID, ref)
+		}
+		step.Agent = &runtime.Agent{ID: ref}
+	}
+	return nil
+}
+
+func (as *agentService) ResolveStep(ctx context.Context, step *runtime.Step) error {
+	if step.Agent == nil || !isPinned(step.Agent.ID) {
+		return nil
+	}
+	agent, err := as.Resolve(ctx, step.Agent.ID)
+	if err != nil {
+		as.log.Errorf("Error while resolving agent: %s of step id: %s by error: %v", step.Agent.ID, step.ID, err)
+		return err
+	}
+	step.Agent = agent
+	return nil
+}
+
+func (as *agentService) PinExecutionFlow(ctx context.Context, flow *runtime.ExecutionFlow) error {
+	if flow == nil {
+		return nil
+	}
+	for i, ar := range flow.Agents {
+		ref, _, err := as.pin(ctx, ar.ID)
+		if err != nil {
+			return err
+		}
+		flow.Agents[i].ID = ref
+	}
+	return nil
+}
+
+// ResolveExecutionFlow fills the role of pinned agent refs from their registered definition
+func (as *agentService) ResolveExecutionFlow(ctx context.Context, flow *runtime.ExecutionFlow) error {
+	if flow == nil {
+		return nil
+	}
+	for i, ar := range flow.Agents {
+		if !isPinned(ar.ID) {
+			continue
+		}
+		agent, err := as.Resolve(ctx, ar.ID)
+		if err != nil {
+			as.log.Errorf("Error while resolving agent: %s of workflow id: %s by error: %v", ar.ID, flow.ID, err)
+			return err
+		}
+		flow.Agents[i].Role = agent.Role
+	}
+	return nil
+}
+
+func isPinned(ref string) bool {
+	_, version, ok := model.ParseAgentRef(ref)
+	return ok && version > 0
+}
+
+func agentDigest(agent *runtime.Agent) string {
+	a := *agent
+	a.LastUpdatedAt = time.Time{}
+	b, _ := json.Marshal(a)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func NewAgentService(log *logger.Logger, tr trace.Tracer, repo repo.AgentRepo) AgentService {
+	return &agentService{
+		log:  log,
+		tr:   tr,
+		repo: repo,
+	}
+}
@@ -0,0 +1,66 @@
+package svc
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// AuditService keeps the audit trail of the writes made through the api and the command topic
+type AuditService interface {
+	Record(ctx context.Context, entry *model.AuditEntry) error
+	Query(ctx context.Context, query model.AuditQuery) ([]*model.AuditEntry, error)
+}
+
+type auditService struct {
+	log       *logger.Logger
+	tr        trace.Tracer
+	auditRepo repo.AuditRepo
+	cfg       settings.Audit
+}
+
+// Record stores the entry, the interaction is taken from the written keys when the operation did
+// not name one, like the creation of an interaction
+func (as *auditService) Record(ctx context.Context, entry *model.AuditEntry) error {
+	if entry.ID == "" {
+		entry.ID = uuid.NewString()
+	}
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if entry.InteractionId == "" {
+		for _, change := range entry.Changes {
+			if iid, ok := repo.InteractionOf(change.Key); ok {
+				entry.InteractionId = iid
+				break
+			}
+		}
+	}
+	if err := as.auditRepo.Save(ctx, entry, as.cfg.Retention); err != nil {
+		as.log.Errorf("Error while saving audit entry of %s by actor: %s by error: %v", entry.Operation, entry.Actor, err)
+		return err
+	}
+	return nil
+}
+
+func (as *auditService) Query(ctx context.Context, query model.AuditQuery) ([]*model.AuditEntry, error) {
+	if query.Limit <= 0 || query.Limit > as.cfg.QueryLimit {
+		query.Limit = as.cfg.QueryLimit
+	}
+	return as.auditRepo.Query(ctx, query)
+}
+
+func NewAuditService(log *logger.Logger, tr trace.Tracer, auditRepo repo.AuditRepo) AuditService {
+	return &auditService{
+		log:       log,
+		tr:        tr,
+		auditRepo: auditRepo,
+		cfg:       settings.GetAudit(),
+	}
+}
@@ -0,0 +1,135 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/patch"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchService runs several writes on one interaction as a whole. The operations run in order in a
+// unit of work, each one seeing the writes of those before it, and nothing is written unless all of
+// them succeed.
+type BatchService interface {
+	Execute(ctx context.Context, req *model.BatchRequest) (*model.BatchResponse, error)
+}
+
+// BatchError is the failure of one operation of a batch, it matches the error of the operation
+type BatchError struct {
+	Index int
+	Op    model.BatchOp
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+type batchService struct {
+	log            *logger.Logger
+	tr             trace.Tracer
+	interactionSvc InteractionService
+	stepSvc        StepService
+	outboxRepo     repo.OutboxRepo
+	cfg            settings.Batch
+}
+
+// Execute runs the operations and commits their writes. A batch whose interaction or steps were
+// changed by a concurrent write before the commit is run again on the new state.
+func (bs *batchService) Execute(ctx context.Context, req *model.BatchRequest) (*model.BatchResponse, error) {
+	if len(req.Operations) == 0 {
+		return nil, errs.Invalid("a batch needs at least one operation")
+	}
+	if len(req.Operations) > bs.cfg.MaxOperations {
+		return nil, errs.Invalid("a batch has at most %d operations", bs.cfg.MaxOperations)
+	}
+	var err error
+	for attempt := 0; attempt < bs.cfg.MaxAttempts; attempt++ {
+		var resp *model.BatchResponse
+		resp, err = bs.execute(ctx, req)
+		if !errors.Is(err, repo.ErrRevisionConflict) {
+			return resp, err
+		}
+	}
+	bs.log.Errorf("Error while committing batch of %d operations on interaction id: %s by error: %v", len(req.Operations), req.InteractionId, err)
+	return nil, err
+}
+
+func (bs *batchService) execute(ctx context.Context, req *model.BatchRequest) (*model.BatchResponse, error) {
+	uow := repo.NewUnitOfWork()
+	staged := repo.WithUnitOfWork(ctx, uow)
+	if _, err := bs.interactionSvc.GetById(staged, req.InteractionId); err != nil {
+		return nil, err
+	}
+	resp := &model.BatchResponse{InteractionId: req.InteractionId, Results: make([]model.BatchResult, 0, len(req.Operations))}
+	for i := range req.Operations {
+		op := &req.Operations[i]
+		result, err := bs.apply(staged, req.InteractionId, op)
+		if err != nil {
+			if errors.Is(err, repo.ErrRevisionConflict) {
+				return nil, err
+			}
+			return nil, &BatchError{Index: i, Op: op.Op, Err: err}
+		}
+		resp.Results = append(resp.Results, model.BatchResult{Index: i, Op: op.Op, Result: result})
+	}
+	if err := bs.outboxRepo.Commit(ctx, uow); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (bs *batchService) apply(ctx context.Context, interactionId string, op *model.BatchOperation) (any, error) {
+	switch op.Op {
+	case model.BatchCreateStep:
+		return bs.stepSvc.CreateByInteractionIdAndExecutionId(ctx, interactionId, op.WorkflowId, op.ExecutionId, op.Step)
+	case model.BatchUpdateStep:
+		return bs.stepSvc.UpdateByInteractionIdAndExecutionId(ctx, interactionId, op.WorkflowId, op.ExecutionId, op.Step)
+	case model.BatchPatchStep:
+		step, _, err := bs.stepSvc.PatchByInteractionIdAndExecutionIdAndId(ctx, interactionId, op.WorkflowId, op.ExecutionId, op.StepId, batchPatch(op))
+		return step, err
+	case model.BatchUpdateStepStatus:
+		return bs.stepSvc.UpdateStatusByInteractionIdAndExecutionIdAndId(ctx, interactionId, op.WorkflowId, op.ExecutionId, op.StepId, op.Status)
+	case model.BatchAddArtifact:
+		return bs.stepSvc.AddArtifact(ctx, interactionId, op.WorkflowId, op.ExecutionId, op.StepId, op.Artifact)
+	case model.BatchRecordToolInvocation:
+		return bs.stepSvc.AddToolInvocation(ctx, interactionId, op.WorkflowId, op.ExecutionId, op.StepId, op.Invocation)
+	case model.BatchPatchInteraction:
+		interaction, _, err := bs.interactionSvc.Patch(ctx, interactionId, batchPatch(op))
+		return interaction, err
+	default:
+		return nil, errs.Invalid("unknown operation: %s", op.Op)
+	}
+}
+
+// batchPatch reads the patch of an operation, an array is a JSON patch and an object a merge patch
+func batchPatch(op *model.BatchOperation) PatchRequest {
+	contentType := patch.MergePatchType
+	if bytes.HasPrefix(bytes.TrimSpace(op.Patch), []byte("[")) {
+		contentType = patch.JSONPatchType
+	}
+	return PatchRequest{ContentType: contentType, Body: op.Patch}
+}
+
+func NewBatchService(log *logger.Logger, tr trace.Tracer, interactionSvc InteractionService, stepSvc StepService, outboxRepo repo.OutboxRepo) BatchService {
+	return &batchService{
+		log:            log,
+		tr:             tr,
+		interactionSvc: interactionSvc,
+		stepSvc:        stepSvc,
+		outboxRepo:     outboxRepo,
+		cfg:            settings.GetBatch(),
+	}
+}
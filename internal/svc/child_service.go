@@ -0,0 +1,304 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ChildService runs interactions as nodes of the graph of a parent interaction. The status of the
+// node follows the child through the interaction repo of NewChildSyncRepo.
+type ChildService interface {
+	Create(ctx context.Context, parentId, workflowId, executionId string, req *model.CreateChildRequest) (*model.ChildLink, error)
+	Children(ctx context.Context, interactionId string) ([]model.ChildLink, error)
+	Tree(ctx context.Context, interactionId string) (*model.InteractionTree, error)
+}
+
+type childService struct {
+	log        *logger.Logger
+	tr         trace.Tracer
+	childRepo  repo.ChildRepo
+	workflows  workflows
+	outboxRepo repo.OutboxRepo
+	agentSvc   AgentService
+}
+
+// Create creates the child interaction with a pending node for it in the execution graph of the
+// parent. The child, its node and the links between them are committed together.
+func (cs *childService) Create(ctx context.Context, parentId, workflowId, executionId string, req *model.CreateChildRequest) (*model.ChildLink, error) {
+	child := req.Interaction
+	if child.ID == "" {
+		child.ID = uuid.NewString()
+	}
+	if req.NodeId == "" {
+		req.NodeId = child.ID
+	}
+	if req.Name == "" {
+		req.Name = req.NodeId
+	}
+	if child.ID == parentId {
+		return nil, errs.Invalid("an interaction cannot be its own child")
+	}
+	child.CreatedAt = time.Now()
+	if err := cs.agentSvc.PinExecutionFlow(ctx, child.ExecutionFlow); err != nil {
+		cs.log.Errorf("Error while pinning workflow agents of child interaction: %s by error: %v", child.ID, err)
+		return nil, err
+	}
+	link := &model.ChildLink{
+		ParentId:    parentId,
+		WorkflowId:  workflowId,
+		ExecutionId: executionId,
+		NodeId:      req.NodeId,
+		ChildId:     child.ID,
+		CreatedAt:   child.CreatedAt,
+	}
+	err := commitUnit(ctx, cs.outboxRepo, func(ctx context.Context) error {
+		if _, err := cs.workflows.interactionRepo.Get(ctx, child.ID); err == nil {
+			return errs.Conflict("interaction id: %s already exists", child.ID)
+		} else if !errors.Is(err, errs.ErrNotFound) {
+			return err
+		}
+		ref, err := cs.workflows.findGraph(ctx, parentId, workflowId, executionId)
+		if err != nil {
+			return err
+		}
+		for _, node := range ref.flow.ExecutionGraph.Nodes {
+			if node.StepId == req.NodeId {
+				return errs.Conflict("node id: %s already exists in execution graph: %s", req.NodeId, executionId)
+			}
+		}
+		ref.flow.ExecutionGraph.Nodes = append(ref.flow.ExecutionGraph.Nodes, runtime.ExecutionNode{
+			StepId: req.NodeId,
+			Name:   req.Name,
+			Status: runtime.StatusPending,
+		})
+		evt := stepEvent(events.ChildCreated, parentId, workflowId, executionId, req.NodeId, link)
+		if err = cs.workflows.save(ctx, ref, evt); err != nil {
+			return err
+		}
+		if err = cs.workflows.interactionRepo.Save(ctx, child, events.New(events.InteractionCreated, child.ID, child)); err != nil {
+			return err
+		}
+		return cs.childRepo.Link(ctx, link)
+	})
+	if err != nil {
+		cs.log.Errorf("Error while creating child interaction: %s of interaction id: %s by error: %v", child.ID, parentId, err)
+		return nil, err
+	}
+	return link, nil
+}
+
+func (cs *childService) Children(ctx context.Context, interactionId string) ([]model.ChildLink, error) {
+	if _, err := cs.workflows.interactionRepo.Get(ctx, interactionId); err != nil {
+		return nil, err
+	}
+	set, err := cs.childRepo.Children(ctx, interactionId)
+	if err != nil {
+		cs.log.Errorf("Error while getting children of interaction id: %s by error: %v", interactionId, err)
+		return nil, err
+	}
+	if set.Children == nil {
+		return []model.ChildLink{}, nil
+	}
+	return set.Children, nil
+}
+
+// Tree returns the interaction with its children, and theirs, down to the interactions without
+// children. A child that was deleted is left out.
+func (cs *childService) Tree(ctx context.Context, interactionId string) (*model.InteractionTree, error) {
+	root, err := cs.workflows.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	visited := map[string]bool{}
+	var grow func(interaction *runtime.Interaction, link *model.ChildLink) (*model.InteractionTree, error)
+	grow = func(interaction *runtime.Interaction, link *model.ChildLink) (*model.InteractionTree, error) {
+		visited[interaction.ID] = true
+		if err := cs.agentSvc.ResolveExecutionFlow(ctx, interaction.ExecutionFlow); err != nil {
+			return nil, err
+		}
+		tree := &model.InteractionTree{
+			Interaction: interaction,
+			Link:        link,
+			Status:      childStatus(interaction),
+			Children:    []*model.InteractionTree{},
+		}
+		set, err := cs.childRepo.Children(ctx, interaction.ID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range set.Children {
+			childLink := &set.Children[i]
+			if visited[childLink.ChildId] {
+				continue
+			}
+			child, err := cs.workflows.interactionRepo.Get(ctx, childLink.ChildId)
+			if errors.Is(err, errs.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			subtree, err := grow(child, childLink)
+			if err != nil {
+				return nil, err
+			}
+			tree.Children = append(tree.Children, subtree)
+		}
+		return tree, nil
+	}
+	tree, err := grow(root, nil)
+	if err != nil {
+		cs.log.Errorf("Error while building the tree of interaction id: %s by error: %v", interactionId, err)
+		return nil, err
+	}
+	return tree, nil
+}
+
+// childStatus is the status an interaction gives the node it runs under. It is running once a node
+// of its graph has started and is decided when it completes: error when a node failed, stop when
+// one was stopped, success otherwise.
+func childStatus(interaction *runtime.Interaction) runtime.Status {
+	var nodes []runtime.ExecutionNode
+	if flow := interaction.ExecutionFlow; flow != nil && flow.ExecutionGraph != nil {
+		nodes = flow.ExecutionGraph.Nodes
+	}
+	if !interaction.CompletedAt.IsZero() {
+		status := runtime.StatusSuccess
+		for _, node := range nodes {
+			switch node.Status {
+			case runtime.StatusError:
+				return runtime.StatusError
+			case runtime.StatusStop:
+				status = runtime.StatusStop
+			}
+		}
+		return status
+	}
+	for _, node := range nodes {
+		if node.Status != "" && node.Status != runtime.StatusPending {
+			return runtime.StatusRunning
+		}
+	}
+	return runtime.StatusPending
+}
+
+// childNodes returns the ids of the nodes of the execution graph that run child interactions,
+// which have no step document
+func childNodes(ctx context.Context, childRepo repo.ChildRepo, interactionId, executionId string) (map[string]bool, error) {
+	set, err := childRepo.Children(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make(map[string]bool, len(set.Children))
+	for _, link := range set.Children {
+		if link.ExecutionId == executionId {
+			nodes[link.NodeId] = true
+		}
+	}
+	return nodes, nil
+}
+
+// childSyncRepo writes interactions and sets the status of the node a child runs under in its
+// parent in the same unit of work, so the parent graph moves on with the child or not at all. The
+// parent is written through this repo as well, which carries the change up to its own parent.
+type childSyncRepo struct {
+	repo.InteractionRepo
+	log        *logger.Logger
+	childRepo  repo.ChildRepo
+	workflows  workflows
+	outboxRepo repo.OutboxRepo
+}
+
+func (cr *childSyncRepo) Save(ctx context.Context, interaction *runtime.Interaction, evts ...*events.Event) error {
+	return cr.write(ctx, interaction, evts, cr.InteractionRepo.Save)
+}
+
+func (cr *childSyncRepo) Update(ctx context.Context, interaction *runtime.Interaction, evts ...*events.Event) error {
+	return cr.write(ctx, interaction, evts, cr.InteractionRepo.Update)
+}
+
+type interactionWrite func(ctx context.Context, interaction *runtime.Interaction, evts ...*events.Event) error
+
+// write stages the interaction and the node of its parent in the unit of work of ctx, a write
+// outside of one commits in a unit of its own
+func (cr *childSyncRepo) write(ctx context.Context, interaction *runtime.Interaction, evts []*events.Event, fn interactionWrite) error {
+	if !repo.InUnitOfWork(ctx) {
+		return commitUnit(ctx, cr.outboxRepo, func(ctx context.Context) error {
+			return cr.write(ctx, interaction, evts, fn)
+		})
+	}
+	if err := fn(ctx, interaction, evts...); err != nil {
+		return err
+	}
+	return cr.syncParent(ctx, interaction)
+}
+
+// syncParent sets the status of the node of the child in its parent from the state of the child.
+// An interaction that is not a child, or whose parent or node is gone, is left alone.
+func (cr *childSyncRepo) syncParent(ctx context.Context, child *runtime.Interaction) error {
+	link, err := cr.childRepo.Parent(ctx, child.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	ref, err := cr.workflows.findGraph(ctx, link.ParentId, link.WorkflowId, link.ExecutionId)
+	if errors.Is(err, errs.ErrNotFound) || errors.Is(err, errs.ErrInvalid) {
+		cr.log.Warnf("Execution graph: %s of the parent of interaction id: %s is gone: %v", link.ExecutionId, child.ID, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	status := childStatus(child)
+	nodes := ref.flow.ExecutionGraph.Nodes
+	for i, node := range nodes {
+		if node.StepId != link.NodeId {
+			continue
+		}
+		if node.Status == status {
+			return nil
+		}
+		nodes[i].Status = status
+		change := events.StatusChange{StepId: node.StepId, Previous: string(node.Status), Status: string(status)}
+		evt := stepEvent(events.StepStatusChanged, link.ParentId, link.WorkflowId, link.ExecutionId, node.StepId, change)
+		return cr.workflows.save(ctx, ref, evt)
+	}
+	cr.log.Warnf("Node: %s of interaction id: %s is gone from its parent: %s", link.NodeId, child.ID, link.ParentId)
+	return nil
+}
+
+// NewChildSyncRepo wraps the interaction repo the services write interactions through, so that
+// every write of a child updates its node in the parent
+func NewChildSyncRepo(log *logger.Logger, interactionRepo repo.InteractionRepo, childRepo repo.ChildRepo, workflowRepo repo.WorkflowRepo, outboxRepo repo.OutboxRepo) repo.InteractionRepo {
+	cr := &childSyncRepo{
+		InteractionRepo: interactionRepo,
+		log:             log,
+		childRepo:       childRepo,
+		outboxRepo:      outboxRepo,
+	}
+	cr.workflows = workflows{interactionRepo: cr, workflowRepo: workflowRepo}
+	return cr
+}
+
+func NewChildService(log *logger.Logger, tr trace.Tracer, childRepo repo.ChildRepo, interactionRepo repo.InteractionRepo, workflowRepo repo.WorkflowRepo, outboxRepo repo.OutboxRepo, agentSvc AgentService) ChildService {
+	return &childService{
+		log:        log,
+		tr:         tr,
+		childRepo:  childRepo,
+		workflows:  workflows{interactionRepo: interactionRepo, workflowRepo: workflowRepo},
+		outboxRepo: outboxRepo,
+		agentSvc:   agentSvc,
+	}
+}
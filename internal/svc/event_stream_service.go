@@ -0,0 +1,108 @@
+package svc
+
+import (
+	"context"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EventStreamService feeds the per interaction event streams and reads them back for live
+// subscribers. It is an events.Publisher so the outbox relay can deliver to it next to the bus.
+type EventStreamService interface {
+	events.Publisher
+	Subscribe(ctx context.Context, interactionId, lastEventId string, types []events.Type) (<-chan events.StreamEntry, error)
+	Listen(ctx context.Context) <-chan events.Event
+}
+
+type eventStreamService struct {
+	log     *logger.Logger
+	tr      trace.Tracer
+	streams repo.EventStreamRepo
+	cfg     settings.Events
+}
+
+// Publish appends the event to its interaction stream and broadcasts it. A failed broadcast is only
+// logged, retrying would append the event twice and live listeners do not resume anyway.
+func (es *eventStreamService) Publish(ctx context.Context, evt *events.Event) error {
+	if err := es.streams.Append(ctx, evt); err != nil {
+		return err
+	}
+	if err := es.streams.Broadcast(ctx, evt); err != nil {
+		es.log.Warnf("Error while broadcasting %s event of interaction id: %s by error: %v", evt.Type, evt.InteractionId, err)
+	}
+	return nil
+}
+
+// Listen returns the events of all interactions published from now on
+func (es *eventStreamService) Listen(ctx context.Context) <-chan events.Event {
+	return es.streams.Listen(ctx)
+}
+
+// Subscribe streams the events of the interaction after lastEventId, or the ones appended from now
+// on when it is empty, until ctx is cancelled. Only the given types are sent when types is not empty.
+func (es *eventStreamService) Subscribe(ctx context.Context, interactionId, lastEventId string, types []events.Type) (<-chan events.StreamEntry, error) {
+	after := lastEventId
+	if after == "" {
+		last, err := es.streams.Last(ctx, interactionId)
+		if err != nil {
+			es.log.Errorf("Error while reading event stream of interaction id: %s by error: %v", interactionId, err)
+			return nil, err
+		}
+		after = last
+	}
+	wanted := make(map[events.Type]bool, len(types))
+	for _, t := range types {
+		wanted[t] = true
+	}
+
+	out := make(chan events.StreamEntry)
+	go func() {
+		defer close(out)
+		for ctx.Err() == nil {
+			entries, err := es.streams.Read(ctx, interactionId, after, es.cfg.Heartbeat)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				es.log.Errorf("Error while reading event stream of interaction id: %s by error: %v", interactionId, err)
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Second):
+				}
+				continue
+			}
+			for _, entry := range entries {
+				after = entry.StreamId
+				if len(wanted) > 0 && !wanted[entry.Event.Type] {
+					continue
+				}
+				select {
+				case <-ctx.Done():
+					return
+				case out <- entry:
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (es *eventStreamService) Close() error {
+	es.streams.Close()
+	return nil
+}
+
+func NewEventStreamService(log *logger.Logger, tr trace.Tracer, streams repo.EventStreamRepo) EventStreamService {
+	return &eventStreamService{
+		log:     log,
+		tr:      tr,
+		streams: streams,
+		cfg:     settings.GetEvents(),
+	}
+}
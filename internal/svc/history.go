@@ -0,0 +1,56 @@
+package svc
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/patch"
+)
+
+// replay rebuilds a document from its history as it was at the time, nil when it did not exist
+// then or its records of that time were trimmed
+func replay[T any](records []model.HistoryRecord, at time.Time) (*T, error) {
+	var doc json.RawMessage
+	var err error
+	for _, record := range records {
+		if record.Time.After(at) {
+			break
+		}
+		switch {
+		case record.Op == model.HistoryDelete:
+			doc = nil
+		case record.Base:
+			doc, err = patch.MergeApply(nil, record.Patch)
+		case record.Ops != nil:
+			doc, err = patch.Apply(doc, record.Ops)
+		default:
+			doc, err = patch.MergeApply(doc, record.Patch)
+		}
+		if err != nil {
+			return nil, errs.Internal(fmt.Errorf("replaying version %d: %w", record.Version, err))
+		}
+	}
+	if doc == nil {
+		return nil, nil
+	}
+	var v T
+	if err = json.Unmarshal(doc, &v); err != nil {
+		return nil, errs.Internal(err)
+	}
+	return &v, nil
+}
+
+// recorded returns the history of a document, a NotFound when it has none because it was never
+// written or its history expired after it was deleted
+func recorded(records []model.HistoryRecord, err error, format string, args ...any) ([]model.HistoryRecord, error) {
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errs.NotFound(format, args...)
+	}
+	return records, nil
+}
@@ -0,0 +1,82 @@
+package svc
+
+import (
+	"context"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrIdempotencyKeyReused   = errs.Invalid("idempotency key was used with a different request")
+	ErrIdempotencyKeyInFlight = errs.Conflict("a request with this idempotency key is still being served")
+)
+
+// IdempotencyService makes retried creates safe. Begin reserves the key of a request, a retry of a
+// completed request gets the stored response back and the caller replays it instead of serving
+// the request again.
+type IdempotencyService interface {
+	Begin(ctx context.Context, key, requestHash string) (*model.IdempotentResponse, error)
+	Complete(ctx context.Context, response *model.IdempotentResponse) error
+	Abandon(ctx context.Context, key string) error
+}
+
+type idempotencyService struct {
+	log             *logger.Logger
+	tr              trace.Tracer
+	idempotencyRepo repo.IdempotencyRepo
+	cfg             settings.Idempotency
+}
+
+// Begin returns nil when the key was reserved for this request, or the stored response to replay.
+// A key used for a different request or for a request that is still being served is an error.
+func (is *idempotencyService) Begin(ctx context.Context, key, requestHash string) (*model.IdempotentResponse, error) {
+	pending := &model.IdempotentResponse{Key: key, RequestHash: requestHash, CreatedAt: time.Now().UTC()}
+	existing, reserved, err := is.idempotencyRepo.Reserve(ctx, pending, is.cfg.LockTTL)
+	if err != nil {
+		is.log.Errorf("Error while reserving idempotency key: %s by error: %v", key, err)
+		return nil, err
+	}
+	switch {
+	case reserved:
+		return nil, nil
+	case existing.RequestHash != requestHash:
+		return nil, ErrIdempotencyKeyReused
+	case existing.Pending():
+		return nil, ErrIdempotencyKeyInFlight
+	default:
+		return existing, nil
+	}
+}
+
+// Complete stores the response of the request that reserved the key
+func (is *idempotencyService) Complete(ctx context.Context, response *model.IdempotentResponse) error {
+	if err := is.idempotencyRepo.Complete(ctx, response, is.cfg.TTL); err != nil {
+		is.log.Errorf("Error while storing response of idempotency key: %s by error: %v", response.Key, err)
+		return err
+	}
+	return nil
+}
+
+// Abandon releases the key of a request that failed, so that a retry is served again
+func (is *idempotencyService) Abandon(ctx context.Context, key string) error {
+	if err := is.idempotencyRepo.Release(ctx, key); err != nil {
+		is.log.Errorf("Error while releasing idempotency key: %s by error: %v", key, err)
+		return err
+	}
+	return nil
+}
+
+func NewIdempotencyService(log *logger.Logger, tr trace.Tracer, idempotencyRepo repo.IdempotencyRepo) IdempotencyService {
+	return &idempotencyService{
+		log:             log,
+		tr:              tr,
+		idempotencyRepo: idempotencyRepo,
+		cfg:             settings.GetIdempotency(),
+	}
+}
@@ -2,34 +2,142 @@ package svc
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/patch"
 	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/validation"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrNoHistory is returned when the interaction did not exist at the requested time
+var ErrNoHistory = errs.NotFound("no recorded state")
+
 type InteractionService interface {
 	GetById(ctx context.Context, interactionId string) (*runtime.Interaction, error)
+	GetAt(ctx context.Context, interactionId string, at time.Time) (*model.InteractionSnapshot, error)
+	History(ctx context.Context, interactionId string) ([]model.HistoryRecord, error)
 	Create(ctx context.Context, interaction *runtime.Interaction) (*runtime.Interaction, error)
 	Update(ctx context.Context, interaction *runtime.Interaction) (*runtime.Interaction, error)
+	Patch(ctx context.Context, interactionId string, req PatchRequest) (*runtime.Interaction, int64, error)
+	Revision(ctx context.Context, interactionId string) (int64, error)
 	DeleteById(ctx context.Context, interactionId string) error
 
-	UpdatePlan(ctx context.Context, interactionId, planId string, plan *runtime.Plan) (*runtime.Interaction, error)
 	UpdateExecutionFlow(ctx context.Context, interactionId, executionFlowId string, executionFlow *runtime.ExecutionFlow) (*runtime.Interaction, error)
 	UpdateExecutionGraph(ctx context.Context, interactionId, executionFlowId, executionGraphId string, graph *runtime.ExecutionGraph) (*runtime.Interaction, error)
 }
 
 type interactionService struct {
-	log  *logger.Logger
-	tr   trace.Tracer
-	repo repo.InteractionRepo
+	log       *logger.Logger
+	tr        trace.Tracer
+	repo      repo.InteractionRepo
+	workflows workflows
+	stepRepo  repo.StepRepo
+	agentSvc  AgentService
+	// v checks patched documents like the handlers check full ones
+	v *validation.Validator
 }
 
 func (is *interactionService) GetById(ctx context.Context, iid string) (*runtime.Interaction, error) {
-	return is.repo.Get(ctx, iid)
+	interaction, err := is.repo.Get(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	if err = is.agentSvc.ResolveExecutionFlow(ctx, interaction.ExecutionFlow); err != nil {
+		return nil, err
+	}
+	return interaction, nil
+}
+
+// GetAt rebuilds the interaction as it was at the given time by replaying its history, with the
+// steps of its workflows replayed from theirs
+func (is *interactionService) GetAt(ctx context.Context, iid string, at time.Time) (*model.InteractionSnapshot, error) {
+	records, err := is.History(ctx, iid)
+	if err != nil {
+		is.log.Errorf("Error while reading history of interaction id: %s by error: %v", iid, err)
+		return nil, err
+	}
+	interaction, err := replay[runtime.Interaction](records, at)
+	if err != nil {
+		is.log.Errorf("Error while replaying interaction id: %s by error: %v", iid, err)
+		return nil, err
+	}
+	if interaction == nil {
+		return nil, fmt.Errorf("%w: interaction id: %s at %s", ErrNoHistory, iid, at.Format(time.RFC3339Nano))
+	}
+	if err = is.agentSvc.ResolveExecutionFlow(ctx, interaction.ExecutionFlow); err != nil {
+		return nil, err
+	}
+	flows, err := is.flowsAt(ctx, interaction, at)
+	if err != nil {
+		return nil, err
+	}
+	steps := []*runtime.Step{}
+	for _, flow := range flows {
+		if flow.ExecutionGraph == nil {
+			continue
+		}
+		for _, node := range flow.ExecutionGraph.Nodes {
+			// a node of a child graph has no step at this key and is skipped like a step created later
+			records, err = is.stepRepo.History(ctx, iid, flow.ID, flow.ExecutionGraph.ID, node.StepId)
+			if err != nil {
+				is.log.Errorf("Error while reading history of step id: %s by error: %v", node.StepId, err)
+				return nil, err
+			}
+			step, err := replay[runtime.Step](records, at)
+			if err != nil {
+				is.log.Errorf("Error while replaying step id: %s by error: %v", node.StepId, err)
+				return nil, err
+			}
+			if step == nil {
+				continue
+			}
+			if err = is.agentSvc.ResolveStep(ctx, step); err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		}
+	}
+	return &model.InteractionSnapshot{At: at, Interaction: interaction, Steps: steps}, nil
+}
+
+// flowsAt returns the active workflow of the interaction and the other workflows it had at the time
+func (is *interactionService) flowsAt(ctx context.Context, interaction *runtime.Interaction, at time.Time) ([]*runtime.ExecutionFlow, error) {
+	var flows []*runtime.ExecutionFlow
+	if interaction.ExecutionFlow != nil {
+		flows = append(flows, interaction.ExecutionFlow)
+	}
+	records, err := is.workflows.workflowRepo.History(ctx, interaction.ID)
+	if err != nil {
+		is.log.Errorf("Error while reading workflow history of interaction id: %s by error: %v", interaction.ID, err)
+		return nil, err
+	}
+	set, err := replay[model.WorkflowSet](records, at)
+	if err != nil {
+		is.log.Errorf("Error while replaying workflows of interaction id: %s by error: %v", interaction.ID, err)
+		return nil, err
+	}
+	if set == nil {
+		return flows, nil
+	}
+	for i := range set.Workflows {
+		flows = append(flows, &set.Workflows[i])
+	}
+	return flows, nil
+}
+
+// History returns the recorded writes of the interaction, oldest first
+func (is *interactionService) History(ctx context.Context, iid string) ([]model.HistoryRecord, error) {
+	records, err := is.repo.History(ctx, iid)
+	return recorded(records, err, "no history for interaction id: %s", iid)
 }
 
 func (is *interactionService) Create(ctx context.Context, interaction *runtime.Interaction) (*runtime.Interaction, error) {
@@ -37,7 +145,12 @@ func (is *interactionService) Create(ctx context.Context, interaction *runtime.I
 		interaction.ID = uuid.NewString()
 	}
 	interaction.CreatedAt = time.Now()
-	if err := is.repo.Save(ctx, interaction); err != nil {
+	if err := is.agentSvc.PinExecutionFlow(ctx, interaction.ExecutionFlow); err != nil {
+		is.log.Errorf("Error while pinning workflow agents of interaction: %v", err)
+		return nil, err
+	}
+	evt := events.New(events.InteractionCreated, interaction.ID, interaction)
+	if err := is.repo.Save(ctx, interaction, evt); err != nil {
 		is.log.Errorf("Error while saving interaction: %v", err)
 		return nil, err
 	}
@@ -45,69 +158,125 @@ func (is *interactionService) Create(ctx context.Context, interaction *runtime.I
 }
 
 func (is *interactionService) Update(ctx context.Context, interaction *runtime.Interaction) (*runtime.Interaction, error) {
-	if err := is.repo.Update(ctx, interaction); err != nil {
+	evts := []*events.Event{events.New(events.InteractionUpdated, interaction.ID, interaction)}
+	if previous, err := is.repo.Get(ctx, interaction.ID); err == nil && previous != nil {
+		// replacing the active workflow here would drop it, it is switched by activating another one
+		if flow := previous.ExecutionFlow; flow != nil && (interaction.ExecutionFlow == nil || interaction.ExecutionFlow.ID != flow.ID) {
+			return nil, errs.Invalid("the workflow id: %s of interaction id: %s cannot be changed, activate another workflow instead", flow.ID, interaction.ID)
+		}
+		evts = append(evts, addedMessages(previous, interaction)...)
+		if previous.CompletedAt.IsZero() && !interaction.CompletedAt.IsZero() {
+			evts = append(evts, events.New(events.InteractionCompleted, interaction.ID, interaction))
+		}
+	}
+	if err := is.agentSvc.PinExecutionFlow(ctx, interaction.ExecutionFlow); err != nil {
+		is.log.Errorf("Error while pinning workflow agents of interaction: %v", err)
+		return nil, err
+	}
+	if err := is.repo.Update(ctx, interaction, evts...); err != nil {
 		is.log.Errorf("Error while updating interaction: %v", err)
 		return nil, err
 	}
 	return interaction, nil
 }
 
-func (is *interactionService) DeleteById(ctx context.Context, iid string) error {
-	return is.repo.Delete(ctx, iid)
-}
-
-func (is *interactionService) UpdatePlan(ctx context.Context, interactionId, planId string, plan *runtime.Plan) (*runtime.Interaction, error) {
-	interaction, err := is.repo.Get(ctx, interactionId)
-	if err != nil {
-		is.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
-		return nil, err
+// Patch applies a merge patch or JSON patch to the current revision of the interaction and returns
+// the new revision
+func (is *interactionService) Patch(ctx context.Context, iid string, req PatchRequest) (*runtime.Interaction, int64, error) {
+	load := func(ctx context.Context) (*runtime.Interaction, int64, error) {
+		revision, err := is.repo.Revision(ctx, iid)
+		if err != nil {
+			return nil, 0, err
+		}
+		interaction, err := is.repo.Get(ctx, iid)
+		return interaction, revision, err
 	}
-	if interaction.Plan.ID == planId {
-		interaction.Plan = plan
+	store := func(ctx context.Context, interaction *runtime.Interaction) error {
+		if interaction.ID != iid {
+			return fmt.Errorf("%w: the interaction id cannot be changed", patch.ErrInvalidPatch)
+		}
+		_, err := is.Update(ctx, interaction)
+		return err
 	}
-	if err = is.repo.Update(ctx, interaction); err != nil {
-		is.log.Errorf("Error while updating interaction id:%s with plan: %s by error: %v", interactionId, plan.ID, err)
-		return nil, err
+	return applyPatch(ctx, repo.InteractionKey(iid), req, load, is.v.Interaction, store)
+}
+
+func (is *interactionService) Revision(ctx context.Context, iid string) (int64, error) {
+	return is.repo.Revision(ctx, iid)
+}
+
+func (is *interactionService) DeleteById(ctx context.Context, iid string) error {
+	if err := is.repo.Delete(ctx, iid, events.New(events.InteractionDeleted, iid, nil)); err != nil {
+		return err
 	}
-	return interaction, nil
+	return is.workflows.workflowRepo.Delete(ctx, iid)
 }
 
 func (is *interactionService) UpdateExecutionFlow(ctx context.Context, interactionId, executionId string, executionFlow *runtime.ExecutionFlow) (*runtime.Interaction, error) {
-	interaction, err := is.repo.Get(ctx, interactionId)
+	ref, err := is.workflows.find(ctx, interactionId, executionId)
 	if err != nil {
-		is.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+		is.log.Errorf("Error while getting workflow id: %s of interaction id:%s by error: %v", executionId, interactionId, err)
+		return nil, err
+	}
+	if err = is.agentSvc.PinExecutionFlow(ctx, executionFlow); err != nil {
+		is.log.Errorf("Error while pinning agents of executionflow: %s by error: %v", executionFlow.ID, err)
 		return nil, err
 	}
-	if interaction.ExecutionFlow.ID == executionId {
-		interaction.ExecutionFlow = executionFlow
+	if ref.active() {
+		ref.interaction.ExecutionFlow = executionFlow
+		ref.flow = executionFlow
+	} else {
+		*ref.flow = *executionFlow
 	}
-	if err = is.repo.Update(ctx, interaction); err != nil {
+	evt := events.New(events.ExecutionFlowUpdated, interactionId, executionFlow)
+	evt.WorkflowId = executionId
+	if err = is.workflows.save(ctx, ref, evt); err != nil {
 		is.log.Errorf("Error while updating interaction id:%s with executionflow: %s by error: %v", interactionId, executionFlow.ID, err)
 		return nil, err
 	}
-	return interaction, nil
+	return ref.interaction, nil
 }
 
 func (is *interactionService) UpdateExecutionGraph(ctx context.Context, interactionId, executionId, executionGraphId string, graph *runtime.ExecutionGraph) (*runtime.Interaction, error) {
-	interaction, err := is.repo.Get(ctx, interactionId)
+	ref, err := is.workflows.findGraph(ctx, interactionId, executionId, executionGraphId)
 	if err != nil {
-		is.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+		is.log.Errorf("Error while getting execution graph: %s of interaction id:%s by error: %v", executionGraphId, interactionId, err)
 		return nil, err
 	}
-	if interaction.ExecutionFlow.ID == executionId && interaction.ExecutionFlow.ExecutionGraph.ID == executionGraphId {
-		interaction.ExecutionFlow.ExecutionGraph = graph
-	}
-	if err = is.repo.Update(ctx, interaction); err != nil {
+	ref.flow.ExecutionGraph = graph
+	evt := events.New(events.ExecutionGraphUpdated, interactionId, graph)
+	evt.WorkflowId = executionId
+	evt.ExecutionId = executionGraphId
+	if err = is.workflows.save(ctx, ref, evt); err != nil {
 		is.log.Errorf("Error while updating interaction id:%s with execution graph: %s by error: %v", interactionId, graph.ID, err)
 		return nil, err
 	}
-	return interaction, nil
+	return ref.interaction, nil
+}
+
+// addedMessages returns a MessageAdded event for every message that was not on the previous version
+func addedMessages(previous, interaction *runtime.Interaction) []*events.Event {
+	known := make(map[string]bool, len(previous.Messages))
+	for _, msg := range previous.Messages {
+		known[msg.ID] = true
+	}
+	var evts []*events.Event
+	for _, msg := range interaction.Messages {
+		if !known[msg.ID] {
+			evts = append(evts, events.New(events.MessageAdded, interaction.ID, msg))
+		}
+	}
+	return evts
 }
 
-func NewInteractionService(log *logger.Logger, tr trace.Tracer, repo repo.InteractionRepo) InteractionService {
+func NewInteractionService(log *logger.Logger, tr trace.Tracer, repo repo.InteractionRepo, workflowRepo repo.WorkflowRepo, stepRepo repo.StepRepo, agentSvc AgentService) InteractionService {
 	return &interactionService{
-		log:  log,
-		tr:   tr,
-		repo: repo,
+		log:       log,
+		tr:        tr,
+		repo:      repo,
+		workflows: workflows{interactionRepo: repo, workflowRepo: workflowRepo},
+		stepRepo:  stepRepo,
+		agentSvc:  agentSvc,
+		v:         validation.New(settings.GetValidation()),
 	}
 }
@@ -0,0 +1,266 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/mcpclient"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type McpDiscoveryService interface {
+	GetConnection(ctx context.Context, interactionId, workflowId, mcpId string) (*model.McpConnection, error)
+	SetEndpoint(ctx context.Context, interactionId, workflowId, mcpId string, endpoint *model.McpEndpoint) (*model.McpConnection, error)
+	RemoveEndpoint(ctx context.Context, interactionId, workflowId, mcpId string) error
+	Sync(ctx context.Context, interactionId, workflowId, mcpId string) (*model.McpConnection, error)
+	Start(ctx context.Context)
+}
+
+type mcpDiscoveryService struct {
+	log      *logger.Logger
+	tr       trace.Tracer
+	mcpRepo  repo.MCPRepo
+	connRepo repo.McpConnectionRepo
+	cfg      settings.McpDiscovery
+	client   *http.Client
+}
+
+// GetConnection returns the connection with the header values and env values of its endpoint
+// redacted, they carry the credentials of the server
+func (ds *mcpDiscoveryService) GetConnection(ctx context.Context, interactionId, workflowId, mcpId string) (*model.McpConnection, error) {
+	conn, err := ds.connRepo.Get(ctx, interactionId, workflowId, mcpId)
+	if err != nil {
+		return nil, err
+	}
+	return redactConnection(conn), nil
+}
+
+// SetEndpoint declares the server for the mcp and runs a first sync so the caller sees reachability.
+// Stdio servers must run one of the configured commands and http servers must be public unless
+// private networks are allowed.
+func (ds *mcpDiscoveryService) SetEndpoint(ctx context.Context, interactionId, workflowId, mcpId string, endpoint *model.McpEndpoint) (*model.McpConnection, error) {
+	if err := ds.validateEndpoint(endpoint); err != nil {
+		return nil, err
+	}
+	if _, err := ds.mcpRepo.Get(ctx, interactionId, workflowId, mcpId); err != nil {
+		ds.log.Errorf("Error while getting mcp id: %s to set endpoint by error: %v", mcpId, err)
+		return nil, err
+	}
+	conn := &model.McpConnection{
+		InteractionId: interactionId,
+		WorkflowId:    workflowId,
+		McpId:         mcpId,
+		Endpoint:      *endpoint,
+		Status:        model.McpStatus{Reachability: model.McpUnknown},
+	}
+	if err := ds.connRepo.Save(ctx, conn); err != nil {
+		ds.log.Errorf("Error while saving mcp connection for mcp id: %s by error: %v", mcpId, err)
+		return nil, err
+	}
+	return ds.Sync(ctx, interactionId, workflowId, mcpId)
+}
+
+func (ds *mcpDiscoveryService) validateEndpoint(endpoint *model.McpEndpoint) error {
+	switch endpoint.Transport {
+	case model.McpTransportStdio:
+		if !slices.Contains(ds.cfg.Commands, endpoint.Command) {
+			return errs.Invalid("mcp command: %q is not an allowed command", endpoint.Command)
+		}
+	case model.McpTransportHttp:
+		target, err := url.Parse(endpoint.URL)
+		if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+			return errs.Invalid("mcp url must be an absolute http or https url")
+		}
+		if !ds.cfg.AllowPrivateNetworks && privateURL(target) {
+			return errs.Invalid("mcp url must not point to a loopback or private address")
+		}
+	default:
+		return errs.Invalid("unsupported mcp transport: %q", endpoint.Transport)
+	}
+	return nil
+}
+
+// redactConnection copies the connection with the values of the endpoint headers and env cleared
+func redactConnection(conn *model.McpConnection) *model.McpConnection {
+	redacted := *conn
+	if conn.Endpoint.Headers != nil {
+		redacted.Endpoint.Headers = make(map[string]string, len(conn.Endpoint.Headers))
+		for k := range conn.Endpoint.Headers {
+			redacted.Endpoint.Headers[k] = ""
+		}
+	}
+	if conn.Endpoint.Env != nil {
+		redacted.Endpoint.Env = make([]string, len(conn.Endpoint.Env))
+		for i, kv := range conn.Endpoint.Env {
+			name, _, _ := strings.Cut(kv, "=")
+			redacted.Endpoint.Env[i] = name + "="
+		}
+	}
+	return &redacted
+}
+
+func (ds *mcpDiscoveryService) RemoveEndpoint(ctx context.Context, interactionId, workflowId, mcpId string) error {
+	return ds.connRepo.Delete(ctx, interactionId, workflowId, mcpId)
+}
+
+// Sync connects to the declared server, lists its tools and replaces the tools of the mcp with them.
+// An unreachable server is recorded on the connection status and the mcp tools are left untouched.
+func (ds *mcpDiscoveryService) Sync(ctx context.Context, interactionId, workflowId, mcpId string) (*model.McpConnection, error) {
+	conn, err := ds.connRepo.Get(ctx, interactionId, workflowId, mcpId)
+	if err != nil {
+		ds.log.Errorf("Error while getting mcp connection for mcp id: %s by error: %v", mcpId, err)
+		return nil, err
+	}
+
+	info, tools, err := ds.discover(ctx, conn.Endpoint)
+	conn.Status.LastCheckedAt = time.Now()
+	if err != nil {
+		ds.log.Warnf("MCP id: %s is unreachable: %v", mcpId, err)
+		conn.Status.Reachability = model.McpUnreachable
+		conn.Status.Error = err.Error()
+		if err = ds.connRepo.Save(ctx, conn); err != nil {
+			ds.log.Errorf("Error while saving mcp connection status for mcp id: %s by error: %v", mcpId, err)
+			return nil, err
+		}
+		return redactConnection(conn), nil
+	}
+
+	mcp, err := ds.mcpRepo.Get(ctx, interactionId, workflowId, mcpId)
+	if errors.Is(err, errs.ErrNotFound) {
+		// the mcp was deleted without its connection
+		ds.log.Warnf("Removing connection of deleted mcp id: %s", mcpId)
+		if err := ds.connRepo.Delete(ctx, interactionId, workflowId, mcpId); err != nil {
+			ds.log.Errorf("Error while deleting connection of mcp id: %s by error: %v", mcpId, err)
+		}
+		return nil, err
+	}
+	if err != nil {
+		ds.log.Errorf("Error while getting mcp id: %s to sync tools by error: %v", mcpId, err)
+		return nil, err
+	}
+	mcp.Tools = make([]runtime.Tool, 0, len(tools))
+	for _, td := range tools {
+		tool, err := toRuntimeTool(td)
+		if err != nil {
+			ds.log.Warnf("Skipping tool %s of mcp id: %s: %v", td.Name, mcpId, err)
+			continue
+		}
+		mcp.Tools = append(mcp.Tools, tool)
+	}
+	evt := mcpEvent(events.McpToolsChanged, interactionId, workflowId, mcpId, mcp.Tools)
+	if err = ds.mcpRepo.Update(ctx, interactionId, workflowId, mcp, evt); err != nil {
+		ds.log.Errorf("Error while updating tools of mcp id: %s by error: %v", mcpId, err)
+		return nil, err
+	}
+
+	conn.Status = model.McpStatus{
+		Reachability:  model.McpReachable,
+		ServerName:    info.Name,
+		ServerVersion: info.Version,
+		ToolCount:     len(mcp.Tools),
+		LastCheckedAt: conn.Status.LastCheckedAt,
+		LastSyncedAt:  conn.Status.LastCheckedAt,
+	}
+	if err = ds.connRepo.Save(ctx, conn); err != nil {
+		ds.log.Errorf("Error while saving mcp connection status for mcp id: %s by error: %v", mcpId, err)
+		return nil, err
+	}
+	return redactConnection(conn), nil
+}
+
+func (ds *mcpDiscoveryService) discover(ctx context.Context, endpoint model.McpEndpoint) (*mcpclient.ServerInfo, []mcpclient.ToolDescriptor, error) {
+	// a connection stored before the command was taken off the allowlist must not run either
+	if err := ds.validateEndpoint(&endpoint); err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, ds.cfg.Timeout)
+	defer cancel()
+	client, err := mcpclient.New(ctx, endpoint, ds.client)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			ds.log.Warnf("Error while closing mcp client: %v", err)
+		}
+	}()
+	info, err := client.Initialize(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	tools, err := client.ListTools(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, tools, nil
+}
+
+// Start refreshes every declared mcp on the configured interval until ctx is cancelled
+func (ds *mcpDiscoveryService) Start(ctx context.Context) {
+	ticker := time.NewTicker(ds.cfg.RefreshInterval)
+	go func() {
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				ds.refreshAll(ctx)
+			}
+		}
+	}()
+}
+
+func (ds *mcpDiscoveryService) refreshAll(ctx context.Context) {
+	refs, err := ds.connRepo.List(ctx)
+	if err != nil {
+		ds.log.Errorf("Error while listing mcp connections: %v", err)
+		return
+	}
+	for _, ref := range refs {
+		if _, err = ds.Sync(ctx, ref.InteractionId, ref.WorkflowId, ref.McpId); err != nil {
+			ds.log.Errorf("Error while refreshing mcp id: %s by error: %v", ref.McpId, err)
+		}
+	}
+}
+
+// toRuntimeTool maps the MCP wire format onto runtime.Tool through its json representation
+func toRuntimeTool(td mcpclient.ToolDescriptor) (runtime.Tool, error) {
+	var tool runtime.Tool
+	b, err := json.Marshal(map[string]any{
+		"name":          td.Name,
+		"description":   td.Description,
+		"input_schema":  td.InputSchema,
+		"output_schema": td.OutputSchema,
+	})
+	if err != nil {
+		return tool, err
+	}
+	err = json.Unmarshal(b, &tool)
+	return tool, err
+}
+
+func NewMcpDiscoveryService(log *logger.Logger, tr trace.Tracer, mcpRepo repo.MCPRepo, connRepo repo.McpConnectionRepo) McpDiscoveryService {
+	cfg := settings.GetMcpDiscovery()
+	return &mcpDiscoveryService{
+		log:      log,
+		tr:       tr,
+		mcpRepo:  mcpRepo,
+		connRepo: connRepo,
+		cfg:      cfg,
+		client:   outboundClient(cfg.Timeout, cfg.AllowPrivateNetworks),
+	}
+}
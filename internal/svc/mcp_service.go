@@ -2,61 +2,86 @@ package svc
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/patch"
 	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/validation"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type McpService interface {
 	GetByInteractionIdAndWorkflowIdAndId(ctx context.Context, interactionId, workflowId, mcpId string) (*runtime.MCP, error)
+	GetAllByInteractionIdAndWorkflowId(ctx context.Context, interactionId, workflowId string, mcpIds []string) ([]*runtime.MCP, error)
 	CreateByInteractionIdAndWorkflowId(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) (*runtime.MCP, error)
 	UpdateByInteractionIdAndWorkflowId(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) (*runtime.MCP, error)
+	PatchByInteractionIdAndWorkflowIdAndId(ctx context.Context, interactionId, workflowId, mcpId string, req PatchRequest) (*runtime.MCP, int64, error)
+	Revision(ctx context.Context, interactionId, workflowId, mcpId string) (int64, error)
+	History(ctx context.Context, interactionId, workflowId, mcpId string) ([]model.HistoryRecord, error)
 	AddTool(ctx context.Context, interactionId, workflowId, mcpId string, tool *runtime.Tool) (*runtime.MCP, error)
 	DeleteByInteractionIdAndWorkflowIdAndId(ctx context.Context, interactionId, workflowId, mcpId string) error
 }
 
 type mcpService struct {
-	log             *logger.Logger
-	tr              trace.Tracer
-	mcpRepo         repo.MCPRepo
-	interactionRepo repo.InteractionRepo
+	log       *logger.Logger
+	tr        trace.Tracer
+	mcpRepo   repo.MCPRepo
+	connRepo  repo.McpConnectionRepo
+	workflows workflows
+	// v checks patched documents like the handlers check full ones
+	v *validation.Validator
+}
+
+func mcpEvent(t events.Type, interactionId, workflowId, mcpId string, data any) *events.Event {
+	evt := events.New(t, interactionId, data)
+	evt.WorkflowId = workflowId
+	evt.McpId = mcpId
+	return evt
 }
 
 func (ms *mcpService) GetByInteractionIdAndWorkflowIdAndId(ctx context.Context, interactionId, workflowId, mcpId string) (*runtime.MCP, error) {
 	return ms.mcpRepo.Get(ctx, interactionId, workflowId, mcpId)
 }
 
+// GetAllByInteractionIdAndWorkflowId returns the mcps in the order of mcpIds, nil for a missing one
+func (ms *mcpService) GetAllByInteractionIdAndWorkflowId(ctx context.Context, interactionId, workflowId string, mcpIds []string) ([]*runtime.MCP, error) {
+	return ms.mcpRepo.GetAll(ctx, interactionId, workflowId, mcpIds)
+}
+
 // CreateByInteractionIdAndWorkflowId Saves the mcp and updates the reference in workflow
 func (ms *mcpService) CreateByInteractionIdAndWorkflowId(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) (*runtime.MCP, error) {
 	if mcp.ID == "" {
 		mcp.ID = uuid.NewString()
 	}
-	err := ms.mcpRepo.Save(ctx, interactionId, workflowId, mcp)
+	ref, err := ms.workflows.find(ctx, interactionId, workflowId)
 	if err != nil {
-		ms.log.Errorf("Error while saving mcp: %v", err)
+		ms.log.Errorf("Error while getting workflow id: %s of interaction id:%s to update MCP by error: %v", workflowId, interactionId, err)
 		return nil, err
 	}
-	interaction, err := ms.interactionRepo.Get(ctx, interactionId)
+	evt := mcpEvent(events.McpCreated, interactionId, workflowId, mcp.ID, mcp)
+	err = ms.mcpRepo.Save(ctx, interactionId, workflowId, mcp, evt)
 	if err != nil {
-		ms.log.Errorf("Error while getting interaction id:%s to update MCP by error: %v", interactionId, err)
+		ms.log.Errorf("Error while saving mcp: %v", err)
 		return nil, err
 	}
-	if interaction.ExecutionFlow.ID == workflowId {
-		interaction.ExecutionFlow.AvailableMcpRefs = append(interaction.ExecutionFlow.AvailableMcpRefs, mcp.ID)
-		err = ms.interactionRepo.Update(ctx, interaction)
-		if err != nil {
-			ms.log.Errorf("Error while updating interaction id:%s with mcpId: %s by error: %v", interactionId, mcp.ID, err)
-			return nil, err
-		}
+	ref.flow.AvailableMcpRefs = append(ref.flow.AvailableMcpRefs, mcp.ID)
+	err = ms.workflows.save(ctx, ref)
+	if err != nil {
+		ms.log.Errorf("Error while updating interaction id:%s with mcpId: %s by error: %v", interactionId, mcp.ID, err)
+		return nil, err
 	}
 	return mcp, nil
 }
 
 func (ms *mcpService) UpdateByInteractionIdAndWorkflowId(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) (*runtime.MCP, error) {
-	err := ms.mcpRepo.Update(ctx, interactionId, workflowId, mcp)
+	evt := mcpEvent(events.McpUpdated, interactionId, workflowId, mcp.ID, mcp)
+	err := ms.mcpRepo.Update(ctx, interactionId, workflowId, mcp, evt)
 	if err != nil {
 		ms.log.Errorf("Error while updating mcp: %v", err)
 		return nil, err
@@ -64,6 +89,37 @@ func (ms *mcpService) UpdateByInteractionIdAndWorkflowId(ctx context.Context, in
 	return mcp, nil
 }
 
+// PatchByInteractionIdAndWorkflowIdAndId applies a merge patch or JSON patch to the current
+// revision of the mcp and returns the new revision
+func (ms *mcpService) PatchByInteractionIdAndWorkflowIdAndId(ctx context.Context, interactionId, workflowId, mcpId string, req PatchRequest) (*runtime.MCP, int64, error) {
+	load := func(ctx context.Context) (*runtime.MCP, int64, error) {
+		revision, err := ms.mcpRepo.Revision(ctx, interactionId, workflowId, mcpId)
+		if err != nil {
+			return nil, 0, err
+		}
+		mcp, err := ms.mcpRepo.Get(ctx, interactionId, workflowId, mcpId)
+		return mcp, revision, err
+	}
+	store := func(ctx context.Context, mcp *runtime.MCP) error {
+		if mcp.ID != mcpId {
+			return fmt.Errorf("%w: the mcp id cannot be changed", patch.ErrInvalidPatch)
+		}
+		_, err := ms.UpdateByInteractionIdAndWorkflowId(ctx, interactionId, workflowId, mcp)
+		return err
+	}
+	return applyPatch(ctx, repo.McpKey(interactionId, workflowId, mcpId), req, load, ms.v.Mcp, store)
+}
+
+func (ms *mcpService) Revision(ctx context.Context, interactionId, workflowId, mcpId string) (int64, error) {
+	return ms.mcpRepo.Revision(ctx, interactionId, workflowId, mcpId)
+}
+
+// History returns the recorded writes of the mcp, oldest first
+func (ms *mcpService) History(ctx context.Context, interactionId, workflowId, mcpId string) ([]model.HistoryRecord, error) {
+	records, err := ms.mcpRepo.History(ctx, interactionId, workflowId, mcpId)
+	return recorded(records, err, "no history for mcp id: %s", mcpId)
+}
+
 func (ms *mcpService) AddTool(ctx context.Context, interactionId, workflowId, mcpId string, tool *runtime.Tool) (*runtime.MCP, error) {
 	mcp, err := ms.mcpRepo.Get(ctx, interactionId, workflowId, mcpId)
 	if err != nil {
@@ -71,21 +127,35 @@ func (ms *mcpService) AddTool(ctx context.Context, interactionId, workflowId, mc
 		return nil, err
 	}
 	mcp.Tools = append(mcp.Tools, *tool)
-	if err = ms.mcpRepo.Update(ctx, interactionId, workflowId, mcp); err != nil {
+	evt := mcpEvent(events.McpToolsChanged, interactionId, workflowId, mcpId, mcp.Tools)
+	if err = ms.mcpRepo.Update(ctx, interactionId, workflowId, mcp, evt); err != nil {
 		ms.log.Errorf("Error while updating mcp: %v", err)
 		return nil, err
 	}
 	return mcp, nil
 }
 
+// DeleteByInteractionIdAndWorkflowIdAndId deletes the mcp and the connection to its server. A
+// connection left behind by a failure here is removed by the next refresh.
 func (ms *mcpService) DeleteByInteractionIdAndWorkflowIdAndId(ctx context.Context, interactionId, workflowId, mcpId string) error {
-	return ms.mcpRepo.Delete(ctx, interactionId, workflowId, mcpId)
+	err := ms.mcpRepo.Delete(ctx, interactionId, workflowId, mcpId, mcpEvent(events.McpDeleted, interactionId, workflowId, mcpId, nil))
+	if err != nil {
+		return err
+	}
+	if err = ms.connRepo.Delete(ctx, interactionId, workflowId, mcpId); err != nil {
+		ms.log.Errorf("Error while deleting connection of mcp id: %s by error: %v", mcpId, err)
+		return err
+	}
+	return nil
 }
 
-func NewMcpService(log *logger.Logger, tr trace.Tracer, repo repo.MCPRepo) McpService {
+func NewMcpService(log *logger.Logger, tr trace.Tracer, mcpRepo repo.MCPRepo, connRepo repo.McpConnectionRepo, interactionRepo repo.InteractionRepo, workflowRepo repo.WorkflowRepo) McpService {
 	return &mcpService{
-		log:     log,
-		tr:      tr,
-		mcpRepo: repo,
+		log:       log,
+		tr:        tr,
+		mcpRepo:   mcpRepo,
+		connRepo:  connRepo,
+		workflows: workflows{interactionRepo: interactionRepo, workflowRepo: workflowRepo},
+		v:         validation.New(settings.GetValidation()),
 	}
 }
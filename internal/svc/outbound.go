@@ -0,0 +1,55 @@
+package svc
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// sharedAddrs is the carrier grade NAT range, not routed on the internet
+var sharedAddrs = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddr reports whether the service may connect to the address on behalf of a caller.
+// Loopback, private, link local and shared addresses reach the networks of the service itself.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddrs.Contains(addr)
+}
+
+// privateURL reports whether the url names localhost or a literal address that is not public.
+// Host names are checked again once resolved, by the dialer of outboundClient.
+func privateURL(target *url.URL) bool {
+	host := strings.ToLower(target.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && !publicAddr(addr)
+}
+
+// outboundClient is the http client for urls supplied by callers. Unless private networks are
+// allowed it refuses to connect to an address that is not public, which a host name can resolve
+// to after the url was validated or a redirect can lead to.
+func outboundClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivateNetworks {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if addr, err := netip.ParseAddr(host); err != nil || !publicAddr(addr) {
+				return fmt.Errorf("refusing to connect to the non public address %s", host)
+			}
+			return nil
+		}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
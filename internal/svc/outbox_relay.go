@@ -0,0 +1,243 @@
+package svc
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// OutboxRelay drains the events committed with the state writes to the event bus. An event is
+// removed from the outbox only after it was published, so delivery is at-least-once and consumers
+// deduplicate by event id.
+type OutboxRelay interface {
+	Start(ctx context.Context)
+	Stats(ctx context.Context) (*model.OutboxStats, error)
+	Close()
+}
+
+type outboxRelay struct {
+	log       *logger.Logger
+	tr        trace.Tracer
+	outbox    repo.OutboxRepo
+	publisher events.Publisher
+	cfg       settings.Outbox
+	owner     string
+	done      chan struct{}
+
+	published atomic.Int64
+	failed    atomic.Int64
+	mu        sync.Mutex
+	lastRun   time.Time
+	lastError string
+}
+
+type pendingEvent struct {
+	outboxKey string
+	index     int
+	evt       events.Event
+}
+
+// Start relays on the configured interval, after a failed round the interval doubles up to the
+// configured maximum and resets once a round succeeds. The relay lock is released when ctx is
+// cancelled, so another replica takes over without waiting for it to expire.
+func (rl *outboxRelay) Start(ctx context.Context) {
+	go func() {
+		defer close(rl.done)
+		defer rl.unlock()
+		wait := rl.cfg.Interval
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(wait):
+			}
+			if err := rl.relay(ctx); err != nil {
+				rl.log.Errorf("Error while relaying outbox: %v", err)
+				rl.recordError(err)
+				wait = min(wait*2, rl.cfg.MaxBackoff)
+				continue
+			}
+			wait = rl.cfg.Interval
+		}
+	}()
+}
+
+func (rl *outboxRelay) unlock() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := rl.outbox.Unlock(ctx, rl.owner); err != nil {
+		rl.log.Warnf("Error while releasing the outbox relay lock: %v", err)
+	}
+}
+
+func (rl *outboxRelay) recordError(err error) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	rl.lastRun = time.Now()
+	rl.lastError = err.Error()
+}
+
+// relay publishes the oldest events of every outbox in time order, in batches of the configured
+// size. The lock is extended before every batch, a relay that lost it stops. When an event fails,
+// the later events of its interaction are held back so they keep their order on the next round.
+func (rl *outboxRelay) relay(ctx context.Context) error {
+	locked, err := rl.outbox.Lock(ctx, rl.owner, rl.cfg.LockTTL)
+	if err != nil || !locked {
+		return err
+	}
+	if err = rl.outbox.Recover(ctx, rl.cfg.UnitTimeout); err != nil {
+		rl.log.Errorf("Error while recovering unfinished units of work: %v", err)
+	}
+	keys, err := rl.outbox.Keys(ctx)
+	if err != nil {
+		return err
+	}
+	var pending []pendingEvent
+	for _, key := range keys {
+		evts, err := rl.outbox.Peek(ctx, key, rl.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		if len(evts) == 0 {
+			if err = rl.outbox.Release(ctx, key); err != nil {
+				rl.log.Warnf("Error while releasing outbox: %s by error: %v", key, err)
+			}
+			continue
+		}
+		for i, evt := range evts {
+			pending = append(pending, pendingEvent{outboxKey: key, index: i, evt: evt})
+		}
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].evt.Time.Before(pending[j].evt.Time)
+	})
+
+	acked := make(map[string]int)
+	held := make(map[string]bool)
+	var lastErr error
+	for start := 0; start < len(pending); start += rl.cfg.BatchSize {
+		if start > 0 {
+			if locked, err = rl.outbox.Lock(ctx, rl.owner, rl.cfg.LockTTL); err != nil || !locked {
+				rl.log.Warnf("Outbox relay lost its lock during a round: %v", err)
+				lastErr = err
+				break
+			}
+		}
+		rl.publish(ctx, pending[start:min(start+rl.cfg.BatchSize, len(pending))], acked, held, &lastErr)
+	}
+	for key, n := range acked {
+		if err = rl.outbox.Ack(ctx, key, n); err != nil {
+			rl.log.Errorf("Error while acking %d events of outbox: %s by error: %v", n, key, err)
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return lastErr
+	}
+	rl.mu.Lock()
+	rl.lastRun = time.Now()
+	rl.lastError = ""
+	rl.mu.Unlock()
+	return nil
+}
+
+// publish sends a batch of pending events and counts the delivered ones into acked. Only a prefix
+// of an outbox can be acked, an event out of turn waits for the next round.
+func (rl *outboxRelay) publish(ctx context.Context, batch []pendingEvent, acked map[string]int, held map[string]bool, lastErr *error) {
+	next := make(map[string]int, len(acked))
+	for key, n := range acked {
+		next[key] = n
+	}
+	sent := make([]pendingEvent, 0, len(batch))
+	evts := make([]*events.Event, 0, len(batch))
+	for _, pe := range batch {
+		if held[pe.evt.InteractionId] || next[pe.outboxKey] != pe.index {
+			continue
+		}
+		next[pe.outboxKey]++
+		sent = append(sent, pe)
+		evts = append(evts, &sent[len(sent)-1].evt)
+	}
+	if len(evts) == 0 {
+		return
+	}
+	for i, err := range events.PublishAll(ctx, rl.publisher, evts) {
+		pe := sent[i]
+		switch {
+		case err != nil:
+			rl.failed.Add(1)
+			held[pe.evt.InteractionId] = true
+			*lastErr = err
+		case held[pe.evt.InteractionId] || acked[pe.outboxKey] != pe.index:
+			// delivered behind a failed event, it is sent again with that one
+		default:
+			rl.published.Add(1)
+			acked[pe.outboxKey]++
+		}
+	}
+}
+
+// Stats reports the pending events and the age of the oldest one as the relay lag
+func (rl *outboxRelay) Stats(ctx context.Context) (*model.OutboxStats, error) {
+	keys, err := rl.outbox.Keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stats := &model.OutboxStats{
+		Published: rl.published.Load(),
+		Failed:    rl.failed.Load(),
+	}
+	for _, key := range keys {
+		size, err := rl.outbox.Size(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if size == 0 {
+			continue
+		}
+		stats.Pending += size
+		head, err := rl.outbox.Peek(ctx, key, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(head) > 0 && (stats.OldestPendingAt == nil || head[0].Time.Before(*stats.OldestPendingAt)) {
+			oldest := head[0].Time
+			stats.OldestPendingAt = &oldest
+		}
+	}
+	if stats.OldestPendingAt != nil {
+		stats.LagSeconds = time.Since(*stats.OldestPendingAt).Seconds()
+	}
+	rl.mu.Lock()
+	stats.LastRelayAt = rl.lastRun
+	stats.LastError = rl.lastError
+	rl.mu.Unlock()
+	return stats, nil
+}
+
+// Close waits for the relay loop to stop, the context passed to Start has to be cancelled first
+func (rl *outboxRelay) Close() {
+	<-rl.done
+}
+
+func NewOutboxRelay(log *logger.Logger, tr trace.Tracer, outbox repo.OutboxRepo, publisher events.Publisher) OutboxRelay {
+	return &outboxRelay{
+		log:       log,
+		tr:        tr,
+		outbox:    outbox,
+		publisher: publisher,
+		cfg:       settings.GetOutbox(),
+		owner:     uuid.NewString(),
+		done:      make(chan struct{}),
+	}
+}
@@ -0,0 +1,68 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/patch"
+	"github.com/mangudaigb/state-service/internal/repo"
+)
+
+const maxPatchAttempts = 5
+
+// ErrPreconditionFailed is returned when a patch names a revision the document is no longer at
+var ErrPreconditionFailed = errs.Conflict("revision does not match")
+
+// PatchRequest is a partial update of a document, ContentType selects merge patch or JSON patch.
+// A positive IfMatch applies the patch only to that revision of the document.
+type PatchRequest struct {
+	ContentType string
+	Body        []byte
+	IfMatch     int64
+}
+
+// applyPatch applies the patch to the current version of the document under key, checks the result
+// with validate like a full update and writes it through store, which fails if the document
+// changed since it was read. Without IfMatch the patch is applied again to the new version, so
+// concurrent patches of different fields all land. It returns the patched document and its
+// revision.
+func applyPatch[T any](ctx context.Context, key string, req PatchRequest, load func(ctx context.Context) (*T, int64, error), validate func(doc *T) error, store func(ctx context.Context, doc *T) error) (*T, int64, error) {
+	for attempt := 0; attempt < maxPatchAttempts; attempt++ {
+		current, revision, err := load(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+		if req.IfMatch > 0 && req.IfMatch != revision {
+			return nil, revision, ErrPreconditionFailed
+		}
+		doc, err := json.Marshal(current)
+		if err != nil {
+			return nil, 0, err
+		}
+		if doc, err = patch.ApplyType(req.ContentType, doc, req.Body); err != nil {
+			return nil, 0, err
+		}
+		var patched T
+		if err = json.Unmarshal(doc, &patched); err != nil {
+			return nil, 0, fmt.Errorf("%w: %v", patch.ErrInvalidPatch, err)
+		}
+		if err = validate(&patched); err != nil {
+			return nil, 0, err
+		}
+		err = store(repo.ExpectRevision(ctx, key, revision), &patched)
+		if errors.Is(err, repo.ErrRevisionConflict) {
+			if req.IfMatch > 0 {
+				return nil, 0, ErrPreconditionFailed
+			}
+			continue
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		return &patched, revision + 1, nil
+	}
+	return nil, 0, repo.ErrRevisionConflict
+}
@@ -0,0 +1,615 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	ModeSequential = "sequential"
+	ModeParallel   = "parallel"
+
+	EdgeDependsOn = "depends_on"
+)
+
+var (
+	ErrPlanNotFound = errs.NotFound("plan not found")
+	ErrPlanMismatch = errs.Conflict("plan id does not match the interaction plan")
+)
+
+type PlanService interface {
+	Update(ctx context.Context, interactionId, planId string, plan *runtime.Plan, reason string) (*runtime.Interaction, error)
+	Replan(ctx context.Context, interactionId string, plan *runtime.Plan, reason string) (*runtime.Interaction, error)
+	Revisions(ctx context.Context, interactionId string) ([]model.PlanRevision, error)
+	Revision(ctx context.Context, interactionId string, revision int) (*model.PlanRevision, error)
+	Diff(ctx context.Context, interactionId string, from, to int) (*model.PlanDiff, error)
+
+	Compile(ctx context.Context, interactionId, planId, mode string) (*runtime.Interaction, error)
+	Reconcile(ctx context.Context, interactionId string, revision int) (*model.ReconcileResult, error)
+}
+
+type planService struct {
+	log             *logger.Logger
+	tr              trace.Tracer
+	interactionRepo repo.InteractionRepo
+	stepRepo        repo.StepRepo
+	planRepo        repo.PlanRepo
+	childRepo       repo.ChildRepo
+	outboxRepo      repo.OutboxRepo
+}
+
+func graphUpdated(interaction *runtime.Interaction) *events.Event {
+	flow := interaction.ExecutionFlow
+	evt := events.New(events.ExecutionGraphUpdated, interaction.ID, flow.ExecutionGraph)
+	evt.WorkflowId = flow.ID
+	evt.ExecutionId = flow.ExecutionGraph.ID
+	return evt
+}
+
+// history loads the plan history of the interaction. Interactions created before revisions were
+// kept get their current plan recorded as the first revision.
+func (ps *planService) history(ctx context.Context, interaction *runtime.Interaction) (*model.PlanHistory, error) {
+	history, err := ps.planRepo.GetHistory(ctx, interaction.ID)
+	if errors.Is(err, errs.ErrNotFound) {
+		history = &model.PlanHistory{InteractionId: interaction.ID}
+	} else if err != nil {
+		ps.log.Errorf("Error while getting plan history of interaction id:%s by error: %v", interaction.ID, err)
+		return nil, err
+	}
+	if len(history.Revisions) == 0 && interaction.Plan != nil {
+		history.Revisions = append(history.Revisions, model.PlanRevision{
+			Revision:  1,
+			Plan:      *interaction.Plan,
+			Reason:    "initial",
+			CreatedAt: interaction.CreatedAt,
+		})
+	}
+	return history, nil
+}
+
+// addRevision makes the plan the current one and records it in the history, within the unit of
+// work of the caller so that the interaction and its history are written together
+func (ps *planService) addRevision(ctx context.Context, interaction *runtime.Interaction, plan *runtime.Plan, reason string) error {
+	history, err := ps.history(ctx, interaction)
+	if err != nil {
+		return err
+	}
+	next := 1
+	if latest := history.Latest(); latest != nil {
+		next = latest.Revision + 1
+	}
+	revision := model.PlanRevision{
+		Revision:  next,
+		Plan:      *plan,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	}
+	history.Revisions = append(history.Revisions, revision)
+	interaction.Plan = plan
+	evt := events.New(events.PlanRevised, interaction.ID, revision)
+	if err := ps.interactionRepo.Update(ctx, interaction, evt); err != nil {
+		ps.log.Errorf("Error while updating interaction id:%s with plan: %s by error: %v", interaction.ID, plan.ID, err)
+		return err
+	}
+	if err := ps.planRepo.SaveHistory(ctx, history); err != nil {
+		ps.log.Errorf("Error while saving plan revision: %d of interaction id:%s by error: %v", next, interaction.ID, err)
+		return err
+	}
+	return nil
+}
+
+// Update stores a new revision of the current plan, planId has to be the id of the current plan
+func (ps *planService) Update(ctx context.Context, interactionId, planId string, plan *runtime.Plan, reason string) (*runtime.Interaction, error) {
+	var interaction *runtime.Interaction
+	err := commitUnit(ctx, ps.outboxRepo, func(ctx context.Context) error {
+		var err error
+		interaction, err = ps.interactionRepo.Get(ctx, interactionId)
+		if err != nil {
+			ps.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+			return err
+		}
+		if interaction.Plan == nil {
+			return ErrPlanNotFound
+		}
+		if interaction.Plan.ID != planId {
+			ps.log.Errorf("Mismatch in plan ids, i.plan.Id/planId:%s/%s", interaction.Plan.ID, planId)
+			return ErrPlanMismatch
+		}
+		return ps.addRevision(ctx, interaction, plan, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return interaction, nil
+}
+
+// Replan replaces the current plan with a new one, the previous plan stays in the history
+func (ps *planService) Replan(ctx context.Context, interactionId string, plan *runtime.Plan, reason string) (*runtime.Interaction, error) {
+	if plan.ID == "" {
+		plan.ID = uuid.NewString()
+	}
+	var interaction *runtime.Interaction
+	err := commitUnit(ctx, ps.outboxRepo, func(ctx context.Context) error {
+		var err error
+		interaction, err = ps.interactionRepo.Get(ctx, interactionId)
+		if err != nil {
+			ps.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+			return err
+		}
+		return ps.addRevision(ctx, interaction, plan, reason)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return interaction, nil
+}
+
+func (ps *planService) Revisions(ctx context.Context, interactionId string) ([]model.PlanRevision, error) {
+	interaction, err := ps.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		ps.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+		return nil, err
+	}
+	history, err := ps.history(ctx, interaction)
+	if err != nil {
+		return nil, err
+	}
+	if history.Revisions == nil {
+		return []model.PlanRevision{}, nil
+	}
+	return history.Revisions, nil
+}
+
+func (ps *planService) Revision(ctx context.Context, interactionId string, revision int) (*model.PlanRevision, error) {
+	interaction, err := ps.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		ps.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+		return nil, err
+	}
+	history, err := ps.history(ctx, interaction)
+	if err != nil {
+		return nil, err
+	}
+	return revisionOf(history, revision)
+}
+
+// revisionOf returns the requested revision, revision 0 returns the latest one
+func revisionOf(history *model.PlanHistory, revision int) (*model.PlanRevision, error) {
+	var rev *model.PlanRevision
+	if revision == 0 {
+		rev = history.Latest()
+	} else {
+		rev = history.Get(revision)
+	}
+	if rev == nil {
+		return nil, ErrPlanNotFound
+	}
+	return rev, nil
+}
+
+func (ps *planService) Diff(ctx context.Context, interactionId string, from, to int) (*model.PlanDiff, error) {
+	interaction, err := ps.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		ps.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+		return nil, err
+	}
+	history, err := ps.history(ctx, interaction)
+	if err != nil {
+		return nil, err
+	}
+	fromRev, err := revisionOf(history, from)
+	if err != nil {
+		return nil, err
+	}
+	toRev, err := revisionOf(history, to)
+	if err != nil {
+		return nil, err
+	}
+	return diffPlans(fromRev, toRev), nil
+}
+
+func diffPlans(from, to *model.PlanRevision) *model.PlanDiff {
+	diff := &model.PlanDiff{
+		From:      from.Revision,
+		To:        to.Revision,
+		Added:     []runtime.PlanStep{},
+		Removed:   []runtime.PlanStep{},
+		Changed:   []model.PlanStepChange{},
+		Unchanged: []string{},
+	}
+	before := make(map[string]runtime.PlanStep, len(from.Plan.Steps))
+	for _, s := range from.Plan.Steps {
+		before[s.ID] = s
+	}
+	after := make(map[string]bool, len(to.Plan.Steps))
+	for _, s := range to.Plan.Steps {
+		after[s.ID] = true
+		prev, ok := before[s.ID]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, s)
+		case reflect.DeepEqual(prev, s):
+			diff.Unchanged = append(diff.Unchanged, s.ID)
+		default:
+			diff.Changed = append(diff.Changed, model.PlanStepChange{Before: prev, After: s})
+		}
+	}
+	for _, s := range from.Plan.Steps {
+		if !after[s.ID] {
+			diff.Removed = append(diff.Removed, s)
+		}
+	}
+	return diff
+}
+
+// Compile turns the stored plan into the execution graph of the interaction: one pending step per
+// plan step, depends_on edges from the plan dependencies and, in sequential mode, between the plan
+// steps in dependency order. The steps, the interaction and its plan history are written in one
+// unit of work.
+func (ps *planService) Compile(ctx context.Context, interactionId, planId, mode string) (*runtime.Interaction, error) {
+	var interaction *runtime.Interaction
+	err := commitUnit(ctx, ps.outboxRepo, func(ctx context.Context) error {
+		var err error
+		interaction, err = ps.compile(ctx, interactionId, planId, mode)
+		return err
+	})
+	if err != nil {
+		ps.log.Errorf("Error while compiling plan: %s of interaction id:%s by error: %v", planId, interactionId, err)
+		return nil, err
+	}
+	return interaction, nil
+}
+
+func (ps *planService) compile(ctx context.Context, interactionId, planId, mode string) (*runtime.Interaction, error) {
+	interaction, err := ps.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		ps.log.Errorf("Error while getting interaction id:%s by error: %v", interactionId, err)
+		return nil, err
+	}
+	if interaction.Plan == nil {
+		return nil, ErrPlanNotFound
+	}
+	if interaction.Plan.ID != planId {
+		return nil, ErrPlanMismatch
+	}
+	if interaction.ExecutionFlow == nil {
+		interaction.ExecutionFlow = &runtime.ExecutionFlow{ID: uuid.NewString()}
+	}
+	flow := interaction.ExecutionFlow
+	if flow.ExecutionGraph == nil {
+		flow.ExecutionGraph = &runtime.ExecutionGraph{ID: uuid.NewString()}
+	}
+	graph := flow.ExecutionGraph
+	if len(graph.Nodes) > 0 {
+		return nil, errs.Conflict("execution graph id: %s already has steps", graph.ID)
+	}
+	if mode == "" {
+		mode = flow.Mode
+	}
+	if mode == "" {
+		mode = ModeSequential
+	}
+	if mode != ModeSequential && mode != ModeParallel {
+		return nil, errs.Invalid("invalid workflow mode: %s", mode)
+	}
+
+	stepIds, steps, err := bindPlanSteps(interaction.Plan, nil)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := planEdges(interaction.Plan, mode, stepIds)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = ps.saveSteps(ctx, interactionId, flow, steps); err != nil {
+		return nil, err
+	}
+	graph.Edges = edges
+	flow.Mode = mode
+	flow.PlanID = planId
+
+	if err = ps.interactionRepo.Update(ctx, interaction, graphUpdated(interaction)); err != nil {
+		return nil, err
+	}
+	history, err := ps.history(ctx, interaction)
+	if err != nil {
+		return nil, err
+	}
+	history.StepBindings = stepIds
+	if err = ps.planRepo.SaveHistory(ctx, history); err != nil {
+		return nil, err
+	}
+	return interaction, nil
+}
+
+// Reconcile aligns the compiled execution graph with a plan revision. Steps of plan steps that are
+// still present are kept, new plan steps get pending steps and steps compiled from plan steps that
+// were removed are stopped unless they already finished. Steps added to the graph by hand are left
+// alone. Edges are rebuilt for the steps of the plan. The steps, the interaction and its plan
+// history are written in one unit of work.
+func (ps *planService) Reconcile(ctx context.Context, interactionId string, revision int) (*model.ReconcileResult, error) {
+	var result *model.ReconcileResult
+	err := commitUnit(ctx, ps.outboxRepo, func(ctx context.Context) error {
+		var err error
+		result, err = ps.reconcile(ctx, interactionId, revision)
+		return err
+	})
+	if err != nil {
+		ps.log.Errorf("Error while reconciling interaction id:%s with plan revision: %d by error: %v", interactionId, revision, err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func (ps *planService) reconcile(ctx context.Context, interactionId string, revision int) (*model.ReconcileResult, error) {
+	interaction, err := ps.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	flow := interaction.ExecutionFlow
+	if flow == nil || flow.ExecutionGraph == nil {
+		return nil, errs.Conflict("interaction id: %s has no execution graph to reconcile", interactionId)
+	}
+	graph := flow.ExecutionGraph
+	history, err := ps.history(ctx, interaction)
+	if err != nil {
+		return nil, err
+	}
+	rev, err := revisionOf(history, revision)
+	if err != nil {
+		return nil, err
+	}
+	mode := flow.Mode
+	if mode == "" {
+		mode = ModeSequential
+	}
+
+	nodes := make(map[string]int, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		nodes[node.StepId] = i
+	}
+	bindings := make(map[string]string, len(history.StepBindings))
+	compiled := make(map[string]bool, len(history.StepBindings))
+	for planStepId, stepId := range history.StepBindings {
+		compiled[stepId] = true
+		if _, ok := nodes[stepId]; ok {
+			bindings[planStepId] = stepId
+		}
+	}
+
+	stepIds, added, err := bindPlanSteps(&rev.Plan, bindings)
+	if err != nil {
+		return nil, err
+	}
+	edges, err := planEdges(&rev.Plan, mode, stepIds)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &model.ReconcileResult{Revision: rev.Revision, Added: []string{}, Cancelled: []string{}, Kept: []string{}}
+	inPlan := make(map[string]bool, len(stepIds))
+	for _, stepId := range stepIds {
+		inPlan[stepId] = true
+		if _, ok := nodes[stepId]; ok {
+			result.Kept = append(result.Kept, stepId)
+		}
+	}
+
+	children, err := childNodes(ctx, ps.childRepo, interactionId, graph.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i, node := range graph.Nodes {
+		if inPlan[node.StepId] {
+			continue
+		}
+		// a child interaction has no step to stop, it runs on under its node
+		if !compiled[node.StepId] || isTerminal(node.Status) || children[node.StepId] {
+			result.Kept = append(result.Kept, node.StepId)
+			continue
+		}
+		step, err := ps.stepRepo.Get(ctx, interactionId, flow.ID, graph.ID, node.StepId)
+		if err != nil {
+			return nil, err
+		}
+		step.Status = runtime.StatusStop
+		step.FinishedAt = time.Now()
+		if err = ps.stepRepo.Update(ctx, interactionId, flow.ID, graph.ID, step); err != nil {
+			return nil, err
+		}
+		graph.Nodes[i].Status = step.Status
+		result.Cancelled = append(result.Cancelled, node.StepId)
+	}
+
+	saved, err := ps.saveSteps(ctx, interactionId, flow, added)
+	if err != nil {
+		return nil, err
+	}
+	result.Added = saved
+
+	// edges between steps that left the plan are kept as history of the earlier revision
+	for _, e := range graph.Edges {
+		if !inPlan[e.From] || !inPlan[e.To] {
+			edges = append(edges, e)
+		}
+	}
+	graph.Edges = edges
+	flow.PlanID = rev.Plan.ID
+
+	if err = ps.interactionRepo.Update(ctx, interaction, graphUpdated(interaction)); err != nil {
+		return nil, err
+	}
+	history.StepBindings = stepIds
+	if err = ps.planRepo.SaveHistory(ctx, history); err != nil {
+		return nil, err
+	}
+	result.Interaction = interaction
+	return result, nil
+}
+
+// saveSteps writes the steps and appends their nodes to the graph. It runs in the unit of work of
+// the caller, which writes none of them when it fails.
+func (ps *planService) saveSteps(ctx context.Context, interactionId string, flow *runtime.ExecutionFlow, steps []*runtime.Step) ([]string, error) {
+	graph := flow.ExecutionGraph
+	saved := make([]string, 0, len(steps))
+	nodes := make([]runtime.ExecutionNode, 0, len(steps))
+	for _, step := range steps {
+		if err := ps.stepRepo.Save(ctx, interactionId, flow.ID, graph.ID, step); err != nil {
+			ps.log.Errorf("Error while saving compiled step: %s by error: %v", step.ID, err)
+			return nil, err
+		}
+		saved = append(saved, step.ID)
+		nodes = append(nodes, runtime.ExecutionNode{
+			StepId: step.ID,
+			Name:   step.Name,
+			Status: step.Status,
+		})
+	}
+	graph.Nodes = append(graph.Nodes, nodes...)
+	return saved, nil
+}
+
+// bindPlanSteps maps every plan step onto a step id. Plan steps already bound keep their step,
+// the others get a new pending step which is returned to be saved.
+func bindPlanSteps(plan *runtime.Plan, bindings map[string]string) (map[string]string, []*runtime.Step, error) {
+	now := time.Now()
+	stepIds := make(map[string]string, len(plan.Steps))
+	var steps []*runtime.Step
+	for i, planStep := range plan.Steps {
+		if planStep.ID == "" {
+			return nil, nil, errs.Invalid("plan step at index %d has no id", i)
+		}
+		if _, ok := stepIds[planStep.ID]; ok {
+			return nil, nil, errs.Invalid("duplicate plan step id: %s", planStep.ID)
+		}
+		if stepId, ok := bindings[planStep.ID]; ok {
+			stepIds[planStep.ID] = stepId
+			continue
+		}
+		step := &runtime.Step{
+			ID:     uuid.NewString(),
+			Index:  i + 1,
+			Name:   planStep.Name,
+			Status: runtime.StatusPending,
+			Query: &runtime.Query{
+				ID:        uuid.NewString(),
+				Content:   planStep.Description,
+				Timestamp: now,
+			},
+		}
+		stepIds[planStep.ID] = step.ID
+		steps = append(steps, step)
+	}
+	return stepIds, steps, nil
+}
+
+func planEdges(plan *runtime.Plan, mode string, stepIds map[string]string) ([]runtime.Edge, error) {
+	var edges []runtime.Edge
+	seen := make(map[[2]string]bool)
+	addEdge := func(from, to string) {
+		key := [2]string{from, to}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		edges = append(edges, runtime.Edge{From: from, To: to, Type: EdgeDependsOn})
+	}
+	for _, planStep := range plan.Steps {
+		to := stepIds[planStep.ID]
+		for _, dep := range planStep.DependsOn {
+			from, ok := stepIds[dep]
+			if !ok {
+				return nil, errs.Invalid("plan step: %s depends on unknown step: %s", planStep.ID, dep)
+			}
+			addEdge(from, to)
+		}
+	}
+	if mode == ModeSequential {
+		order, ok := sequence(plan)
+		if !ok {
+			return nil, errs.Invalid("plan dependencies contain a cycle")
+		}
+		for i := 1; i < len(order); i++ {
+			addEdge(stepIds[order[i-1]], stepIds[order[i]])
+		}
+	}
+	if hasCycle(stepIds, edges) {
+		return nil, errs.Invalid("plan dependencies contain a cycle")
+	}
+	return edges, nil
+}
+
+// sequence orders the plan step ids so every step follows the steps it depends on, otherwise the
+// plan order is kept. ok is false when the dependencies contain a cycle.
+func sequence(plan *runtime.Plan) ([]string, bool) {
+	placed := make(map[string]bool, len(plan.Steps))
+	order := make([]string, 0, len(plan.Steps))
+	for len(order) < len(plan.Steps) {
+		next := slices.IndexFunc(plan.Steps, func(planStep runtime.PlanStep) bool {
+			return !placed[planStep.ID] && !slices.ContainsFunc(planStep.DependsOn, func(dep string) bool { return !placed[dep] })
+		})
+		if next < 0 {
+			return nil, false
+		}
+		placed[plan.Steps[next].ID] = true
+		order = append(order, plan.Steps[next].ID)
+	}
+	return order, true
+}
+
+func hasCycle(stepIds map[string]string, edges []runtime.Edge) bool {
+	inDegree := make(map[string]int, len(stepIds))
+	next := make(map[string][]string, len(stepIds))
+	for _, e := range edges {
+		inDegree[e.To]++
+		next[e.From] = append(next[e.From], e.To)
+	}
+	queue := make([]string, 0, len(stepIds))
+	for _, id := range stepIds {
+		if inDegree[id] == 0 {
+			queue = append(queue, id)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, to := range next[id] {
+			inDegree[to]--
+			if inDegree[to] == 0 {
+				queue = append(queue, to)
+			}
+		}
+	}
+	return visited != len(stepIds)
+}
+
+func isTerminal(status runtime.Status) bool {
+	return status == runtime.StatusStop || status == runtime.StatusError || status == runtime.StatusSuccess
+}
+
+func NewPlanService(log *logger.Logger, tr trace.Tracer, interactionRepo repo.InteractionRepo, stepRepo repo.StepRepo, planRepo repo.PlanRepo, childRepo repo.ChildRepo, outboxRepo repo.OutboxRepo) PlanService {
+	return &planService{
+		log:             log,
+		tr:              tr,
+		interactionRepo: interactionRepo,
+		stepRepo:        stepRepo,
+		planRepo:        planRepo,
+		childRepo:       childRepo,
+		outboxRepo:      outboxRepo,
+	}
+}
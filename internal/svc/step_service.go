@@ -3,125 +3,397 @@ package svc
 import (
 	"context"
 	"errors"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/patch"
 	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"github.com/mangudaigb/state-service/internal/validation"
 	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrStepExists is returned when a step is created with the id of a step of the graph
+var ErrStepExists = errs.Conflict("step already exists")
+
 type StepService interface {
 	GetByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId string, stepId string) (*runtime.Step, error)
+	GetAllByInteractionIdAndExecutionId(ctx context.Context, interactionId, workflowId, executionId string, stepIds []string) ([]*runtime.Step, error)
 	CreateByInteractionIdAndExecutionId(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) (*runtime.Step, error)
 	UpdateByInteractionIdAndExecutionId(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) (*runtime.Step, error)
+	PatchByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string, req PatchRequest) (*runtime.Step, int64, error)
+	Revision(ctx context.Context, interactionId, workflowId, executionId, stepId string) (int64, error)
+	History(ctx context.Context, interactionId, workflowId, executionId, stepId string) ([]model.HistoryRecord, error)
 	UpdateStatusByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string, status runtime.Status) (*runtime.Step, error)
+	AddToolInvocation(ctx context.Context, interactionId, workflowId, executionId, stepId string, invocation *runtime.McpToolInvocation) (*runtime.Step, error)
+	AddArtifact(ctx context.Context, interactionId, workflowId, executionId, stepId string, artifact *runtime.Artifact) (*runtime.Step, error)
 	DeleteByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string) error
+	Locate(ctx context.Context, interactionId, stepId string) (*model.StepLocation, error)
 }
 
 type stepService struct {
-	log             *logger.Logger
-	tr              trace.Tracer
-	stepRepo        repo.StepRepo
-	interactionRepo repo.InteractionRepo
+	log        *logger.Logger
+	tr         trace.Tracer
+	stepRepo   repo.StepRepo
+	workflows  workflows
+	childRepo  repo.ChildRepo
+	outboxRepo repo.OutboxRepo
+	agentSvc   AgentService
+	// v checks patched documents like the handlers check full ones
+	v *validation.Validator
+}
+
+func stepEvent(t events.Type, interactionId, workflowId, executionId, stepId string, data any) *events.Event {
+	evt := events.New(t, interactionId, data)
+	evt.WorkflowId = workflowId
+	evt.ExecutionId = executionId
+	evt.StepId = stepId
+	return evt
 }
 
+// GetByInteractionIdAndExecutionIdAndId returns the step with its pinned agent reference resolved
 func (ss *stepService) GetByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string) (*runtime.Step, error) {
-	return ss.stepRepo.Get(ctx, interactionId, workflowId, executionId, stepId)
+	step, err := ss.stepRepo.Get(ctx, interactionId, workflowId, executionId, stepId)
+	if err != nil {
+		return nil, err
+	}
+	if err = ss.agentSvc.ResolveStep(ctx, step); err != nil {
+		return nil, err
+	}
+	return step, nil
+}
+
+// GetAllByInteractionIdAndExecutionId returns the steps in the order of stepIds, nil for a step that
+// was not created yet, with their pinned agent references resolved
+func (ss *stepService) GetAllByInteractionIdAndExecutionId(ctx context.Context, interactionId, workflowId, executionId string, stepIds []string) ([]*runtime.Step, error) {
+	steps, err := ss.stepRepo.GetAll(ctx, interactionId, workflowId, executionId, stepIds)
+	if err != nil {
+		ss.log.Errorf("Error while getting %d steps of interaction id: %s by error: %v", len(stepIds), interactionId, err)
+		return nil, err
+	}
+	for _, step := range steps {
+		if step == nil {
+			continue
+		}
+		if err = ss.agentSvc.ResolveStep(ctx, step); err != nil {
+			return nil, err
+		}
+	}
+	return steps, nil
 }
 
-// CreateByInteractionIdAndExecutionId Saves the step and updates the reference in execution graph
+// hasNode reports whether the execution graph has a node of the step
+func hasNode(graph *runtime.ExecutionGraph, stepId string) bool {
+	return slices.ContainsFunc(graph.Nodes, func(n runtime.ExecutionNode) bool { return n.StepId == stepId })
+}
+
+// CreateByInteractionIdAndExecutionId Saves the step and updates the reference in execution graph.
+// Both are written in one unit of work, a concurrent change of the graph is retried on top of it.
 func (ss *stepService) CreateByInteractionIdAndExecutionId(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) (*runtime.Step, error) {
 	if step.ID == "" {
 		step.ID = uuid.NewString()
 	}
 	step.Status = runtime.StatusPending
-	interaction, err := ss.interactionRepo.Get(ctx, interactionId)
-	if err != nil {
-		ss.log.Errorf("Error while getting interaction id: %s to update Step: %s by error: %v", interactionId, step.ID, err)
+	if err := ss.agentSvc.PinStep(ctx, step); err != nil {
+		ss.log.Errorf("Error while pinning agent of step: %s by error: %v", step.ID, err)
 		return nil, err
 	}
-	if interaction.ExecutionFlow.ID != workflowId || interaction.ExecutionFlow.ExecutionGraph.ID != executionId {
-		ss.log.Errorf("Mismatch in ids, i.wf.Id/wfid:%s/%s, i.wf.eg.Id/egId:%s/%s", workflowId, interaction.ExecutionFlow.ID, executionId, interaction.ExecutionFlow.ExecutionGraph.ID)
-		return nil, errors.New("mismatch in workflow or execution graph or both ids")
+	err := commitUnit(ctx, ss.outboxRepo, func(ctx context.Context) error {
+		ref, err := ss.workflows.findGraph(ctx, interactionId, workflowId, executionId)
+		if err != nil {
+			ss.log.Errorf("Error while getting execution graph: %s of interaction id: %s to create Step: %s by error: %v", executionId, interactionId, step.ID, err)
+			return err
+		}
+		if hasNode(ref.flow.ExecutionGraph, step.ID) {
+			return fmt.Errorf("%w: step id: %s", ErrStepExists, step.ID)
+		}
+		if err = ss.stepRepo.Save(ctx, interactionId, workflowId, executionId, step); err != nil {
+			return err
+		}
+		en := runtime.ExecutionNode{
+			StepId: step.ID,
+			Name:   step.Name,
+			Status: step.Status,
+		}
+		ref.flow.ExecutionGraph.Nodes = append(ref.flow.ExecutionGraph.Nodes, en)
+		evt := stepEvent(events.StepCreated, interactionId, workflowId, executionId, step.ID, step)
+		return ss.workflows.save(ctx, ref, evt)
+	})
+	if err != nil {
+		ss.log.Errorf("Error while creating step: %s of interaction id: %s by error: %v", step.ID, interactionId, err)
+		return nil, err
 	}
-	err = ss.stepRepo.Save(ctx, interactionId, workflowId, executionId, step)
+	return step, nil
+}
+
+// UpdateByInteractionIdAndExecutionId replaces a step of the execution graph, a step of an unknown
+// workflow, execution or one that is not in its graph is NotFound
+func (ss *stepService) UpdateByInteractionIdAndExecutionId(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) (*runtime.Step, error) {
+	ref, err := ss.workflows.findGraph(ctx, interactionId, workflowId, executionId)
 	if err != nil {
-		ss.log.Errorf("Error while creating step: %v for interaction id: %s", err, step.ID)
+		ss.log.Errorf("Error while getting execution graph: %s of interaction id: %s to update Step: %s by error: %v", executionId, interactionId, step.ID, err)
+		return nil, err
+	}
+	if !hasNode(ref.flow.ExecutionGraph, step.ID) {
+		return nil, errs.NotFound("step id: %s not found in execution graph: %s", step.ID, executionId)
+	}
+	if err = ss.agentSvc.PinStep(ctx, step); err != nil {
+		ss.log.Errorf("Error while pinning agent of step: %s by error: %v", step.ID, err)
 		return nil, err
 	}
-	en := runtime.ExecutionNode{
-		StepId: step.ID,
-		Name:   step.Name,
-		Status: step.Status,
+	evts := []*events.Event{stepEvent(events.StepUpdated, interactionId, workflowId, executionId, step.ID, step)}
+	if previous, err := ss.stepRepo.Get(ctx, interactionId, workflowId, executionId, step.ID); err == nil && previous != nil {
+		known := make(map[string]bool, len(previous.Artifacts))
+		for _, artifact := range previous.Artifacts {
+			known[artifact.ID] = true
+		}
+		for _, artifact := range step.Artifacts {
+			if !known[artifact.ID] {
+				evts = append(evts, stepEvent(events.ArtifactAdded, interactionId, workflowId, executionId, step.ID, artifact))
+			}
+		}
 	}
-	interaction.ExecutionFlow.ExecutionGraph.Nodes = append(interaction.ExecutionFlow.ExecutionGraph.Nodes, en)
-	err = ss.interactionRepo.Update(ctx, interaction)
+	err = ss.stepRepo.Update(ctx, interactionId, workflowId, executionId, step, evts...)
 	if err != nil {
-		ss.log.Errorf("Error while updating interaction id: %s with step: %s by error: %v", interactionId, step.ID, err)
+		ss.log.Errorf("Error while updating step: %v", err)
 		return nil, err
 	}
 	return step, nil
 }
 
-func (ss *stepService) UpdateByInteractionIdAndExecutionId(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) (*runtime.Step, error) {
-	err := ss.stepRepo.Update(ctx, interactionId, workflowId, executionId, step)
+// PatchByInteractionIdAndExecutionIdAndId applies a merge patch or JSON patch to the current
+// revision of the step and returns the new revision
+func (ss *stepService) PatchByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string, req PatchRequest) (*runtime.Step, int64, error) {
+	load := func(ctx context.Context) (*runtime.Step, int64, error) {
+		revision, err := ss.stepRepo.Revision(ctx, interactionId, workflowId, executionId, stepId)
+		if err != nil {
+			return nil, 0, err
+		}
+		step, err := ss.stepRepo.Get(ctx, interactionId, workflowId, executionId, stepId)
+		return step, revision, err
+	}
+	store := func(ctx context.Context, step *runtime.Step) error {
+		if step.ID != stepId {
+			return fmt.Errorf("%w: the step id cannot be changed", patch.ErrInvalidPatch)
+		}
+		_, err := ss.UpdateByInteractionIdAndExecutionId(ctx, interactionId, workflowId, executionId, step)
+		return err
+	}
+	return applyPatch(ctx, repo.StepKey(interactionId, workflowId, executionId, stepId), req, load, ss.v.Step, store)
+}
+
+func (ss *stepService) Revision(ctx context.Context, interactionId, workflowId, executionId, stepId string) (int64, error) {
+	return ss.stepRepo.Revision(ctx, interactionId, workflowId, executionId, stepId)
+}
+
+// History returns the recorded writes of the step, oldest first
+func (ss *stepService) History(ctx context.Context, interactionId, workflowId, executionId, stepId string) ([]model.HistoryRecord, error) {
+	records, err := ss.stepRepo.History(ctx, interactionId, workflowId, executionId, stepId)
+	return recorded(records, err, "no history for step id: %s", stepId)
+}
+
+// UpdateStatusByInteractionIdAndExecutionIdAndId Saves the step and updates the status in the step reference in
+// execution graph, in one unit of work
+func (ss *stepService) UpdateStatusByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string, status runtime.Status) (*runtime.Step, error) {
+	var step *runtime.Step
+	err := commitUnit(ctx, ss.outboxRepo, func(ctx context.Context) error {
+		ref, err := ss.workflows.findGraph(ctx, interactionId, workflowId, executionId)
+		if err != nil {
+			ss.log.Errorf("Error while getting execution graph: %s of interaction id: %s to update Step: %s by error: %v", executionId, interactionId, stepId, err)
+			return err
+		}
+		step, err = ss.stepRepo.Get(ctx, interactionId, workflowId, executionId, stepId)
+		if err != nil {
+			return err
+		}
+		if isTerminal(status) {
+			step.FinishedAt = time.Now()
+		}
+		previous := step.Status
+		step.Status = status
+		if err = ss.stepRepo.Update(ctx, interactionId, workflowId, executionId, step); err != nil {
+			return err
+		}
+		nodes := ref.flow.ExecutionGraph.Nodes
+		for i, node := range nodes {
+			if node.StepId == step.ID {
+				nodes[i].Status = step.Status
+				break
+			}
+		}
+		change := events.StatusChange{StepId: step.ID, Previous: string(previous), Status: string(step.Status)}
+		evts := []*events.Event{stepEvent(events.StepStatusChanged, interactionId, workflowId, executionId, step.ID, change)}
+		if step.Status == runtime.StatusError && previous != runtime.StatusError {
+			evts = append(evts, stepEvent(events.StepFailed, interactionId, workflowId, executionId, step.ID, step))
+		}
+		return ss.workflows.save(ctx, ref, evts...)
+	})
 	if err != nil {
-		ss.log.Errorf("Error while updating step: %v", err)
+		ss.log.Errorf("Error while updating status of step: %s of interaction id: %s by error: %v", stepId, interactionId, err)
 		return nil, err
 	}
 	return step, nil
 }
 
-// UpdateStatusByInteractionIdAndExecutionIdAndId Saves the step and updates the status in the step reference in execution graph
-func (ss *stepService) UpdateStatusByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string, status runtime.Status) (*runtime.Step, error) {
-	interaction, err := ss.interactionRepo.Get(ctx, interactionId)
+// AddToolInvocation records a tool invocation in the curated tools of the step
+func (ss *stepService) AddToolInvocation(ctx context.Context, interactionId, workflowId, executionId, stepId string, invocation *runtime.McpToolInvocation) (*runtime.Step, error) {
+	step, err := ss.stepRepo.Get(ctx, interactionId, workflowId, executionId, stepId)
 	if err != nil {
-		ss.log.Errorf("Error while getting interaction id: %s to update Step: %s by error: %v", interactionId, stepId, err)
+		ss.log.Errorf("Error while getting step by id: %v", err)
 		return nil, err
 	}
-	if interaction.ExecutionFlow.ID != workflowId || interaction.ExecutionFlow.ExecutionGraph.ID != executionId {
-		ss.log.Errorf("Mismatch in ids, i.wf.Id/wfid:%s/%s, i.wf.eg.Id/egId:%s/%s", workflowId, interaction.ExecutionFlow.ID, executionId, interaction.ExecutionFlow.ExecutionGraph.ID)
-		return nil, errors.New("mismatch in workflow or execution graph or both ids")
+	if invocation.ID == "" {
+		invocation.ID = uuid.NewString()
+	}
+	// a redelivered invocation is recorded once
+	for _, recorded := range step.CuratedTools {
+		if recorded.ID == invocation.ID {
+			return step, nil
+		}
+	}
+	invocation.StepID = step.ID
+	step.CuratedTools = append(step.CuratedTools, *invocation)
+	evt := stepEvent(events.ToolInvoked, interactionId, workflowId, executionId, stepId, invocation)
+	if err = ss.stepRepo.Update(ctx, interactionId, workflowId, executionId, step, evt); err != nil {
+		ss.log.Errorf("Error while recording tool invocation: %s on step: %s by error: %v", invocation.ID, stepId, err)
+		return nil, err
 	}
+	return step, nil
+}
 
+// AddArtifact appends an artifact to the artifacts of the step
+func (ss *stepService) AddArtifact(ctx context.Context, interactionId, workflowId, executionId, stepId string, artifact *runtime.Artifact) (*runtime.Step, error) {
 	step, err := ss.stepRepo.Get(ctx, interactionId, workflowId, executionId, stepId)
 	if err != nil {
 		ss.log.Errorf("Error while getting step by id: %v", err)
 		return nil, err
 	}
-	if status == runtime.StatusStop || status == runtime.StatusError || status == runtime.StatusSuccess {
-		step.FinishedAt = time.Now()
+	if artifact.ID == "" {
+		artifact.ID = uuid.NewString()
 	}
-	step.Status = status
-	err = ss.stepRepo.Update(ctx, interactionId, workflowId, executionId, step)
-	if err != nil {
-		ss.log.Errorf("Error while updating status of step: %v", err)
-		return nil, err
+	if artifact.CreatedByStepID == "" {
+		artifact.CreatedByStepID = step.ID
 	}
-	for i, node := range interaction.ExecutionFlow.ExecutionGraph.Nodes {
-		if node.StepId == step.ID {
-			interaction.ExecutionFlow.ExecutionGraph.Nodes[i].Status = step.Status
-			break
-		}
+	if artifact.CreatedAt.IsZero() {
+		artifact.CreatedAt = time.Now()
 	}
-	err = ss.interactionRepo.Update(ctx, interaction)
-	if err != nil {
-		ss.log.Errorf("Error while updating interaction id: %s with step: %s by error: %v", interactionId, step.ID, err)
+	step.Artifacts = append(step.Artifacts, *artifact)
+	evt := stepEvent(events.ArtifactAdded, interactionId, workflowId, executionId, stepId, artifact)
+	if err = ss.stepRepo.Update(ctx, interactionId, workflowId, executionId, step, evt); err != nil {
+		ss.log.Errorf("Error while adding artifact: %s to step: %s by error: %v", artifact.ID, stepId, err)
 		return nil, err
 	}
 	return step, nil
 }
 
+// DeleteByInteractionIdAndExecutionIdAndId deletes the step with its node and the edges of the node
+// in the execution graph
 func (ss *stepService) DeleteByInteractionIdAndExecutionIdAndId(ctx context.Context, interactionId, workflowId, executionId, stepId string) error {
-	return ss.stepRepo.Delete(ctx, interactionId, workflowId, executionId, stepId)
+	err := commitUnit(ctx, ss.outboxRepo, func(ctx context.Context) error {
+		ref, err := ss.workflows.findGraph(ctx, interactionId, workflowId, executionId)
+		if err != nil {
+			return err
+		}
+		evt := stepEvent(events.StepDeleted, interactionId, workflowId, executionId, stepId, nil)
+		if err = ss.stepRepo.Delete(ctx, interactionId, workflowId, executionId, stepId, evt); err != nil {
+			return err
+		}
+		graph := ref.flow.ExecutionGraph
+		if !hasNode(graph, stepId) {
+			return nil
+		}
+		graph.Nodes = slices.DeleteFunc(graph.Nodes, func(n runtime.ExecutionNode) bool { return n.StepId == stepId })
+		graph.Edges = slices.DeleteFunc(graph.Edges, func(e runtime.Edge) bool { return e.From == stepId || e.To == stepId })
+		return ss.workflows.save(ctx, ref)
+	})
+	if err != nil {
+		ss.log.Errorf("Error while deleting step: %s of interaction id: %s by error: %v", stepId, interactionId, err)
+	}
+	return err
+}
+
+// Locate finds where the step with the id is stored, within the interaction unless interactionId
+// is empty. A step id that several interactions use is a Conflict without an interaction. Steps of
+// an interaction that were not indexed yet are looked up in the execution graph of its workflow.
+func (ss *stepService) Locate(ctx context.Context, interactionId, stepId string) (*model.StepLocation, error) {
+	locations, err := ss.stepRepo.Locate(ctx, stepId)
+	if err != nil && !errors.Is(err, errs.ErrNotFound) {
+		ss.log.Errorf("Error while locating step: %s by error: %v", stepId, err)
+		return nil, err
+	}
+	if interactionId != "" {
+		scoped := locations[:0:0]
+		for _, location := range locations {
+			if location.InteractionId == interactionId {
+				scoped = append(scoped, location)
+			}
+		}
+		locations = scoped
+		if len(locations) == 0 {
+			return ss.locateInGraph(ctx, interactionId, stepId)
+		}
+	}
+	switch len(locations) {
+	case 0:
+		return nil, errs.NotFound("step id: %s not found", stepId)
+	case 1:
+		return &locations[0], nil
+	default:
+		return nil, errs.Conflict("step id: %s is used by %d executions, address it by its interaction, workflow and execution", stepId, len(locations))
+	}
+}
+
+// locateInGraph looks the step up in the graphs of the workflows of the interaction, the active
+// workflow first. The nodes running child interactions are not steps.
+func (ss *stepService) locateInGraph(ctx context.Context, interactionId, stepId string) (*model.StepLocation, error) {
+	interaction, err := ss.workflows.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	set, err := ss.workflows.workflowRepo.Get(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	flows := make([]*runtime.ExecutionFlow, 0, len(set.Workflows)+1)
+	if interaction.ExecutionFlow != nil {
+		flows = append(flows, interaction.ExecutionFlow)
+	}
+	for i := range set.Workflows {
+		flows = append(flows, &set.Workflows[i])
+	}
+	for _, flow := range flows {
+		if flow.ExecutionGraph == nil {
+			continue
+		}
+		children, err := childNodes(ctx, ss.childRepo, interactionId, flow.ExecutionGraph.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, node := range flow.ExecutionGraph.Nodes {
+			if node.StepId == stepId && !children[node.StepId] {
+				return &model.StepLocation{InteractionId: interactionId, WorkflowId: flow.ID, ExecutionId: flow.ExecutionGraph.ID}, nil
+			}
+		}
+	}
+	return nil, errs.NotFound("step id: %s not found", stepId)
 }
 
-func NewStepService(log *logger.Logger, tr trace.Tracer, repo repo.StepRepo) StepService {
+func NewStepService(log *logger.Logger, tr trace.Tracer, stepRepo repo.StepRepo, interactionRepo repo.InteractionRepo, workflowRepo repo.WorkflowRepo, childRepo repo.ChildRepo, outboxRepo repo.OutboxRepo, agentSvc AgentService) StepService {
 	return &stepService{
-		log:      log,
-		tr:       tr,
-		stepRepo: repo,
+		log:        log,
+		tr:         tr,
+		stepRepo:   stepRepo,
+		workflows:  workflows{interactionRepo: interactionRepo, workflowRepo: workflowRepo},
+		childRepo:  childRepo,
+		outboxRepo: outboxRepo,
+		agentSvc:   agentSvc,
+		v:          validation.New(settings.GetValidation()),
 	}
 }
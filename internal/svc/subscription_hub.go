@@ -0,0 +1,323 @@
+package svc
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// SubscriptionHub multiplexes the live events of all interactions onto the attached subscribers.
+// Each subscriber has a bounded queue, events that do not fit are dropped and counted instead of
+// slowing down the hub.
+type SubscriptionHub interface {
+	Start(ctx context.Context)
+	Attach() *Subscriber
+	Detach(s *Subscriber)
+}
+
+// Delivery is an event with the ids of the subscriptions it matched
+type Delivery struct {
+	Subscriptions []string
+	Event         events.Event
+}
+
+type Subscriber struct {
+	deliveries chan Delivery
+	dropped    atomic.Int64
+	mu         sync.RWMutex
+	filters    map[string]model.SubscriptionFilter
+}
+
+func (s *Subscriber) Deliveries() <-chan Delivery {
+	return s.deliveries
+}
+
+// Dropped returns the events dropped since the last call and resets the count
+func (s *Subscriber) Dropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+func (s *Subscriber) Subscribe(id string, filter model.SubscriptionFilter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.filters[id] = filter
+}
+
+func (s *Subscriber) Unsubscribe(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.filters[id]
+	delete(s.filters, id)
+	return ok
+}
+
+func (s *Subscriber) needsTags() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, f := range s.filters {
+		if len(f.Tags) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Subscriber) match(evt *events.Event, tags []string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var ids []string
+	for id, f := range s.filters {
+		if len(f.InteractionIds) > 0 && !slices.Contains(f.InteractionIds, evt.InteractionId) {
+			continue
+		}
+		if len(f.Types) > 0 && !slices.Contains(f.Types, string(evt.Type)) {
+			continue
+		}
+		if len(f.Tags) > 0 && !slices.ContainsFunc(f.Tags, func(t string) bool { return slices.Contains(tags, t) }) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	return ids
+}
+
+type subscriptionHub struct {
+	log            *logger.Logger
+	tr             trace.Tracer
+	streams        EventStreamService
+	interactionSvc InteractionService
+	cfg            settings.Subscriptions
+
+	mu          sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+	// tags of the interactions seen recently, kept current from the interaction events
+	tags *tagCache
+	// resolving holds the events of the interactions whose tags are being read, they are
+	// dispatched in order once the tags are known
+	resolving map[string][]*events.Event
+}
+
+func (sh *subscriptionHub) Start(ctx context.Context) {
+	live := sh.streams.Listen(ctx)
+	go func() {
+		for evt := range live {
+			sh.dispatch(ctx, &evt)
+		}
+	}()
+}
+
+// dispatch delivers the event, or queues it when a subscriber filters by tags and the tags of its
+// interaction are not known yet. Those are read off the hub goroutine, a slow read must not hold
+// up the events of the other interactions.
+func (sh *subscriptionHub) dispatch(ctx context.Context, evt *events.Event) {
+	if !sh.needsTags() {
+		sh.deliver(evt, nil)
+		return
+	}
+	sh.mu.Lock()
+	if queue, ok := sh.resolving[evt.InteractionId]; ok {
+		sh.resolving[evt.InteractionId] = append(queue, evt)
+		sh.mu.Unlock()
+		return
+	}
+	tags, ok := sh.eventTags(evt)
+	if !ok {
+		sh.resolving[evt.InteractionId] = []*events.Event{evt}
+		sh.mu.Unlock()
+		go sh.resolve(ctx, evt.InteractionId)
+		return
+	}
+	sh.mu.Unlock()
+	sh.deliver(evt, tags)
+}
+
+// resolve reads the tags of the interaction and dispatches its queued events, including the ones
+// queued while it delivers
+func (sh *subscriptionHub) resolve(ctx context.Context, interactionId string) {
+	var tags []string
+	interaction, err := sh.interactionSvc.GetById(ctx, interactionId)
+	if err != nil {
+		sh.log.Warnf("Error while reading tags of interaction id: %s by error: %v", interactionId, err)
+	} else if interaction != nil {
+		tags = tagsOf(interaction)
+	}
+	sh.mu.Lock()
+	sh.tags.put(interactionId, tags)
+	sh.mu.Unlock()
+	for {
+		sh.mu.Lock()
+		queue := sh.resolving[interactionId]
+		if len(queue) == 0 {
+			delete(sh.resolving, interactionId)
+			sh.mu.Unlock()
+			return
+		}
+		sh.resolving[interactionId] = nil
+		queued := make([][]string, len(queue))
+		for i, evt := range queue {
+			if known, ok := sh.eventTags(evt); ok {
+				queued[i] = known
+			} else {
+				queued[i] = tags
+			}
+		}
+		sh.mu.Unlock()
+		for i, evt := range queue {
+			sh.deliver(evt, queued[i])
+		}
+	}
+}
+
+func (sh *subscriptionHub) needsTags() bool {
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	for s := range sh.subscribers {
+		if s.needsTags() {
+			return true
+		}
+	}
+	return false
+}
+
+func (sh *subscriptionHub) deliver(evt *events.Event, tags []string) {
+	sh.mu.RLock()
+	subscribers := make([]*Subscriber, 0, len(sh.subscribers))
+	for s := range sh.subscribers {
+		subscribers = append(subscribers, s)
+	}
+	sh.mu.RUnlock()
+
+	for _, s := range subscribers {
+		ids := s.match(evt, tags)
+		if len(ids) == 0 {
+			continue
+		}
+		select {
+		case s.deliveries <- Delivery{Subscriptions: ids, Event: *evt}:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// eventTags returns the tags of the interaction of the event from the event itself or the cache,
+// ok is false when they have to be read. The caller holds the lock.
+func (sh *subscriptionHub) eventTags(evt *events.Event) ([]string, bool) {
+	switch evt.Type {
+	case events.InteractionCreated, events.InteractionUpdated:
+		var interaction runtime.Interaction
+		if err := json.Unmarshal(evt.Data, &interaction); err == nil {
+			tags := tagsOf(&interaction)
+			sh.tags.put(evt.InteractionId, tags)
+			return tags, true
+		}
+	case events.InteractionDeleted:
+		tags, _ := sh.tags.get(evt.InteractionId)
+		sh.tags.remove(evt.InteractionId)
+		return tags, true
+	}
+	return sh.tags.get(evt.InteractionId)
+}
+
+// tagsOf returns the tags of the base query of the interaction
+func tagsOf(interaction *runtime.Interaction) []string {
+	if interaction.BaseQuery == nil {
+		return nil
+	}
+	return interaction.BaseQuery.Tags
+}
+
+// tagCache keeps the tags of the most recently used interactions for up to ttl
+type tagCache struct {
+	size    int
+	ttl     time.Duration
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+type tagEntry struct {
+	interactionId string
+	tags          []string
+	expires       time.Time
+}
+
+func (tc *tagCache) get(interactionId string) ([]string, bool) {
+	el, ok := tc.entries[interactionId]
+	if !ok {
+		return nil, false
+	}
+	entry := el.Value.(*tagEntry)
+	if time.Now().After(entry.expires) {
+		tc.remove(interactionId)
+		return nil, false
+	}
+	tc.order.MoveToFront(el)
+	return entry.tags, true
+}
+
+func (tc *tagCache) put(interactionId string, tags []string) {
+	entry := &tagEntry{interactionId: interactionId, tags: tags, expires: time.Now().Add(tc.ttl)}
+	if el, ok := tc.entries[interactionId]; ok {
+		el.Value = entry
+		tc.order.MoveToFront(el)
+		return
+	}
+	tc.entries[interactionId] = tc.order.PushFront(entry)
+	for tc.order.Len() > tc.size {
+		tc.remove(tc.order.Back().Value.(*tagEntry).interactionId)
+	}
+}
+
+func (tc *tagCache) remove(interactionId string) {
+	if el, ok := tc.entries[interactionId]; ok {
+		tc.order.Remove(el)
+		delete(tc.entries, interactionId)
+	}
+}
+
+func newTagCache(size int, ttl time.Duration) *tagCache {
+	return &tagCache{size: size, ttl: ttl, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+func (sh *subscriptionHub) Attach() *Subscriber {
+	s := &Subscriber{
+		deliveries: make(chan Delivery, sh.cfg.Buffer),
+		filters:    make(map[string]model.SubscriptionFilter),
+	}
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.subscribers[s] = struct{}{}
+	return s
+}
+
+func (sh *subscriptionHub) Detach(s *Subscriber) {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	delete(sh.subscribers, s)
+}
+
+func NewSubscriptionHub(log *logger.Logger, tr trace.Tracer, streams EventStreamService, interactionSvc InteractionService) SubscriptionHub {
+	cfg := settings.GetSubscriptions()
+	return &subscriptionHub{
+		log:            log,
+		tr:             tr,
+		streams:        streams,
+		interactionSvc: interactionSvc,
+		cfg:            cfg,
+		subscribers:    make(map[*Subscriber]struct{}),
+		tags:           newTagCache(cfg.TagCacheSize, cfg.TagCacheTTL),
+		resolving:      make(map[string][]*events.Event),
+	}
+}
@@ -0,0 +1,365 @@
+package svc
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_.-]*)\s*\}\}`)
+
+type TemplateService interface {
+	GetById(ctx context.Context, templateId string) (*model.WorkflowTemplate, error)
+	List(ctx context.Context) ([]*model.WorkflowTemplate, error)
+	Create(ctx context.Context, template *model.WorkflowTemplate) (*model.WorkflowTemplate, error)
+	Update(ctx context.Context, template *model.WorkflowTemplate) (*model.WorkflowTemplate, error)
+	DeleteById(ctx context.Context, templateId string) error
+	Instantiate(ctx context.Context, templateId string, req *model.InstantiateRequest) (*runtime.Interaction, error)
+}
+
+type templateService struct {
+	log             *logger.Logger
+	tr              trace.Tracer
+	templateRepo    repo.TemplateRepo
+	interactionRepo repo.InteractionRepo
+	stepRepo        repo.StepRepo
+	mcpRepo         repo.MCPRepo
+	agentSvc        AgentService
+}
+
+func (ts *templateService) GetById(ctx context.Context, templateId string) (*model.WorkflowTemplate, error) {
+	return ts.templateRepo.Get(ctx, templateId)
+}
+
+func (ts *templateService) List(ctx context.Context) ([]*model.WorkflowTemplate, error) {
+	ids, err := ts.templateRepo.List(ctx)
+	if err != nil {
+		ts.log.Errorf("Error while listing templates: %v", err)
+		return nil, err
+	}
+	templates := make([]*model.WorkflowTemplate, 0, len(ids))
+	for _, id := range ids {
+		template, err := ts.templateRepo.Get(ctx, id)
+		if err != nil {
+			ts.log.Warnf("Skipping template id: %s listed in index: %v", id, err)
+			continue
+		}
+		templates = append(templates, template)
+	}
+	return templates, nil
+}
+
+func (ts *templateService) Create(ctx context.Context, template *model.WorkflowTemplate) (*model.WorkflowTemplate, error) {
+	if template.ID == "" {
+		template.ID = uuid.NewString()
+	}
+	if err := validateTemplate(template); err != nil {
+		return nil, err
+	}
+	template.CreatedAt = time.Now()
+	template.UpdatedAt = template.CreatedAt
+	if err := ts.templateRepo.Save(ctx, template); err != nil {
+		ts.log.Errorf("Error while saving template: %v", err)
+		return nil, err
+	}
+	return template, nil
+}
+
+func (ts *templateService) Update(ctx context.Context, template *model.WorkflowTemplate) (*model.WorkflowTemplate, error) {
+	existing, err := ts.templateRepo.Get(ctx, template.ID)
+	if err != nil {
+		ts.log.Errorf("Error while getting template id: %s by error: %v", template.ID, err)
+		return nil, err
+	}
+	if err = validateTemplate(template); err != nil {
+		return nil, err
+	}
+	template.CreatedAt = existing.CreatedAt
+	template.UpdatedAt = time.Now()
+	if err = ts.templateRepo.Update(ctx, template); err != nil {
+		ts.log.Errorf("Error while updating template: %v", err)
+		return nil, err
+	}
+	return template, nil
+}
+
+func (ts *templateService) DeleteById(ctx context.Context, templateId string) error {
+	return ts.templateRepo.Delete(ctx, templateId)
+}
+
+// Instantiate creates a new interaction from the template. Placeholders are substituted with the
+// supplied variables or their defaults, which the workflow keeps in its variables, then the
+// workflow, graph and steps get fresh ids. Steps and mcps are written before the interaction,
+// which is the commit point.
+func (ts *templateService) Instantiate(ctx context.Context, templateId string, req *model.InstantiateRequest) (*runtime.Interaction, error) {
+	template, err := ts.templateRepo.Get(ctx, templateId)
+	if err != nil {
+		ts.log.Errorf("Error while getting template id: %s by error: %v", templateId, err)
+		return nil, err
+	}
+	values, err := templateValues(template, req.Variables)
+	if err != nil {
+		return nil, err
+	}
+	if err = substitute(template, values); err != nil {
+		ts.log.Errorf("Error while substituting variables of template id: %s by error: %v", templateId, err)
+		return nil, err
+	}
+
+	interactionId := req.InteractionId
+	if interactionId == "" {
+		interactionId = uuid.NewString()
+	}
+	if existing, err := ts.interactionRepo.Get(ctx, interactionId); err == nil && existing != nil {
+		return nil, errs.Conflict("interaction id: %s already exists", interactionId)
+	}
+
+	flow := template.ExecutionFlow
+	flow.ID = uuid.NewString()
+	variables := make(map[string]string, len(flow.Variables)+len(values))
+	for name, value := range flow.Variables {
+		variables[name] = value
+	}
+	for name, value := range values {
+		variables[name] = value
+	}
+	flow.Variables = variables
+	graph := &runtime.ExecutionGraph{ID: uuid.NewString()}
+	if flow.ExecutionGraph != nil {
+		*graph = *flow.ExecutionGraph
+		graph.ID = uuid.NewString()
+	}
+	flow.ExecutionGraph = graph
+	if err = ts.agentSvc.PinExecutionFlow(ctx, &flow); err != nil {
+		return nil, err
+	}
+
+	stepIds := make(map[string]string, len(template.Steps))
+	steps := make([]*runtime.Step, 0, len(template.Steps))
+	for i := range template.Steps {
+		step := template.Steps[i]
+		stepIds[step.ID] = uuid.NewString()
+		step.ID = stepIds[step.ID]
+		step.Status = runtime.StatusPending
+		if err = ts.agentSvc.PinStep(ctx, &step); err != nil {
+			return nil, err
+		}
+		steps = append(steps, &step)
+	}
+	graph.Nodes = append([]runtime.ExecutionNode(nil), graph.Nodes...)
+	for i, node := range graph.Nodes {
+		graph.Nodes[i].StepId = stepIds[node.StepId]
+		graph.Nodes[i].Status = runtime.StatusPending
+	}
+	graph.Edges = append([]runtime.Edge(nil), graph.Edges...)
+	for i, e := range graph.Edges {
+		graph.Edges[i].From = stepIds[e.From]
+		graph.Edges[i].To = stepIds[e.To]
+	}
+
+	var savedSteps, savedMcps []string
+	rollback := func() {
+		for _, stepId := range savedSteps {
+			if err := ts.stepRepo.Delete(ctx, interactionId, flow.ID, graph.ID, stepId); err != nil {
+				ts.log.Errorf("Error while rolling back step: %s of interaction id: %s by error: %v", stepId, interactionId, err)
+			}
+		}
+		for _, mcpId := range savedMcps {
+			if err := ts.mcpRepo.Delete(ctx, interactionId, flow.ID, mcpId); err != nil {
+				ts.log.Errorf("Error while rolling back mcp: %s of interaction id: %s by error: %v", mcpId, interactionId, err)
+			}
+		}
+	}
+	for _, step := range steps {
+		if err = ts.stepRepo.Save(ctx, interactionId, flow.ID, graph.ID, step); err != nil {
+			ts.log.Errorf("Error while saving step: %s of template id: %s by error: %v", step.ID, templateId, err)
+			rollback()
+			return nil, err
+		}
+		savedSteps = append(savedSteps, step.ID)
+	}
+	for i := range template.Mcps {
+		mcp := &template.Mcps[i]
+		if err = ts.mcpRepo.Save(ctx, interactionId, flow.ID, mcp); err != nil {
+			ts.log.Errorf("Error while saving mcp: %s of template id: %s by error: %v", mcp.ID, templateId, err)
+			rollback()
+			return nil, err
+		}
+		savedMcps = append(savedMcps, mcp.ID)
+	}
+
+	interaction := &runtime.Interaction{
+		ID:            interactionId,
+		BaseQuery:     template.BaseQuery,
+		BaseContext:   template.BaseContext,
+		ExecutionFlow: &flow,
+		CreatedAt:     time.Now(),
+	}
+	evt := events.New(events.InteractionCreated, interaction.ID, interaction)
+	if err = ts.interactionRepo.Save(ctx, interaction, evt); err != nil {
+		ts.log.Errorf("Error while saving interaction from template id: %s by error: %v", templateId, err)
+		rollback()
+		return nil, err
+	}
+	return interaction, nil
+}
+
+func validateTemplate(template *model.WorkflowTemplate) error {
+	if strings.TrimSpace(template.Name) == "" {
+		return errs.Invalid("template name is required")
+	}
+	stepIds := make(map[string]bool, len(template.Steps))
+	for _, step := range template.Steps {
+		if step.ID == "" {
+			return errs.Invalid("template steps require an id")
+		}
+		stepIds[step.ID] = true
+	}
+	if graph := template.ExecutionFlow.ExecutionGraph; graph != nil {
+		for _, node := range graph.Nodes {
+			if !stepIds[node.StepId] {
+				return errs.Invalid("graph node references unknown template step: %s", node.StepId)
+			}
+		}
+		for _, e := range graph.Edges {
+			if !stepIds[e.From] || !stepIds[e.To] {
+				return errs.Invalid("graph edge %s -> %s references an unknown template step", e.From, e.To)
+			}
+		}
+	}
+	mcpIds := make(map[string]bool, len(template.Mcps))
+	for _, mcp := range template.Mcps {
+		mcpIds[mcp.ID] = true
+	}
+	for _, ref := range template.ExecutionFlow.AvailableMcpRefs {
+		if !mcpIds[ref] {
+			return errs.Invalid("workflow references unknown template mcp: %s", ref)
+		}
+	}
+	declared := make(map[string]bool, len(template.Variables))
+	for _, v := range template.Variables {
+		declared[v.Name] = true
+	}
+	used, err := placeholders(template)
+	if err != nil {
+		return err
+	}
+	for _, name := range used {
+		if !declared[name] {
+			return errs.Invalid("placeholder {{%s}} is not a declared template variable", name)
+		}
+	}
+	return nil
+}
+
+// templateValues merges the supplied variables with the declared defaults and reports every
+// required variable that is missing at once
+func templateValues(template *model.WorkflowTemplate, supplied map[string]string) (map[string]string, error) {
+	values := make(map[string]string, len(template.Variables))
+	var missing []string
+	for _, v := range template.Variables {
+		if value, ok := supplied[v.Name]; ok {
+			values[v.Name] = value
+			continue
+		}
+		if v.Required && v.Default == "" {
+			missing = append(missing, v.Name)
+			continue
+		}
+		values[v.Name] = v.Default
+	}
+	if len(missing) > 0 {
+		return nil, errs.Invalid("missing template variables: %s", strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+func placeholders(template *model.WorkflowTemplate) ([]string, error) {
+	seen := map[string]bool{}
+	err := walkTemplate(template, func(s string) string {
+		for _, m := range placeholderPattern.FindAllStringSubmatch(s, -1) {
+			seen[m[1]] = true
+		}
+		return s
+	})
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, err
+}
+
+func substitute(template *model.WorkflowTemplate, values map[string]string) error {
+	return walkTemplate(template, func(s string) string {
+		return placeholderPattern.ReplaceAllStringFunc(s, func(p string) string {
+			name := placeholderPattern.FindStringSubmatch(p)[1]
+			if value, ok := values[name]; ok {
+				return value
+			}
+			return p
+		})
+	})
+}
+
+// walkTemplate applies fn to every string of the template through its json form, so placeholders
+// work in any field of the runtime types without knowing their shape
+func walkTemplate(template *model.WorkflowTemplate, fn func(string) string) error {
+	b, err := json.Marshal(template)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err = json.Unmarshal(b, &doc); err != nil {
+		return err
+	}
+	doc = walkStrings(doc, fn)
+	if b, err = json.Marshal(doc); err != nil {
+		return err
+	}
+	*template = model.WorkflowTemplate{}
+	return json.Unmarshal(b, template)
+}
+
+func walkStrings(v any, fn func(string) string) any {
+	switch t := v.(type) {
+	case string:
+		return fn(t)
+	case []any:
+		for i := range t {
+			t[i] = walkStrings(t[i], fn)
+		}
+		return t
+	case map[string]any:
+		for k, val := range t {
+			t[k] = walkStrings(val, fn)
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+func NewTemplateService(log *logger.Logger, tr trace.Tracer, templateRepo repo.TemplateRepo, interactionRepo repo.InteractionRepo, stepRepo repo.StepRepo, mcpRepo repo.MCPRepo, agentSvc AgentService) TemplateService {
+	return &templateService{
+		log:             log,
+		tr:              tr,
+		templateRepo:    templateRepo,
+		interactionRepo: interactionRepo,
+		stepRepo:        stepRepo,
+		mcpRepo:         mcpRepo,
+		agentSvc:        agentSvc,
+	}
+}
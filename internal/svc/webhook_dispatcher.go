@@ -0,0 +1,305 @@
+package svc
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	HeaderWebhookDelivery  = "X-Webhook-Delivery"
+	HeaderWebhookEvent     = "X-Webhook-Event"
+	HeaderWebhookTimestamp = "X-Webhook-Timestamp"
+	HeaderWebhookSignature = "X-Webhook-Signature"
+
+	deliveryBatchSize = 50
+)
+
+// WebhookDispatcher turns the published events into deliveries for the matching webhooks and posts
+// them. It is an events.Publisher so the outbox relay feeds it next to the bus, a delivery is
+// recorded before the event is acked and the attempts run in the background.
+type WebhookDispatcher interface {
+	events.Publisher
+	Start(ctx context.Context)
+	// Invalidate makes the next event read the webhooks again, after one was written
+	Invalidate()
+}
+
+type webhookDispatcher struct {
+	log          *logger.Logger
+	tr           trace.Tracer
+	webhookRepo  repo.WebhookRepo
+	deliveryRepo repo.WebhookDeliveryRepo
+	cfg          settings.Webhooks
+	client       *http.Client
+	done         chan struct{}
+
+	mu       sync.Mutex
+	webhooks []*model.Webhook
+	loadedAt time.Time
+}
+
+// Publish records a delivery of the event for every active webhook it matches. The delivery id is
+// derived from the webhook and event ids, so an event relayed twice is delivered once. A failed
+// enqueue does not stop the other webhooks, the relay retries the event on the dispatcher only.
+func (wd *webhookDispatcher) Publish(ctx context.Context, evt *events.Event) error {
+	webhooks, err := wd.subscriptions(ctx)
+	if err != nil {
+		return err
+	}
+	var payload json.RawMessage
+	var failed []error
+	for _, webhook := range webhooks {
+		if !matches(webhook, evt) {
+			continue
+		}
+		if payload == nil {
+			if payload, err = json.Marshal(evt); err != nil {
+				return err
+			}
+		}
+		if err = wd.enqueue(ctx, webhook, evt, payload); err != nil {
+			wd.log.Errorf("Error while enqueueing %s event for webhook id: %s by error: %v", evt.Type, webhook.ID, err)
+			failed = append(failed, err)
+		}
+	}
+	return errors.Join(failed...)
+}
+
+// subscriptions returns the webhooks, read again once the cached ones are older than CacheTTL
+func (wd *webhookDispatcher) subscriptions(ctx context.Context) ([]*model.Webhook, error) {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	if wd.webhooks != nil && time.Since(wd.loadedAt) < wd.cfg.CacheTTL {
+		return wd.webhooks, nil
+	}
+	ids, err := wd.webhookRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	webhooks := make([]*model.Webhook, 0, len(ids))
+	for _, id := range ids {
+		webhook, err := wd.webhookRepo.Get(ctx, id)
+		if errors.Is(err, errs.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	wd.webhooks, wd.loadedAt = webhooks, time.Now()
+	return webhooks, nil
+}
+
+// Invalidate drops the cached webhooks, the next event reads them again
+func (wd *webhookDispatcher) Invalidate() {
+	wd.mu.Lock()
+	defer wd.mu.Unlock()
+	wd.webhooks = nil
+}
+
+func matches(webhook *model.Webhook, evt *events.Event) bool {
+	if !webhook.Active {
+		return false
+	}
+	if len(webhook.EventTypes) > 0 && !slices.Contains(webhook.EventTypes, string(evt.Type)) {
+		return false
+	}
+	return len(webhook.InteractionIds) == 0 || slices.Contains(webhook.InteractionIds, evt.InteractionId)
+}
+
+func (wd *webhookDispatcher) enqueue(ctx context.Context, webhook *model.Webhook, evt *events.Event, payload json.RawMessage) error {
+	sum := sha256.Sum256([]byte(webhook.ID + "/" + evt.ID))
+	deliveryId := hex.EncodeToString(sum[:16])
+	delivery, err := wd.deliveryRepo.Get(ctx, webhook.ID, deliveryId)
+	if err == nil && delivery != nil {
+		// recorded by an earlier relay of the event, it only needs scheduling if that failed
+		if len(delivery.Attempts) > 0 {
+			return nil
+		}
+		return wd.deliveryRepo.Schedule(ctx, delivery)
+	}
+	now := time.Now()
+	delivery = &model.WebhookDelivery{
+		ID:            deliveryId,
+		WebhookId:     webhook.ID,
+		EventId:       evt.ID,
+		EventType:     string(evt.Type),
+		InteractionId: evt.InteractionId,
+		Payload:       payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err = wd.deliveryRepo.Save(ctx, delivery); err != nil {
+		return err
+	}
+	return wd.deliveryRepo.Schedule(ctx, delivery)
+}
+
+// Start polls the due deliveries on the configured interval and attempts them concurrently. A
+// claimed delivery is leased for twice the request timeout, it is attempted again if this replica
+// dies before recording the attempt.
+func (wd *webhookDispatcher) Start(ctx context.Context) {
+	go func() {
+		defer close(wd.done)
+		ticker := time.NewTicker(wd.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			deliveries, err := wd.deliveryRepo.Claim(ctx, time.Now(), 2*wd.cfg.Timeout, deliveryBatchSize)
+			if err != nil && ctx.Err() == nil {
+				wd.log.Errorf("Error while claiming due webhook deliveries: %v", err)
+			}
+			var wg sync.WaitGroup
+			for _, delivery := range deliveries {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					wd.attempt(ctx, delivery)
+				}()
+			}
+			wg.Wait()
+		}
+	}()
+}
+
+// attempt posts the delivery once and records the outcome. A failed attempt is retried after
+// BackoffBase*2^(n-1), capped at MaxBackoff, until MaxAttempts attempts were made.
+func (wd *webhookDispatcher) attempt(ctx context.Context, delivery *model.WebhookDelivery) {
+	webhook, err := wd.webhookRepo.Get(ctx, delivery.WebhookId)
+	now := time.Now()
+	result := model.DeliveryAttempt{At: now}
+	switch {
+	case err != nil || webhook == nil:
+		result.Error = "webhook was deleted"
+		delivery.Status = model.DeliveryFailed
+	case !webhook.Active:
+		result.Error = "webhook is inactive"
+		delivery.Status = model.DeliveryFailed
+	default:
+		result.StatusCode, err = wd.post(ctx, webhook, delivery)
+		result.Duration = time.Since(now)
+		if err == nil {
+			delivery.Status = model.DeliverySucceeded
+		} else {
+			result.Error = err.Error()
+		}
+	}
+	delivery.Attempts = append(delivery.Attempts, result)
+	delivery.UpdatedAt = time.Now()
+	if delivery.Status == model.DeliveryPending && len(delivery.Attempts) >= wd.cfg.MaxAttempts {
+		delivery.Status = model.DeliveryFailed
+	}
+	if delivery.Status == model.DeliveryPending {
+		delivery.NextAttemptAt = delivery.UpdatedAt.Add(backoff(wd.cfg, len(delivery.Attempts)))
+	} else {
+		delivery.NextAttemptAt = time.Time{}
+	}
+
+	if err = wd.deliveryRepo.Update(ctx, delivery); err != nil {
+		wd.log.Errorf("Error while recording attempt of delivery id: %s by error: %v", delivery.ID, err)
+		return
+	}
+	if delivery.Status == model.DeliveryPending {
+		err = wd.deliveryRepo.Schedule(ctx, delivery)
+	} else {
+		err = wd.deliveryRepo.Unschedule(ctx, delivery)
+	}
+	if err != nil {
+		wd.log.Errorf("Error while rescheduling delivery id: %s by error: %v", delivery.ID, err)
+	}
+}
+
+func backoff(cfg settings.Webhooks, attempts int) time.Duration {
+	wait := cfg.BackoffBase
+	for i := 1; i < attempts && wait < cfg.MaxBackoff; i++ {
+		wait *= 2
+	}
+	return min(wait, cfg.MaxBackoff)
+}
+
+// post sends the payload signed with the webhook secret, any status outside 2xx is an error
+func (wd *webhookDispatcher) post(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, wd.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderWebhookDelivery, delivery.ID)
+	req.Header.Set(HeaderWebhookEvent, delivery.EventType)
+	req.Header.Set(HeaderWebhookTimestamp, timestamp)
+	req.Header.Set(HeaderWebhookSignature, Sign(webhook.Secret, timestamp, delivery.Payload))
+	resp, err := wd.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the X-Webhook-Signature value of a payload, the hex HMAC-SHA256 of
+// "<timestamp>.<payload>" keyed with the webhook secret. Receivers recompute it and compare with
+// hmac.Equal, and reject stale timestamps to stop replays.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Close waits for the delivery loop to stop, the context passed to Start has to be cancelled first
+func (wd *webhookDispatcher) Close() error {
+	select {
+	case <-wd.done:
+		return nil
+	case <-time.After(2 * wd.cfg.Timeout):
+		return errors.New("webhook dispatcher did not stop in time")
+	}
+}
+
+func NewWebhookDispatcher(log *logger.Logger, tr trace.Tracer, webhookRepo repo.WebhookRepo, deliveryRepo repo.WebhookDeliveryRepo) WebhookDispatcher {
+	cfg := settings.GetWebhooks()
+	return &webhookDispatcher{
+		log:          log,
+		tr:           tr,
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		cfg:          cfg,
+		client:       outboundClient(cfg.Timeout, cfg.AllowPrivateNetworks),
+		done:         make(chan struct{}),
+	}
+}
@@ -0,0 +1,242 @@
+package svc
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/settings"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrWebhookNotFound  = errs.NotFound("webhook not found")
+	ErrDeliveryNotFound = errs.NotFound("webhook delivery not found")
+	ErrInvalidWebhook   = errs.Invalid("invalid webhook")
+)
+
+// WebhookService manages the webhook subscriptions and their delivery logs. The secret of a webhook
+// is only returned when it is created, reads leave it out.
+type WebhookService interface {
+	GetById(ctx context.Context, webhookId string) (*model.Webhook, error)
+	List(ctx context.Context) ([]*model.Webhook, error)
+	Create(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error)
+	Update(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error)
+	DeleteById(ctx context.Context, webhookId string) error
+	Deliveries(ctx context.Context, webhookId string) ([]*model.WebhookDelivery, error)
+	GetDelivery(ctx context.Context, webhookId, deliveryId string) (*model.WebhookDelivery, error)
+	Redeliver(ctx context.Context, webhookId, deliveryId string) (*model.WebhookDelivery, error)
+}
+
+type webhookService struct {
+	log          *logger.Logger
+	tr           trace.Tracer
+	webhookRepo  repo.WebhookRepo
+	deliveryRepo repo.WebhookDeliveryRepo
+	dispatcher   WebhookDispatcher
+	cfg          settings.Webhooks
+}
+
+func (ws *webhookService) get(ctx context.Context, webhookId string) (*model.Webhook, error) {
+	webhook, err := ws.webhookRepo.Get(ctx, webhookId)
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && webhook == nil) {
+		return nil, ErrWebhookNotFound
+	}
+	if err != nil {
+		ws.log.Errorf("Error while getting webhook id: %s by error: %v", webhookId, err)
+		return nil, err
+	}
+	return webhook, nil
+}
+
+func (ws *webhookService) GetById(ctx context.Context, webhookId string) (*model.Webhook, error) {
+	webhook, err := ws.get(ctx, webhookId)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Secret = ""
+	return webhook, nil
+}
+
+func (ws *webhookService) List(ctx context.Context) ([]*model.Webhook, error) {
+	ids, err := ws.webhookRepo.List(ctx)
+	if err != nil {
+		ws.log.Errorf("Error while listing webhooks: %v", err)
+		return nil, err
+	}
+	webhooks := make([]*model.Webhook, 0, len(ids))
+	for _, id := range ids {
+		webhook, err := ws.webhookRepo.Get(ctx, id)
+		if err != nil || webhook == nil {
+			ws.log.Warnf("Skipping webhook id: %s listed in index: %v", id, err)
+			continue
+		}
+		webhook.Secret = ""
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, nil
+}
+
+// Create stores the webhook, a random secret is generated when none is given
+func (ws *webhookService) Create(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	if webhook.ID == "" {
+		webhook.ID = uuid.NewString()
+	}
+	if err := validateWebhook(webhook, ws.cfg); err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, err
+		}
+		webhook.Secret = hex.EncodeToString(secret)
+	}
+	webhook.CreatedAt = time.Now()
+	webhook.UpdatedAt = webhook.CreatedAt
+	if err := ws.webhookRepo.Save(ctx, webhook); err != nil {
+		ws.log.Errorf("Error while saving webhook: %v", err)
+		return nil, err
+	}
+	ws.dispatcher.Invalidate()
+	return webhook, nil
+}
+
+// Update replaces the webhook, the secret is kept unless a new one is given
+func (ws *webhookService) Update(ctx context.Context, webhook *model.Webhook) (*model.Webhook, error) {
+	existing, err := ws.get(ctx, webhook.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateWebhook(webhook, ws.cfg); err != nil {
+		return nil, err
+	}
+	if webhook.Secret == "" {
+		webhook.Secret = existing.Secret
+	}
+	webhook.CreatedAt = existing.CreatedAt
+	webhook.UpdatedAt = time.Now()
+	if err = ws.webhookRepo.Update(ctx, webhook); err != nil {
+		ws.log.Errorf("Error while updating webhook: %v", err)
+		return nil, err
+	}
+	ws.dispatcher.Invalidate()
+	updated := *webhook
+	updated.Secret = ""
+	return &updated, nil
+}
+
+func (ws *webhookService) DeleteById(ctx context.Context, webhookId string) error {
+	if _, err := ws.get(ctx, webhookId); err != nil {
+		return err
+	}
+	if err := ws.webhookRepo.Delete(ctx, webhookId); err != nil {
+		return err
+	}
+	ws.dispatcher.Invalidate()
+	return nil
+}
+
+// Deliveries returns the delivery log of the webhook, newest first
+func (ws *webhookService) Deliveries(ctx context.Context, webhookId string) ([]*model.WebhookDelivery, error) {
+	if _, err := ws.get(ctx, webhookId); err != nil {
+		return nil, err
+	}
+	ids, err := ws.deliveryRepo.List(ctx, webhookId)
+	if err != nil {
+		ws.log.Errorf("Error while listing deliveries of webhook id: %s by error: %v", webhookId, err)
+		return nil, err
+	}
+	deliveries := make([]*model.WebhookDelivery, 0, len(ids))
+	for _, id := range ids {
+		delivery, err := ws.deliveryRepo.Get(ctx, webhookId, id)
+		if err != nil || delivery == nil {
+			continue
+		}
+		deliveries = append(deliveries, delivery)
+	}
+	return deliveries, nil
+}
+
+func (ws *webhookService) GetDelivery(ctx context.Context, webhookId, deliveryId string) (*model.WebhookDelivery, error) {
+	delivery, err := ws.deliveryRepo.Get(ctx, webhookId, deliveryId)
+	if errors.Is(err, errs.ErrNotFound) || (err == nil && delivery == nil) {
+		return nil, ErrDeliveryNotFound
+	}
+	if err != nil {
+		ws.log.Errorf("Error while getting delivery id: %s of webhook id: %s by error: %v", deliveryId, webhookId, err)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Redeliver sends the payload of a past delivery again as a new delivery with a fresh retry budget,
+// the original entry of the log is left as it is
+func (ws *webhookService) Redeliver(ctx context.Context, webhookId, deliveryId string) (*model.WebhookDelivery, error) {
+	if _, err := ws.get(ctx, webhookId); err != nil {
+		return nil, err
+	}
+	original, err := ws.GetDelivery(ctx, webhookId, deliveryId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	delivery := &model.WebhookDelivery{
+		ID:            uuid.NewString(),
+		WebhookId:     webhookId,
+		EventId:       original.EventId,
+		EventType:     original.EventType,
+		InteractionId: original.InteractionId,
+		RedeliveryOf:  original.ID,
+		Payload:       original.Payload,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err = ws.deliveryRepo.Save(ctx, delivery); err != nil {
+		ws.log.Errorf("Error while saving redelivery of delivery id: %s by error: %v", deliveryId, err)
+		return nil, err
+	}
+	if err = ws.deliveryRepo.Schedule(ctx, delivery); err != nil {
+		ws.log.Errorf("Error while scheduling redelivery of delivery id: %s by error: %v", deliveryId, err)
+		return nil, err
+	}
+	return delivery, nil
+}
+
+func validateWebhook(webhook *model.Webhook, cfg settings.Webhooks) error {
+	target, err := url.Parse(webhook.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidWebhook)
+	}
+	if !cfg.AllowPrivateNetworks && privateURL(target) {
+		return fmt.Errorf("%w: url must not point to a loopback or private address", ErrInvalidWebhook)
+	}
+	for _, t := range webhook.EventTypes {
+		if t == "" {
+			return fmt.Errorf("%w: event types must not be empty", ErrInvalidWebhook)
+		}
+	}
+	return nil
+}
+
+func NewWebhookService(log *logger.Logger, tr trace.Tracer, webhookRepo repo.WebhookRepo, deliveryRepo repo.WebhookDeliveryRepo, dispatcher WebhookDispatcher) WebhookService {
+	return &webhookService{
+		log:          log,
+		tr:           tr,
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		dispatcher:   dispatcher,
+		cfg:          settings.GetWebhooks(),
+	}
+}
@@ -0,0 +1,301 @@
+package svc
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxUnitCommitAttempts bounds the retries of a unit of work racing another write
+const maxUnitCommitAttempts = 3
+
+// WorkflowService manages the workflows of an interaction. The active workflow is the
+// ExecutionFlow of the interaction, which the plans compile into and the clients of a single
+// workflow see; the others, like the original workflow after a replan or a parallel diagnostic
+// one, are kept beside it and are addressed by id like the active one.
+type WorkflowService interface {
+	List(ctx context.Context, interactionId string) ([]model.Workflow, error)
+	Get(ctx context.Context, interactionId, workflowId string) (*model.Workflow, error)
+	Create(ctx context.Context, interactionId string, flow *runtime.ExecutionFlow, activate bool) (*model.Workflow, error)
+	Activate(ctx context.Context, interactionId, workflowId string) (*runtime.Interaction, error)
+	Delete(ctx context.Context, interactionId, workflowId string) error
+}
+
+// workflowRef is a workflow of an interaction with the document it is stored in: the interaction
+// for the active workflow, the workflow set for the others
+type workflowRef struct {
+	interaction *runtime.Interaction
+	set         *model.WorkflowSet
+	// revision is the revision the document holding the workflow was read at
+	revision int64
+	flow     *runtime.ExecutionFlow
+}
+
+func (ref *workflowRef) active() bool {
+	return ref.interaction.ExecutionFlow == ref.flow
+}
+
+// workflows finds the workflows of interactions by id for the services that write into them
+type workflows struct {
+	interactionRepo repo.InteractionRepo
+	workflowRepo    repo.WorkflowRepo
+}
+
+// find returns the workflow of the interaction, a NotFound when it has no workflow with the id
+func (w workflows) find(ctx context.Context, interactionId, workflowId string) (*workflowRef, error) {
+	// the revision is read first, a write in between fails the save instead of being lost
+	interactionRevision, err := w.interactionRepo.Revision(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	interaction, err := w.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	if flow := interaction.ExecutionFlow; flow != nil && flow.ID == workflowId {
+		return &workflowRef{interaction: interaction, revision: interactionRevision, flow: flow}, nil
+	}
+	revision, err := w.workflowRepo.Revision(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	set, err := w.workflowRepo.Get(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	for i := range set.Workflows {
+		if set.Workflows[i].ID == workflowId {
+			return &workflowRef{interaction: interaction, set: set, revision: revision, flow: &set.Workflows[i]}, nil
+		}
+	}
+	return nil, errs.NotFound("workflow id: %s not found in interaction id: %s", workflowId, interactionId)
+}
+
+// findGraph returns the workflow whose execution graph has the id
+func (w workflows) findGraph(ctx context.Context, interactionId, workflowId, executionId string) (*workflowRef, error) {
+	ref, err := w.find(ctx, interactionId, workflowId)
+	if err != nil {
+		return nil, err
+	}
+	if ref.flow.ExecutionGraph == nil || ref.flow.ExecutionGraph.ID != executionId {
+		return nil, errs.NotFound("execution graph id: %s not found in workflow id: %s", executionId, workflowId)
+	}
+	return ref, nil
+}
+
+// save writes the document holding the workflow with the events of the change. It is written only
+// when it is still at the revision it was read at, another write may have changed it since.
+func (w workflows) save(ctx context.Context, ref *workflowRef, evts ...*events.Event) error {
+	if ref.active() {
+		ctx = repo.ExpectRevision(ctx, repo.InteractionKey(ref.interaction.ID), ref.revision)
+		return w.interactionRepo.Update(ctx, ref.interaction, evts...)
+	}
+	ctx = repo.ExpectRevision(ctx, repo.WorkflowSetKey(ref.set.InteractionId), ref.revision)
+	return w.workflowRepo.Save(ctx, ref.set, evts...)
+}
+
+func workflowEvent(t events.Type, interactionId string, flow *runtime.ExecutionFlow) *events.Event {
+	evt := events.New(t, interactionId, flow)
+	evt.WorkflowId = flow.ID
+	return evt
+}
+
+type workflowService struct {
+	log        *logger.Logger
+	tr         trace.Tracer
+	workflows  workflows
+	outboxRepo repo.OutboxRepo
+	agentSvc   AgentService
+}
+
+// List returns the workflows of the interaction, the active one first
+func (ws *workflowService) List(ctx context.Context, interactionId string) ([]model.Workflow, error) {
+	interaction, err := ws.workflows.interactionRepo.Get(ctx, interactionId)
+	if err != nil {
+		return nil, err
+	}
+	set, err := ws.workflows.workflowRepo.Get(ctx, interactionId)
+	if err != nil {
+		ws.log.Errorf("Error while getting workflows of interaction id: %s by error: %v", interactionId, err)
+		return nil, err
+	}
+	list := make([]model.Workflow, 0, len(set.Workflows)+1)
+	if interaction.ExecutionFlow != nil {
+		list = append(list, model.Workflow{ExecutionFlow: *interaction.ExecutionFlow, Active: true})
+	}
+	for _, flow := range set.Workflows {
+		list = append(list, model.Workflow{ExecutionFlow: flow})
+	}
+	for i := range list {
+		if err = ws.agentSvc.ResolveExecutionFlow(ctx, &list[i].ExecutionFlow); err != nil {
+			return nil, err
+		}
+	}
+	return list, nil
+}
+
+func (ws *workflowService) Get(ctx context.Context, interactionId, workflowId string) (*model.Workflow, error) {
+	ref, err := ws.workflows.find(ctx, interactionId, workflowId)
+	if err != nil {
+		return nil, err
+	}
+	workflow := &model.Workflow{ExecutionFlow: *ref.flow, Active: ref.active()}
+	if err = ws.agentSvc.ResolveExecutionFlow(ctx, &workflow.ExecutionFlow); err != nil {
+		return nil, err
+	}
+	return workflow, nil
+}
+
+// Create adds the workflow to the interaction. It becomes the active workflow when activate is set
+// or the interaction has none, the workflow active before is then kept beside it.
+func (ws *workflowService) Create(ctx context.Context, interactionId string, flow *runtime.ExecutionFlow, activate bool) (*model.Workflow, error) {
+	if flow.ID == "" {
+		flow.ID = uuid.NewString()
+	}
+	if flow.ExecutionGraph == nil {
+		flow.ExecutionGraph = &runtime.ExecutionGraph{ID: uuid.NewString()}
+	}
+	if err := ws.agentSvc.PinExecutionFlow(ctx, flow); err != nil {
+		ws.log.Errorf("Error while pinning agents of workflow: %s by error: %v", flow.ID, err)
+		return nil, err
+	}
+	var created *model.Workflow
+	err := commitUnit(ctx, ws.outboxRepo, func(ctx context.Context) error {
+		if _, err := ws.workflows.find(ctx, interactionId, flow.ID); err == nil {
+			return errs.Conflict("workflow id: %s already exists in interaction id: %s", flow.ID, interactionId)
+		} else if !errors.Is(err, errs.ErrNotFound) {
+			return err
+		}
+		interaction, err := ws.workflows.interactionRepo.Get(ctx, interactionId)
+		if err != nil {
+			return err
+		}
+		set, err := ws.workflows.workflowRepo.Get(ctx, interactionId)
+		if err != nil {
+			return err
+		}
+		evts := []*events.Event{workflowEvent(events.WorkflowCreated, interactionId, flow)}
+		if !activate && interaction.ExecutionFlow != nil {
+			set.Workflows = append(set.Workflows, *flow)
+			created = &model.Workflow{ExecutionFlow: *flow}
+			return ws.workflows.workflowRepo.Save(ctx, set, evts...)
+		}
+		if previous := interaction.ExecutionFlow; previous != nil {
+			set.Workflows = append(set.Workflows, *previous)
+			if err = ws.workflows.workflowRepo.Save(ctx, set); err != nil {
+				return err
+			}
+		}
+		interaction.ExecutionFlow = flow
+		evts = append(evts, workflowEvent(events.WorkflowActivated, interactionId, flow))
+		created = &model.Workflow{ExecutionFlow: *flow, Active: true}
+		return ws.workflows.interactionRepo.Update(ctx, interaction, evts...)
+	})
+	if err != nil {
+		ws.log.Errorf("Error while creating workflow: %s of interaction id: %s by error: %v", flow.ID, interactionId, err)
+		return nil, err
+	}
+	return created, nil
+}
+
+// Activate makes the workflow the ExecutionFlow of the interaction, the workflow active before is
+// kept beside it
+func (ws *workflowService) Activate(ctx context.Context, interactionId, workflowId string) (*runtime.Interaction, error) {
+	var interaction *runtime.Interaction
+	err := commitUnit(ctx, ws.outboxRepo, func(ctx context.Context) error {
+		ref, err := ws.workflows.find(ctx, interactionId, workflowId)
+		if err != nil {
+			return err
+		}
+		interaction = ref.interaction
+		if ref.active() {
+			return nil
+		}
+		flow := *ref.flow
+		workflows := ref.set.Workflows[:0]
+		for _, other := range ref.set.Workflows {
+			if other.ID != workflowId {
+				workflows = append(workflows, other)
+			}
+		}
+		if previous := interaction.ExecutionFlow; previous != nil {
+			workflows = append(workflows, *previous)
+		}
+		ref.set.Workflows = workflows
+		if err = ws.workflows.save(ctx, ref); err != nil {
+			return err
+		}
+		interaction.ExecutionFlow = &flow
+		return ws.workflows.interactionRepo.Update(ctx, interaction, workflowEvent(events.WorkflowActivated, interactionId, &flow))
+	})
+	if err != nil {
+		ws.log.Errorf("Error while activating workflow: %s of interaction id: %s by error: %v", workflowId, interactionId, err)
+		return nil, err
+	}
+	return interaction, nil
+}
+
+// Delete removes a workflow that is not active, the active workflow is only replaced by activating
+// another one. The steps and mcps of the workflow are left to be deleted on their own.
+func (ws *workflowService) Delete(ctx context.Context, interactionId, workflowId string) error {
+	ref, err := ws.workflows.find(ctx, interactionId, workflowId)
+	if err != nil {
+		return err
+	}
+	if ref.active() {
+		return errs.Conflict("workflow id: %s is the active workflow of interaction id: %s", workflowId, interactionId)
+	}
+	workflows := ref.set.Workflows[:0]
+	for _, other := range ref.set.Workflows {
+		if other.ID != workflowId {
+			workflows = append(workflows, other)
+		}
+	}
+	evt := events.New(events.WorkflowDeleted, interactionId, nil)
+	evt.WorkflowId = workflowId
+	ref.set.Workflows = workflows
+	if err = ws.workflows.save(ctx, ref, evt); err != nil {
+		ws.log.Errorf("Error while deleting workflow: %s of interaction id: %s by error: %v", workflowId, interactionId, err)
+		return err
+	}
+	return nil
+}
+
+// commitUnit runs the writes of fn in a unit of work, for the changes that write several documents
+// like moving a workflow in or out of the interaction. A concurrent write of any of them is retried
+// on the new state. Within the unit of work of a caller, like a batch, fn writes in that one and
+// the caller commits and retries.
+func commitUnit(ctx context.Context, outboxRepo repo.OutboxRepo, fn func(ctx context.Context) error) error {
+	if repo.InUnitOfWork(ctx) {
+		return fn(ctx)
+	}
+	var err error
+	for attempt := 0; attempt < maxUnitCommitAttempts; attempt++ {
+		uow := repo.NewUnitOfWork()
+		if err = fn(repo.WithUnitOfWork(ctx, uow)); err != nil {
+			return err
+		}
+		if err = outboxRepo.Commit(ctx, uow); !errors.Is(err, repo.ErrRevisionConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+func NewWorkflowService(log *logger.Logger, tr trace.Tracer, interactionRepo repo.InteractionRepo, workflowRepo repo.WorkflowRepo, outboxRepo repo.OutboxRepo, agentSvc AgentService) WorkflowService {
+	return &workflowService{
+		log:        log,
+		tr:         tr,
+		workflows:  workflows{interactionRepo: interactionRepo, workflowRepo: workflowRepo},
+		outboxRepo: outboxRepo,
+		agentSvc:   agentSvc,
+	}
+}
@@ -0,0 +1,470 @@
+// Package validation checks request payloads for the semantic rules json binding does not enforce:
+// required fields, enum values, id formats, size limits and timestamps that are not in the future.
+// A check collects every field error of the payload instead of stopping at the first one.
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/settings"
+)
+
+// idPattern matches the ids clients may choose, generated ids are uuids which match it as well
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]*$`)
+
+var statuses = map[runtime.Status]bool{
+	runtime.StatusPending: true,
+	runtime.StatusRunning: true,
+	runtime.StatusSuccess: true,
+	runtime.StatusError:   true,
+	runtime.StatusStop:    true,
+}
+
+// modes are the workflow modes plan compilation understands, empty means sequential
+var modes = map[string]bool{"": true, "sequential": true, "parallel": true}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Errors are the field errors of a payload, they match errs.ErrInvalid
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Field + ": " + fe.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e Errors) Unwrap() error {
+	return errs.ErrInvalid
+}
+
+type Validator struct {
+	cfg           settings.Validation
+	artifactTypes map[string]bool
+	now           func() time.Time
+}
+
+func New(cfg settings.Validation) *Validator {
+	types := make(map[string]bool, len(cfg.ArtifactTypes))
+	for _, t := range cfg.ArtifactTypes {
+		types[t] = true
+	}
+	return &Validator{cfg: cfg, artifactTypes: types, now: time.Now}
+}
+
+func (v *Validator) Interaction(interaction *runtime.Interaction) error {
+	c := v.checker()
+	c.id("id", interaction.ID, false)
+	if interaction.BaseQuery == nil {
+		c.add("base_query", "is required")
+	} else {
+		c.query("base_query", interaction.BaseQuery, true)
+	}
+	if interaction.BaseContext != nil {
+		c.context("base_context", interaction.BaseContext)
+	}
+	if interaction.Plan != nil {
+		c.plan("plan", interaction.Plan)
+	}
+	if interaction.ExecutionFlow != nil {
+		c.flow("workflow", interaction.ExecutionFlow)
+	}
+	c.items("messages", len(interaction.Messages))
+	for i := range interaction.Messages {
+		c.message(fmt.Sprintf("messages[%d]", i), &interaction.Messages[i])
+	}
+	c.text("summary", interaction.Summary)
+	c.period("created_at", "completed_at", interaction.CreatedAt, interaction.CompletedAt)
+	return c.err()
+}
+
+func (v *Validator) ExecutionFlow(flow *runtime.ExecutionFlow) error {
+	c := v.checker()
+	c.flow("", flow)
+	return c.err()
+}
+
+func (v *Validator) ExecutionGraph(graph *runtime.ExecutionGraph) error {
+	c := v.checker()
+	c.graph("", graph)
+	return c.err()
+}
+
+func (v *Validator) Step(step *runtime.Step) error {
+	c := v.checker()
+	c.step("", step)
+	return c.err()
+}
+
+func (v *Validator) Artifact(artifact *runtime.Artifact) error {
+	c := v.checker()
+	c.artifact("", artifact)
+	return c.err()
+}
+
+func (v *Validator) ToolInvocation(invocation *runtime.McpToolInvocation) error {
+	c := v.checker()
+	c.invocation("", invocation)
+	return c.err()
+}
+
+func (v *Validator) Mcp(mcp *runtime.MCP) error {
+	c := v.checker()
+	c.id("id", mcp.ID, false)
+	c.name("name", mcp.Name, true)
+	c.items("tools", len(mcp.Tools))
+	seen := make(map[string]bool, len(mcp.Tools))
+	for i := range mcp.Tools {
+		field := fmt.Sprintf("tools[%d]", i)
+		c.tool(field, &mcp.Tools[i])
+		if name := mcp.Tools[i].Name; name != "" && seen[name] {
+			c.add(field+".name", "duplicate tool name: "+name)
+		} else {
+			seen[name] = true
+		}
+	}
+	return c.err()
+}
+
+func (v *Validator) Tool(tool *runtime.Tool) error {
+	c := v.checker()
+	c.tool("", tool)
+	return c.err()
+}
+
+func (v *Validator) Status(status runtime.Status) error {
+	c := v.checker()
+	c.status("status", status, true)
+	return c.err()
+}
+
+// Batch checks that every operation names its step and carries the payload its op needs
+func (v *Validator) Batch(req *model.BatchRequest) error {
+	c := v.checker()
+	c.id("interaction_id", req.InteractionId, true)
+	if len(req.Operations) == 0 {
+		c.add("operations", "is required")
+	}
+	for i := range req.Operations {
+		c.operation(fmt.Sprintf("operations[%d]", i), &req.Operations[i])
+	}
+	return c.err()
+}
+
+func (v *Validator) checker() *checker {
+	return &checker{v: v, now: v.now()}
+}
+
+// checker collects the field errors of one payload
+type checker struct {
+	v      *Validator
+	now    time.Time
+	errors Errors
+}
+
+func (c *checker) err() error {
+	if len(c.errors) == 0 {
+		return nil
+	}
+	return c.errors
+}
+
+func (c *checker) add(field, message string) {
+	c.errors = append(c.errors, FieldError{Field: field, Message: message})
+}
+
+func (c *checker) required(field, value string) bool {
+	if strings.TrimSpace(value) == "" {
+		c.add(field, "is required")
+		return false
+	}
+	return true
+}
+
+func (c *checker) length(field, value string, max int) {
+	if len(value) > max {
+		c.add(field, fmt.Sprintf("must be at most %d bytes", max))
+	}
+}
+
+func (c *checker) id(field, value string, required bool) {
+	if value == "" {
+		if required {
+			c.add(field, "is required")
+		}
+		return
+	}
+	if len(value) > c.v.cfg.MaxIdLength {
+		c.length(field, value, c.v.cfg.MaxIdLength)
+	} else if !idPattern.MatchString(value) {
+		c.add(field, "must start with a letter or digit and contain only letters, digits, '.', '_', ':' and '-'")
+	}
+}
+
+func (c *checker) name(field, value string, required bool) {
+	if required && !c.required(field, value) {
+		return
+	}
+	c.length(field, value, c.v.cfg.MaxNameLength)
+}
+
+func (c *checker) text(field, value string) {
+	c.length(field, value, c.v.cfg.MaxTextLength)
+}
+
+func (c *checker) items(field string, n int) {
+	if n > c.v.cfg.MaxItems {
+		c.add(field, fmt.Sprintf("must have at most %d items", c.v.cfg.MaxItems))
+	}
+}
+
+func (c *checker) status(field string, status runtime.Status, required bool) {
+	if status == "" {
+		if required {
+			c.add(field, "is required")
+		}
+		return
+	}
+	if !statuses[status] {
+		c.add(field, fmt.Sprintf("unknown status %q, expected one of pending, running, success, error, stop", status))
+	}
+}
+
+// past rejects timestamps further ahead of the server clock than the allowed skew
+func (c *checker) past(field string, t time.Time) {
+	if !t.IsZero() && t.After(c.now.Add(c.v.cfg.ClockSkew)) {
+		c.add(field, "must not be in the future")
+	}
+}
+
+// period checks the start and end timestamps of something, the end cannot come before the start
+func (c *checker) period(startField, endField string, start, end time.Time) {
+	c.past(startField, start)
+	c.past(endField, end)
+	if !start.IsZero() && !end.IsZero() && end.Before(start) {
+		c.add(endField, "must not be before "+startField)
+	}
+}
+
+func (c *checker) query(field string, query *runtime.Query, required bool) {
+	c.id(field+".id", query.ID, false)
+	if required && !c.required(field+".content", query.Content) {
+		return
+	}
+	c.text(field+".content", query.Content)
+	c.items(field+".tags", len(query.Tags))
+	c.items(field+".metadata", len(query.Metadata))
+	c.past(field+".timestamp", query.Timestamp)
+}
+
+func (c *checker) context(field string, ctx *runtime.Context) {
+	c.id(field+".id", ctx.ID, false)
+	c.text(field+".content", ctx.Content)
+	c.items(field+".workspace", len(ctx.Workspace))
+	c.items(field+".knowledge", len(ctx.Knowledge))
+}
+
+func (c *checker) message(field string, message *runtime.Message) {
+	c.id(field+".id", message.ID, false)
+	c.name(field+".role", message.Role, true)
+	if message.Query != nil {
+		c.query(field+".query", message.Query, false)
+	}
+	if message.Answer != nil {
+		c.text(field+".answer.content", message.Answer.Content)
+		c.past(field+".answer.timestamp", message.Answer.Timestamp)
+	}
+	c.past(field+".timestamp", message.Timestamp)
+}
+
+func (c *checker) plan(field string, plan *runtime.Plan) {
+	c.id(field+".id", plan.ID, false)
+	c.items(field+".steps", len(plan.Steps))
+	seen := make(map[string]bool, len(plan.Steps))
+	for i, step := range plan.Steps {
+		stepField := fmt.Sprintf("%s.steps[%d]", field, i)
+		c.id(stepField+".id", step.ID, true)
+		if step.ID != "" && seen[step.ID] {
+			c.add(stepField+".id", "duplicate plan step id: "+step.ID)
+		}
+		seen[step.ID] = true
+		c.name(stepField+".name", step.Name, false)
+		c.text(stepField+".description", step.Description)
+	}
+}
+
+func (c *checker) flow(field string, flow *runtime.ExecutionFlow) {
+	c.id(join(field, "id"), flow.ID, false)
+	c.name(join(field, "name"), flow.Name, false)
+	c.text(join(field, "description"), flow.Description)
+	if !modes[flow.Mode] {
+		c.add(join(field, "mode"), fmt.Sprintf("unknown mode %q, expected sequential or parallel", flow.Mode))
+	}
+	c.items(join(field, "agents"), len(flow.Agents))
+	for i, agent := range flow.Agents {
+		agentField := join(field, fmt.Sprintf("agents[%d].id", i))
+		if c.required(agentField, agent.ID) {
+			c.length(agentField, agent.ID, c.v.cfg.MaxIdLength)
+		}
+	}
+	c.items(join(field, "variables"), len(flow.Variables))
+	c.items(join(field, "available_mcp_refs"), len(flow.AvailableMcpRefs))
+	c.id(join(field, "plan_id"), flow.PlanID, false)
+	if flow.ExecutionGraph != nil {
+		c.graph(join(field, "graph"), flow.ExecutionGraph)
+	}
+}
+
+func (c *checker) graph(field string, graph *runtime.ExecutionGraph) {
+	c.id(join(field, "id"), graph.ID, false)
+	c.items(join(field, "nodes"), len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		nodeField := join(field, fmt.Sprintf("nodes[%d]", i))
+		c.id(nodeField+".step_id", node.StepId, true)
+		c.name(nodeField+".name", node.Name, false)
+		c.status(nodeField+".status", node.Status, false)
+	}
+	c.items(join(field, "edges"), len(graph.Edges))
+	for i, edge := range graph.Edges {
+		edgeField := join(field, fmt.Sprintf("edges[%d]", i))
+		c.id(edgeField+".from", edge.From, true)
+		c.id(edgeField+".to", edge.To, true)
+		c.name(edgeField+".type", edge.Type, false)
+		c.name(edgeField+".label", edge.Label, false)
+	}
+}
+
+func (c *checker) step(field string, step *runtime.Step) {
+	c.id(join(field, "id"), step.ID, false)
+	c.name(join(field, "name"), step.Name, true)
+	if step.Index < 0 {
+		c.add(join(field, "index"), "must not be negative")
+	}
+	c.status(join(field, "status"), step.Status, false)
+	c.text(join(field, "error"), step.Error)
+	if step.Agent != nil {
+		// an inline agent has no id, a reference is an agent id with an optional @version
+		c.length(join(field, "agent.id"), step.Agent.ID, c.v.cfg.MaxIdLength)
+		c.name(join(field, "agent.name"), step.Agent.Name, false)
+		c.text(join(field, "agent.system_prompt"), step.Agent.SystemPrompt)
+		c.text(join(field, "agent.user_prompt"), step.Agent.UserPrompt)
+	}
+	if step.InputContext != nil {
+		c.context(join(field, "input_context"), step.InputContext)
+	}
+	if step.OutputContext != nil {
+		c.context(join(field, "output_context"), step.OutputContext)
+	}
+	if step.Query != nil {
+		c.query(join(field, "query"), step.Query, false)
+	}
+	if step.Answer != nil {
+		c.text(join(field, "answer.content"), step.Answer.Content)
+		c.past(join(field, "answer.timestamp"), step.Answer.Timestamp)
+	}
+	c.items(join(field, "artifacts"), len(step.Artifacts))
+	for i := range step.Artifacts {
+		c.artifact(join(field, fmt.Sprintf("artifacts[%d]", i)), &step.Artifacts[i])
+	}
+	c.items(join(field, "curated_tools"), len(step.CuratedTools))
+	for i := range step.CuratedTools {
+		c.invocation(join(field, fmt.Sprintf("curated_tools[%d]", i)), &step.CuratedTools[i])
+	}
+	c.id(join(field, "input_step_id"), step.InputStepID, false)
+	c.period(join(field, "started_at"), join(field, "finished_at"), step.StartedAt, step.FinishedAt)
+}
+
+func (c *checker) artifact(field string, artifact *runtime.Artifact) {
+	c.id(join(field, "id"), artifact.ID, false)
+	c.name(join(field, "name"), artifact.Name, true)
+	c.name(join(field, "path"), artifact.Path, false)
+	if c.required(join(field, "type"), artifact.Type) && !c.v.artifactTypes[artifact.Type] {
+		c.add(join(field, "type"), fmt.Sprintf("unknown artifact type %q, expected one of %s", artifact.Type, strings.Join(c.v.cfg.ArtifactTypes, ", ")))
+	}
+	c.id(join(field, "created_by_step_id"), artifact.CreatedByStepID, false)
+	c.past(join(field, "created_at"), artifact.CreatedAt)
+}
+
+func (c *checker) invocation(field string, invocation *runtime.McpToolInvocation) {
+	c.id(join(field, "id"), invocation.ID, false)
+	c.name(join(field, "tool_name"), invocation.ToolName, true)
+	c.id(join(field, "mcp_id"), invocation.MCPID, false)
+	c.name(join(field, "category"), invocation.Category, false)
+	c.status(join(field, "status"), invocation.Status, false)
+	c.text(join(field, "error"), invocation.Error)
+	c.period(join(field, "started_at"), join(field, "finished_at"), invocation.StartedAt, invocation.FinishedAt)
+}
+
+func (c *checker) tool(field string, tool *runtime.Tool) {
+	c.name(join(field, "name"), tool.Name, true)
+	c.text(join(field, "description"), tool.Description)
+}
+
+func (c *checker) operation(field string, op *model.BatchOperation) {
+	switch op.Op {
+	case model.BatchCreateStep, model.BatchUpdateStep, model.BatchPatchStep, model.BatchUpdateStepStatus,
+		model.BatchAddArtifact, model.BatchRecordToolInvocation, model.BatchPatchInteraction:
+	default:
+		c.add(field+".op", fmt.Sprintf("unknown operation %q", op.Op))
+		return
+	}
+	if op.Op == model.BatchPatchInteraction {
+		if len(op.Patch) == 0 {
+			c.add(field+".patch", "is required")
+		}
+		return
+	}
+	c.id(field+".workflow_id", op.WorkflowId, true)
+	c.id(field+".execution_id", op.ExecutionId, true)
+	if op.Op != model.BatchCreateStep && op.Op != model.BatchUpdateStep {
+		c.id(field+".step_id", op.StepId, true)
+	}
+	switch op.Op {
+	case model.BatchCreateStep, model.BatchUpdateStep:
+		if op.Step == nil {
+			c.add(field+".step", "is required")
+			return
+		}
+		c.step(field+".step", op.Step)
+		if op.Op == model.BatchUpdateStep {
+			c.id(field+".step.id", op.Step.ID, true)
+		}
+	case model.BatchPatchStep:
+		if len(op.Patch) == 0 {
+			c.add(field+".patch", "is required")
+		}
+	case model.BatchUpdateStepStatus:
+		c.status(field+".status", op.Status, true)
+	case model.BatchAddArtifact:
+		if op.Artifact == nil {
+			c.add(field+".artifact", "is required")
+			return
+		}
+		c.artifact(field+".artifact", op.Artifact)
+	case model.BatchRecordToolInvocation:
+		if op.Invocation == nil {
+			c.add(field+".invocation", "is required")
+			return
+		}
+		c.invocation(field+".invocation", op.Invocation)
+	}
+}
+
+// join prefixes a field with the path of the object it belongs to, top level fields have none
+func join(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
@@ -0,0 +1,258 @@
+// Package client is the Go client of the REST api of the state service. Every endpoint of the
+// OpenAPI document at /api/v1/openapi.json has a typed method taking the context of the call, whose
+// deadline and cancellation bound the request and its retries.
+//
+// Requests that are safe to repeat are retried on network errors and on 429, 502, 503 and 504
+// responses: GET, PUT and DELETE requests, and the creates, which are sent with an Idempotency-Key
+// so that a retried create replays the stored response instead of creating twice.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	HeaderActor          = "X-Actor"
+	HeaderRequestId      = "X-Request-ID"
+	HeaderIdempotencyKey = "Idempotency-Key"
+
+	basePath = "/api/v1"
+)
+
+type Client struct {
+	baseURL    *url.URL
+	http       *http.Client
+	actor      string
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient sends the requests with hc instead of a client with a 30 second timeout. The
+// event streams need a client without a timeout, they use hc's transport only.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.http = hc }
+}
+
+// WithActor sends actor as the X-Actor header that the audit log records
+func WithActor(actor string) Option {
+	return func(c *Client) { c.actor = actor }
+}
+
+// WithRetries retries a request at most n times, waiting between min and max with exponential
+// backoff and jitter, or as long as a Retry-After header asks
+func WithRetries(n int, min, max time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = n
+		c.minBackoff = min
+		c.maxBackoff = max
+	}
+}
+
+type requestIdKey struct{}
+type idempotencyKeyKey struct{}
+
+// WithRequestId sends id as the X-Request-ID of the requests made with ctx, so that the server
+// logs and problems of a call carry the id of the caller's own request
+func WithRequestId(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
+
+// WithIdempotencyKey sends key as the Idempotency-Key of the create made with ctx instead of a
+// generated one, so that the create can be repeated across processes
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// request is one call of the api. body is encoded once so that a retry sends the same bytes.
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	body        any
+	contentType string
+	// idempotent marks a POST the server deduplicates by Idempotency-Key
+	idempotent bool
+	// safe marks a POST that only reads, like a graphql query
+	safe bool
+}
+
+func (c *Client) do(ctx context.Context, req *request, out any) (http.Header, error) {
+	var body []byte
+	switch b := req.body.(type) {
+	case nil:
+	case []byte:
+		body = b
+	default:
+		var err error
+		if body, err = json.Marshal(b); err != nil {
+			return nil, fmt.Errorf("encoding request body: %w", err)
+		}
+	}
+	header := req.header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		header.Set("Content-Type", contentType)
+	}
+	header.Set("Accept", "application/json, application/problem+json")
+	c.setHeaders(ctx, header)
+	if req.idempotent && header.Get(HeaderIdempotencyKey) == "" {
+		key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+		if key == "" {
+			key = uuid.NewString()
+		}
+		header.Set(HeaderIdempotencyKey, key)
+	}
+	retryable := req.method == http.MethodGet || req.method == http.MethodPut || req.method == http.MethodDelete || req.idempotent || req.safe
+
+	u := c.url(req.path, req.query)
+	for attempt := 0; ; attempt++ {
+		httpReq, err := http.NewRequestWithContext(ctx, req.method, u, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		httpReq.Header = header.Clone()
+		resp, err := c.http.Do(httpReq)
+		if err != nil {
+			if ctx.Err() != nil || !retryable || attempt >= c.maxRetries {
+				return nil, err
+			}
+			if err = c.wait(ctx, attempt, nil); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if retryable && attempt < c.maxRetries && retryStatus(resp.StatusCode) {
+			drain(resp)
+			if err = c.wait(ctx, attempt, resp); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		return resp.Header, decode(resp, out)
+	}
+}
+
+// setHeaders sets the headers of every request, the stream requests included
+func (c *Client) setHeaders(ctx context.Context, header http.Header) {
+	if c.actor != "" {
+		header.Set(HeaderActor, c.actor)
+	}
+	if id, _ := ctx.Value(requestIdKey{}).(string); id != "" {
+		header.Set(HeaderRequestId, id)
+	}
+}
+
+// url joins the escaped path to the base url
+func (c *Client) url(path string, query url.Values) string {
+	u := strings.TrimSuffix(c.baseURL.String(), "/") + basePath + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// wait sleeps before retry attempt+1, resp is the response that failed if there was one
+func (c *Client) wait(ctx context.Context, attempt int, resp *http.Response) error {
+	backoff := c.backoff(attempt)
+	if resp != nil {
+		if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && secs >= 0 {
+			backoff = min(time.Duration(secs)*time.Second, c.maxBackoff)
+		}
+	}
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// backoff doubles with every attempt up to maxBackoff, the upper half of it is random
+func (c *Client) backoff(attempt int) time.Duration {
+	backoff := c.minBackoff << min(attempt, 16)
+	if backoff <= 0 || backoff > c.maxBackoff {
+		backoff = c.maxBackoff
+	}
+	return backoff/2 + rand.N(backoff/2+1)
+}
+
+func retryStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// decode reads a success response into out, or an error response into an *Error
+func decode(resp *http.Response, out any) error {
+	defer drain(resp)
+	if resp.StatusCode >= http.StatusBadRequest {
+		return errorOf(resp)
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("decoding response of %s %s: %w", resp.Request.Method, resp.Request.URL.Path, err)
+	}
+	return nil
+}
+
+func drain(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	_ = resp.Body.Close()
+}
+
+// segment escapes an id for use as a path segment
+func segment(id string) string {
+	return url.PathEscape(id)
+}
+
+// NewClient returns a client of the server at baseURL, like http://localhost:8080
+func NewClient(baseURL string, opts ...Option) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parsing base url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("base url %q is not an http url", baseURL)
+	}
+	c := &Client{
+		baseURL:    u,
+		http:       &http.Client{Timeout: 30 * time.Second},
+		maxRetries: 3,
+		minBackoff: 100 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
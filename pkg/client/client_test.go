@@ -0,0 +1,773 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/mangudaigb/dhauli-base/config"
+	"github.com/mangudaigb/dhauli-base/logger"
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal"
+	"github.com/mangudaigb/state-service/internal/errs"
+	"github.com/mangudaigb/state-service/internal/handler"
+	"github.com/mangudaigb/state-service/internal/model"
+	"github.com/mangudaigb/state-service/internal/openapi"
+	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/svc"
+	"github.com/mangudaigb/state-service/pkg/client"
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// memoryIdempotency keeps the idempotency keys of the test server in memory
+type memoryIdempotency struct {
+	mu        sync.Mutex
+	responses map[string]*model.IdempotentResponse
+}
+
+func (mi *memoryIdempotency) Reserve(ctx context.Context, pending *model.IdempotentResponse, ttl time.Duration) (*model.IdempotentResponse, bool, error) {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	if existing, ok := mi.responses[pending.Key]; ok {
+		return existing, false, nil
+	}
+	mi.responses[pending.Key] = pending
+	return nil, true, nil
+}
+
+func (mi *memoryIdempotency) Complete(ctx context.Context, response *model.IdempotentResponse, ttl time.Duration) error {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	mi.responses[response.Key] = response
+	return nil
+}
+
+func (mi *memoryIdempotency) Release(ctx context.Context, key string) error {
+	mi.mu.Lock()
+	defer mi.mu.Unlock()
+	delete(mi.responses, key)
+	return nil
+}
+
+func (mi *memoryIdempotency) Close() {}
+
+// memoryAudit drops the audit entries of the test server
+type memoryAudit struct{}
+
+func (memoryAudit) Record(ctx context.Context, entry *model.AuditEntry) error { return nil }
+
+func (memoryAudit) Query(ctx context.Context, query model.AuditQuery) ([]*model.AuditEntry, error) {
+	return []*model.AuditEntry{}, nil
+}
+
+// memoryTemplates keeps the templates of the test server in memory as json, so that a caller
+// changing a template it got does not change the stored one
+type memoryTemplates struct {
+	mu        sync.Mutex
+	templates map[string][]byte
+}
+
+func (mt *memoryTemplates) Get(ctx context.Context, templateId string) (*model.WorkflowTemplate, error) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	data, ok := mt.templates[templateId]
+	if !ok {
+		return nil, errs.NotFound("template id: %s not found", templateId)
+	}
+	var template model.WorkflowTemplate
+	err := json.Unmarshal(data, &template)
+	return &template, err
+}
+
+func (mt *memoryTemplates) Save(ctx context.Context, template *model.WorkflowTemplate) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	if _, ok := mt.templates[template.ID]; ok {
+		return errs.Conflict("template id: %s already exists", template.ID)
+	}
+	data, err := json.Marshal(template)
+	mt.templates[template.ID] = data
+	return err
+}
+
+func (mt *memoryTemplates) Update(ctx context.Context, template *model.WorkflowTemplate) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	data, err := json.Marshal(template)
+	mt.templates[template.ID] = data
+	return err
+}
+
+func (mt *memoryTemplates) Delete(ctx context.Context, templateId string) error {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	delete(mt.templates, templateId)
+	return nil
+}
+
+func (mt *memoryTemplates) List(ctx context.Context) ([]string, error) {
+	mt.mu.Lock()
+	defer mt.mu.Unlock()
+	ids := make([]string, 0, len(mt.templates))
+	for id := range mt.templates {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (mt *memoryTemplates) Close() {}
+
+// testServer is the router of the service with the interaction, workflow, step, plan, template and
+// batch services on a miniredis, the other services are not wired
+type testServer struct {
+	*httptest.Server
+	idempotency *memoryIdempotency
+	// lose makes the server drop the response of the next n requests it served, answering 503
+	lose atomic.Int32
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	viper.Set("redis.host", mr.Addr())
+	t.Cleanup(func() { viper.Set("redis.host", "") })
+
+	ctx := context.Background()
+	cfg := &config.Config{}
+	log, err := logger.NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("NewLogger: %v", err)
+	}
+	tr := noop.NewTracerProvider().Tracer("test")
+	oRepo, err := repo.NewOutboxRepo(ctx, cfg, log, tr)
+	if err != nil {
+		t.Fatalf("NewOutboxRepo: %v", err)
+	}
+	iRepo, _ := repo.NewInteractionRepo(ctx, cfg, log, tr, oRepo)
+	sRepo, _ := repo.NewStepRepo(ctx, cfg, log, tr, oRepo)
+	wfRepo, _ := repo.NewWorkflowRepo(ctx, cfg, log, tr, oRepo)
+	chRepo, _ := repo.NewChildRepo(ctx, cfg, log, tr, oRepo)
+	pRepo, _ := repo.NewPlanRepo(ctx, cfg, log, tr, oRepo)
+	mRepo, _ := repo.NewMcpRepo(ctx, cfg, log, tr, oRepo)
+	iRepo = svc.NewChildSyncRepo(log, iRepo, chRepo, wfRepo, oRepo)
+
+	// the flows of the tests have no agents, the registry is never read
+	aSvc := svc.NewAgentService(log, tr, nil)
+	iSvc := svc.NewInteractionService(log, tr, iRepo, wfRepo, sRepo, aSvc)
+	sSvc := svc.NewStepService(log, tr, sRepo, iRepo, wfRepo, chRepo, oRepo, aSvc)
+	wfSvc := svc.NewWorkflowService(log, tr, iRepo, wfRepo, oRepo, aSvc)
+	pSvc := svc.NewPlanService(log, tr, iRepo, sRepo, pRepo, chRepo, oRepo)
+	tSvc := svc.NewTemplateService(log, tr, &memoryTemplates{templates: map[string][]byte{}}, iRepo, sRepo, mRepo, aSvc)
+	idempotency := &memoryIdempotency{responses: map[string]*model.IdempotentResponse{}}
+	idSvc := svc.NewIdempotencyService(log, tr, idempotency)
+	bSvc := svc.NewBatchService(log, tr, iSvc, sSvc, oRepo)
+
+	gin.SetMode(gin.TestMode)
+	gh := gin.New()
+	routes := openapi.NewRoutes()
+	internal.SetupRouter(gh, routes, log,
+		handler.NewInteractionHandler(log, tr, iSvc),
+		handler.NewStepHandler(log, tr, sSvc),
+		handler.NewMcpHandler(log, tr, nil, nil),
+		handler.NewAgentHandler(log, tr, aSvc),
+		handler.NewPlanHandler(log, tr, pSvc),
+		handler.NewTemplateHandler(log, tr, tSvc),
+		handler.NewOutboxHandler(log, tr, nil),
+		handler.NewEventHandler(log, tr, nil, iSvc),
+		handler.NewSubscriptionHandler(log, tr, nil),
+		handler.NewWebhookHandler(log, tr, nil),
+		handler.NewAuditHandler(log, tr, memoryAudit{}),
+		handler.NewIdempotencyHandler(log, tr, idSvc),
+		handler.NewBatchHandler(log, tr, bSvc),
+		handler.NewWorkflowHandler(log, tr, wfSvc),
+		handler.NewChildHandler(log, tr, nil),
+		handler.NewGraphQLHandler(log, tr, nil),
+		handler.NewOpenAPIHandler(log, tr, routes),
+	)
+
+	ts := &testServer{idempotency: idempotency}
+	ts.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ts.lose.Load() > 0 {
+			ts.lose.Add(-1)
+			gh.ServeHTTP(httptest.NewRecorder(), r)
+			http.Error(w, "response lost", http.StatusServiceUnavailable)
+			return
+		}
+		gh.ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func newClient(t *testing.T, ts *testServer) *client.Client {
+	t.Helper()
+	c, err := client.NewClient(ts.URL, client.WithActor("tester"), client.WithRetries(2, time.Millisecond, 10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c
+}
+
+func newInteraction(id string) *runtime.Interaction {
+	return &runtime.Interaction{
+		ID:        id,
+		BaseQuery: &runtime.Query{Content: "what is the state of the order"},
+		ExecutionFlow: &runtime.ExecutionFlow{
+			ID:             "flow-1",
+			Name:           "answer",
+			ExecutionGraph: &runtime.ExecutionGraph{ID: "graph-1"},
+		},
+	}
+}
+
+func TestInteractionLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	created, err := c.CreateInteraction(ctx, newInteraction("i-1"))
+	if err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	if created.ID != "i-1" || created.ExecutionFlow == nil || created.ExecutionFlow.ID != "flow-1" {
+		t.Fatalf("created = %+v, want i-1 with flow-1", created)
+	}
+
+	got, revision, err := c.GetInteractionRevision(ctx, "i-1")
+	if err != nil {
+		t.Fatalf("GetInteractionRevision: %v", err)
+	}
+	if got.BaseQuery == nil || got.BaseQuery.Content != "what is the state of the order" || revision < 1 {
+		t.Errorf("got = %+v at revision %d, want the created interaction", got, revision)
+	}
+
+	patched, patchedRevision, err := c.PatchInteraction(ctx, "i-1", client.Patch{Body: []byte(`{"summary":"shipped"}`), IfMatch: revision})
+	if err != nil {
+		t.Fatalf("PatchInteraction: %v", err)
+	}
+	if patched.Summary != "shipped" || patchedRevision <= revision {
+		t.Errorf("patched = %q at revision %d, want shipped after %d", patched.Summary, patchedRevision, revision)
+	}
+	// a patch of the revision read before is stale now
+	_, _, err = c.PatchInteraction(ctx, "i-1", client.Patch{Body: []byte(`{"summary":"lost"}`), IfMatch: revision})
+	if !errors.Is(err, client.ErrPreconditionFailed) || !errors.Is(err, client.ErrConflict) {
+		t.Errorf("stale patch error = %v, want a failed precondition", err)
+	}
+
+	history, err := c.GetInteractionHistory(ctx, "i-1")
+	if err != nil {
+		t.Fatalf("GetInteractionHistory: %v", err)
+	}
+	if len(history) != 2 {
+		t.Errorf("history = %d records, want 2", len(history))
+	}
+	if _, err = c.GetInteractionHistory(ctx, "missing"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("history of a missing interaction error = %v, want not found", err)
+	}
+
+	if err = c.DeleteInteraction(ctx, "i-1"); err != nil {
+		t.Fatalf("DeleteInteraction: %v", err)
+	}
+	if _, err = c.GetInteraction(ctx, "i-1"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetInteraction after delete error = %v, want not found", err)
+	}
+}
+
+func TestErrorsCarryTheProblem(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := client.WithRequestId(context.Background(), "req-1")
+
+	_, err := c.GetInteraction(ctx, "missing")
+	var problem *client.Error
+	if !errors.As(err, &problem) {
+		t.Fatalf("error = %T %v, want a *client.Error", err, err)
+	}
+	if problem.Status != http.StatusNotFound || problem.RequestId != "req-1" || !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("problem = %+v, want a 404 of request req-1", problem)
+	}
+
+	invalid := newInteraction("i-2")
+	invalid.BaseQuery = nil
+	_, err = c.CreateInteraction(context.Background(), invalid)
+	if !errors.As(err, &problem) || !errors.Is(err, client.ErrInvalid) || len(problem.Errors) == 0 {
+		t.Errorf("invalid create error = %v, want field errors", err)
+	}
+
+	// a patch is validated like a full update
+	if _, err = c.CreateInteraction(context.Background(), newInteraction("i-3")); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	_, _, err = c.PatchInteraction(context.Background(), "i-3", client.Patch{Body: []byte(`{"base_query":null}`)})
+	if !errors.As(err, &problem) || !errors.Is(err, client.ErrInvalid) || len(problem.Errors) == 0 {
+		t.Errorf("invalid patch error = %v, want field errors", err)
+	}
+}
+
+func TestCreateIsRetriedWithItsIdempotencyKey(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	// the server creates the interaction but the response is lost, the retry replays it
+	ts.lose.Store(1)
+	created, err := c.CreateInteraction(ctx, newInteraction("i-3"))
+	if err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	if created.ID != "i-3" {
+		t.Errorf("created = %q, want i-3", created.ID)
+	}
+
+	// a create with the same key from another process replays the first response
+	keyed := client.WithIdempotencyKey(ctx, "create-i-4")
+	first, err := c.CreateInteraction(keyed, newInteraction("i-4"))
+	if err != nil {
+		t.Fatalf("CreateInteraction with key: %v", err)
+	}
+	replayed, err := c.CreateInteraction(keyed, newInteraction("i-4"))
+	if err != nil {
+		t.Fatalf("CreateInteraction again with key: %v", err)
+	}
+	if !replayed.CreatedAt.Equal(first.CreatedAt) {
+		t.Errorf("replayed create at %v, want the response of the create at %v", replayed.CreatedAt, first.CreatedAt)
+	}
+}
+
+func TestIdempotencyKeyBoundToItsRequest(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+	var problem *client.Error
+
+	keyed := client.WithIdempotencyKey(ctx, "create-i-9")
+	if _, err := c.CreateInteraction(keyed, newInteraction("i-9")); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	_, err := c.CreateInteraction(keyed, newInteraction("i-10"))
+	if !errors.As(err, &problem) || problem.Status != http.StatusUnprocessableEntity {
+		t.Errorf("key reused for another body error = %v, want 422", err)
+	}
+	if _, err = c.GetInteraction(ctx, "i-10"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetInteraction of the rejected create error = %v, want not found", err)
+	}
+
+	// a request whose response is not stored yet is still being served
+	ts.idempotency.mu.Lock()
+	ts.idempotency.responses["create-i-9"].Status = 0
+	ts.idempotency.mu.Unlock()
+	if _, err = c.CreateInteraction(keyed, newInteraction("i-9")); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("retry of a request in flight error = %v, want a conflict", err)
+	}
+
+	// a failed request releases its key, the retry is served again
+	retried := client.WithIdempotencyKey(ctx, "create-s-1")
+	step := &runtime.Step{ID: "s-1", Name: "lookup"}
+	if _, err = c.CreateStep(retried, "i-11", "flow-1", "graph-1", step); !errors.Is(err, client.ErrNotFound) {
+		t.Fatalf("create of a step of a missing interaction error = %v, want not found", err)
+	}
+	if _, err = c.CreateInteraction(ctx, newInteraction("i-11")); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	if _, err = c.CreateStep(retried, "i-11", "flow-1", "graph-1", step); err != nil {
+		t.Errorf("retry after the failed request error = %v, want it served", err)
+	}
+}
+
+func TestWorkflowsThroughTheClient(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	if _, err := c.CreateInteraction(ctx, newInteraction("i-13")); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	flow := &runtime.ExecutionFlow{ID: "flow-2", Name: "escalate", ExecutionGraph: &runtime.ExecutionGraph{ID: "graph-2"}}
+	created, err := c.CreateWorkflow(ctx, "i-13", flow, false)
+	if err != nil {
+		t.Fatalf("CreateWorkflow: %v", err)
+	}
+	if created.ID != "flow-2" || created.Active {
+		t.Errorf("created = %q active %v, want the inactive flow-2", created.ID, created.Active)
+	}
+	if _, err = c.CreateWorkflow(ctx, "i-13", flow, false); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("second create of flow-2 error = %v, want a conflict", err)
+	}
+	workflows, err := c.ListWorkflows(ctx, "i-13")
+	if err != nil {
+		t.Fatalf("ListWorkflows: %v", err)
+	}
+	if len(workflows) != 2 {
+		t.Errorf("workflows = %d, want the active one and flow-2", len(workflows))
+	}
+
+	// an activation is a POST without an idempotency key, a lost response is not sent again
+	ts.lose.Store(1)
+	if _, err = c.ActivateWorkflow(ctx, "i-13", "flow-2"); !errors.Is(err, client.ErrUnavailable) {
+		t.Errorf("activate with a lost response error = %v, want unavailable", err)
+	}
+	interaction, err := c.GetInteraction(ctx, "i-13")
+	if err != nil {
+		t.Fatalf("GetInteraction: %v", err)
+	}
+	if interaction.ExecutionFlow.ID != "flow-2" {
+		t.Errorf("active flow = %q, want flow-2 activated by the one request", interaction.ExecutionFlow.ID)
+	}
+	previous, err := c.GetWorkflow(ctx, "i-13", "flow-1")
+	if err != nil {
+		t.Fatalf("GetWorkflow: %v", err)
+	}
+	if previous.Active {
+		t.Errorf("flow-1 is still active")
+	}
+	if err = c.DeleteWorkflow(ctx, "i-13", "flow-1"); err != nil {
+		t.Fatalf("DeleteWorkflow: %v", err)
+	}
+	if _, err = c.GetWorkflow(ctx, "i-13", "flow-1"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetWorkflow after delete error = %v, want not found", err)
+	}
+}
+
+func TestStepsLocatedById(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	if _, err := c.CreateInteraction(ctx, newInteraction("i-5")); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	step, err := c.CreateStep(ctx, "i-5", "flow-1", "graph-1", &runtime.Step{ID: "s-1", Name: "lookup"})
+	if err != nil {
+		t.Fatalf("CreateStep: %v", err)
+	}
+	if step.ID != "s-1" {
+		t.Fatalf("step = %q, want s-1", step.ID)
+	}
+	step, err = c.UpdateStepStatusById(ctx, "i-5", "s-1", runtime.StatusRunning)
+	if err != nil {
+		t.Fatalf("UpdateStepStatusById: %v", err)
+	}
+	if step.Status != runtime.StatusRunning {
+		t.Errorf("status = %q, want running", step.Status)
+	}
+	step, err = c.GetStep(ctx, "i-5", "flow-1", "graph-1", "s-1")
+	if err != nil {
+		t.Fatalf("GetStep: %v", err)
+	}
+	if step.Status != runtime.StatusRunning {
+		t.Errorf("status of the nested route = %q, want running", step.Status)
+	}
+	if _, _, err = c.PatchStepById(ctx, "i-5", "s-1", client.Patch{Body: []byte(`{"status":"bogus"}`)}); !errors.Is(err, client.ErrInvalid) {
+		t.Errorf("patch to an unknown status error = %v, want invalid", err)
+	}
+	if err = c.DeleteStepById(ctx, "i-5", "s-1"); err != nil {
+		t.Fatalf("DeleteStepById: %v", err)
+	}
+	if _, err = c.GetStepById(ctx, "i-5", "s-1"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetStepById after delete error = %v, want not found", err)
+	}
+}
+
+func TestStepsBelongToTheExecutionGraph(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	if _, err := c.CreateInteraction(ctx, newInteraction("i-6")); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	orphan := &runtime.Step{ID: "s-1", Name: "lookup"}
+	if _, err := c.UpdateStep(ctx, "i-6", "flow-1", "missing", orphan); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("update in a missing execution error = %v, want not found", err)
+	}
+	if _, err := c.UpdateStep(ctx, "i-6", "flow-1", "graph-1", orphan); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("update of a step not in the graph error = %v, want not found", err)
+	}
+
+	for _, id := range []string{"s-1", "s-2"} {
+		if _, err := c.CreateStep(ctx, "i-6", "flow-1", "graph-1", &runtime.Step{ID: id, Name: id}); err != nil {
+			t.Fatalf("CreateStep %s: %v", id, err)
+		}
+	}
+	if _, err := c.CreateStep(ctx, "i-6", "flow-1", "graph-1", &runtime.Step{ID: "s-1", Name: "again"}); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("create of an existing step error = %v, want a conflict", err)
+	}
+	if err := c.DeleteStep(ctx, "i-6", "flow-1", "graph-1", "s-1"); err != nil {
+		t.Fatalf("DeleteStep: %v", err)
+	}
+	interaction, err := c.GetInteraction(ctx, "i-6")
+	if err != nil {
+		t.Fatalf("GetInteraction: %v", err)
+	}
+	nodes := interaction.ExecutionFlow.ExecutionGraph.Nodes
+	if len(nodes) != 1 || nodes[0].StepId != "s-2" {
+		t.Errorf("nodes = %+v, want only s-2", nodes)
+	}
+}
+
+func TestPlanCompiledAndReconciled(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	interaction := newInteraction("i-7")
+	interaction.Plan = &runtime.Plan{ID: "p-1", Steps: []runtime.PlanStep{
+		{ID: "fetch", Name: "fetch"},
+		{ID: "rank", Name: "rank", DependsOn: []string{"fetch"}},
+		{ID: "notify", Name: "notify"},
+	}}
+	if _, err := c.CreateInteraction(ctx, interaction); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	if _, err := c.CompilePlan(ctx, "i-7", "other", ""); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("compile of another plan error = %v, want a conflict", err)
+	}
+	compiled, err := c.CompilePlan(ctx, "i-7", "p-1", "parallel")
+	if err != nil {
+		t.Fatalf("CompilePlan: %v", err)
+	}
+	graph := compiled.ExecutionFlow.ExecutionGraph
+	if len(graph.Nodes) != 3 || compiled.ExecutionFlow.Mode != "parallel" || compiled.ExecutionFlow.PlanID != "p-1" {
+		t.Fatalf("compiled flow = %+v, want 3 nodes of plan p-1 in parallel", compiled.ExecutionFlow)
+	}
+	stepOf := make(map[string]string, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		stepOf[node.Name] = node.StepId
+		if node.Status != runtime.StatusPending {
+			t.Errorf("node %s is %q, want pending", node.Name, node.Status)
+		}
+	}
+	// in parallel mode only the dependency is an edge
+	if len(graph.Edges) != 1 || graph.Edges[0].From != stepOf["fetch"] || graph.Edges[0].To != stepOf["rank"] {
+		t.Errorf("edges = %+v, want fetch before rank", graph.Edges)
+	}
+	if _, err = c.CompilePlan(ctx, "i-7", "p-1", ""); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("second compile error = %v, want a conflict", err)
+	}
+
+	// the next revision drops notify and adds archive after rank
+	revised := &runtime.Plan{ID: "p-1", Steps: []runtime.PlanStep{
+		{ID: "fetch", Name: "fetch"},
+		{ID: "rank", Name: "rank", DependsOn: []string{"fetch"}},
+		{ID: "archive", Name: "archive", DependsOn: []string{"rank"}},
+	}}
+	if _, err = c.UpdatePlan(ctx, "i-7", "p-1", revised, "notify is manual"); err != nil {
+		t.Fatalf("UpdatePlan: %v", err)
+	}
+	result, err := c.ReconcilePlan(ctx, "i-7", 0)
+	if err != nil {
+		t.Fatalf("ReconcilePlan: %v", err)
+	}
+	if len(result.Added) != 1 || len(result.Cancelled) != 1 || result.Cancelled[0] != stepOf["notify"] {
+		t.Errorf("result = added %v cancelled %v, want archive added and notify cancelled", result.Added, result.Cancelled)
+	}
+	if !slices.Contains(result.Kept, stepOf["fetch"]) || !slices.Contains(result.Kept, stepOf["rank"]) {
+		t.Errorf("kept = %v, want the steps of fetch and rank", result.Kept)
+	}
+	stopped, err := c.GetStep(ctx, "i-7", compiled.ExecutionFlow.ID, graph.ID, stepOf["notify"])
+	if err != nil {
+		t.Fatalf("GetStep: %v", err)
+	}
+	if stopped.Status != runtime.StatusStop {
+		t.Errorf("notify step is %q, want stopped", stopped.Status)
+	}
+	if len(result.Interaction.ExecutionFlow.ExecutionGraph.Nodes) != 4 {
+		t.Errorf("nodes = %d, want the stopped node kept next to the added one", len(result.Interaction.ExecutionFlow.ExecutionGraph.Nodes))
+	}
+}
+
+func TestTemplateInstantiatedWithVariables(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	undeclared := &client.WorkflowTemplate{
+		ID:        "t-0",
+		Name:      "broken",
+		BaseQuery: &runtime.Query{Content: "status of {{order}}"},
+	}
+	if _, err := c.CreateTemplate(ctx, undeclared); !errors.Is(err, client.ErrInvalid) {
+		t.Errorf("template with an undeclared placeholder error = %v, want invalid", err)
+	}
+
+	template := &client.WorkflowTemplate{
+		ID:   "t-1",
+		Name: "order status",
+		Variables: []model.TemplateVariable{
+			{Name: "customer", Required: true},
+			{Name: "region", Default: "eu"},
+		},
+		BaseQuery: &runtime.Query{Content: "status of {{customer}} in {{region}}"},
+		ExecutionFlow: runtime.ExecutionFlow{
+			Name: "status for {{customer}}",
+			ExecutionGraph: &runtime.ExecutionGraph{
+				Nodes: []runtime.ExecutionNode{{StepId: "lookup", Name: "lookup"}},
+			},
+		},
+		Steps: []runtime.Step{{ID: "lookup", Name: "lookup {{customer}}"}},
+	}
+	if _, err := c.CreateTemplate(ctx, template); err != nil {
+		t.Fatalf("CreateTemplate: %v", err)
+	}
+	if _, err := c.InstantiateTemplate(ctx, "t-1", &client.InstantiateRequest{InteractionId: "i-8"}); !errors.Is(err, client.ErrInvalid) {
+		t.Errorf("instantiate without a required variable error = %v, want invalid", err)
+	}
+
+	interaction, err := c.InstantiateTemplate(ctx, "t-1", &client.InstantiateRequest{
+		InteractionId: "i-8",
+		Variables:     map[string]string{"customer": "acme"},
+	})
+	if err != nil {
+		t.Fatalf("InstantiateTemplate: %v", err)
+	}
+	if interaction.BaseQuery == nil || interaction.BaseQuery.Content != "status of acme in eu" {
+		t.Errorf("base query = %+v, want the variable and the default substituted", interaction.BaseQuery)
+	}
+	flow := interaction.ExecutionFlow
+	if flow.Name != "status for acme" || flow.Variables["customer"] != "acme" || flow.Variables["region"] != "eu" {
+		t.Errorf("flow = %q with variables %v, want the substituted name and both variables", flow.Name, flow.Variables)
+	}
+	nodes := flow.ExecutionGraph.Nodes
+	if len(nodes) != 1 || nodes[0].StepId == "lookup" {
+		t.Fatalf("nodes = %+v, want one node with a fresh step id", nodes)
+	}
+	step, err := c.GetStep(ctx, "i-8", flow.ID, flow.ExecutionGraph.ID, nodes[0].StepId)
+	if err != nil {
+		t.Fatalf("GetStep: %v", err)
+	}
+	if step.Name != "lookup acme" || step.Status != runtime.StatusPending {
+		t.Errorf("step = %q %q, want the substituted pending step", step.Name, step.Status)
+	}
+
+	stored, err := c.GetTemplate(ctx, "t-1")
+	if err != nil {
+		t.Fatalf("GetTemplate: %v", err)
+	}
+	if stored.BaseQuery.Content != "status of {{customer}} in {{region}}" {
+		t.Errorf("stored base query = %q, want the placeholders kept", stored.BaseQuery.Content)
+	}
+	if _, err = c.InstantiateTemplate(ctx, "t-1", &client.InstantiateRequest{InteractionId: "i-8", Variables: map[string]string{"customer": "acme"}}); !errors.Is(err, client.ErrConflict) {
+		t.Errorf("instantiate into an existing interaction error = %v, want a conflict", err)
+	}
+}
+
+func TestBatchCommittedAsAWhole(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+	ctx := context.Background()
+
+	if _, err := c.CreateInteraction(ctx, newInteraction("i-12")); err != nil {
+		t.Fatalf("CreateInteraction: %v", err)
+	}
+	if _, err := c.ExecuteBatch(ctx, &client.BatchRequest{InteractionId: "i-12"}); !errors.Is(err, client.ErrInvalid) {
+		t.Errorf("empty batch error = %v, want invalid", err)
+	}
+
+	step := func(op client.BatchOp, stepId string) client.BatchOperation {
+		return client.BatchOperation{Op: op, WorkflowId: "flow-1", ExecutionId: "graph-1", StepId: stepId}
+	}
+	create := step(model.BatchCreateStep, "")
+	create.Step = &runtime.Step{ID: "s-1", Name: "lookup"}
+	running := step(model.BatchUpdateStepStatus, "s-1")
+	running.Status = runtime.StatusRunning
+	artifact := step(model.BatchAddArtifact, "s-1")
+	artifact.Artifact = &runtime.Artifact{ID: "a-1", Name: "orders", Type: "query_result"}
+	summary := client.BatchOperation{Op: model.BatchPatchInteraction, Patch: []byte(`{"summary":"looked up"}`)}
+	resp, err := c.ExecuteBatch(ctx, &client.BatchRequest{
+		InteractionId: "i-12",
+		Operations:    []client.BatchOperation{create, running, artifact, summary},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteBatch: %v", err)
+	}
+	if len(resp.Results) != 4 || resp.Results[1].Op != model.BatchUpdateStepStatus {
+		t.Errorf("results = %+v, want one per operation in order", resp.Results)
+	}
+	got, err := c.GetStep(ctx, "i-12", "flow-1", "graph-1", "s-1")
+	if err != nil {
+		t.Fatalf("GetStep: %v", err)
+	}
+	if got.Status != runtime.StatusRunning || len(got.Artifacts) != 1 {
+		t.Errorf("step = %q with %d artifacts, want running with the artifact", got.Status, len(got.Artifacts))
+	}
+	interaction, err := c.GetInteraction(ctx, "i-12")
+	if err != nil {
+		t.Fatalf("GetInteraction: %v", err)
+	}
+	nodes := interaction.ExecutionFlow.ExecutionGraph.Nodes
+	if interaction.Summary != "looked up" || len(nodes) != 1 || nodes[0].Status != runtime.StatusRunning {
+		t.Errorf("interaction = %q with nodes %+v, want the summary and the running node", interaction.Summary, nodes)
+	}
+
+	// the second operation fails, the step of the first one is not written either
+	second := step(model.BatchCreateStep, "")
+	second.Step = &runtime.Step{ID: "s-2", Name: "rank"}
+	missing := step(model.BatchUpdateStepStatus, "missing")
+	missing.Status = runtime.StatusRunning
+	_, err = c.ExecuteBatch(ctx, &client.BatchRequest{
+		InteractionId: "i-12",
+		Operations:    []client.BatchOperation{second, missing},
+	})
+	var problem *client.Error
+	if !errors.As(err, &problem) || !errors.Is(err, client.ErrNotFound) || !strings.Contains(problem.Detail, "operation 1") {
+		t.Errorf("failed batch error = %v, want the not found of operation 1", err)
+	}
+	if _, err = c.GetStep(ctx, "i-12", "flow-1", "graph-1", "s-2"); !errors.Is(err, client.ErrNotFound) {
+		t.Errorf("GetStep of the failed batch error = %v, want not found", err)
+	}
+	interaction, err = c.GetInteraction(ctx, "i-12")
+	if err != nil {
+		t.Fatalf("GetInteraction: %v", err)
+	}
+	if n := len(interaction.ExecutionFlow.ExecutionGraph.Nodes); n != 1 {
+		t.Errorf("nodes = %d after the failed batch, want 1", n)
+	}
+}
+
+func TestOpenAPIDocumentsTheRoutes(t *testing.T) {
+	ts := newTestServer(t)
+	c := newClient(t, ts)
+
+	raw, err := c.GetOpenAPI(context.Background())
+	if err != nil {
+		t.Fatalf("GetOpenAPI: %v", err)
+	}
+	var doc openapi.Document
+	if err = json.Unmarshal(raw, &doc); err != nil {
+		t.Fatalf("decoding document: %v", err)
+	}
+	for path, want := range map[string]map[string]string{
+		"/api/v1/interactions":                                       {"post": "createInteraction"},
+		"/api/v1/interactions/{interactionId}":                       {"get": "getInteraction", "patch": "patchInteraction"},
+		"/api/v1/steps/{stepId}":                                     {"get": "getStepById", "delete": "deleteStepById"},
+		"/api/v1/interactions/{interactionId}/steps/{stepId}/status": {"post": "updateStepStatusOfInteraction"},
+		"/api/v1/openapi.json":                                       {"get": "getOpenAPI"},
+	} {
+		item, ok := doc.Paths[path]
+		if !ok {
+			t.Errorf("path %s is missing", path)
+			continue
+		}
+		for method, id := range want {
+			op, ok := (*item)[method]
+			if !ok {
+				t.Errorf("%s %s is missing", method, path)
+				continue
+			}
+			if op.OperationId != id {
+				t.Errorf("%s %s has operation id %q, want %q", method, path, op.OperationId, id)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/mangudaigb/state-service/internal/errs"
+)
+
+// The kinds of the errors of the api, an *Error matches the kind of its status with errors.Is
+var (
+	ErrNotFound           = errs.ErrNotFound
+	ErrConflict           = errs.ErrConflict
+	ErrInvalid            = errs.ErrInvalid
+	ErrUnavailable        = errs.ErrUnavailable
+	ErrPreconditionFailed = errors.New("precondition failed")
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is the problem details body of a failed request
+type Error struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestId string       `json:"requestId,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Title
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.RequestId != "" {
+		msg += " (request id: " + e.RequestId + ")"
+	}
+	return msg
+}
+
+func (e *Error) Is(target error) bool {
+	switch e.Status {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusPreconditionFailed:
+		// a stale revision is a conflicting write, like on the server
+		return target == ErrPreconditionFailed || target == ErrConflict
+	case http.StatusBadRequest, http.StatusUnprocessableEntity, http.StatusUnsupportedMediaType:
+		return target == ErrInvalid
+	case http.StatusServiceUnavailable:
+		return target == ErrUnavailable
+	default:
+		return false
+	}
+}
+
+// errorOf reads the error of a failed response, a body that is no problem becomes the detail
+func errorOf(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	e := &Error{}
+	if err := json.Unmarshal(body, e); err != nil || e.Status == 0 {
+		e = &Error{Title: http.StatusText(resp.StatusCode), Detail: strings.TrimSpace(string(body))}
+	}
+	e.Status = resp.StatusCode
+	if e.RequestId == "" {
+		e.RequestId = resp.Header.Get(HeaderRequestId)
+	}
+	return e
+}
@@ -0,0 +1,260 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+)
+
+// StreamOptions select the events of StreamEvents
+type StreamOptions struct {
+	// Types are the event types to receive, all types when empty
+	Types []EventType
+	// LastEventId resumes the stream after the event of that stream id
+	LastEventId string
+}
+
+// StreamedEvent is an event with the stream id to resume after it, which is empty for the events
+// of a websocket subscription
+type StreamedEvent struct {
+	StreamId string
+	Event
+}
+
+// EventStream delivers events until its context is done or it fails. Err tells why the channel of
+// Events was closed, it is nil when the context ended the stream.
+type EventStream struct {
+	events chan StreamedEvent
+	mu     sync.Mutex
+	err    error
+}
+
+func (s *EventStream) Events() <-chan StreamedEvent {
+	return s.events
+}
+
+func (s *EventStream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+func (s *EventStream) close(err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	close(s.events)
+}
+
+// send delivers an event unless ctx is done first
+func (s *EventStream) send(ctx context.Context, evt StreamedEvent) bool {
+	select {
+	case s.events <- evt:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// StreamEvents streams the events of the interaction from its server-sent event stream. A dropped
+// connection is resumed after the last received event, retrying like the other requests; an error
+// response, like for an interaction that was deleted, ends the stream.
+func (c *Client) StreamEvents(ctx context.Context, interactionId string, opts StreamOptions) (*EventStream, error) {
+	query := url.Values{}
+	if len(opts.Types) > 0 {
+		types := make([]string, len(opts.Types))
+		for i, t := range opts.Types {
+			types[i] = string(t)
+		}
+		query.Set("types", strings.Join(types, ","))
+	}
+	u := c.url(interactionPath(interactionId)+"/events", query)
+	// the stream lasts longer than any client timeout
+	hc := &http.Client{Transport: c.http.Transport, Jar: c.http.Jar}
+
+	resp, err := c.connect(ctx, hc, u, opts.LastEventId)
+	if err != nil {
+		return nil, err
+	}
+	stream := &EventStream{events: make(chan StreamedEvent)}
+	go func() {
+		lastEventId := opts.LastEventId
+		for attempt := 0; ; {
+			received, err := stream.read(ctx, resp.Body, &lastEventId)
+			_ = resp.Body.Close()
+			if ctx.Err() != nil {
+				stream.close(nil)
+				return
+			}
+			if received {
+				attempt = 0
+			}
+			for {
+				if attempt >= c.maxRetries {
+					stream.close(err)
+					return
+				}
+				if werr := c.wait(ctx, attempt, nil); werr != nil {
+					stream.close(nil)
+					return
+				}
+				attempt++
+				if resp, err = c.connect(ctx, hc, u, lastEventId); err == nil {
+					break
+				}
+				var apiErr *Error
+				if errors.As(err, &apiErr) && !retryStatus(apiErr.Status) {
+					stream.close(err)
+					return
+				}
+			}
+		}
+	}()
+	return stream, nil
+}
+
+func (c *Client) connect(ctx context.Context, hc *http.Client, u, lastEventId string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventId != "" {
+		req.Header.Set("Last-Event-ID", lastEventId)
+	}
+	c.setHeaders(ctx, req.Header)
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer drain(resp)
+		return nil, errorOf(resp)
+	}
+	return resp, nil
+}
+
+// read delivers the events of one connection, keeping lastEventId at the id of the last one. It
+// reports whether it received an event and the error that ended the connection.
+func (s *EventStream) read(ctx context.Context, body io.Reader, lastEventId *string) (bool, error) {
+	reader := bufio.NewReader(body)
+	received := false
+	var id string
+	var data []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+			return received, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "id":
+				id = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+		// a blank line ends an event, heartbeat comments have no data
+		if len(data) == 0 {
+			continue
+		}
+		evt := StreamedEvent{StreamId: id}
+		if err = json.Unmarshal([]byte(strings.Join(data, "\n")), &evt.Event); err != nil {
+			return received, fmt.Errorf("decoding event %s: %w", id, err)
+		}
+		data = data[:0]
+		if !s.send(ctx, evt) {
+			return received, ctx.Err()
+		}
+		received = true
+		if id != "" {
+			*lastEventId = id
+		}
+	}
+}
+
+// subscriptionMessage is a message of the subscriptions websocket
+type subscriptionMessage struct {
+	Type   string             `json:"type,omitempty"`
+	Action string             `json:"action,omitempty"`
+	ID     string             `json:"id,omitempty"`
+	Filter SubscriptionFilter `json:"filter"`
+	Error  string             `json:"error,omitempty"`
+	Event  *Event             `json:"event,omitempty"`
+}
+
+// Subscribe receives the events matching the filter, of any interaction, over the subscriptions
+// websocket. Events the server dropped for a slow reader are not delivered again and a closed
+// connection ends the stream.
+func (c *Client) Subscribe(ctx context.Context, filter SubscriptionFilter) (*EventStream, error) {
+	u := c.url("/subscriptions", nil)
+	u = "ws" + strings.TrimPrefix(u, "http")
+	header := http.Header{}
+	c.setHeaders(ctx, header)
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u, header)
+	if err != nil {
+		if resp != nil && resp.StatusCode >= http.StatusBadRequest {
+			return nil, errorOf(resp)
+		}
+		return nil, err
+	}
+	id := uuid.NewString()
+	if err = conn.WriteJSON(subscriptionMessage{Action: "subscribe", ID: id, Filter: filter}); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	stream := &EventStream{events: make(chan StreamedEvent)}
+	done := make(chan struct{})
+	go func() {
+		// closing the connection unblocks the read when ctx is done
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+		_ = conn.Close()
+	}()
+	go func() {
+		defer close(done)
+		for {
+			var msg subscriptionMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				if ctx.Err() != nil {
+					err = nil
+				}
+				stream.close(err)
+				return
+			}
+			switch msg.Type {
+			case "event":
+				if msg.Event != nil && !stream.send(ctx, StreamedEvent{Event: *msg.Event}) {
+					stream.close(nil)
+					return
+				}
+			case "error":
+				stream.close(fmt.Errorf("subscription %s: %s", msg.ID, msg.Error))
+				return
+			}
+		}
+	}()
+	return stream, nil
+}
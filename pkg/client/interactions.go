@@ -0,0 +1,164 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+func interactionPath(interactionId string) string {
+	return "/interactions/" + segment(interactionId)
+}
+
+func workflowPath(interactionId, workflowId string) string {
+	return interactionPath(interactionId) + "/workflows/" + segment(workflowId)
+}
+
+func executionPath(interactionId, workflowId, executionId string) string {
+	return workflowPath(interactionId, workflowId) + "/executions/" + segment(executionId)
+}
+
+func (c *Client) CreateInteraction(ctx context.Context, interaction *runtime.Interaction) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: "/interactions", body: interaction, idempotent: true}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) GetInteraction(ctx context.Context, interactionId string) (*runtime.Interaction, error) {
+	interaction, _, err := c.GetInteractionRevision(ctx, interactionId)
+	return interaction, err
+}
+
+// GetInteractionRevision returns the interaction with its revision, the IfMatch of a Patch
+func (c *Client) GetInteractionRevision(ctx context.Context, interactionId string) (*runtime.Interaction, int64, error) {
+	var out runtime.Interaction
+	header, err := c.do(ctx, &request{method: http.MethodGet, path: interactionPath(interactionId)}, &out)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &out, revisionOf(header), nil
+}
+
+// GetInteractionAt returns the interaction as it was at the time
+func (c *Client) GetInteractionAt(ctx context.Context, interactionId string, at time.Time) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	query := url.Values{"at": {at.Format(time.RFC3339Nano)}}
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: interactionPath(interactionId), query: query}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) UpdateInteraction(ctx context.Context, interaction *runtime.Interaction) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: interactionPath(interaction.ID), body: interaction}, &out)
+	return ret(&out, err)
+}
+
+// PatchInteraction returns the patched interaction with its new revision
+func (c *Client) PatchInteraction(ctx context.Context, interactionId string, patch Patch) (*runtime.Interaction, int64, error) {
+	var out runtime.Interaction
+	header, err := c.do(ctx, patch.request(interactionPath(interactionId)), &out)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &out, revisionOf(header), nil
+}
+
+func (c *Client) DeleteInteraction(ctx context.Context, interactionId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: interactionPath(interactionId)}, nil)
+	return err
+}
+
+func (c *Client) GetInteractionHistory(ctx context.Context, interactionId string) ([]HistoryRecord, error) {
+	var out []HistoryRecord
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: interactionPath(interactionId) + "/history"}, &out)
+	return out, err
+}
+
+// GetInteractionSnapshot returns the interaction with the steps of its workflows as they were at
+// the time
+func (c *Client) GetInteractionSnapshot(ctx context.Context, interactionId string, at time.Time) (*InteractionSnapshot, error) {
+	var out InteractionSnapshot
+	query := url.Values{"at": {at.Format(time.RFC3339Nano)}}
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: interactionPath(interactionId) + "/snapshot", query: query}, &out)
+	return ret(&out, err)
+}
+
+// ListWorkflows returns the workflows of the interaction, the active one first
+func (c *Client) ListWorkflows(ctx context.Context, interactionId string) ([]Workflow, error) {
+	var out []Workflow
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: interactionPath(interactionId) + "/workflows"}, &out)
+	return out, err
+}
+
+func (c *Client) GetWorkflow(ctx context.Context, interactionId, workflowId string) (*Workflow, error) {
+	var out Workflow
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: workflowPath(interactionId, workflowId)}, &out)
+	return ret(&out, err)
+}
+
+// CreateWorkflow adds the workflow to the interaction, activate makes it the execution flow of the
+// interaction in place of the active one
+func (c *Client) CreateWorkflow(ctx context.Context, interactionId string, flow *runtime.ExecutionFlow, activate bool) (*Workflow, error) {
+	var out Workflow
+	body := CreateWorkflowRequest{Workflow: *flow, Activate: activate}
+	path := interactionPath(interactionId) + "/workflows"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: body, idempotent: true}, &out)
+	return ret(&out, err)
+}
+
+// ActivateWorkflow makes the workflow the execution flow of the interaction
+func (c *Client) ActivateWorkflow(ctx context.Context, interactionId, workflowId string) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: workflowPath(interactionId, workflowId) + "/activate"}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) DeleteWorkflow(ctx context.Context, interactionId, workflowId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: workflowPath(interactionId, workflowId)}, nil)
+	return err
+}
+
+func (c *Client) UpdateWorkflow(ctx context.Context, interactionId, workflowId string, flow *runtime.ExecutionFlow) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: workflowPath(interactionId, workflowId), body: flow}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) UpdateExecutionGraph(ctx context.Context, interactionId, workflowId, executionId string, graph *runtime.ExecutionGraph) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: executionPath(interactionId, workflowId, executionId), body: graph}, &out)
+	return ret(&out, err)
+}
+
+// CreateChild creates a child interaction under a new node of the execution graph, the status of
+// the node then follows the child
+func (c *Client) CreateChild(ctx context.Context, interactionId, workflowId, executionId string, req *CreateChildRequest) (*ChildLink, error) {
+	var out ChildLink
+	path := executionPath(interactionId, workflowId, executionId) + "/children"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: req, idempotent: true}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) ListChildren(ctx context.Context, interactionId string) ([]ChildLink, error) {
+	var out []ChildLink
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: interactionPath(interactionId) + "/children"}, &out)
+	return out, err
+}
+
+// GetInteractionTree returns the interaction with its child interactions, nested
+func (c *Client) GetInteractionTree(ctx context.Context, interactionId string) (*InteractionTree, error) {
+	var out InteractionTree
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: interactionPath(interactionId) + "/tree"}, &out)
+	return ret(&out, err)
+}
+
+// ret returns out, or nil with the error of the request
+func ret[T any](out *T, err error) (*T, error) {
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+func mcpPath(interactionId, workflowId, mcpId string) string {
+	return workflowPath(interactionId, workflowId) + "/mcps/" + segment(mcpId)
+}
+
+func (c *Client) CreateMcp(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) (*runtime.MCP, error) {
+	var out runtime.MCP
+	path := workflowPath(interactionId, workflowId) + "/mcps"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: mcp, idempotent: true}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) GetMcp(ctx context.Context, interactionId, workflowId, mcpId string) (*runtime.MCP, error) {
+	var out runtime.MCP
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: mcpPath(interactionId, workflowId, mcpId)}, &out)
+	return ret(&out, err)
+}
+
+// GetMcpHistory returns the recorded writes of the mcp, oldest first
+func (c *Client) GetMcpHistory(ctx context.Context, interactionId, workflowId, mcpId string) ([]HistoryRecord, error) {
+	var out []HistoryRecord
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: mcpPath(interactionId, workflowId, mcpId) + "/history"}, &out)
+	return out, err
+}
+
+func (c *Client) UpdateMcp(ctx context.Context, interactionId, workflowId string, mcp *runtime.MCP) (*runtime.MCP, error) {
+	var out runtime.MCP
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: mcpPath(interactionId, workflowId, mcp.ID), body: mcp}, &out)
+	return ret(&out, err)
+}
+
+// PatchMcp returns the patched mcp with its new revision
+func (c *Client) PatchMcp(ctx context.Context, interactionId, workflowId, mcpId string, patch Patch) (*runtime.MCP, int64, error) {
+	var out runtime.MCP
+	header, err := c.do(ctx, patch.request(mcpPath(interactionId, workflowId, mcpId)), &out)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &out, revisionOf(header), nil
+}
+
+func (c *Client) DeleteMcp(ctx context.Context, interactionId, workflowId, mcpId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: mcpPath(interactionId, workflowId, mcpId)}, nil)
+	return err
+}
+
+func (c *Client) AddTool(ctx context.Context, interactionId, workflowId, mcpId string, tool *runtime.Tool) (*runtime.MCP, error) {
+	var out runtime.MCP
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: mcpPath(interactionId, workflowId, mcpId) + "/tools", body: tool}, &out)
+	return ret(&out, err)
+}
+
+// SyncTools replaces the tools of the mcp with the tools its server lists
+func (c *Client) SyncTools(ctx context.Context, interactionId, workflowId, mcpId string) (*McpConnection, error) {
+	var out McpConnection
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: mcpPath(interactionId, workflowId, mcpId) + "/tools/sync"}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) GetMcpConnection(ctx context.Context, interactionId, workflowId, mcpId string) (*McpConnection, error) {
+	var out McpConnection
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: mcpPath(interactionId, workflowId, mcpId) + "/connection"}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) UpdateMcpConnection(ctx context.Context, interactionId, workflowId, mcpId string, endpoint *McpEndpoint) (*McpConnection, error) {
+	var out McpConnection
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: mcpPath(interactionId, workflowId, mcpId) + "/connection", body: endpoint}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) DeleteMcpConnection(ctx context.Context, interactionId, workflowId, mcpId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: mcpPath(interactionId, workflowId, mcpId) + "/connection"}, nil)
+	return err
+}
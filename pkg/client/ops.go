@@ -0,0 +1,96 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ListAuditEntries returns the audit entries matching the non zero fields of the query
+func (c *Client) ListAuditEntries(ctx context.Context, query AuditQuery) ([]*AuditEntry, error) {
+	values := url.Values{}
+	if query.InteractionId != "" {
+		values.Set("interactionId", query.InteractionId)
+	}
+	if query.Actor != "" {
+		values.Set("actor", query.Actor)
+	}
+	if !query.From.IsZero() {
+		values.Set("from", query.From.Format(time.RFC3339Nano))
+	}
+	if !query.To.IsZero() {
+		values.Set("to", query.To.Format(time.RFC3339Nano))
+	}
+	if query.Limit > 0 {
+		values.Set("limit", strconv.Itoa(query.Limit))
+	}
+	var out []*AuditEntry
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/audit", query: values}, &out)
+	return out, err
+}
+
+// ExecuteBatch applies the operations of the batch on its interaction as a whole
+func (c *Client) ExecuteBatch(ctx context.Context, batch *BatchRequest) (*BatchResponse, error) {
+	var out BatchResponse
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: "/batch", body: batch, idempotent: true}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) GetOutboxStats(ctx context.Context) (*OutboxStats, error) {
+	var out OutboxStats
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/outbox/stats"}, &out)
+	return ret(&out, err)
+}
+
+// GetOpenAPI returns the OpenAPI document of the api
+func (c *Client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/openapi.json"}, &out)
+	return out, err
+}
+
+type GraphQLError struct {
+	Message    string         `json:"message"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// GraphQLErrors are the errors of a graphql response, the fields they name resolved to null
+type GraphQLErrors []GraphQLError
+
+func (e GraphQLErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, qe := range e {
+		msgs[i] = qe.Message
+	}
+	return "graphql: " + strings.Join(msgs, "; ")
+}
+
+// GraphQL runs the query and decodes its data into out. The data that resolved is decoded even
+// when the response has errors, which are returned as GraphQLErrors.
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	body := map[string]any{"query": query}
+	if len(variables) > 0 {
+		body["variables"] = variables
+	}
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors GraphQLErrors   `json:"errors"`
+	}
+	if _, err := c.do(ctx, &request{method: http.MethodPost, path: "/graphql", body: body, safe: true}, &resp); err != nil {
+		return err
+	}
+	if out != nil && len(resp.Data) > 0 {
+		if err := json.Unmarshal(resp.Data, out); err != nil {
+			return err
+		}
+	}
+	if len(resp.Errors) > 0 {
+		return resp.Errors
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+func planPath(interactionId string) string {
+	return interactionPath(interactionId) + "/plans"
+}
+
+func reasonQuery(reason string) url.Values {
+	if reason == "" {
+		return nil
+	}
+	return url.Values{"reason": {reason}}
+}
+
+// Replan replaces the plan of the interaction with a new revision
+func (c *Client) Replan(ctx context.Context, interactionId string, plan *runtime.Plan, reason string) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: planPath(interactionId), query: reasonQuery(reason), body: plan}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) UpdatePlan(ctx context.Context, interactionId, planId string, plan *runtime.Plan, reason string) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: planPath(interactionId) + "/" + segment(planId), query: reasonQuery(reason), body: plan}, &out)
+	return ret(&out, err)
+}
+
+// CompilePlan builds the execution flow of the plan, mode is the mode of the flow
+func (c *Client) CompilePlan(ctx context.Context, interactionId, planId, mode string) (*runtime.Interaction, error) {
+	var query url.Values
+	if mode != "" {
+		query = url.Values{"mode": {mode}}
+	}
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: planPath(interactionId) + "/" + segment(planId) + "/compile", query: query}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) ListPlanRevisions(ctx context.Context, interactionId string) ([]PlanRevision, error) {
+	var out []PlanRevision
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: planPath(interactionId) + "/revisions"}, &out)
+	return out, err
+}
+
+func (c *Client) GetPlanRevision(ctx context.Context, interactionId string, revision int) (*PlanRevision, error) {
+	var out PlanRevision
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: planPath(interactionId) + "/revisions/" + strconv.Itoa(revision)}, &out)
+	return ret(&out, err)
+}
+
+// DiffPlanRevisions diffs revision from with revision to, the latest revision when to is 0
+func (c *Client) DiffPlanRevisions(ctx context.Context, interactionId string, from, to int) (*PlanDiff, error) {
+	query := url.Values{"from": {strconv.Itoa(from)}, "to": {strconv.Itoa(to)}}
+	var out PlanDiff
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: planPath(interactionId) + "/diff", query: query}, &out)
+	return ret(&out, err)
+}
+
+// ReconcilePlan reconciles the execution graph with a plan revision, the latest when revision is 0
+func (c *Client) ReconcilePlan(ctx context.Context, interactionId string, revision int) (*ReconcileResult, error) {
+	query := url.Values{"revision": {strconv.Itoa(revision)}}
+	var out ReconcileResult
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: planPath(interactionId) + "/reconcile", query: query}, &out)
+	return ret(&out, err)
+}
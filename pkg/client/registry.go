@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+// CreateAgent creates the agent with its first version
+func (c *Client) CreateAgent(ctx context.Context, agent *runtime.Agent, comment string) (*AgentVersion, error) {
+	var out AgentVersion
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: "/agents", body: agentVersionRequest{Agent: agent, Comment: comment}}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) ListAgents(ctx context.Context) ([]*AgentHead, error) {
+	var out []*AgentHead
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/agents"}, &out)
+	return out, err
+}
+
+// GetAgent returns the latest version of the agent
+func (c *Client) GetAgent(ctx context.Context, agentId string) (*AgentHead, error) {
+	var out AgentHead
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/agents/" + segment(agentId)}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) CreateAgentVersion(ctx context.Context, agentId string, agent *runtime.Agent, comment string) (*AgentVersion, error) {
+	var out AgentVersion
+	path := "/agents/" + segment(agentId) + "/versions"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: agentVersionRequest{Agent: agent, Comment: comment}}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) GetAgentVersion(ctx context.Context, agentId string, version int) (*AgentVersion, error) {
+	var out AgentVersion
+	path := "/agents/" + segment(agentId) + "/versions/" + strconv.Itoa(version)
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: path}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) CreateTemplate(ctx context.Context, template *WorkflowTemplate) (*WorkflowTemplate, error) {
+	var out WorkflowTemplate
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: "/templates", body: template}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) ListTemplates(ctx context.Context) ([]*WorkflowTemplate, error) {
+	var out []*WorkflowTemplate
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/templates"}, &out)
+	return out, err
+}
+
+func (c *Client) GetTemplate(ctx context.Context, templateId string) (*WorkflowTemplate, error) {
+	var out WorkflowTemplate
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/templates/" + segment(templateId)}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) UpdateTemplate(ctx context.Context, template *WorkflowTemplate) (*WorkflowTemplate, error) {
+	var out WorkflowTemplate
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: "/templates/" + segment(template.ID), body: template}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) DeleteTemplate(ctx context.Context, templateId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: "/templates/" + segment(templateId)}, nil)
+	return err
+}
+
+// InstantiateTemplate creates an interaction from the template
+func (c *Client) InstantiateTemplate(ctx context.Context, templateId string, req *InstantiateRequest) (*runtime.Interaction, error) {
+	var out runtime.Interaction
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: "/templates/" + segment(templateId) + "/instantiate", body: req}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	var out Webhook
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: "/webhooks", body: webhook}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) ListWebhooks(ctx context.Context) ([]*Webhook, error) {
+	var out []*Webhook
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/webhooks"}, &out)
+	return out, err
+}
+
+func (c *Client) GetWebhook(ctx context.Context, webhookId string) (*Webhook, error) {
+	var out Webhook
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/webhooks/" + segment(webhookId)}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, webhook *Webhook) (*Webhook, error) {
+	var out Webhook
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: "/webhooks/" + segment(webhook.ID), body: webhook}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, webhookId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: "/webhooks/" + segment(webhookId)}, nil)
+	return err
+}
+
+func (c *Client) ListWebhookDeliveries(ctx context.Context, webhookId string) ([]*WebhookDelivery, error) {
+	var out []*WebhookDelivery
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: "/webhooks/" + segment(webhookId) + "/deliveries"}, &out)
+	return out, err
+}
+
+func (c *Client) GetWebhookDelivery(ctx context.Context, webhookId, deliveryId string) (*WebhookDelivery, error) {
+	var out WebhookDelivery
+	path := "/webhooks/" + segment(webhookId) + "/deliveries/" + segment(deliveryId)
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: path}, &out)
+	return ret(&out, err)
+}
+
+// RedeliverWebhookDelivery sends the delivery again, the returned delivery is still pending
+func (c *Client) RedeliverWebhookDelivery(ctx context.Context, webhookId, deliveryId string) (*WebhookDelivery, error) {
+	var out WebhookDelivery
+	path := "/webhooks/" + segment(webhookId) + "/deliveries/" + segment(deliveryId) + "/redeliver"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path}, &out)
+	return ret(&out, err)
+}
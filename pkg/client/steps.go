@@ -0,0 +1,130 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+)
+
+func stepPath(interactionId, workflowId, executionId, stepId string) string {
+	return executionPath(interactionId, workflowId, executionId) + "/steps/" + segment(stepId)
+}
+
+func (c *Client) CreateStep(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) (*runtime.Step, error) {
+	var out runtime.Step
+	path := executionPath(interactionId, workflowId, executionId) + "/steps"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: step, idempotent: true}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) GetStep(ctx context.Context, interactionId, workflowId, executionId, stepId string) (*runtime.Step, error) {
+	var out runtime.Step
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: stepPath(interactionId, workflowId, executionId, stepId)}, &out)
+	return ret(&out, err)
+}
+
+// GetStepHistory returns the recorded writes of the step, oldest first
+func (c *Client) GetStepHistory(ctx context.Context, interactionId, workflowId, executionId, stepId string) ([]HistoryRecord, error) {
+	var out []HistoryRecord
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: stepPath(interactionId, workflowId, executionId, stepId) + "/history"}, &out)
+	return out, err
+}
+
+func (c *Client) UpdateStep(ctx context.Context, interactionId, workflowId, executionId string, step *runtime.Step) (*runtime.Step, error) {
+	var out runtime.Step
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: stepPath(interactionId, workflowId, executionId, step.ID), body: step}, &out)
+	return ret(&out, err)
+}
+
+// PatchStep returns the patched step with its new revision
+func (c *Client) PatchStep(ctx context.Context, interactionId, workflowId, executionId, stepId string, patch Patch) (*runtime.Step, int64, error) {
+	var out runtime.Step
+	header, err := c.do(ctx, patch.request(stepPath(interactionId, workflowId, executionId, stepId)), &out)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &out, revisionOf(header), nil
+}
+
+func (c *Client) UpdateStepStatus(ctx context.Context, interactionId, workflowId, executionId, stepId string, status runtime.Status) (*runtime.Step, error) {
+	var out runtime.Step
+	path := stepPath(interactionId, workflowId, executionId, stepId) + "/status"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: status}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) AddToolInvocation(ctx context.Context, interactionId, workflowId, executionId, stepId string, invocation *runtime.McpToolInvocation) (*runtime.Step, error) {
+	var out runtime.Step
+	path := stepPath(interactionId, workflowId, executionId, stepId) + "/tools"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: invocation}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) AddArtifact(ctx context.Context, interactionId, workflowId, executionId, stepId string, artifact *runtime.Artifact) (*runtime.Step, error) {
+	var out runtime.Step
+	path := stepPath(interactionId, workflowId, executionId, stepId) + "/artifacts"
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: path, body: artifact}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) DeleteStep(ctx context.Context, interactionId, workflowId, executionId, stepId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: stepPath(interactionId, workflowId, executionId, stepId)}, nil)
+	return err
+}
+
+// The steps located by their id, without the ids of their workflow and execution. A step id that
+// several interactions use fails with ErrConflict unless the interaction is given; interactionId
+// is empty for a step located across interactions.
+
+func locatedStepPath(interactionId, stepId string) string {
+	if interactionId == "" {
+		return "/steps/" + segment(stepId)
+	}
+	return interactionPath(interactionId) + "/steps/" + segment(stepId)
+}
+
+func (c *Client) GetStepById(ctx context.Context, interactionId, stepId string) (*runtime.Step, error) {
+	var out runtime.Step
+	_, err := c.do(ctx, &request{method: http.MethodGet, path: locatedStepPath(interactionId, stepId)}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) UpdateStepById(ctx context.Context, interactionId string, step *runtime.Step) (*runtime.Step, error) {
+	var out runtime.Step
+	_, err := c.do(ctx, &request{method: http.MethodPut, path: locatedStepPath(interactionId, step.ID), body: step}, &out)
+	return ret(&out, err)
+}
+
+// PatchStepById returns the patched step with its new revision
+func (c *Client) PatchStepById(ctx context.Context, interactionId, stepId string, patch Patch) (*runtime.Step, int64, error) {
+	var out runtime.Step
+	header, err := c.do(ctx, patch.request(locatedStepPath(interactionId, stepId)), &out)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &out, revisionOf(header), nil
+}
+
+func (c *Client) UpdateStepStatusById(ctx context.Context, interactionId, stepId string, status runtime.Status) (*runtime.Step, error) {
+	var out runtime.Step
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: locatedStepPath(interactionId, stepId) + "/status", body: status}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) AddToolInvocationById(ctx context.Context, interactionId, stepId string, invocation *runtime.McpToolInvocation) (*runtime.Step, error) {
+	var out runtime.Step
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: locatedStepPath(interactionId, stepId) + "/tools", body: invocation}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) AddArtifactById(ctx context.Context, interactionId, stepId string, artifact *runtime.Artifact) (*runtime.Step, error) {
+	var out runtime.Step
+	_, err := c.do(ctx, &request{method: http.MethodPost, path: locatedStepPath(interactionId, stepId) + "/artifacts", body: artifact}, &out)
+	return ret(&out, err)
+}
+
+func (c *Client) DeleteStepById(ctx context.Context, interactionId, stepId string) error {
+	_, err := c.do(ctx, &request{method: http.MethodDelete, path: locatedStepPath(interactionId, stepId)}, nil)
+	return err
+}
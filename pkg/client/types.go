@@ -0,0 +1,82 @@
+package client
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/mangudaigb/dhauli-base/types/runtime"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/model"
+)
+
+// The documents of the api. The runtime types are used as they are, the types of the service are
+// aliased here so that callers outside the module can name them.
+type (
+	AgentHead             = model.AgentHead
+	AgentVersion          = model.AgentVersion
+	AuditEntry            = model.AuditEntry
+	AuditQuery            = model.AuditQuery
+	BatchOp               = model.BatchOp
+	BatchOperation        = model.BatchOperation
+	BatchRequest          = model.BatchRequest
+	BatchResponse         = model.BatchResponse
+	ChildLink             = model.ChildLink
+	CreateChildRequest    = model.CreateChildRequest
+	CreateWorkflowRequest = model.CreateWorkflowRequest
+	Event                 = events.Event
+	EventType             = events.Type
+	HistoryRecord         = model.HistoryRecord
+	InstantiateRequest    = model.InstantiateRequest
+	InteractionSnapshot   = model.InteractionSnapshot
+	InteractionTree       = model.InteractionTree
+	McpConnection         = model.McpConnection
+	McpEndpoint           = model.McpEndpoint
+	OutboxStats           = model.OutboxStats
+	PlanDiff              = model.PlanDiff
+	PlanRevision          = model.PlanRevision
+	ReconcileResult       = model.ReconcileResult
+	SubscriptionFilter    = model.SubscriptionFilter
+	Webhook               = model.Webhook
+	WebhookDelivery       = model.WebhookDelivery
+	Workflow              = model.Workflow
+	WorkflowTemplate      = model.WorkflowTemplate
+)
+
+// The media types of a Patch
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// Patch is a merge patch or a JSON patch of a document. With IfMatch it applies only to that
+// revision of the document and fails with ErrPreconditionFailed on a newer one.
+type Patch struct {
+	ContentType string
+	Body        []byte
+	IfMatch     int64
+}
+
+// request is the request of p on path, it is not retried as a patch may not apply twice
+func (p Patch) request(path string) *request {
+	req := &request{method: http.MethodPatch, path: path, body: p.Body, contentType: p.ContentType}
+	if req.contentType == "" {
+		req.contentType = MergePatchType
+	}
+	if p.IfMatch > 0 {
+		req.header = http.Header{"If-Match": {`"` + strconv.FormatInt(p.IfMatch, 10) + `"`}}
+	}
+	return req
+}
+
+// revisionOf reads the revision of the ETag of a response, 0 without one
+func revisionOf(header http.Header) int64 {
+	revision, _ := strconv.ParseInt(strings.Trim(strings.TrimPrefix(header.Get("ETag"), "W/"), `"`), 10, 64)
+	return revision
+}
+
+// agentVersionRequest is the body of the agent creates
+type agentVersionRequest struct {
+	Agent   *runtime.Agent `json:"agent"`
+	Comment string         `json:"comment"`
+}
@@ -3,6 +3,7 @@ package pkg
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -12,8 +13,14 @@ import (
 	"github.com/mangudaigb/dhauli-base/config"
 	"github.com/mangudaigb/dhauli-base/logger"
 	"github.com/mangudaigb/state-service/internal"
+	"github.com/mangudaigb/state-service/internal/consumer"
+	"github.com/mangudaigb/state-service/internal/events"
+	"github.com/mangudaigb/state-service/internal/gql"
 	"github.com/mangudaigb/state-service/internal/handler"
+	"github.com/mangudaigb/state-service/internal/openapi"
 	"github.com/mangudaigb/state-service/internal/repo"
+	"github.com/mangudaigb/state-service/internal/rpc"
+	"github.com/mangudaigb/state-service/internal/settings"
 	"github.com/mangudaigb/state-service/internal/svc"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/net/context"
@@ -26,29 +33,129 @@ type StateServer struct {
 }
 
 func (ss *StateServer) Start() {
-	iRepo, err := repo.NewInteractionRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	oRepo, err := repo.NewOutboxRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating outbox repo: %v", err)
+	}
+	iRepo, err := repo.NewInteractionRepo(context.Background(), ss.cfg, ss.log, ss.tr, oRepo)
 	if err != nil {
 		ss.log.Fatalf("Error while creating interaction repo: %v", err)
 	}
-	mRepo, err := repo.NewMcpRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	mRepo, err := repo.NewMcpRepo(context.Background(), ss.cfg, ss.log, ss.tr, oRepo)
 	if err != nil {
 		ss.log.Fatalf("Error while creating mcp repo: %v", err)
 	}
-	sRepo, err := repo.NewStepRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	sRepo, err := repo.NewStepRepo(context.Background(), ss.cfg, ss.log, ss.tr, oRepo)
 	if err != nil {
 		ss.log.Fatalf("Error while creating step repo: %v", err)
 	}
+	wfRepo, err := repo.NewWorkflowRepo(context.Background(), ss.cfg, ss.log, ss.tr, oRepo)
+	if err != nil {
+		ss.log.Fatalf("Error while creating workflow repo: %v", err)
+	}
+	chRepo, err := repo.NewChildRepo(context.Background(), ss.cfg, ss.log, ss.tr, oRepo)
+	if err != nil {
+		ss.log.Fatalf("Error while creating child repo: %v", err)
+	}
+	iRepo = svc.NewChildSyncRepo(ss.log, iRepo, chRepo, wfRepo, oRepo)
+	mcRepo, err := repo.NewMcpConnectionRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating mcp connection repo: %v", err)
+	}
+	aRepo, err := repo.NewAgentRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating agent repo: %v", err)
+	}
+	pRepo, err := repo.NewPlanRepo(context.Background(), ss.cfg, ss.log, ss.tr, oRepo)
+	if err != nil {
+		ss.log.Fatalf("Error while creating plan repo: %v", err)
+	}
+	tRepo, err := repo.NewTemplateRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating template repo: %v", err)
+	}
+	cRepo, err := repo.NewCommandRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating command repo: %v", err)
+	}
+	esRepo, err := repo.NewEventStreamRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating event stream repo: %v", err)
+	}
+	auRepo, err := repo.NewAuditRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating audit repo: %v", err)
+	}
+	wRepo, err := repo.NewWebhookRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating webhook repo: %v", err)
+	}
+	wdRepo, err := repo.NewWebhookDeliveryRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating webhook delivery repo: %v", err)
+	}
+	idRepo, err := repo.NewIdempotencyRepo(context.Background(), ss.cfg, ss.log, ss.tr)
+	if err != nil {
+		ss.log.Fatalf("Error while creating idempotency repo: %v", err)
+	}
+
+	aSvc := svc.NewAgentService(ss.log, ss.tr, aRepo)
+	iSvc := svc.NewInteractionService(ss.log, ss.tr, iRepo, wfRepo, sRepo, aSvc)
+	mSvc := svc.NewMcpService(ss.log, ss.tr, mRepo, mcRepo, iRepo, wfRepo)
+	sSvc := svc.NewStepService(ss.log, ss.tr, sRepo, iRepo, wfRepo, chRepo, oRepo, aSvc)
+	wfSvc := svc.NewWorkflowService(ss.log, ss.tr, iRepo, wfRepo, oRepo, aSvc)
+	chSvc := svc.NewChildService(ss.log, ss.tr, chRepo, iRepo, wfRepo, oRepo, aSvc)
+	pSvc := svc.NewPlanService(ss.log, ss.tr, iRepo, sRepo, pRepo, chRepo, oRepo)
+	tSvc := svc.NewTemplateService(ss.log, ss.tr, tRepo, iRepo, sRepo, mRepo, aSvc)
+	dSvc := svc.NewMcpDiscoveryService(ss.log, ss.tr, mRepo, mcRepo)
+	esSvc := svc.NewEventStreamService(ss.log, ss.tr, esRepo)
+	auSvc := svc.NewAuditService(ss.log, ss.tr, auRepo)
+	idSvc := svc.NewIdempotencyService(ss.log, ss.tr, idRepo)
+	bSvc := svc.NewBatchService(ss.log, ss.tr, iSvc, sSvc, oRepo)
+	dispatcher := svc.NewWebhookDispatcher(ss.log, ss.tr, wRepo, wdRepo)
+	wSvc := svc.NewWebhookService(ss.log, ss.tr, wRepo, wdRepo, dispatcher)
+	publisher := events.NewFanout(events.NewPublisher(settings.GetEvents(), ss.cfg.Kafka.Brokers), esSvc, dispatcher)
+	relay := svc.NewOutboxRelay(ss.log, ss.tr, oRepo, publisher)
+
+	bgCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	dSvc.Start(bgCtx)
+	relay.Start(bgCtx)
+	dispatcher.Start(bgCtx)
+	hub := svc.NewSubscriptionHub(ss.log, ss.tr, esSvc, iSvc)
+	hub.Start(bgCtx)
 
-	iSvc := svc.NewInteractionService(ss.log, ss.tr, iRepo)
-	mSvc := svc.NewMcpService(ss.log, ss.tr, mRepo)
-	sSvc := svc.NewStepService(ss.log, ss.tr, sRepo)
+	stateConsumer, err := consumer.NewStateConsumer(ss.cfg, ss.log, ss.tr, consumer.NewDispatcher(iSvc, sSvc), cRepo, auSvc)
+	if err != nil {
+		ss.log.Fatalf("Error while creating state consumer: %v", err)
+	}
+	stateConsumer.Start(bgCtx)
 
 	ih := handler.NewInteractionHandler(ss.log, ss.tr, iSvc)
-	mh := handler.NewMcpHandler(ss.log, ss.tr, mSvc)
+	mh := handler.NewMcpHandler(ss.log, ss.tr, mSvc, dSvc)
 	sh := handler.NewStepHandler(ss.log, ss.tr, sSvc)
+	ah := handler.NewAgentHandler(ss.log, ss.tr, aSvc)
+	ph := handler.NewPlanHandler(ss.log, ss.tr, pSvc)
+	th := handler.NewTemplateHandler(ss.log, ss.tr, tSvc)
+	oh := handler.NewOutboxHandler(ss.log, ss.tr, relay)
+	eh := handler.NewEventHandler(ss.log, ss.tr, esSvc, iSvc)
+	subh := handler.NewSubscriptionHandler(ss.log, ss.tr, hub)
+	wh := handler.NewWebhookHandler(ss.log, ss.tr, wSvc)
+	auh := handler.NewAuditHandler(ss.log, ss.tr, auSvc)
+	idh := handler.NewIdempotencyHandler(ss.log, ss.tr, idSvc)
+	bh := handler.NewBatchHandler(ss.log, ss.tr, bSvc)
+	wfh := handler.NewWorkflowHandler(ss.log, ss.tr, wfSvc)
+	chh := handler.NewChildHandler(ss.log, ss.tr, chSvc)
+	schema, err := gql.NewSchema(ss.log, ss.tr, iSvc, sSvc, mSvc, hub)
+	if err != nil {
+		ss.log.Fatalf("Error while parsing graphql schema: %v", err)
+	}
+	gqh := handler.NewGraphQLHandler(ss.log, ss.tr, schema)
 
 	gh := gin.Default()
-	internal.SetupRouter(gh, ih, sh, mh)
+	routes := openapi.NewRoutes()
+	oah := handler.NewOpenAPIHandler(ss.log, ss.tr, routes)
+	internal.SetupRouter(gh, routes, ss.log, ih, sh, mh, ah, ph, th, oh, eh, subh, wh, auh, idh, bh, wfh, chh, gqh, oah)
 
 	serverAddr := fmt.Sprintf(":%d", ss.cfg.Server.Port)
 
@@ -67,15 +174,42 @@ func (ss *StateServer) Start() {
 	}()
 	ss.log.Infof("Server listening on %s", serverAddr)
 
+	grpcServer := rpc.NewServer(ss.log, ss.tr, iSvc, sSvc, mSvc, esSvc, auSvc)
+	grpcAddr := fmt.Sprintf(":%d", settings.GetGrpc().Port)
+	lis, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		ss.log.Fatalf("Error while listening for grpc on %s: %v", grpcAddr, err)
+	}
+	go func() {
+		ss.log.Infof("Starting grpc server on %s", grpcAddr)
+		if err := grpcServer.Serve(lis); err != nil {
+			ss.log.Fatalf("Error while starting grpc server: %v", err)
+		}
+	}()
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, os.Kill)
 	<-quit
 	ss.log.Info("Shutting down server...")
+	stopBackground()
+	stateConsumer.Close()
+	relay.Close()
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
+	if err := grpcServer.Shutdown(ctx); err != nil {
+		ss.log.Errorf("Grpc server forced to stop (timeout/error): %v", err)
+	}
 	if err := server.Shutdown(ctx); err != nil {
 		ss.log.Fatalf("Server forced to shutdown (timeout/error): %v", err)
 	}
+	if err := publisher.Close(); err != nil {
+		ss.log.Errorf("Error while closing event publisher: %v", err)
+	}
+	oRepo.Close()
+	wRepo.Close()
+	wdRepo.Close()
+	auRepo.Close()
+	idRepo.Close()
 	ss.log.Info("Server successfully exited.")
 }
 